@@ -0,0 +1,101 @@
+package dynamo
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// AutoProject makes All request only the attributes of the struct type it unmarshals into,
+// as named by its fields and struct tags, instead of whole items.
+// For models with a handful of fields read from large items, this cuts down on the data transferred and decoded.
+// Capacity is still consumed according to the size of the whole item.
+// It has no effect if a projection is set with Project or ProjectExpr, or when results are unmarshaled
+// into maps, interfaces, or types implementing Unmarshaler.
+func (q *Query) AutoProject() *Query {
+	q.autoProject = true
+	return q
+}
+
+// AutoProject makes All request only the attributes of the struct type it unmarshals into. See Query.AutoProject.
+func (s *Scan) AutoProject() *Scan {
+	s.autoProject = true
+	return s
+}
+
+// autoProjected returns a copy of q that projects the attributes of out's type, or q itself if it can't.
+func (q *Query) autoProjected(out interface{}) *Query {
+	if !q.autoProject || q.projection != "" || q.hydrate {
+		return q
+	}
+	names := q.codec.projectionFor(out)
+	if len(names) == 0 {
+		return q
+	}
+	cp := *q
+	cp.subber = q.subber.clone()
+	cp.projection = cp.subber.projectNames(names)
+	return &cp
+}
+
+// autoProjected returns a copy of s that projects the attributes of out's type, or s itself if it can't.
+func (s *Scan) autoProjected(out interface{}) *Scan {
+	if !s.autoProject || s.projection != "" {
+		return s
+	}
+	names := s.codec.projectionFor(out)
+	if len(names) == 0 {
+		return s
+	}
+	cp := *s
+	cp.subber = s.subber.clone()
+	cp.projection = cp.subber.projectNames(names)
+	return &cp
+}
+
+// projectNames returns a projection of the given top-level attribute names.
+func (s *subber) projectNames(names []string) string {
+	subs := make([]string, len(names))
+	for i, name := range names {
+		subs[i] = s.subName(name)
+	}
+	return strings.Join(subs, ", ")
+}
+
+// projectionFor returns the sorted attribute names of the struct that out, a pointer to a struct or a slice of structs, decodes into.
+// It returns nil if the attributes can't be known from the type alone.
+func (c codec) projectionFor(out interface{}) []string {
+	rt := reflect.TypeOf(out)
+	if rt == nil || rt.Kind() != reflect.Ptr {
+		return nil
+	}
+	rt = rt.Elem()
+	if rt.Kind() == reflect.Slice {
+		rt = rt.Elem()
+	}
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct || customDecoded(rt) {
+		return nil
+	}
+	if _, ok := c.decoders[rt]; ok {
+		return nil
+	}
+	fields := c.modelFields(rt)
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// customDecoded returns whether rt decodes itself from a whole item.
+func customDecoded(rt reflect.Type) bool {
+	pt := reflect.PtrTo(rt)
+	return pt.Implements(reflect.TypeOf((*Unmarshaler)(nil)).Elem()) ||
+		pt.Implements(reflect.TypeOf((*dynamodbattribute.Unmarshaler)(nil)).Elem())
+}
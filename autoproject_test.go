@@ -0,0 +1,96 @@
+package dynamo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// queryProjectionClient is a fake client that returns no results, recording the projection of queries.
+type queryProjectionClient struct {
+	*describeClient
+	projection string
+}
+
+func (c *queryProjectionClient) QueryWithContext(_ aws.Context, input *dynamodb.QueryInput, _ ...request.Option) (*dynamodb.QueryOutput, error) {
+	c.projection = unsubstitute(aws.StringValue(input.ProjectionExpression), input.ExpressionAttributeNames)
+	return &dynamodb.QueryOutput{}, nil
+}
+
+func TestAutoProject(t *testing.T) {
+	type Summary struct {
+		UserID int
+		Msg    string `dynamo:"msg.text"`
+		Secret string `dynamo:"-"`
+		Meta   struct {
+			Tag string
+		} `dynamo:",flatten"`
+	}
+
+	scans := &keysClient{}
+	table := NewFromIface(scans).Table(testTable)
+	item, err := marshalItem(widget{UserID: 1, Msg: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scans.items = append(scans.items, item)
+
+	var summaries []Summary
+	if err := table.Scan().AutoProject().All(&summaries); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "Tag, UserID, msg.text"; scans.projection != expect {
+		t.Errorf("bad projection: %q ≠ %q", scans.projection, expect)
+	}
+	if len(summaries) != 1 || summaries[0].UserID != 1 {
+		t.Error("bad result:", summaries)
+	}
+
+	s := table.Scan().AutoProject()
+	var ptrs []*Summary
+	if err := s.All(&ptrs); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "Tag, UserID, msg.text"; scans.projection != expect {
+		t.Errorf("bad projection for pointers: %q ≠ %q", scans.projection, expect)
+	}
+	if s.projection != "" || len(s.nameExpr) != 0 {
+		t.Error("AutoProject modified the scan:", s.projection, s.nameExpr)
+	}
+
+	// an explicit projection wins
+	if err := table.Scan().AutoProject().Project("Msg").All(&summaries); err != nil {
+		t.Fatal(err)
+	}
+	if scans.projection != "Msg" {
+		t.Error("explicit projection overridden:", scans.projection)
+	}
+
+	// maps can hold anything
+	var maps []map[string]interface{}
+	if err := table.Scan().AutoProject().All(&maps); err != nil {
+		t.Fatal(err)
+	}
+	if scans.projection != "" {
+		t.Error("unexpected projection for maps:", scans.projection)
+	}
+
+	// off by default
+	if err := table.Scan().All(&summaries); err != nil {
+		t.Fatal(err)
+	}
+	if scans.projection != "" {
+		t.Error("unexpected projection without AutoProject:", scans.projection)
+	}
+
+	queries := &queryProjectionClient{}
+	table = NewFromIface(queries).Table(testTable)
+	if err := table.Get("UserID", 1).AutoProject().All(&summaries); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "Tag, UserID, msg.text"; queries.projection != expect {
+		t.Errorf("bad query projection: %q ≠ %q", queries.projection, expect)
+	}
+}
@@ -0,0 +1,427 @@
+package dynamo
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"golang.org/x/net/context"
+)
+
+// BackfillFunc transforms an item during a backfill.
+// It returns the new version of the item to write back, or nil to leave the item as is.
+// Returning an error skips the item; the error is counted and sampled in the result.
+type BackfillFunc func(item map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, error)
+
+// Backfill is a job that scans a table, transforms each item, and conditionally writes back the changed items.
+// It is useful for data cleanups and renaming attributes.
+//
+// Items are written back with a condition that they still exist,
+// and, if Check is used, that they haven't changed since they were read,
+// so the job is safe to run alongside live traffic.
+type Backfill struct {
+	table      Table
+	fn         BackfillFunc
+	segments   int
+	pageSize   int64
+	rate       float64
+	check      string
+	dryRun     bool
+	checkpoint CheckpointStore
+	samples    int
+	timeout    time.Duration
+
+	filters []backfillFilter
+
+	err error
+}
+
+// BackfillResult summarizes a backfill.
+type BackfillResult struct {
+	// Scanned is the number of items read.
+	Scanned int64
+	// Changed is the number of items the transform changed.
+	// In dry-run mode, this is the number of items that would have been written.
+	Changed int64
+	// Written is the number of items written back.
+	Written int64
+	// Conflicts is the number of items that weren't written because they were deleted or modified during the backfill.
+	Conflicts int64
+	// Failed is the number of items whose transform returned an error.
+	Failed int64
+	// Errors is a sample of the errors returned by the transform, up to the limit set by SampleErrors.
+	Errors []BackfillError
+}
+
+// BackfillError is an error transforming an item.
+type BackfillError struct {
+	// Key is the primary key of the item.
+	Key map[string]*dynamodb.AttributeValue
+	Err error
+}
+
+func (e BackfillError) Error() string {
+	return fmt.Sprintf("dynamo: backfill: item %s: %v", keyString(e.Key), e.Err)
+}
+
+// CheckpointStore saves the progress of a backfill or scan, so that an interrupted job can resume where it left off.
+// See TableCheckpoints and FileCheckpoints.
+type CheckpointStore interface {
+	// LoadCheckpoint returns the saved progress of a scan segment.
+	// It returns a nil key and false if the segment hasn't been started.
+	LoadCheckpoint(ctx aws.Context, segment int) (key PagingKey, done bool, err error)
+	// SaveCheckpoint saves the progress of a scan segment.
+	// The key is the last evaluated key of a fully processed page, and done is true once the segment is finished.
+	SaveCheckpoint(ctx aws.Context, segment int, key PagingKey, done bool) error
+}
+
+// Backfill creates a new backfill job for this table that transforms items with fn.
+func (table Table) Backfill(fn BackfillFunc) *Backfill {
+	return &Backfill{
+		table:    table,
+		fn:       fn,
+		segments: 1,
+		samples:  10,
+	}
+}
+
+// Segments sets the number of segments to scan in parallel. The default is 1.
+func (b *Backfill) Segments(n int) *Backfill {
+	if n < 1 {
+		b.setError(fmt.Errorf("dynamo: backfill: invalid number of segments: %d", n))
+	}
+	b.segments = n
+	return b
+}
+
+// PageSize sets the maximum number of items read by each scan request.
+// Progress is checkpointed after every page.
+func (b *Backfill) PageSize(n int64) *Backfill {
+	b.pageSize = n
+	return b
+}
+
+// RateLimit limits the number of items written per second, across all segments.
+// By default, writes are not limited.
+func (b *Backfill) RateLimit(perSecond float64) *Backfill {
+	if perSecond < 0 {
+		b.setError(fmt.Errorf("dynamo: backfill: invalid rate limit: %v", perSecond))
+	}
+	b.rate = perSecond
+	return b
+}
+
+// Check makes writes conditional on the given attribute being unchanged since the item was read,
+// such as a version number or last-modified time.
+// Items that were modified in the meantime are counted as conflicts and left as is.
+func (b *Backfill) Check(attribute string) *Backfill {
+	b.check = attribute
+	return b
+}
+
+// Filter takes an expression that restricts the items to transform.
+// Multiple calls to Filter will combine their expressions with AND.
+func (b *Backfill) Filter(expr string, args ...interface{}) *Backfill {
+	b.filters = append(b.filters, backfillFilter{expr: expr, args: args})
+	return b
+}
+
+type backfillFilter struct {
+	expr string
+	args []interface{}
+}
+
+// DryRun runs the transform without writing anything or saving checkpoints.
+func (b *Backfill) DryRun(dryRun bool) *Backfill {
+	b.dryRun = dryRun
+	return b
+}
+
+// Checkpoint sets where to save progress, so that an interrupted backfill can be resumed by running it again.
+// Segments already marked as done are skipped.
+// The store must be used with the same number of segments every time.
+func (b *Backfill) Checkpoint(store CheckpointStore) *Backfill {
+	b.checkpoint = store
+	return b
+}
+
+// SampleErrors sets the maximum number of transform errors kept in the result. The default is 10.
+func (b *Backfill) SampleErrors(n int) *Backfill {
+	b.samples = n
+	return b
+}
+
+// Timeout limits the total amount of time this backfill may take.
+// Backfills of large tables can take hours, so unlike other requests, Run is not bounded by RetryTimeout;
+// without a timeout, it runs until it finishes or fails.
+func (b *Backfill) Timeout(timeout time.Duration) *Backfill {
+	b.timeout = timeout
+	return b
+}
+
+// Run executes this backfill. It isn't bounded by RetryTimeout, see Timeout.
+func (b *Backfill) Run() (BackfillResult, error) {
+	ctx, cancel := context.WithCancel(aws.BackgroundContext())
+	defer cancel()
+	return b.RunWithContext(ctx)
+}
+
+// RunWithContext executes this backfill.
+// It stops at the first error writing to or reading from DynamoDB, returning the progress so far along with the error.
+// Errors returned by the transform don't stop the backfill.
+func (b *Backfill) RunWithContext(ctx aws.Context) (BackfillResult, error) {
+	if b.err != nil {
+		return BackfillResult{}, b.err
+	}
+	ctx, cancel := withTimeout(ctx, b.timeout)
+	defer cancel()
+	desc, err := b.table.description(ctx)
+	if err != nil {
+		return BackfillResult{}, err
+	}
+	keys := []string{desc.HashKey}
+	if desc.RangeKey != "" {
+		keys = append(keys, desc.RangeKey)
+	}
+
+	run := &backfillRun{Backfill: b, keys: keys}
+	if b.rate > 0 {
+		run.limit = &rateLimiter{interval: time.Duration(float64(time.Second) / b.rate)}
+	}
+
+	// stop every segment at the first error, which is returned instead of the cancelation it causes in the others
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	var wg sync.WaitGroup
+	var once sync.Once
+	var first error
+	for seg := 0; seg < b.segments; seg++ {
+		wg.Add(1)
+		go func(seg int) {
+			defer wg.Done()
+			if err := run.segment(ctx, seg); err != nil {
+				once.Do(func() {
+					first = err
+					stop()
+				})
+			}
+		}(seg)
+	}
+	wg.Wait()
+	return run.result, first
+}
+
+// backfillRun is the state of a running backfill.
+type backfillRun struct {
+	*Backfill
+	keys  []string
+	limit *rateLimiter
+
+	mu     sync.Mutex
+	result BackfillResult
+}
+
+func (r *backfillRun) segment(ctx aws.Context, seg int) error {
+	var start PagingKey
+	if r.checkpoint != nil {
+		key, done, err := r.checkpoint.LoadCheckpoint(ctx, seg)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		start = key
+	}
+
+	// scan one page at a time, so SearchLimit can set the page size
+	var page []map[string]*dynamodb.AttributeValue
+	for {
+		iter := r.scan(seg, start).Iter().(*scanIter)
+		iter.NextPageWithContext(ctx, &page)
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		for _, item := range page {
+			if err := r.process(ctx, item); err != nil {
+				return err
+			}
+		}
+		if start = iter.LastEvaluatedKey(); start == nil {
+			break
+		}
+		if r.checkpoint != nil && !r.dryRun {
+			if err := r.checkpoint.SaveCheckpoint(ctx, seg, start, false); err != nil {
+				return err
+			}
+		}
+	}
+	if r.checkpoint != nil && !r.dryRun {
+		return r.checkpoint.SaveCheckpoint(ctx, seg, nil, true)
+	}
+	return nil
+}
+
+func (r *backfillRun) scan(seg int, start PagingKey) *Scan {
+	// always a segment, as a deliberate full scan
+	scan := r.table.Scan().Consistent(true).Segment(int64(seg), int64(r.segments))
+	if r.pageSize > 0 {
+		scan.SearchLimit(r.pageSize)
+	}
+	if start != nil {
+		scan.StartFrom(start)
+	}
+	for _, f := range r.filters {
+		scan.Filter(f.expr, f.args...)
+	}
+	return scan
+}
+
+func (r *backfillRun) process(ctx aws.Context, item map[string]*dynamodb.AttributeValue) error {
+	r.count(func(res *BackfillResult) { res.Scanned++ })
+
+	changed, err := r.fn(item)
+	if err == nil && changed != nil {
+		err = r.sameKey(item, changed)
+	}
+	if err != nil {
+		r.count(func(res *BackfillResult) {
+			res.Failed++
+			if len(res.Errors) < r.samples {
+				res.Errors = append(res.Errors, BackfillError{Key: r.keyOf(item), Err: err})
+			}
+		})
+		return nil
+	}
+	if changed == nil {
+		return nil
+	}
+	r.count(func(res *BackfillResult) { res.Changed++ })
+	if r.dryRun {
+		return nil
+	}
+
+	if err := r.limit.wait(ctx); err != nil {
+		return err
+	}
+	put := r.table.Put(changed).If("attribute_exists($)", r.keys[0])
+	if r.check != "" {
+		if old, ok := item[r.check]; ok {
+			put.If("$ = ?", r.check, old)
+		} else {
+			put.If("attribute_not_exists($)", r.check)
+		}
+	}
+	err = put.RunWithContext(ctx)
+	if ae, ok := err.(awserr.Error); ok && ae.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		r.count(func(res *BackfillResult) { res.Conflicts++ })
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	r.count(func(res *BackfillResult) { res.Written++ })
+	return nil
+}
+
+// sameKey returns an error if the transform changed the item's primary key.
+func (r *backfillRun) sameKey(item, changed map[string]*dynamodb.AttributeValue) error {
+	for _, k := range r.keys {
+		if keyString(map[string]*dynamodb.AttributeValue{k: item[k]}) != keyString(map[string]*dynamodb.AttributeValue{k: changed[k]}) {
+			return fmt.Errorf("transform changed key attribute %s", k)
+		}
+	}
+	return nil
+}
+
+func (r *backfillRun) keyOf(item map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	key := make(map[string]*dynamodb.AttributeValue, len(r.keys))
+	for _, k := range r.keys {
+		key[k] = item[k]
+	}
+	return key
+}
+
+func (r *backfillRun) count(fn func(*BackfillResult)) {
+	r.mu.Lock()
+	fn(&r.result)
+	r.mu.Unlock()
+}
+
+func (b *Backfill) setError(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// rateLimiter spaces out events by a fixed interval.
+type rateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// wait blocks until the next event is allowed. A nil rateLimiter never blocks.
+func (r *rateLimiter) wait(ctx aws.Context) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	now := time.Now()
+	if r.next.Before(now) {
+		r.next = now
+	}
+	at := r.next
+	r.next = r.next.Add(r.interval)
+	r.mu.Unlock()
+	return aws.SleepWithContext(ctx, at.Sub(now))
+}
+
+// TableCheckpoints returns a CheckpointStore that saves progress in a DynamoDB table with a string hash key,
+// with one item per segment keyed by the job name and segment number.
+func TableCheckpoints(table Table, hashKey, job string) CheckpointStore {
+	return tableCheckpoints{table: table, hashKey: hashKey, job: job}
+}
+
+type tableCheckpoints struct {
+	table   Table
+	hashKey string
+	job     string
+}
+
+func (tc tableCheckpoints) id(segment int) string {
+	return tc.job + "#" + strconv.Itoa(segment)
+}
+
+func (tc tableCheckpoints) LoadCheckpoint(ctx aws.Context, segment int) (PagingKey, bool, error) {
+	var item map[string]*dynamodb.AttributeValue
+	err := tc.table.Get(tc.hashKey, tc.id(segment)).Consistent(true).OneWithContext(ctx, &item)
+	if err == ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var key PagingKey
+	if av := item["Key"]; av != nil && av.M != nil {
+		key = av.M
+	}
+	done := item["Done"] != nil && aws.BoolValue(item["Done"].BOOL)
+	return key, done, nil
+}
+
+func (tc tableCheckpoints) SaveCheckpoint(ctx aws.Context, segment int, key PagingKey, done bool) error {
+	item := map[string]*dynamodb.AttributeValue{
+		tc.hashKey: {S: aws.String(tc.id(segment))},
+		"Done":     {BOOL: aws.Bool(done)},
+	}
+	if key != nil {
+		item["Key"] = &dynamodb.AttributeValue{M: key}
+	}
+	return tc.table.Put(item).RunWithContext(ctx)
+}
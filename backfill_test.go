@@ -0,0 +1,226 @@
+package dynamo
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"golang.org/x/net/context"
+)
+
+// backfillClient is a fake client holding a table with a string hash key "ID".
+// It pages through scans in key order and understands the conditions used by Backfill.
+type backfillClient struct {
+	*describeClient
+	ids      []string
+	items    map[string]map[string]*dynamodb.AttributeValue
+	scans    int
+	failScan int // fail this scan call, counting from 1
+}
+
+func newBackfillClient(n int) *backfillClient {
+	c := &backfillClient{
+		describeClient: newDescribeClient(&dynamodb.TableDescription{
+			TableName:   aws.String("Backfill"),
+			TableStatus: aws.String(dynamodb.TableStatusActive),
+			KeySchema:   keySchema("ID", ""),
+		}),
+		items: make(map[string]map[string]*dynamodb.AttributeValue),
+	}
+	for i := 0; i < n; i++ {
+		id := strconv.Itoa(i)
+		c.ids = append(c.ids, id)
+		c.items[id] = map[string]*dynamodb.AttributeValue{
+			"ID":      {S: aws.String(id)},
+			"Name":    {S: aws.String("item " + id)},
+			"Version": {N: aws.String("1")},
+		}
+	}
+	return c
+}
+
+func (c *backfillClient) ScanWithContext(_ aws.Context, input *dynamodb.ScanInput, _ ...request.Option) (*dynamodb.ScanOutput, error) {
+	c.scans++
+	if c.scans == c.failScan {
+		return nil, awserr.New("InternalFailure", "scan failed", nil)
+	}
+	start := 0
+	if input.ExclusiveStartKey != nil {
+		for i, id := range c.ids {
+			if id == *input.ExclusiveStartKey["ID"].S {
+				start = i + 1
+			}
+		}
+	}
+	end := len(c.ids)
+	if input.Limit != nil && start+int(*input.Limit) < end {
+		end = start + int(*input.Limit)
+	}
+	out := &dynamodb.ScanOutput{}
+	for _, id := range c.ids[start:end] {
+		out.Items = append(out.Items, c.items[id])
+	}
+	if end < len(c.ids) {
+		out.LastEvaluatedKey = map[string]*dynamodb.AttributeValue{"ID": {S: aws.String(c.ids[end-1])}}
+	}
+	return out, nil
+}
+
+func (c *backfillClient) PutItemWithContext(_ aws.Context, input *dynamodb.PutItemInput, _ ...request.Option) (*dynamodb.PutItemOutput, error) {
+	id := *input.Item["ID"].S
+	old := c.items[id]
+	cond := aws.StringValue(input.ConditionExpression)
+	if !strings.Contains(cond, "attribute_exists") {
+		return nil, errors.New("missing existence condition: " + cond)
+	}
+	if old == nil {
+		return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "gone", nil)
+	}
+	for _, v := range input.ExpressionAttributeValues {
+		if *v.N != *old["Version"].N {
+			return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "modified", nil)
+		}
+	}
+	c.items[id] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+type memCheckpoints map[int]PagingKey
+
+func (m memCheckpoints) LoadCheckpoint(_ aws.Context, segment int) (PagingKey, bool, error) {
+	key, ok := m[segment]
+	return key, ok && key == nil, nil
+}
+
+func (m memCheckpoints) SaveCheckpoint(_ aws.Context, segment int, key PagingKey, done bool) error {
+	if done {
+		key = nil
+	}
+	m[segment] = key
+	return nil
+}
+
+func rename(item map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, error) {
+	if item["Title"] != nil {
+		return nil, nil
+	}
+	changed := make(map[string]*dynamodb.AttributeValue, len(item))
+	for k, v := range item {
+		changed[k] = v
+	}
+	changed["Title"] = changed["Name"]
+	delete(changed, "Name")
+	return changed, nil
+}
+
+func TestBackfill(t *testing.T) {
+	client := newBackfillClient(5)
+	table := NewFromIface(client).Table("Backfill")
+
+	res, err := table.Backfill(rename).DryRun(true).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Scanned != 5 || res.Changed != 5 || res.Written != 0 {
+		t.Error("bad dry run result:", res)
+	}
+	if client.items["0"]["Title"] != nil {
+		t.Error("dry run wrote item")
+	}
+
+	// item 1 is modified and item 2 deleted while the backfill runs
+	delete(client.items, "2")
+	fn := func(item map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, error) {
+		switch *item["ID"].S {
+		case "1":
+			client.items["1"] = map[string]*dynamodb.AttributeValue{"ID": item["ID"], "Version": {N: aws.String("2")}}
+		case "3":
+			return nil, errors.New("bad item")
+		case "4":
+			return map[string]*dynamodb.AttributeValue{"ID": {S: aws.String("other")}}, nil
+		}
+		return rename(item)
+	}
+	client.ids = []string{"0", "1", "3", "4"}
+	res, err = table.Backfill(fn).Check("Version").SampleErrors(1).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Scanned != 4 || res.Changed != 2 || res.Written != 1 || res.Conflicts != 1 || res.Failed != 2 {
+		t.Error("bad result:", res)
+	}
+	if len(res.Errors) != 1 || *res.Errors[0].Key["ID"].S != "3" {
+		t.Error("bad error sample:", res.Errors)
+	}
+	if client.items["0"]["Title"] == nil || client.items["1"]["Title"] != nil {
+		t.Error("bad items:", client.items)
+	}
+}
+
+func TestBackfillCheckpoint(t *testing.T) {
+	client := newBackfillClient(6)
+	client.failScan = 2
+	table := NewFromIface(client).Table("Backfill")
+	checkpoints := make(memCheckpoints)
+
+	res, err := table.Backfill(rename).PageSize(2).Checkpoint(checkpoints).Run()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if res.Written != 2 || *checkpoints[0]["ID"].S != "1" {
+		t.Error("bad progress:", res, checkpoints)
+	}
+
+	res, err = table.Backfill(rename).PageSize(2).Checkpoint(checkpoints).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Scanned != 4 || res.Written != 4 {
+		t.Error("didn't resume from checkpoint:", res)
+	}
+	if key, ok := checkpoints[0]; !ok || key != nil {
+		t.Error("segment not marked as done:", checkpoints)
+	}
+
+	res, err = table.Backfill(rename).Checkpoint(checkpoints).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Scanned != 0 {
+		t.Error("finished segment was scanned again:", res)
+	}
+}
+
+// failingSegmentClient is a backfillClient whose scans of segment 1 fail,
+// while scans of other segments wait until they are canceled.
+type failingSegmentClient struct {
+	*backfillClient
+}
+
+func (c failingSegmentClient) ScanWithContext(ctx aws.Context, input *dynamodb.ScanInput, _ ...request.Option) (*dynamodb.ScanOutput, error) {
+	if aws.Int64Value(input.Segment) == 1 {
+		return nil, awserr.New("InternalFailure", "scan failed", nil)
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestBackfillFirstError(t *testing.T) {
+	table := NewFromIface(failingSegmentClient{newBackfillClient(2)}).Table("Backfill")
+	_, err := table.Backfill(rename).Segments(2).Run()
+	if err == nil || err == context.Canceled || !strings.Contains(err.Error(), "scan failed") {
+		t.Error("expected the scan error, got", err)
+	}
+
+	// the timeout bounds the whole run
+	_, err = table.Backfill(rename).Timeout(time.Millisecond).Run()
+	if err != context.DeadlineExceeded {
+		t.Error("expected timeout, got", err)
+	}
+}
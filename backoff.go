@@ -0,0 +1,108 @@
+package dynamo
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// BackoffStrategy determines how long batch requests wait between retries of unprocessed keys or items.
+type BackoffStrategy int
+
+const (
+	// ExponentialBackoff grows the wait by half each time, randomized by up to 50% either way.
+	// This is the default.
+	ExponentialBackoff BackoffStrategy = iota
+	// JitteredBackoff is exponential backoff with full jitter:
+	// each wait is random, between zero and double the previous upper bound.
+	// It spreads out retries from many concurrent clients the most.
+	JitteredBackoff
+	// ConstantBackoff always waits for the same interval.
+	ConstantBackoff
+)
+
+// Backoff configures how batch requests wait before retrying unprocessed keys or items.
+// See BatchGet.Backoff and BatchWrite.Backoff.
+type Backoff struct {
+	// Strategy is the backoff algorithm to use.
+	Strategy BackoffStrategy
+	// Interval is the first wait, or every wait for ConstantBackoff.
+	// If zero, it defaults to 500ms.
+	Interval time.Duration
+	// MaxInterval caps each individual wait.
+	// If zero, it defaults to one minute.
+	MaxInterval time.Duration
+}
+
+func (b Backoff) newBackOff() backoff.BackOff {
+	interval := b.Interval
+	if interval == 0 {
+		interval = backoff.DefaultInitialInterval
+	}
+	max := b.MaxInterval
+	if max == 0 {
+		max = backoff.DefaultMaxInterval
+	}
+	if interval > max {
+		interval = max
+	}
+
+	switch b.Strategy {
+	case ConstantBackoff:
+		return backoff.NewConstantBackOff(interval)
+	case JitteredBackoff:
+		return &jitteredBackOff{interval: interval, max: max, next: interval}
+	}
+	exp := backoff.NewExponentialBackOff()
+	exp.InitialInterval = interval
+	exp.MaxInterval = max
+	exp.MaxElapsedTime = 0
+	exp.Reset()
+	// randomization could otherwise exceed MaxInterval by half
+	return cappedBackOff{BackOff: exp, max: max}
+}
+
+// cappedBackOff limits the waits of a BackOff to max.
+type cappedBackOff struct {
+	backoff.BackOff
+	max time.Duration
+}
+
+func (b cappedBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	if next > b.max {
+		return b.max
+	}
+	return next
+}
+
+// jitteredBackOff implements exponential backoff with full jitter.
+type jitteredBackOff struct {
+	interval time.Duration
+	max      time.Duration
+	next     time.Duration // upper bound of the next wait
+}
+
+var (
+	jitterRand   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	jitterRandMu sync.Mutex
+)
+
+func (b *jitteredBackOff) NextBackOff() time.Duration {
+	bound := b.next
+	if b.next < b.max {
+		b.next *= 2
+		if b.next > b.max {
+			b.next = b.max
+		}
+	}
+	jitterRandMu.Lock()
+	defer jitterRandMu.Unlock()
+	return time.Duration(jitterRand.Int63n(int64(bound) + 1))
+}
+
+func (b *jitteredBackOff) Reset() {
+	b.next = b.interval
+}
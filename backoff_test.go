@@ -0,0 +1,30 @@
+package dynamo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	constant := Backoff{Strategy: ConstantBackoff, Interval: time.Second}.newBackOff()
+	for i := 0; i < 3; i++ {
+		if next := constant.NextBackOff(); next != time.Second {
+			t.Error("constant backoff: expected 1s, got", next)
+		}
+	}
+
+	jittered := Backoff{Strategy: JitteredBackoff, Interval: 100 * time.Millisecond, MaxInterval: 300 * time.Millisecond}.newBackOff()
+	for i, bound := range []time.Duration{100, 200, 300, 300} {
+		bound *= time.Millisecond
+		if next := jittered.NextBackOff(); next < 0 || next > bound {
+			t.Errorf("jittered backoff #%d: expected at most %v, got %v", i, bound, next)
+		}
+	}
+
+	exp := Backoff{Interval: 100 * time.Millisecond, MaxInterval: 200 * time.Millisecond}.newBackOff()
+	for i := 0; i < 5; i++ {
+		if next := exp.NextBackOff(); next > 200*time.Millisecond {
+			t.Error("exponential backoff: MaxInterval exceeded:", next)
+		}
+	}
+}
@@ -1,8 +1,17 @@
 package dynamo
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 const batchSize = 101
@@ -90,3 +99,311 @@ func TestBatchGetWrite(t *testing.T) {
 		t.Error("expected 0 results, got", len(results))
 	}
 }
+
+// fakeBatchGetClient is a fake client that serves BatchGetItem requests from items.
+type fakeBatchGetClient struct {
+	dynamodbiface.DynamoDBAPI
+	items []map[string]*dynamodb.AttributeValue
+}
+
+func (c fakeBatchGetClient) BatchGetItemWithContext(_ aws.Context, input *dynamodb.BatchGetItemInput, _ ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	out := &dynamodb.BatchGetItemOutput{
+		Responses: make(map[string][]map[string]*dynamodb.AttributeValue),
+	}
+	for table, kas := range input.RequestItems {
+		for _, key := range kas.Keys {
+			for _, item := range c.items {
+				if keyString(key) == keyString(map[string]*dynamodb.AttributeValue{"UserID": item["UserID"]}) {
+					out.Responses[table] = append(out.Responses[table], item)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+func TestBatchGetRequireAll(t *testing.T) {
+	item, err := marshalItem(widget{UserID: 1, Msg: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := NewFromIface(fakeBatchGetClient{items: []map[string]*dynamodb.AttributeValue{item}})
+	batch := db.Table(testTable).Batch("UserID")
+
+	var results []widget
+	err = batch.Get(Keys{1}, Keys{2}, Keys{3}).RequireAll(true).All(&results)
+	missing, ok := err.(*MissingKeysError)
+	if !ok {
+		t.Fatal("expected MissingKeysError, got", err)
+	}
+	if expected := []Keyed{Keys{2}, Keys{3}}; !reflect.DeepEqual(missing.Keys, expected) {
+		t.Error("bad missing keys:", missing.Keys, "≠", expected)
+	}
+	if len(results) != 1 {
+		t.Error("expected 1 result, got", len(results))
+	}
+
+	results = nil
+	err = batch.Get(Keys{1}).RequireAll(true).All(&results)
+	if err != nil {
+		t.Error("unexpected error:", err)
+	}
+}
+
+func TestBatchGetKey(t *testing.T) {
+	var items []map[string]*dynamodb.AttributeValue
+	for _, w := range []widget{{UserID: 1, Msg: "hello"}, {UserID: 2, Msg: "world"}} {
+		item, err := marshalItem(w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+	}
+	batch := NewFromIface(fakeBatchGetClient{items: items}).Table(testTable).Batch("UserID")
+
+	var results []widget
+	if err := batch.Get(Key{Hash: 1}, HashKey(2)).All(&results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Error("expected 2 results, got", len(results))
+	}
+	if k := HashRangeKey(1, "a"); k.HashKey() != 1 || k.RangeKey() != "a" {
+		t.Error("bad key:", k)
+	}
+	// keys are marshaled like any other value
+	for _, v := range []interface{}{"a", "", 42, int64(-7), uint(3), []byte("b"), &dynamodb.AttributeValue{S: aws.String("c")}, nil} {
+		fast, err1 := codec{}.marshalKey(v)
+		slow, err2 := marshal(v, "")
+		if !reflect.DeepEqual(fast, slow) || err1 != err2 {
+			t.Errorf("marshalKey(%#v) = %v, %v; marshal = %v, %v", v, fast, err1, slow, err2)
+		}
+	}
+	in, err := NewFromIface(nil).Table(testTable).Batch("UserID", "Time").Get(Keys{1, "a"}, Keys{2, "b"}).input(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := in.RequestItems[testTable].Keys
+	if len(keys) != 2 || *keys[1]["UserID"].N != "2" || *keys[1]["Time"].S != "b" {
+		t.Error("bad request keys:", keys)
+	}
+	if err := batch.Get(Keys{make(chan int)}).err; err == nil {
+		t.Error("expected error for unsupported key type")
+	}
+}
+
+func TestBatchGetAllowEmpty(t *testing.T) {
+	db := NewFromIface(fakeBatchGetClient{})
+	batch := db.Table(testTable).Batch("UserID")
+
+	var results []widget
+	if err := batch.Get(Keys{1}).All(&results); err != ErrNotFound {
+		t.Error("expected ErrNotFound, got", err)
+	}
+	if err := batch.Get(Keys{1}).AllowEmpty(true).All(&results); err != nil {
+		t.Error("unexpected error:", err)
+	}
+	if len(results) != 0 {
+		t.Error("expected 0 results, got", len(results))
+	}
+}
+
+func TestBatchGetNextPage(t *testing.T) {
+	var items []map[string]*dynamodb.AttributeValue
+	var keys []Keyed
+	for i := 0; i < 150; i++ {
+		item, err := marshalItem(widget{UserID: i})
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+		keys = append(keys, Keys{i})
+	}
+	db := NewFromIface(fakeBatchGetClient{items: items})
+
+	var sizes []int
+	var results []widget
+	itr := db.Table(testTable).Batch("UserID").Get(keys...).Iter()
+	for itr.(PageIter).NextPage(&results) {
+		sizes = append(sizes, len(results))
+	}
+	if err := itr.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{100, 50}; !reflect.DeepEqual(sizes, expected) {
+		t.Error("bad page sizes:", sizes, "≠", expected)
+	}
+	if unprocessed := itr.(BatchGetIter).UnprocessedKeys(); len(unprocessed) != 0 {
+		t.Error("unexpected unprocessed keys:", unprocessed)
+	}
+}
+
+// projectingClient records the projection of each BatchGetItem request.
+type projectingClient struct {
+	fakeBatchGetClient
+	projections []string
+}
+
+func (c *projectingClient) BatchGetItemWithContext(ctx aws.Context, input *dynamodb.BatchGetItemInput, opts ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	for _, kas := range input.RequestItems {
+		proj := aws.StringValue(kas.ProjectionExpression)
+		for placeholder, name := range kas.ExpressionAttributeNames {
+			proj = strings.Replace(proj, placeholder, *name, -1)
+		}
+		c.projections = append(c.projections, fmt.Sprintf("%s (%d)", proj, len(kas.Keys)))
+	}
+	return c.fakeBatchGetClient.BatchGetItemWithContext(ctx, input, opts...)
+}
+
+func TestBatchGetProject(t *testing.T) {
+	var items []map[string]*dynamodb.AttributeValue
+	for i := 0; i < 5; i++ {
+		item, err := marshalItem(widget{UserID: i, Msg: "hello"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+	}
+	client := &projectingClient{fakeBatchGetClient: fakeBatchGetClient{items: items}}
+	batch := NewFromIface(client).Table(testTable).Batch("UserID")
+
+	var results []widget
+	err := batch.Get(Keys{0}).
+		AndProject([]string{"UserID", "Msg"}, Keys{1}, Keys{2}).
+		And(Keys{3}).
+		AndProject([]string{"UserID"}, Keys{4}).
+		Project("UserID", "Time").
+		All(&results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"UserID, Time (2)", "UserID, Msg (2)", "UserID (1)"}
+	if !reflect.DeepEqual(client.projections, expected) {
+		t.Error("bad requests:", client.projections, "≠", expected)
+	}
+	if len(results) != 5 {
+		t.Error("expected 5 results, got", len(results))
+	}
+
+	client.projections = nil
+	if err := batch.Get(Keys{0}, Keys{1}).All(&results); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{" (2)"}; !reflect.DeepEqual(client.projections, expected) {
+		t.Error("bad requests:", client.projections, "≠", expected)
+	}
+
+	if err := batch.Get(Keys{0}).AndProject(nil, Keys{1}).All(&results); err == nil {
+		t.Error("expected error for empty projection")
+	}
+
+	client.projections = nil
+	if err := batch.Get(Keys{0}).Project("Msg", "Msg").All(&results); err == nil || !strings.Contains(err.Error(), "overlap") {
+		t.Error("expected error for overlapping projection, got", err)
+	}
+	if err := batch.Get(Keys{0}).AndProject([]string{"Meta.Count"}, Keys{1}).All(&results); err == nil || !strings.Contains(err.Error(), "reserved word") {
+		t.Error("expected error for reserved word, got", err)
+	}
+	if len(client.projections) != 0 {
+		t.Error("invalid projections shouldn't be requested:", client.projections)
+	}
+}
+
+func TestBatchConcurrentProducers(t *testing.T) {
+	table := NewFromIface(nil).Table(testTable)
+	const producers, each = 8, 50
+
+	bg := table.Batch("UserID").Get()
+	bw := table.Batch("UserID").Write()
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < each; i++ {
+				id := p*each + i
+				bg.And(Keys{id})
+				bw.Put(widget{UserID: id})
+				bw.Delete(Keys{id})
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	if bg.err != nil || bw.err != nil {
+		t.Fatal("unexpected error:", bg.err, bw.err)
+	}
+	if len(bg.reqs) != producers*each || len(bg.keys) != producers*each {
+		t.Error("lost keys:", len(bg.reqs), len(bg.keys))
+	}
+	seen := make(map[string]bool)
+	for _, req := range bg.reqs {
+		seen[*req.key["UserID"].N] = true
+	}
+	if len(seen) != producers*each {
+		t.Error("duplicate or missing keys:", len(seen))
+	}
+	if len(bw.ops) != 2*producers*each {
+		t.Error("lost writes:", len(bw.ops))
+	}
+}
+
+// uniqueKeysClient is a fake client that, like DynamoDB, rejects batch gets with duplicate keys.
+type uniqueKeysClient struct {
+	fakeBatchGetClient
+}
+
+func (c uniqueKeysClient) BatchGetItemWithContext(ctx aws.Context, input *dynamodb.BatchGetItemInput, opts ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	for _, kas := range input.RequestItems {
+		seen := make(map[string]bool)
+		for _, key := range kas.Keys {
+			if seen[keyString(key)] {
+				return nil, fmt.Errorf("duplicate key: %s", keyString(key))
+			}
+			seen[keyString(key)] = true
+		}
+	}
+	return c.fakeBatchGetClient.BatchGetItemWithContext(ctx, input, opts...)
+}
+
+func TestBatchGetDuplicateKeys(t *testing.T) {
+	var items []map[string]*dynamodb.AttributeValue
+	for _, w := range []widget{{UserID: 1, Msg: "a"}, {UserID: 2, Msg: "b"}} {
+		item, err := marshalItem(w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+	}
+	db := NewFromIface(uniqueKeysClient{fakeBatchGetClient{items: items}})
+	batch := db.Table(testTable).Batch("UserID")
+
+	var results []widget
+	err := batch.Get(Keys{1}, Keys{2}, Keys{1}).And(Keys{2}, Keys{3}, Keys{3}).All(&results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Error("expected 2 results, got", results)
+	}
+
+	// the same key with different projections is requested separately
+	results = nil
+	err = batch.Get(Keys{1}).AndProject([]string{"UserID"}, Keys{1}).All(&results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Error("expected 2 results, got", results)
+	}
+
+	// missing keys are reported once
+	err = batch.Get(Keys{3}, Keys{3}, Keys{1}).RequireAll(true).All(&results)
+	missing, ok := err.(*MissingKeysError)
+	if !ok {
+		t.Fatal("expected MissingKeysError, got", err)
+	}
+	if expected := []Keyed{Keys{3}}; !reflect.DeepEqual(missing.Keys, expected) {
+		t.Error("bad missing keys:", missing.Keys, "≠", expected)
+	}
+}
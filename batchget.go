@@ -1,8 +1,13 @@
 package dynamo
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -40,54 +45,177 @@ func (table Table) Batch(hashAndRangeKeyName ...string) Batch {
 }
 
 // BatchGet is a BatchGetItem operation.
+// And and AndProject are safe to call from multiple goroutines at once, so that concurrent producers can fill a single batch.
+// The batch must not be run until they have returned.
 type BatchGet struct {
 	batch      Batch
-	reqs       []*Query
-	projection string
-	consistent bool
+	mu         *sync.Mutex // guards reqs, keys, and err while adding; shared by copies made while iterating
+	reqs       []batchKey
+	keys       []Keyed
+	projection []string
+	consistent *bool
+	requireAll bool
+	allowEmpty bool
 	err        error
 	cc         *ConsumedCapacity
+	timeout    time.Duration
+	onPage     func(PageStats)
+
+	onUnprocessed  func(UnprocessedEvent)
+	decodeWorkers  int
+	backoff        Backoff
+	includeDeleted bool
+	validateKeys   bool
+}
+
+// MissingKeysError is returned by BatchGet when RequireAll is enabled
+// and some of the requested items could not be found.
+type MissingKeysError struct {
+	// Keys of the missing items, in the order they were requested.
+	Keys []Keyed
+}
+
+func (err *MissingKeysError) Error() string {
+	strs := make([]string, 0, len(err.Keys))
+	for _, k := range err.Keys {
+		if rk := k.RangeKey(); rk != nil {
+			strs = append(strs, fmt.Sprintf("(%v, %v)", k.HashKey(), rk))
+			continue
+		}
+		strs = append(strs, fmt.Sprint(k.HashKey()))
+	}
+	return fmt.Sprintf("dynamo: batch: %d items not found: %s", len(err.Keys), strings.Join(strs, ", "))
 }
 
 // Get creates a new batch get item request with the given keys.
+// Keys given more than once are only requested once, and their items are returned once.
 //	table.Batch("ID", "Month").
 //		Get([]dynamo.Keys{{1, "2015-10"}, {42, "2015-12"}, {42, "1992-02"}}...).
 //		All(&results)
 func (b Batch) Get(keys ...Keyed) *BatchGet {
+	defs := b.table.db.requestDefaults()
 	bg := &BatchGet{
-		batch: b,
-		err:   b.err,
+		batch:   b,
+		mu:      new(sync.Mutex),
+		err:     b.err,
+		cc:      defs.cc,
+		timeout: defs.timeout,
 	}
-	bg.add(keys)
+	bg.add(keys, nil)
 	return bg
 }
 
 // And adds more keys to be gotten.
 func (bg *BatchGet) And(keys ...Keyed) *BatchGet {
-	bg.add(keys)
+	bg.add(keys, nil)
 	return bg
 }
 
-func (bg *BatchGet) add(keys []Keyed) {
+// batchKey is a primary key to get, marshaled directly from the Keyed it was given as, along with its projection if any.
+type batchKey struct {
+	key  map[string]*dynamodb.AttributeValue
+	proj *batchProjection
+}
+
+// batchProjection is a projection shared by every key it was given for.
+type batchProjection struct {
+	expr  string
+	names map[string]*string
+	// group identifies the projection, see projectionOf.
+	group string
+}
+
+func (bg *BatchGet) add(keys []Keyed, proj *batchProjection) {
+	c := bg.batch.table.db.codec()
+	reqs := make([]batchKey, 0, len(keys))
 	for _, key := range keys {
 		if key == nil {
 			bg.setError(errors.New("dynamo: batch: the Keyed interface must not be nil"))
+			keys = keys[:len(reqs)]
 			break
 		}
-		get := bg.batch.table.Get(bg.batch.hashKey, key.HashKey())
+		item := make(map[string]*dynamodb.AttributeValue, 2)
+		hv, err := c.marshalKey(key.HashKey())
+		bg.setError(err)
+		item[bg.batch.hashKey] = hv
 		if rk := key.RangeKey(); bg.batch.rangeKey != "" && rk != nil {
-			get.Range(bg.batch.rangeKey, Equal, rk)
-			bg.setError(get.err)
+			rv, err := c.marshalKey(rk)
+			bg.setError(err)
+			item[bg.batch.rangeKey] = rv
+		}
+		reqs = append(reqs, batchKey{key: item, proj: proj})
+	}
+
+	bg.mu.Lock()
+	defer bg.mu.Unlock()
+	bg.reqs = append(bg.reqs, reqs...)
+	bg.keys = append(bg.keys, keys...)
+}
+
+// newProjection compiles paths into a projection.
+func (bg *BatchGet) newProjection(paths []string) *batchProjection {
+	var s subber
+	var expr string
+	for i, p := range paths {
+		if i != 0 {
+			expr += ", "
 		}
-		bg.reqs = append(bg.reqs, get)
+		name, err := s.escape(p)
+		bg.setError(err)
+		expr += name
 	}
+	return &batchProjection{expr: expr, names: s.nameExpr, group: projectionGroup(expr, s.nameExpr)}
+}
+
+// Project limits the result attributes to the given paths,
+// for every key except those added with AndProject.
+func (bg *BatchGet) Project(paths ...string) *BatchGet {
+	bg.projection = paths
+	return bg
+}
+
+// AndProject adds more keys to be gotten, limiting their result attributes to the given paths
+// instead of the projection set by Project.
+// Keys with different projections are requested separately under the hood,
+// because DynamoDB only allows one projection per table in each request.
+func (bg *BatchGet) AndProject(paths []string, keys ...Keyed) *BatchGet {
+	if len(paths) == 0 {
+		bg.setError(errors.New("dynamo: batch: AndProject requires at least one path"))
+		return bg
+	}
+	bg.add(keys, bg.newProjection(paths))
+	return bg
 }
 
 // Consistent will, if on is true, make this batch use a strongly consistent read.
-// Reads are eventually consistent by default.
+// Reads are eventually consistent by default, unless changed with DB.ConsistentReads or Table.ConsistentReads.
 // Strongly consistent reads are more resource-heavy than eventually consistent reads.
 func (bg *BatchGet) Consistent(on bool) *BatchGet {
-	bg.consistent = on
+	bg.consistent = &on
+	return bg
+}
+
+// consistentRead returns true if this batch should be strongly consistent.
+func (bg *BatchGet) consistentRead() bool {
+	if bg.consistent != nil {
+		return *bg.consistent
+	}
+	return bg.batch.table.consistentReads()
+}
+
+// RequireAll will, if on is true, make this batch return a *MissingKeysError
+// listing the keys of every requested item that could not be found.
+// Projections must include the key attributes for this to work.
+// It takes precedence over AllowEmpty.
+func (bg *BatchGet) RequireAll(on bool) *BatchGet {
+	bg.requireAll = on
+	return bg
+}
+
+// AllowEmpty will, if on is true, make this batch succeed with no results
+// instead of returning ErrNotFound when none of the requested items could be found.
+func (bg *BatchGet) AllowEmpty(on bool) *BatchGet {
+	bg.allowEmpty = on
 	return bg
 }
 
@@ -97,9 +225,51 @@ func (bg *BatchGet) ConsumedCapacity(cc *ConsumedCapacity) *BatchGet {
 	return bg
 }
 
+// OnUnprocessed sets fn to be called after each request of this batch,
+// with the number of keys DynamoDB left unprocessed. See DB.OnUnprocessed.
+func (bg *BatchGet) OnUnprocessed(fn func(UnprocessedEvent)) *BatchGet {
+	bg.onUnprocessed = fn
+	return bg
+}
+
+// Backoff sets how this batch waits before retrying unprocessed keys.
+// By default, it uses ExponentialBackoff.
+func (bg *BatchGet) Backoff(b Backoff) *BatchGet {
+	bg.backoff = b
+	return bg
+}
+
+// Timeout limits the total amount of time this batch may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+// For iterators, it applies to each call of Next or NextPage separately, rather than to the whole iteration;
+// to bound an iteration, pass NextWithContext a context with a deadline.
+func (bg *BatchGet) Timeout(timeout time.Duration) *BatchGet {
+	bg.timeout = timeout
+	return bg
+}
+
+// IncludeDeleted includes soft-deleted items in the results. See DB.WithSoftDelete.
+// Note that if a projection is used, it must include the soft deletion attribute for deleted items to be skipped.
+func (bg *BatchGet) IncludeDeleted() *BatchGet {
+	bg.includeDeleted = true
+	return bg
+}
+
+// OnPage sets fn to be called after each page of results is fetched, with the progress so far.
+// Consumed capacity is requested automatically if ConsumedCapacity isn't set.
+func (bg *BatchGet) OnPage(fn func(stats PageStats)) *BatchGet {
+	bg.onPage = fn
+	return bg
+}
+
 // All executes this request and unmarshals all results to out, which must be a pointer to a slice.
 func (bg *BatchGet) All(out interface{}) error {
-	iter := newBGIter(bg, unmarshalAppend, bg.err)
+	if bg.timeout != 0 || bg.decodeWorkers > 1 {
+		ctx, cancel := timeoutContext(bg.timeout)
+		defer cancel()
+		return bg.AllWithContext(ctx, out)
+	}
+	iter := newBGIter(bg, bg.batch.table.db.codec().unmarshalAppend, bg.err)
 	for iter.Next(out) {
 	}
 	return iter.Err()
@@ -107,7 +277,15 @@ func (bg *BatchGet) All(out interface{}) error {
 
 // AllWithContext executes this request and unmarshals all results to out, which must be a pointer to a slice.
 func (bg *BatchGet) AllWithContext(ctx aws.Context, out interface{}) error {
-	iter := newBGIter(bg, unmarshalAppend, bg.err)
+	ctx, cancel := withTimeout(ctx, bg.timeout)
+	defer cancel()
+	iter := newBGIter(bg, bg.batch.table.db.codec().unmarshalAppend, bg.err)
+	if bg.decodeWorkers > 1 && iter.err == nil {
+		if err := bg.batch.table.db.codec().decodePages(ctx, iter, bg.decodeWorkers, out); err != nil {
+			return err
+		}
+		return iter.Err()
+	}
 	for iter.NextWithContext(ctx, out) {
 	}
 	return iter.Err()
@@ -115,54 +293,208 @@ func (bg *BatchGet) AllWithContext(ctx aws.Context, out interface{}) error {
 
 // Iter returns a results iterator for this batch.
 func (bg *BatchGet) Iter() Iter {
-	return newBGIter(bg, unmarshalItem, bg.err)
+	return newBGIter(bg, bg.batch.table.db.codec().unmarshalItem, bg.err)
 }
 
-func (bg *BatchGet) input(start int) *dynamodb.BatchGetItemInput {
+// input returns the next request, starting with the key at start, or nil if there are no more keys.
+// It returns an error if the projection of any remaining key is invalid.
+func (bg *BatchGet) input(start int) (*dynamodb.BatchGetItemInput, error) {
 	if start >= len(bg.reqs) {
-		return nil // done
+		return nil, nil // done
 	}
 	end := start + maxGetOps
 	if end > len(bg.reqs) {
 		end = len(bg.reqs)
 	}
 
-	in := &dynamodb.BatchGetItemInput{
-		RequestItems: make(map[string]*dynamodb.KeysAndAttributes, 1),
+	// check the projections of the remaining keys before requesting any of them
+	checked := make(map[string]bool)
+	for _, get := range bg.reqs[start:] {
+		group := projectionOf(get)
+		if group == "" || checked[group] {
+			continue
+		}
+		if err := validateProjection(get.proj.expr, get.proj.names); err != nil {
+			return nil, err
+		}
+		checked[group] = true
 	}
 
-	if bg.projection != "" {
-		for _, get := range bg.reqs[start:end] {
-			get.Project(get.projection)
-			bg.setError(get.err)
+	// each request can only have one projection, so stop at the next group of keys
+	group := projectionOf(bg.reqs[start])
+	for i := start + 1; i < end; i++ {
+		if projectionOf(bg.reqs[i]) != group {
+			end = i
+			break
 		}
 	}
+
+	in := &dynamodb.BatchGetItemInput{
+		RequestItems: make(map[string]*dynamodb.KeysAndAttributes, 1),
+	}
 	if bg.cc != nil {
 		in.ReturnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityIndexes)
+	} else if bg.onPage != nil {
+		in.ReturnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityTotal)
 	}
 
-	var kas *dynamodb.KeysAndAttributes
+	keys := make([]map[string]*dynamodb.AttributeValue, 0, end-start)
 	for _, get := range bg.reqs[start:end] {
-		if kas == nil {
-			kas = get.keysAndAttribs()
+		keys = append(keys, get.key)
+	}
+	kas := &dynamodb.KeysAndAttributes{
+		Keys:           keys,
+		ConsistentRead: aws.Bool(bg.consistentRead()),
+	}
+	// every key in the request shares the first key's projection
+	if proj := bg.reqs[start].proj; proj != nil {
+		kas.ProjectionExpression = aws.String(proj.expr)
+		kas.ExpressionAttributeNames = proj.names
+	}
+	in.RequestItems[bg.batch.table.Name()] = kas
+	return in, nil
+}
+
+// prepare applies the default projection, removes duplicate keys, and groups the keys by projection,
+// keeping them in the order they were added otherwise.
+func (bg *BatchGet) prepare() {
+	if len(bg.projection) > 0 {
+		var proj *batchProjection
+		for i := range bg.reqs {
+			if bg.reqs[i].proj == nil {
+				if proj == nil {
+					proj = bg.newProjection(bg.projection)
+				}
+				bg.reqs[i].proj = proj
+			}
+		}
+	}
+	bg.dedupe()
+
+	order := make(map[string]int)
+	for _, get := range bg.reqs {
+		if group := projectionOf(get); order[group] == 0 {
+			order[group] = len(order) + 1
+		}
+	}
+	if len(order) < 2 {
+		return
+	}
+	idx := make([]int, len(bg.reqs))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return order[projectionOf(bg.reqs[idx[i]])] < order[projectionOf(bg.reqs[idx[j]])]
+	})
+	reqs := make([]batchKey, len(bg.reqs))
+	keys := make([]Keyed, len(bg.keys))
+	for i, j := range idx {
+		reqs[i] = bg.reqs[j]
+		keys[i] = bg.keys[j]
+	}
+	bg.reqs, bg.keys = reqs, keys
+}
+
+// dedupe removes keys that were added more than once with the same projection, keeping the first,
+// because DynamoDB rejects requests with duplicate keys.
+// Each item is still returned only once, however many times its key was added.
+func (bg *BatchGet) dedupe() {
+	if len(bg.reqs) < 2 {
+		return
+	}
+	seen := make(map[string]struct{}, len(bg.reqs))
+	reqs, keys := bg.reqs[:0], bg.keys[:0]
+	for i, get := range bg.reqs {
+		id := keyString(get.key) + "\x00" + projectionOf(get)
+		if _, dup := seen[id]; dup {
 			continue
 		}
-		kas.Keys = append(kas.Keys, get.keys())
+		seen[id] = struct{}{}
+		reqs = append(reqs, get)
+		keys = append(keys, bg.keys[i])
 	}
-	if bg.projection != "" {
-		kas.ProjectionExpression = &bg.projection
+	bg.reqs, bg.keys = reqs, keys
+}
+
+// projected returns true if any key has a projection.
+func (bg *BatchGet) projected() bool {
+	for _, get := range bg.reqs {
+		if get.proj != nil {
+			return true
+		}
 	}
-	if bg.consistent {
-		kas.ConsistentRead = &bg.consistent
+	return false
+}
+
+// projectionOf returns a string identifying the projection of get, for use as a map key.
+func projectionOf(get batchKey) string {
+	if get.proj == nil {
+		return ""
 	}
-	in.RequestItems[bg.batch.table.Name()] = kas
-	return in
+	return get.proj.group
+}
+
+// projectionGroup returns a string identifying a projection, for use as a map key.
+// The same paths produce the same expression and names.
+func projectionGroup(expr string, nameExpr map[string]*string) string {
+	names := make([]string, 0, len(nameExpr))
+	for placeholder, name := range nameExpr {
+		names = append(names, placeholder+"="+aws.StringValue(name))
+	}
+	sort.Strings(names)
+	return expr + "\x00" + strings.Join(names, "\x00")
+}
+
+// missing returns the requested keys that are not in found.
+func (bg *BatchGet) missing(found map[string]struct{}) []Keyed {
+	var missing []Keyed
+	for i, get := range bg.reqs {
+		if _, ok := found[keyString(get.key)]; !ok {
+			missing = append(missing, bg.keys[i])
+		}
+	}
+	return missing
+}
+
+// keyString returns a string uniquely identifying the given primary key, for use as a map key.
+func keyString(key map[string]*dynamodb.AttributeValue) string {
+	names := make([]string, 0, len(key))
+	for name := range key {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		av := key[name]
+		b.WriteString(name)
+		switch {
+		case av == nil:
+			b.WriteString("=nil")
+		case av.S != nil:
+			b.WriteString("=S:")
+			b.WriteString(*av.S)
+		case av.N != nil:
+			b.WriteString("=N:")
+			b.WriteString(*av.N)
+		case av.B != nil:
+			b.WriteString("=B:")
+			b.WriteString(base64.StdEncoding.EncodeToString(av.B))
+		}
+		b.WriteByte(0)
+	}
+	return b.String()
 }
 
 func (bg *BatchGet) setError(err error) {
+	if err == nil {
+		return
+	}
+	bg.mu.Lock()
 	if bg.err == nil {
 		bg.err = err
 	}
+	bg.mu.Unlock()
 }
 
 // bgIter is the iterator for Batch Get operations
@@ -174,110 +506,334 @@ type bgIter struct {
 	idx       int
 	total     int
 	processed int
-	backoff   *backoff.ExponentialBackOff
+	backoff   backoff.BackOff
 	unmarshal unmarshalFunc
+	found     map[string]struct{}
+	progress  pageTracker
+	reqID     lastRequestID
+
+	// requested holds every key of the batch, if some were served from the cache.
+	requested *BatchGet
 }
 
 func newBGIter(bg *BatchGet, fn unmarshalFunc, err error) *bgIter {
+	bg.prepare()
+	if err == nil {
+		err = bg.err
+	}
 	iter := &bgIter{
 		bg:        bg,
 		err:       err,
-		backoff:   backoff.NewExponentialBackOff(),
+		backoff:   bg.backoff.newBackOff(),
 		unmarshal: fn,
+		progress:  pageTracker{fn: bg.onPage},
 	}
-	iter.backoff.MaxElapsedTime = 0
 	return iter
 }
 
 // Next tries to unmarshal the next result into out.
 // Returns false when it is complete or if it runs into an error.
 func (itr *bgIter) Next(out interface{}) bool {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(itr.bg.timeout)
 	defer cancel()
 	return itr.NextWithContext(ctx, out)
 }
 
 func (itr *bgIter) NextWithContext(ctx aws.Context, out interface{}) bool {
+	ctx, cancel := withTimeout(ctx, itr.bg.timeout)
+	defer cancel()
 	// stop if we have an error
 	if itr.err != nil {
 		return false
 	}
 
-	tableName := itr.bg.batch.table.Name()
-
 	// can we use results we already have?
-	if itr.output != nil && itr.idx < len(itr.output.Responses[tableName]) {
-		items := itr.output.Responses[tableName]
-		item := items[itr.idx]
-		itr.err = itr.unmarshal(item, out)
+	if !itr.buffered() && !itr.fetch(ctx) {
+		return false
+	}
+
+	item := itr.output.Responses[itr.bg.batch.table.Name()][itr.idx]
+	itr.track(item)
+	itr.err = itr.unmarshal(item, out)
+	itr.idx++
+	itr.total++
+	return itr.err == nil
+}
+
+// NextPage unmarshals the rest of the current page of results into out, which must be a pointer to a slice.
+// If the current page has been consumed, the next page is requested.
+// out is replaced, not appended to. Returns false when it is complete or if it runs into an error.
+func (itr *bgIter) NextPage(out interface{}) bool {
+	ctx, cancel := timeoutContext(itr.bg.timeout)
+	defer cancel()
+	return itr.NextPageWithContext(ctx, out)
+}
+
+func (itr *bgIter) NextPageWithContext(ctx aws.Context, out interface{}) bool {
+	ctx, cancel := withTimeout(ctx, itr.bg.timeout)
+	defer cancel()
+	if itr.err != nil {
+		return false
+	}
+	if itr.err = resetSlice(out); itr.err != nil {
+		return false
+	}
+	if !itr.buffered() && !itr.fetch(ctx) {
+		return false
+	}
+
+	items := itr.output.Responses[itr.bg.batch.table.Name()][itr.idx:]
+	for _, item := range items {
+		itr.track(item)
+		if itr.err = itr.bg.batch.table.db.codec().unmarshalAppend(item, out); itr.err != nil {
+			return false
+		}
 		itr.idx++
 		itr.total++
-		return itr.err == nil
 	}
+	return true
+}
 
-	// new bg
-	if itr.input == nil {
-		itr.input = itr.bg.input(itr.processed)
+func (itr *bgIter) nextItems(ctx aws.Context) ([]map[string]*dynamodb.AttributeValue, bool) {
+	if itr.err != nil {
+		return nil, false
 	}
+	if !itr.buffered() && !itr.fetch(ctx) {
+		return nil, false
+	}
+	items := itr.output.Responses[itr.bg.batch.table.Name()][itr.idx:]
+	for _, item := range items {
+		itr.track(item)
+	}
+	itr.idx += len(items)
+	itr.total += len(items)
+	return items, true
+}
+
+// UnprocessedKeys returns the keys DynamoDB left unprocessed in the last response.
+// They will be requested again by the next call to Next or NextPage.
+func (itr *bgIter) UnprocessedKeys() []map[string]*dynamodb.AttributeValue {
+	if itr.output == nil {
+		return nil
+	}
+	if ka := itr.output.UnprocessedKeys[itr.bg.batch.table.Name()]; ka != nil {
+		return ka.Keys
+	}
+	return nil
+}
+
+// buffered returns true if there are unread results from the last request.
+func (itr *bgIter) buffered() bool {
+	return itr.output != nil && itr.idx < len(itr.output.Responses[itr.bg.batch.table.Name()])
+}
 
-	if itr.output != nil && itr.idx >= len(itr.output.Responses[tableName]) {
-		var unprocessed int
-		if itr.output.UnprocessedKeys != nil && itr.output.UnprocessedKeys[tableName] != nil {
-			unprocessed = len(itr.output.UnprocessedKeys[tableName].Keys)
+// fetch requests pages until one with results is found.
+// Returns false when there are no more results or if it runs into an error.
+func (itr *bgIter) fetch(ctx aws.Context) bool {
+	tableName := itr.bg.batch.table.Name()
+	if itr.input == nil && itr.output == nil {
+		if itr.err = itr.bg.validate(ctx); itr.err != nil {
+			return false
 		}
-		itr.processed += len(itr.input.RequestItems[tableName].Keys) - unprocessed
-		// have we exhausted all results?
-		if len(itr.output.UnprocessedKeys) == 0 {
-			// yes, try to get next inner batch of 100 items
-			if itr.input = itr.bg.input(itr.processed); itr.input == nil {
-				// we're done, no more input
-				if itr.err == nil && itr.total == 0 {
-					itr.err = ErrNotFound
-				}
+		if itr.fromCache() && itr.buffered() {
+			return true
+		}
+	}
+	for {
+		// new bg
+		if itr.input == nil {
+			if itr.input, itr.err = itr.bg.input(itr.processed); itr.err != nil {
 				return false
 			}
-		} else {
-			// no, prepare a new request with the remaining keys
-			itr.input.RequestItems = itr.output.UnprocessedKeys
-			// we need to sleep here a bit as per the official docs
-			if err := aws.SleepWithContext(ctx, itr.backoff.NextBackOff()); err != nil {
-				// timed out
-				itr.err = err
-				return false
+		}
+
+		if itr.output != nil {
+			var unprocessed int
+			if itr.output.UnprocessedKeys != nil && itr.output.UnprocessedKeys[tableName] != nil {
+				unprocessed = len(itr.output.UnprocessedKeys[tableName].Keys)
+			}
+			itr.processed += len(itr.input.RequestItems[tableName].Keys) - unprocessed
+			// have we exhausted all results?
+			if len(itr.output.UnprocessedKeys) == 0 {
+				// yes, try to get next inner batch of 100 items
+				if itr.input, itr.err = itr.bg.input(itr.processed); itr.err != nil {
+					return false
+				}
+				if itr.input == nil {
+					// we're done, no more input
+					itr.done()
+					return false
+				}
+			} else {
+				// no, prepare a new request with the remaining keys
+				itr.input.RequestItems = itr.output.UnprocessedKeys
+				// we need to sleep here a bit as per the official docs
+				if err := sleepBackoff(ctx, itr.backoff); err != nil {
+					// timed out
+					itr.err = err
+					return false
+				}
 			}
 		}
 		itr.idx = 0
+
+		cache := itr.bg.batch.table.db.cache
+		if itr.input.RequestItems[tableName].ProjectionExpression != nil {
+			cache = nil
+		}
+		var gens map[string]uint64
+		if cache != nil {
+			gens = make(map[string]uint64, len(itr.input.RequestItems[tableName].Keys))
+			for _, key := range itr.input.RequestItems[tableName].Keys {
+				gens[keyString(key)] = cache.generation(tableName, key)
+			}
+		}
+
+		itr.progress.begin()
+		itr.err = itr.bg.batch.table.db.retry(ctx, tableName, func() error {
+			var err error
+			itr.output, err = itr.bg.batch.table.db.client.BatchGetItemWithContext(ctx, itr.input, itr.reqID.options(itr.bg.batch.table.db.opts)...)
+			itr.progress.attempt(err)
+			return err
+		})
+		if itr.err != nil {
+			return false
+		}
+		ev := UnprocessedEvent{Op: "BatchGetItem", Table: tableName, Requested: len(itr.input.RequestItems[tableName].Keys)}
+		if ka := itr.output.UnprocessedKeys[tableName]; ka != nil {
+			ev.Unprocessed = len(ka.Keys)
+		}
+		itr.bg.batch.table.db.reportUnprocessed(ev, itr.bg.onUnprocessed)
+		if itr.bg.cc != nil {
+			for _, cc := range itr.output.ConsumedCapacity {
+				addConsumedCapacity(itr.bg.cc, cc)
+			}
+		}
+		if cache != nil {
+			for _, item := range itr.output.Responses[tableName] {
+				key := itr.keyOf(item)
+				cache.set(tableName, key, item, gens[keyString(key)])
+			}
+		}
+		if items := itr.output.Responses[tableName]; len(items) > 0 {
+			itr.output.Responses[tableName] = itr.skipDeleted(items)
+		}
+		itr.progress.page(len(itr.output.Responses[tableName]), nil, itr.output.ConsumedCapacity...)
+
+		if len(itr.output.Responses[tableName]) > 0 {
+			return true
+		}
+		// need to keep going to get more keys
 	}
+}
 
-	itr.err = retry(ctx, func() error {
-		var err error
-		itr.output, err = itr.bg.batch.table.db.client.BatchGetItemWithContext(ctx, itr.input)
-		return err
-	})
-	if itr.err != nil {
+// track records the key of item, if RequireAll is enabled.
+func (itr *bgIter) track(item map[string]*dynamodb.AttributeValue) {
+	if !itr.bg.requireAll {
+		return
+	}
+	if itr.found == nil {
+		itr.found = make(map[string]struct{}, len(itr.bg.reqs))
+	}
+	itr.found[keyString(itr.keyOf(item))] = struct{}{}
+}
+
+// skipDeleted removes soft-deleted items from items, unless IncludeDeleted is set.
+func (itr *bgIter) skipDeleted(items []map[string]*dynamodb.AttributeValue) []map[string]*dynamodb.AttributeValue {
+	db := itr.bg.batch.table.db
+	if db.soft == nil || itr.bg.includeDeleted {
+		return items
+	}
+	kept := items[:0]
+	for _, item := range items {
+		if !db.softDeleted(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// Stats returns statistics about the pages fetched so far.
+func (itr *bgIter) Stats() PageStats {
+	return itr.progress.stats
+}
+
+// RequestID returns the ID of the latest request sent by this iterator.
+func (itr *bgIter) RequestID() string {
+	return itr.reqID.get()
+}
+
+// keyOf returns the primary key of item.
+func (itr *bgIter) keyOf(item map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	key := map[string]*dynamodb.AttributeValue{
+		itr.bg.batch.hashKey: item[itr.bg.batch.hashKey],
+	}
+	if itr.bg.batch.rangeKey != "" {
+		key[itr.bg.batch.rangeKey] = item[itr.bg.batch.rangeKey]
+	}
+	return key
+}
+
+// fromCache serves the requested items that are in the cache as the first page of results,
+// leaving only the rest to be requested. Items known not to exist are skipped.
+// Returns false if nothing was cached.
+func (itr *bgIter) fromCache() bool {
+	c := itr.bg.batch.table.db.cache
+	if c == nil || itr.bg.projected() || itr.bg.consistentRead() {
 		return false
 	}
-	if itr.bg.cc != nil {
-		for _, cc := range itr.output.ConsumedCapacity {
-			addConsumedCapacity(itr.bg.cc, cc)
+	tableName := itr.bg.batch.table.Name()
+	var cached []map[string]*dynamodb.AttributeValue
+	var uncached []batchKey
+	var uncachedKeys []Keyed
+	for i, get := range itr.bg.reqs {
+		item, ok := c.get(tableName, get.key)
+		switch {
+		case !ok:
+			uncached = append(uncached, get)
+			uncachedKeys = append(uncachedKeys, itr.bg.keys[i])
+		case len(item) > 0:
+			cached = append(cached, item)
 		}
 	}
+	if len(uncached) == len(itr.bg.reqs) {
+		return false
+	}
+	cached = itr.skipDeleted(cached)
 
-	items := itr.output.Responses[tableName]
-	if len(items) == 0 {
-		if len(itr.output.UnprocessedKeys) == 0 {
-			if itr.total == 0 {
-				itr.err = ErrNotFound
-			}
-			return false
+	bg := *itr.bg
+	bg.reqs = uncached
+	bg.keys = uncachedKeys
+	itr.requested, itr.bg = itr.bg, &bg
+	// pretend the cached items came from an empty request, so the next fetch moves on to the uncached keys
+	itr.input = &dynamodb.BatchGetItemInput{
+		RequestItems: map[string]*dynamodb.KeysAndAttributes{tableName: {}},
+	}
+	itr.output = &dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]*dynamodb.AttributeValue{tableName: cached},
+	}
+	itr.idx = 0
+	return true
+}
+
+// done is called when there are no more results, and sets the final error.
+func (itr *bgIter) done() {
+	if itr.err != nil {
+		return
+	}
+	switch {
+	case itr.bg.requireAll:
+		requested := itr.bg
+		if itr.requested != nil {
+			// keys known not to exist were never requested, but are still missing
+			requested = itr.requested
+		}
+		if missing := requested.missing(itr.found); len(missing) > 0 {
+			itr.err = &MissingKeysError{Keys: missing}
 		}
-		// need to retry to get more keys
-		return itr.NextWithContext(ctx, out)
+	case itr.total == 0 && !itr.bg.allowEmpty:
+		itr.err = ErrNotFound
 	}
-	itr.err = itr.unmarshal(items[itr.idx], out)
-	itr.idx++
-	itr.total++
-	return itr.err == nil
 }
 
 // Err returns the error encountered, if any.
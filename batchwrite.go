@@ -2,6 +2,8 @@ package dynamo
 
 import (
 	"math"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -12,46 +14,81 @@ import (
 const maxWriteOps = 25
 
 // BatchWrite is a BatchWriteItem operation.
+// Put and Delete are safe to call from multiple goroutines at once, so that concurrent producers can fill a single batch.
+// The batch must not be run until they have returned.
 type BatchWrite struct {
-	batch Batch
-	ops   []*dynamodb.WriteRequest
-	err   error
-	cc    *ConsumedCapacity
+	batch   Batch
+	mu      sync.Mutex // guards ops and err while adding
+	ops     []*dynamodb.WriteRequest
+	err     error
+	cc      *ConsumedCapacity
+	icm     *[]ItemCollectionMetrics
+	reqID   *string
+	timeout time.Duration
+
+	onUnprocessed func(UnprocessedEvent)
+	backoff       Backoff
+	validateKeys  bool
 }
 
 // Write creates a new batch write request, to which
 // puts and deletes can be added.
 func (b Batch) Write() *BatchWrite {
+	defs := b.table.db.requestDefaults()
 	return &BatchWrite{
-		batch: b,
-		err:   b.err,
+		batch:   b,
+		err:     b.err,
+		cc:      defs.cc,
+		timeout: defs.timeout,
 	}
 }
 
 // Put adds put operations for items to this batch.
 func (bw *BatchWrite) Put(items ...interface{}) *BatchWrite {
+	ops := make([]*dynamodb.WriteRequest, 0, len(items))
 	for _, item := range items {
-		encoded, err := marshalItem(item)
+		c := bw.batch.table.db.codec()
+		encoded, err := c.marshalItem(item)
+		if err == nil {
+			err = c.stampItem(item, encoded, bw.batch.table.db.now())
+		}
 		bw.setError(err)
-		bw.ops = append(bw.ops, &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{
+		ops = append(ops, &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{
 			Item: encoded,
 		}})
 	}
+	bw.addOps(ops)
 	return bw
 }
 
 // Delete adds delete operations for the given keys to this batch.
 func (bw *BatchWrite) Delete(keys ...Keyed) *BatchWrite {
+	ops := make([]*dynamodb.WriteRequest, 0, len(keys))
 	for _, key := range keys {
 		del := bw.batch.table.Delete(bw.batch.hashKey, key.HashKey())
 		if rk := key.RangeKey(); bw.batch.rangeKey != "" && rk != nil {
 			del.Range(bw.batch.rangeKey, rk)
 			bw.setError(del.err)
 		}
-		bw.ops = append(bw.ops, &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{
+		ops = append(ops, &dynamodb.WriteRequest{DeleteRequest: &dynamodb.DeleteRequest{
 			Key: del.key(),
 		}})
 	}
+	bw.addOps(ops)
+	return bw
+}
+
+// addOps appends ops to this batch, keeping those added by one call together.
+func (bw *BatchWrite) addOps(ops []*dynamodb.WriteRequest) {
+	bw.mu.Lock()
+	bw.ops = append(bw.ops, ops...)
+	bw.mu.Unlock()
+}
+
+// RequestID will record the ID of the last request sent by this batch in id, even if it failed.
+// It is useful for correlating with AWS support cases and server-side logs.
+func (bw *BatchWrite) RequestID(id *string) *BatchWrite {
+	bw.reqID = id
 	return bw
 }
 
@@ -61,25 +98,68 @@ func (bw *BatchWrite) ConsumedCapacity(cc *ConsumedCapacity) *BatchWrite {
 	return bw
 }
 
+// OnUnprocessed sets fn to be called after each request of this batch,
+// with the number of items DynamoDB left unprocessed. See DB.OnUnprocessed.
+func (bw *BatchWrite) OnUnprocessed(fn func(UnprocessedEvent)) *BatchWrite {
+	bw.onUnprocessed = fn
+	return bw
+}
+
+// ItemCollectionMetrics will append the estimated sizes of the item collections affected by this batch to icm.
+// This is only reported for tables with local secondary indexes.
+func (bw *BatchWrite) ItemCollectionMetrics(icm *[]ItemCollectionMetrics) *BatchWrite {
+	bw.icm = icm
+	return bw
+}
+
+// Backoff sets how this batch waits before retrying unprocessed items.
+// By default, it uses ExponentialBackoff.
+func (bw *BatchWrite) Backoff(b Backoff) *BatchWrite {
+	bw.backoff = b
+	return bw
+}
+
+// Timeout limits the total amount of time this batch may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (bw *BatchWrite) Timeout(timeout time.Duration) *BatchWrite {
+	bw.timeout = timeout
+	return bw
+}
+
 // Run executes this batch.
 // For batches with more than 25 operations, an error could indicate that
 // some records have been written and some have not. Consult the wrote
 // return amount to figure out which operations have succeeded.
 func (bw *BatchWrite) Run() (wrote int, err error) {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(bw.timeout)
 	defer cancel()
 	return bw.RunWithContext(ctx)
 }
 
 func (bw *BatchWrite) RunWithContext(ctx aws.Context) (wrote int, err error) {
+	ctx, cancel := withTimeout(ctx, bw.timeout)
+	defer cancel()
 	if bw.err != nil {
 		return 0, bw.err
 	}
+	if err := bw.validate(ctx); err != nil {
+		return 0, err
+	}
+
+	if db := bw.batch.table.db; db.hasWriteHooks() {
+		events := batchWriteEvents(bw.batch.table.Name(), bw.ops)
+		if err := db.beforeWrite(events...); err != nil {
+			return 0, err
+		}
+		defer func() {
+			db.afterWrite(err, events...)
+		}()
+	}
 
 	// TODO: this could be made to be more efficient,
 	// by combining unprocessed items with the next request.
 
-	boff := backoff.WithContext(backoff.NewExponentialBackOff(), ctx)
+	boff := backoff.WithContext(bw.backoff.newBackOff(), ctx)
 	batches := int(math.Ceil(float64(len(bw.ops)) / maxWriteOps))
 	for i := 0; i < batches; i++ {
 		start, end := i*maxWriteOps, (i+1)*maxWriteOps
@@ -90,11 +170,14 @@ func (bw *BatchWrite) RunWithContext(ctx aws.Context) (wrote int, err error) {
 		for {
 			var res *dynamodb.BatchWriteItemOutput
 			req := bw.input(ops)
-			err := retry(ctx, func() error {
+			err := bw.batch.table.db.retry(ctx, bw.batch.table.Name(), func() error {
 				var err error
-				res, err = bw.batch.table.db.client.BatchWriteItemWithContext(ctx, req)
+				res, err = bw.batch.table.db.client.BatchWriteItemWithContext(ctx, req, bw.batch.table.db.requestOptions(bw.reqID)...)
 				return err
 			})
+			if c := bw.batch.table.db.cache; c != nil {
+				c.invalidateWrites(bw.batch.table.Name(), req.RequestItems[bw.batch.table.Name()])
+			}
 			if err != nil {
 				return wrote, err
 			}
@@ -103,16 +186,29 @@ func (bw *BatchWrite) RunWithContext(ctx aws.Context) (wrote int, err error) {
 					addConsumedCapacity(bw.cc, cc)
 				}
 			}
+			if bw.icm != nil {
+				for table, metrics := range res.ItemCollectionMetrics {
+					for _, raw := range metrics {
+						*bw.icm = append(*bw.icm, newItemCollectionMetrics(table, raw))
+					}
+				}
+			}
 
 			unprocessed := res.UnprocessedItems[bw.batch.table.Name()]
 			wrote += len(ops) - len(unprocessed)
+			bw.batch.table.db.reportUnprocessed(UnprocessedEvent{
+				Op:          "BatchWriteItem",
+				Table:       bw.batch.table.Name(),
+				Requested:   len(ops),
+				Unprocessed: len(unprocessed),
+			}, bw.onUnprocessed)
 			if len(unprocessed) == 0 {
 				break
 			}
 			ops = unprocessed
 
 			// need to sleep when re-requesting, per spec
-			if err := aws.SleepWithContext(ctx, boff.NextBackOff()); err != nil {
+			if err := sleepBackoff(ctx, boff); err != nil {
 				// timed out
 				return wrote, err
 			}
@@ -131,11 +227,19 @@ func (bw *BatchWrite) input(ops []*dynamodb.WriteRequest) *dynamodb.BatchWriteIt
 	if bw.cc != nil {
 		input.ReturnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityIndexes)
 	}
+	if bw.icm != nil {
+		input.ReturnItemCollectionMetrics = aws.String(dynamodb.ReturnItemCollectionMetricsSize)
+	}
 	return input
 }
 
 func (bw *BatchWrite) setError(err error) {
+	if err == nil {
+		return
+	}
+	bw.mu.Lock()
 	if bw.err == nil {
 		bw.err = err
 	}
+	bw.mu.Unlock()
 }
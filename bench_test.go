@@ -102,6 +102,43 @@ func BenchmarkDecodeVeryComplexMap(b *testing.B) {
 	}
 }
 
+func BenchmarkDecodeAppendVeryComplex(b *testing.B) {
+	av, _ := marshalItem(veryComplexObject)
+	b.ReportAllocs()
+
+	out := make([]fancyObject, 0, b.N)
+	for n := 0; n < b.N; n++ {
+		unmarshalAppend(av, &out)
+	}
+}
+
+func BenchmarkBatchGetKeys(b *testing.B) {
+	batch := NewFromIface(nil).Table(testTable).Batch("UserID", "Time")
+	keys := make([]Keyed, maxGetOps)
+	for i := range keys {
+		keys[i] = Keys{i, i}
+	}
+	b.ReportAllocs()
+
+	for n := 0; n < b.N; n++ {
+		batch.Get(keys...)
+	}
+}
+
+func BenchmarkBatchGetInput(b *testing.B) {
+	table := NewFromIface(nil).Table(testTable)
+	keys := make([]Keyed, maxGetOps)
+	for i := range keys {
+		keys[i] = Keys{i, i}
+	}
+	bg := table.Batch("UserID", "Time").Get(keys...)
+	b.ReportAllocs()
+
+	for n := 0; n < b.N; n++ {
+		bg.input(0)
+	}
+}
+
 type simpleObject struct {
 	User  int
 	Other string
@@ -148,3 +185,13 @@ func makeSuperComplex() SuperComplex {
 	sc[0].HelpMe.FFF = []int{1, 2, 3}
 	return sc
 }
+
+func BenchmarkBatchGetAnd(b *testing.B) {
+	table := NewFromIface(nil).Table(testTable)
+	for n := 0; n < b.N; n++ {
+		bg := table.Batch("UserID").Get()
+		for i := 0; i < 1000; i++ {
+			bg.And(Keys{i})
+		}
+	}
+}
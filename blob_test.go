@@ -0,0 +1,70 @@
+package dynamo
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestBlobCodec(t *testing.T) {
+	db := NewFromIface(nil).RegisterBlobCodec("json", BlobCodec{Marshal: json.Marshal, Unmarshal: json.Unmarshal})
+
+	type meta struct {
+		Tags  []string
+		Score int
+	}
+	type event struct {
+		ID    string `dynamo:",hash"`
+		Meta  meta   `dynamo:",json"`
+		Ptr   *meta  `dynamo:",json"`
+		Nil   *meta  `dynamo:",json"`
+		Plain meta
+	}
+	in := event{
+		ID:    "e1",
+		Meta:  meta{Tags: []string{"a"}, Score: 1},
+		Ptr:   &meta{Score: 2},
+		Plain: meta{Tags: []string{"b"}, Score: 3},
+	}
+	item, err := db.MarshalItem(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(item["Meta"].B); got != `{"Tags":["a"],"Score":1}` {
+		t.Error("bad blob:", got)
+	}
+	if item["Ptr"].B == nil {
+		t.Error("pointer not encoded as blob:", item["Ptr"])
+	}
+	if _, ok := item["Nil"]; ok {
+		t.Error("nil pointer should be omitted:", item["Nil"])
+	}
+	if item["Plain"].M == nil {
+		t.Error("untagged struct should be a map:", item["Plain"])
+	}
+
+	var out event
+	if err := db.UnmarshalItem(item, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("bad result: %+v ≠ %+v", out, in)
+	}
+
+	item["Meta"] = &dynamodb.AttributeValue{S: aws.String("nope")}
+	if err := db.UnmarshalItem(item, &out); err == nil {
+		t.Error("expected error decoding a string as a blob")
+	}
+
+	// unregistered names are ignored
+	item, err = MarshalItem(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item["Meta"].M == nil {
+		t.Error("package-level MarshalItem used blob codec:", item["Meta"])
+	}
+}
@@ -0,0 +1,191 @@
+package dynamo
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// ErrCircuitOpen is returned when a request is rejected without being sent because its table's
+// circuit breaker is open. See DB.WithCircuitBreaker.
+var ErrCircuitOpen = errors.New("dynamo: circuit breaker open")
+
+// CircuitBreaker configures per-table circuit breakers. See DB.WithCircuitBreaker.
+type CircuitBreaker struct {
+	// Window is the period over which failures are counted. Defaults to 10 seconds.
+	Window time.Duration
+	// MinRequests is the minimum number of requests within a window before the breaker can open.
+	// Defaults to 20.
+	MinRequests int
+	// ErrorRate is the fraction of failed requests within a window, from 0 to 1, that opens the breaker.
+	// Defaults to 0.5.
+	ErrorRate float64
+	// SlowThreshold, if set, counts requests that take at least this long as failures.
+	SlowThreshold time.Duration
+	// Cooldown is how long the breaker stays open before letting a trial request through.
+	// Defaults to 5 seconds.
+	Cooldown time.Duration
+}
+
+// WithCircuitBreaker returns a copy of this DB that wraps each table's item operations in a circuit breaker.
+// Server errors, throttling, network errors, and requests slower than SlowThreshold count as failures.
+// Once the failure rate exceeds ErrorRate, requests to that table fail immediately with ErrCircuitOpen,
+// including retries of requests already in progress, until the Cooldown has passed and a trial request succeeds.
+// Table management operations and transactions are not affected.
+func (db *DB) WithCircuitBreaker(cfg CircuitBreaker) *DB {
+	if cfg.Window == 0 {
+		cfg.Window = 10 * time.Second
+	}
+	if cfg.MinRequests == 0 {
+		cfg.MinRequests = 20
+	}
+	if cfg.ErrorRate == 0 {
+		cfg.ErrorRate = 0.5
+	}
+	if cfg.Cooldown == 0 {
+		cfg.Cooldown = 5 * time.Second
+	}
+	cp := *db
+	cp.breakers = newBreakers(cfg)
+	return &cp
+}
+
+// retry is like retry, but checks and updates table's circuit breaker around each attempt, if enabled.
+func (db *DB) retry(ctx aws.Context, table string, f func() error) error {
+	ctx = db.budgeted(ctx)
+	if db.breakers == nil {
+		return retry(ctx, f)
+	}
+	b := db.breakers.get(table)
+	return retry(ctx, func() error {
+		if !b.allow() {
+			return ErrCircuitOpen
+		}
+		start := time.Now()
+		err := f()
+		b.record(err, time.Since(start))
+		return err
+	})
+}
+
+type breakers struct {
+	cfg    CircuitBreaker
+	mu     sync.Mutex
+	tables map[string]*breaker
+}
+
+func newBreakers(cfg CircuitBreaker) *breakers {
+	return &breakers{
+		cfg:    cfg,
+		tables: make(map[string]*breaker),
+	}
+}
+
+func (bs *breakers) get(table string) *breaker {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	b, ok := bs.tables[table]
+	if !ok {
+		b = &breaker{cfg: &bs.cfg}
+		bs.tables[table] = b
+	}
+	return b
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breaker is a circuit breaker for a single table.
+type breaker struct {
+	cfg *CircuitBreaker
+
+	mu       sync.Mutex
+	state    breakerState
+	start    time.Time // of the current window, or when the breaker opened
+	requests int
+	failures int
+	trial    bool // a trial request is in flight
+}
+
+// allow reports whether a request may be sent.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.start) < b.cfg.Cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.trial = true
+		return true
+	case breakerHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	}
+	return true
+}
+
+// record updates the breaker with the outcome of a request.
+func (b *breaker) record(err error, took time.Duration) {
+	failed := isFailure(err) || (b.cfg.SlowThreshold > 0 && took >= b.cfg.SlowThreshold)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	switch b.state {
+	case breakerHalfOpen:
+		b.trial = false
+		if failed {
+			b.state = breakerOpen
+			b.start = now
+			return
+		}
+		b.state = breakerClosed
+		b.start = now
+		b.requests, b.failures = 0, 0
+		return
+	case breakerOpen:
+		// a request sent before the breaker opened
+		return
+	}
+
+	if now.Sub(b.start) >= b.cfg.Window {
+		b.start = now
+		b.requests, b.failures = 0, 0
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+	if b.requests >= b.cfg.MinRequests && float64(b.failures)/float64(b.requests) >= b.cfg.ErrorRate {
+		b.state = breakerOpen
+		b.start = now
+	}
+}
+
+// isFailure returns true if err indicates that DynamoDB is unhealthy,
+// as opposed to a problem with the request itself.
+func isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if canRetry(err) {
+		return true
+	}
+	if ae, ok := err.(awserr.Error); ok && ae.Code() == "RequestError" {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package dynamo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// flakyClient is a fake client whose PutItem requests fail with a server error while fail is set.
+type flakyClient struct {
+	dynamodbiface.DynamoDBAPI
+	fail  bool
+	calls int
+}
+
+func (c *flakyClient) PutItemWithContext(aws.Context, *dynamodb.PutItemInput, ...request.Option) (*dynamodb.PutItemOutput, error) {
+	c.calls++
+	if c.fail {
+		return nil, awserr.NewRequestFailure(awserr.New("InternalServerError", "oops", nil), 500, "")
+	}
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	client := &flakyClient{fail: true}
+	db := NewFromIface(client).WithCircuitBreaker(CircuitBreaker{
+		MinRequests: 1,
+		Cooldown:    time.Minute,
+	})
+	table := db.Table(testTable)
+
+	// a failed attempt opens the breaker, stopping further retries
+	err := table.Put(widget{UserID: 42}).Timeout(5 * time.Second).Run()
+	if err != ErrCircuitOpen {
+		t.Fatal("expected ErrCircuitOpen, got", err)
+	}
+	if client.calls != 1 {
+		t.Error("expected 1 call, got", client.calls)
+	}
+
+	// other tables are unaffected
+	if err := db.Table("other").Put(widget{UserID: 42}).Timeout(time.Millisecond).Run(); err == ErrCircuitOpen {
+		t.Error("breaker shared between tables")
+	}
+
+	client.calls = 0
+	if err := table.Put(widget{UserID: 42}).Run(); err != ErrCircuitOpen {
+		t.Error("expected ErrCircuitOpen, got", err)
+	}
+	if client.calls != 0 {
+		t.Error("request sent while breaker open")
+	}
+
+	// a successful trial request closes the breaker
+	b := db.breakers.get(testTable)
+	b.mu.Lock()
+	b.start = b.start.Add(-time.Hour)
+	b.mu.Unlock()
+	client.fail = false
+	if err := table.Put(widget{UserID: 42}).Run(); err != nil {
+		t.Error("unexpected error:", err)
+	}
+	if err := table.Put(widget{UserID: 42}).Run(); err != nil {
+		t.Error("unexpected error:", err)
+	}
+}
+
+func TestBreakerSlow(t *testing.T) {
+	b := &breaker{cfg: &CircuitBreaker{
+		Window:        time.Minute,
+		MinRequests:   1,
+		ErrorRate:     0.5,
+		SlowThreshold: time.Second,
+		Cooldown:      time.Minute,
+	}}
+	b.record(nil, time.Millisecond)
+	if !b.allow() {
+		t.Fatal("breaker opened after fast request")
+	}
+	b.record(nil, 2*time.Second)
+	b.record(nil, 2*time.Second)
+	if b.allow() {
+		t.Error("breaker didn't open after slow requests")
+	}
+}
@@ -0,0 +1,434 @@
+package dynamo
+
+import (
+	"bytes"
+	"errors"
+	"hash/fnv"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// TimeBuckets is a partitioning scheme for time series data.
+// Items are spread across partition keys made of a prefix, the time period they belong to, and a shard number,
+// like "metrics#2019-06-01#3", so that writes for the same period don't all go to a single hot partition.
+// Use Key or ShardKey to pick the partition key when writing, and Table.GetBuckets to query a time range.
+type TimeBuckets struct {
+	// Prefix starts every partition key, such as "metrics".
+	Prefix string
+	// Period is the width of each bucket. Defaults to 24 hours.
+	// Buckets are aligned to multiples of Period since the zero time, in UTC.
+	Period time.Duration
+	// Layout is the time format of the bucket in partition keys. Defaults to "2006-01-02".
+	// It should identify a bucket uniquely, so periods shorter than a day need a layout that includes the time.
+	Layout string
+	// Shards is the number of partition keys for each bucket.
+	// If it is 1 or less, partition keys have no shard suffix.
+	Shards int
+}
+
+func (tb TimeBuckets) period() time.Duration {
+	if tb.Period <= 0 {
+		return 24 * time.Hour
+	}
+	return tb.Period
+}
+
+func (tb TimeBuckets) layout() string {
+	if tb.Layout == "" {
+		return "2006-01-02"
+	}
+	return tb.Layout
+}
+
+// Bucket returns the start of the bucket that t belongs to.
+func (tb TimeBuckets) Bucket(t time.Time) time.Time {
+	return t.UTC().Truncate(tb.period())
+}
+
+// Key returns the partition key for the given time and shard.
+func (tb TimeBuckets) Key(t time.Time, shard int) string {
+	key := tb.Bucket(t).Format(tb.layout())
+	if tb.Prefix != "" {
+		key = tb.Prefix + "#" + key
+	}
+	if tb.Shards > 1 {
+		key += "#" + strconv.Itoa(shard)
+	}
+	return key
+}
+
+// ShardKey returns the partition key for the given time, with a shard chosen by hashing id.
+// The same id is always written to the same shard of a bucket.
+func (tb TimeBuckets) ShardKey(t time.Time, id string) string {
+	var shard int
+	if tb.Shards > 1 {
+		h := fnv.New32a()
+		h.Write([]byte(id))
+		shard = int(h.Sum32() % uint32(tb.Shards))
+	}
+	return tb.Key(t, shard)
+}
+
+// Keys returns the partition keys of every shard of every bucket between start and end, inclusive.
+func (tb TimeBuckets) Keys(start, end time.Time) []string {
+	shards := tb.Shards
+	if shards < 1 {
+		shards = 1
+	}
+	var keys []string
+	for bucket := tb.Bucket(start); !bucket.After(end); bucket = bucket.Add(tb.period()) {
+		for shard := 0; shard < shards; shard++ {
+			keys = append(keys, tb.Key(bucket, shard))
+		}
+	}
+	return keys
+}
+
+// BucketQuery is a request to query several partitions at once,
+// merging their results in range key order.
+// See Table.GetBuckets and ShardedTable.Get.
+type BucketQuery struct {
+	queries  []*Query
+	mergeKey string
+	limit    int64
+	order    Order
+	unshard  string // hash key to remove shard suffixes from
+	dedupe   func(item map[string]*dynamodb.AttributeValue) string
+	err      error
+}
+
+// GetBuckets creates a new request to query every partition of buckets between start and end.
+// Results of the queries are merged in order of the range key given with Range or MergeBy,
+// which should hold the item's timestamp in a lexically or numerically sortable form.
+// The queries run concurrently.
+func (table Table) GetBuckets(hashKey string, buckets TimeBuckets, start, end time.Time) *BucketQuery {
+	bq := &BucketQuery{order: Ascending}
+	if end.Before(start) {
+		bq.err = errors.New("dynamo: bucket query: end is before start")
+	}
+	for _, key := range buckets.Keys(start, end) {
+		bq.queries = append(bq.queries, table.Get(hashKey, key))
+	}
+	return bq
+}
+
+// HashValues creates a new request that runs this query once for each of the given hash key values,
+// instead of the value given to Get, and merges the results.
+// Every query keeps this query's range key condition, filters, and other options.
+// Results are merged in order of the range key. Without a range key condition, use MergeBy to choose the attribute to merge by.
+// The queries run concurrently.
+// This is useful when a logical entity is spread across several partition keys.
+func (q *Query) HashValues(values ...interface{}) *BucketQuery {
+	bq := &BucketQuery{
+		mergeKey: q.rangeKey,
+		limit:    q.limit,
+		order:    Ascending,
+		err:      q.err,
+	}
+	if q.order != nil {
+		bq.order = *q.order
+	}
+	if len(values) == 0 {
+		bq.setError(errors.New("dynamo: HashValues requires at least one value"))
+	}
+	for _, value := range values {
+		cp := *q
+		hv, err := q.table.db.codec().marshal(value, "")
+		bq.setError(err)
+		cp.hashValue = hv
+		// each query may add names, for example for soft delete, so they can't share
+		cp.subber = q.subber.clone()
+		cp.filters = append([]string(nil), q.filters...)
+		bq.queries = append(bq.queries, &cp)
+	}
+	return bq
+}
+
+// Range specifies the range key (sort key) condition for every query,
+// and merges their results in order of this range key.
+func (bq *BucketQuery) Range(name string, op Operator, values ...interface{}) *BucketQuery {
+	bq.mergeKey = name
+	for _, q := range bq.queries {
+		q.Range(name, op, values...)
+	}
+	return bq
+}
+
+// MergeBy merges results in order of the given range key, without a range key condition.
+func (bq *BucketQuery) MergeBy(name string) *BucketQuery {
+	bq.mergeKey = name
+	return bq
+}
+
+// Index specifies the name of the index that will be queried.
+func (bq *BucketQuery) Index(name string) *BucketQuery {
+	for _, q := range bq.queries {
+		q.Index(name)
+	}
+	return bq
+}
+
+// Filter takes an expression that all results will be evaluated against.
+// See Query.Filter.
+func (bq *BucketQuery) Filter(expr string, args ...interface{}) *BucketQuery {
+	for _, q := range bq.queries {
+		q.Filter(expr, args...)
+	}
+	return bq
+}
+
+// Consistent will, if on is true, make these queries strongly consistent.
+func (bq *BucketQuery) Consistent(on bool) *BucketQuery {
+	for _, q := range bq.queries {
+		q.Consistent(on)
+	}
+	return bq
+}
+
+// Order specifies the desired result order of the merged results.
+func (bq *BucketQuery) Order(order Order) *BucketQuery {
+	bq.order = order
+	for _, q := range bq.queries {
+		q.Order(order)
+	}
+	return bq
+}
+
+// Limit specifies the maximum number of merged results to return.
+func (bq *BucketQuery) Limit(limit int64) *BucketQuery {
+	bq.limit = limit
+	for _, q := range bq.queries {
+		q.Limit(limit)
+	}
+	return bq
+}
+
+// Dedupe skips results with the same key, as returned by keyFunc, as an earlier result.
+// Use it when the queried partitions or indexes can overlap, such as while a GSI is being backfilled.
+// See ItemKey. The keys of every result are kept in memory until iteration is done.
+func (bq *BucketQuery) Dedupe(keyFunc func(item map[string]*dynamodb.AttributeValue) string) *BucketQuery {
+	bq.dedupe = keyFunc
+	return bq
+}
+
+// One executes these queries and unmarshals the first merged result to out.
+// Returns ErrNotFound if there are no results.
+func (bq *BucketQuery) One(out interface{}) error {
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return bq.OneWithContext(ctx, out)
+}
+
+// OneWithContext executes these queries and unmarshals the first merged result to out.
+// Returns ErrNotFound if there are no results.
+func (bq *BucketQuery) OneWithContext(ctx aws.Context, out interface{}) error {
+	iter := bq.iter(bq.codec().unmarshalItem)
+	if iter.NextWithContext(ctx, out) {
+		return nil
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	return ErrNotFound
+}
+
+// All executes these queries and unmarshals all merged results to out, which must be a pointer to a slice.
+func (bq *BucketQuery) All(out interface{}) error {
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return bq.AllWithContext(ctx, out)
+}
+
+// AllWithContext executes these queries and unmarshals all merged results to out, which must be a pointer to a slice.
+func (bq *BucketQuery) AllWithContext(ctx aws.Context, out interface{}) error {
+	iter := bq.iter(bq.codec().unmarshalAppend)
+	for iter.NextWithContext(ctx, out) {
+	}
+	return iter.Err()
+}
+
+// Iter returns an iterator of the merged results.
+func (bq *BucketQuery) Iter() Iter {
+	return bq.iter(bq.codec().unmarshalItem)
+}
+
+func (bq *BucketQuery) iter(unmarshal unmarshalFunc) *bucketIter {
+	itr := &bucketIter{
+		query:     bq,
+		unmarshal: unmarshal,
+		err:       bq.err,
+		dedupe:    deduper{key: bq.dedupe},
+	}
+	if itr.err == nil && bq.mergeKey == "" {
+		itr.err = errors.New("dynamo: bucket query: no range key to merge by, use Range or MergeBy")
+	}
+	for _, q := range bq.queries {
+		if itr.err == nil {
+			itr.err = q.err
+		}
+		itr.iters = append(itr.iters, &queryIter{
+			query:     q,
+			unmarshal: unmarshalRaw,
+			err:       q.err,
+		})
+	}
+	return itr
+}
+
+// codec returns the codec of the queries' DB.
+func (bq *BucketQuery) codec() codec {
+	if len(bq.queries) == 0 {
+		return codec{}
+	}
+	return bq.queries[0].table.db.codec()
+}
+
+// unmarshalRaw stores item in out, which must be a *map[string]*dynamodb.AttributeValue.
+func unmarshalRaw(item map[string]*dynamodb.AttributeValue, out interface{}) error {
+	*out.(*map[string]*dynamodb.AttributeValue) = item
+	return nil
+}
+
+// bucketIter merges the results of several query iterators.
+type bucketIter struct {
+	query     *BucketQuery
+	iters     []*queryIter
+	heads     []map[string]*dynamodb.AttributeValue
+	unmarshal unmarshalFunc
+	dedupe    deduper
+	n         int64
+	err       error
+}
+
+// Next tries to unmarshal the next result into out.
+// Returns false when it is complete or if it runs into an error.
+func (itr *bucketIter) Next(out interface{}) bool {
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return itr.NextWithContext(ctx, out)
+}
+
+// NextWithContext tries to unmarshal the next result into out.
+// Returns false when it is complete or if it runs into an error.
+func (itr *bucketIter) NextWithContext(ctx aws.Context, out interface{}) bool {
+	if itr.err != nil {
+		return false
+	}
+	if itr.query.limit > 0 && itr.n >= itr.query.limit {
+		return false
+	}
+	if itr.heads == nil {
+		itr.start(ctx)
+		if itr.err != nil {
+			return false
+		}
+	}
+
+	for {
+		next := -1
+		for i, item := range itr.heads {
+			if item == nil {
+				continue
+			}
+			if next == -1 {
+				next = i
+				continue
+			}
+			cmp := compareAV(item[itr.query.mergeKey], itr.heads[next][itr.query.mergeKey])
+			if (itr.query.order == Ascending && cmp < 0) || (itr.query.order == Descending && cmp > 0) {
+				next = i
+			}
+		}
+		if next == -1 {
+			return false
+		}
+
+		item := itr.heads[next]
+		itr.heads[next] = nil
+		if !itr.advance(ctx, next) {
+			return false
+		}
+		if itr.query.unshard != "" {
+			item = unshardItem(item, itr.query.unshard)
+		}
+		if itr.dedupe.dup(item) {
+			continue
+		}
+		itr.err = itr.unmarshal(item, out)
+		break
+	}
+	itr.n++
+	return itr.err == nil
+}
+
+// start fetches the first result of every query concurrently.
+func (itr *bucketIter) start(ctx aws.Context) {
+	itr.heads = make([]map[string]*dynamodb.AttributeValue, len(itr.iters))
+	var wg sync.WaitGroup
+	for i := range itr.iters {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			itr.iters[i].NextWithContext(ctx, &itr.heads[i])
+		}(i)
+	}
+	wg.Wait()
+	for _, iter := range itr.iters {
+		if err := iter.Err(); err != nil {
+			itr.err = err
+			return
+		}
+	}
+}
+
+// advance fetches the next result of the i-th query.
+func (itr *bucketIter) advance(ctx aws.Context, i int) bool {
+	if itr.iters[i].NextWithContext(ctx, &itr.heads[i]) {
+		return true
+	}
+	itr.heads[i] = nil
+	itr.err = itr.iters[i].Err()
+	return itr.err == nil
+}
+
+// Err returns the error encountered, if any.
+func (itr *bucketIter) Err() error {
+	return itr.err
+}
+
+// compareAV compares two scalar attribute values of the same type, as DynamoDB sorts range keys.
+// Missing values sort first.
+func compareAV(a, b *dynamodb.AttributeValue) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	case a.S != nil && b.S != nil:
+		return strings.Compare(*a.S, *b.S)
+	case a.N != nil && b.N != nil:
+		x, _, errA := big.ParseFloat(*a.N, 10, 128, big.ToNearestEven)
+		y, _, errB := big.ParseFloat(*b.N, 10, 128, big.ToNearestEven)
+		if errA != nil || errB != nil {
+			return strings.Compare(*a.N, *b.N)
+		}
+		return x.Cmp(y)
+	case a.B != nil && b.B != nil:
+		return bytes.Compare(a.B, b.B)
+	}
+	return 0
+}
+
+func (bq *BucketQuery) setError(err error) {
+	if bq.err == nil {
+		bq.err = err
+	}
+}
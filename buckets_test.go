@@ -0,0 +1,153 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// partitionClient is a fake client that serves Query requests from items grouped by partition key,
+// one item per page.
+type partitionClient struct {
+	dynamodbiface.DynamoDBAPI
+	parts map[string][]map[string]*dynamodb.AttributeValue
+}
+
+func (c partitionClient) QueryWithContext(_ aws.Context, input *dynamodb.QueryInput, _ ...request.Option) (*dynamodb.QueryOutput, error) {
+	items := c.parts[*input.KeyConditions["PK"].AttributeValueList[0].S]
+	if input.ScanIndexForward != nil && !*input.ScanIndexForward {
+		reversed := make([]map[string]*dynamodb.AttributeValue, 0, len(items))
+		for i := len(items) - 1; i >= 0; i-- {
+			reversed = append(reversed, items[i])
+		}
+		items = reversed
+	}
+	var start int
+	if input.ExclusiveStartKey != nil {
+		for i, item := range items {
+			if *item["Time"].N == *input.ExclusiveStartKey["Time"].N {
+				start = i + 1
+			}
+		}
+	}
+	out := &dynamodb.QueryOutput{}
+	if start < len(items) {
+		out.Items = items[start : start+1]
+		out.LastEvaluatedKey = items[start]
+	}
+	return out, nil
+}
+
+func TestTimeBuckets(t *testing.T) {
+	tb := TimeBuckets{Prefix: "metrics", Shards: 4}
+	day := time.Date(2019, 6, 1, 15, 4, 5, 0, time.UTC)
+	if key := tb.Key(day, 3); key != "metrics#2019-06-01#3" {
+		t.Error("bad key:", key)
+	}
+	if a, b := tb.ShardKey(day, "host-1"), tb.ShardKey(day.Add(time.Hour), "host-1"); a != b {
+		t.Error("shard key not stable:", a, b)
+	}
+	keys := tb.Keys(day, day.Add(24*time.Hour))
+	if len(keys) != 8 || keys[0] != "metrics#2019-06-01#0" || keys[7] != "metrics#2019-06-02#3" {
+		t.Error("bad keys:", keys)
+	}
+	if key := (TimeBuckets{}).Key(day, 1); key != "2019-06-01" {
+		t.Error("bad unsharded key:", key)
+	}
+}
+
+func TestGetBuckets(t *testing.T) {
+	type point struct {
+		PK   string
+		Time int64
+	}
+	tb := TimeBuckets{Prefix: "m", Shards: 2}
+	day := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	client := partitionClient{parts: make(map[string][]map[string]*dynamodb.AttributeValue)}
+	for _, ts := range []int64{1, 2, 3, 4, 5, 6} {
+		// odd times go to shard 1 of the first day, even to shard 0 of the second
+		at, shard := day, 1
+		if ts%2 == 0 {
+			at, shard = day.Add(24*time.Hour), 0
+		}
+		key := tb.Key(at, shard)
+		item, err := marshalItem(point{PK: key, Time: ts})
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.parts[key] = append(client.parts[key], item)
+	}
+	table := NewFromIface(client).Table("Metrics")
+	times := func(points []point) []int64 {
+		var ts []int64
+		for _, p := range points {
+			ts = append(ts, p.Time)
+		}
+		return ts
+	}
+
+	var points []point
+	err := table.GetBuckets("PK", tb, day, day.Add(24*time.Hour)).MergeBy("Time").All(&points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := times(points); !reflect.DeepEqual(got, []int64{1, 2, 3, 4, 5, 6}) {
+		t.Error("bad merge order:", got)
+	}
+
+	points = nil
+	err = table.GetBuckets("PK", tb, day, day.Add(24*time.Hour)).MergeBy("Time").Order(Descending).Limit(4).All(&points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := times(points); !reflect.DeepEqual(got, []int64{6, 5, 4, 3}) {
+		t.Error("bad descending merge order:", got)
+	}
+
+	if err := table.GetBuckets("PK", tb, day, day).All(&points); err == nil {
+		t.Error("expected error without merge key")
+	}
+}
+
+func TestQueryHashValues(t *testing.T) {
+	type point struct {
+		PK   string
+		Time int64
+	}
+	client := partitionClient{parts: make(map[string][]map[string]*dynamodb.AttributeValue)}
+	for i, pk := range []string{"a", "b", "a", "c", "b", "a"} {
+		item, err := marshalItem(point{PK: pk, Time: int64(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.parts[pk] = append(client.parts[pk], item)
+	}
+	table := NewFromIface(client).Table("Points")
+
+	var results []point
+	err := table.Get("PK", "ignored").Range("Time", GreaterOrEqual, 0).Order(Descending).
+		HashValues("a", "b").
+		All(&results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var times []int64
+	for _, p := range results {
+		times = append(times, p.Time)
+	}
+	if want := []int64{5, 4, 2, 1, 0}; !reflect.DeepEqual(times, want) {
+		t.Error("bad merge order:", times, "≠", want)
+	}
+
+	if err := table.Get("PK", "a").HashValues("a", "b").All(&results); err == nil {
+		t.Error("expected error without a key to merge by")
+	}
+	if err := table.Get("PK", "a").HashValues().All(&results); err == nil {
+		t.Error("expected error without values")
+	}
+}
@@ -0,0 +1,386 @@
+package dynamo
+
+import (
+	"container/list"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Cache is a cache of items, used to serve reads without a round trip to DynamoDB.
+// Items are identified by table name and an opaque string derived from their primary key.
+// See DB.WithCache.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the cached item for key in table, if any.
+	Get(table, key string) (item map[string]*dynamodb.AttributeValue, ok bool)
+	// Set caches item for key in table. If ttl is non-zero, the item expires after ttl.
+	// An empty item means that the item is known not to exist, and must be returned as such by Get.
+	Set(table, key string, item map[string]*dynamodb.AttributeValue, ttl time.Duration)
+	// Invalidate removes key in table from the cache.
+	Invalidate(table, key string)
+}
+
+// WithCache returns a copy of this DB that uses cache as a read-through cache for items, which expire after ttl.
+// GetItem requests (made by Query.One) and BatchGet are served from the cache when possible.
+// Consistent reads and projected reads bypass the cache.
+// Items written to by Put, Update, Delete, BatchWrite, and WriteTx through the returned DB are invalidated automatically.
+// Writes made through other clients are only reflected once cached items expire.
+// Items are copied when they are cached and when they are read from the cache, so results can be modified freely.
+func (db *DB) WithCache(cache Cache, ttl time.Duration) *DB {
+	cp := *db
+	cp.cache = &itemCache{
+		cache:  cache,
+		ttl:    ttl,
+		schema: new(keySchemas),
+		gens:   new(generations),
+	}
+	return &cp
+}
+
+// CacheNotFound returns a copy of this DB that also caches ErrNotFound results of GetItem requests for ttl,
+// protecting against repeated existence checks for items that don't exist.
+// Writes through the returned DB invalidate cached not-found results like any other item.
+// BatchGet will skip keys known not to exist, but will not cache new not-found results.
+// It has no effect unless a cache has been set by WithCache.
+func (db *DB) CacheNotFound(ttl time.Duration) *DB {
+	cp := *db
+	if db.cache != nil {
+		c := *db.cache
+		c.notFoundTTL = ttl
+		cp.cache = &c
+	}
+	return &cp
+}
+
+// itemCache wraps a Cache, keeping track of each table's key attributes so written items can be invalidated.
+type itemCache struct {
+	cache       Cache
+	ttl         time.Duration
+	notFoundTTL time.Duration
+	schema      *keySchemas
+	gens        *generations
+}
+
+// generations counts the invalidations of keys, so that a read that overlaps a write doesn't cache what it read.
+// Keys share a fixed number of counters, so a write can occasionally keep an unrelated item from being cached.
+type generations [64]struct {
+	mu sync.Mutex
+	n  uint64
+}
+
+// lock locks and returns the invalidation counter of key in table.
+func (g *generations) lock(table, key string) (n *uint64, unlock func()) {
+	h := fnv.New32a()
+	h.Write([]byte(table))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	gen := &g[h.Sum32()%uint32(len(g))]
+	gen.mu.Lock()
+	return &gen.n, gen.mu.Unlock
+}
+
+// keySchemas records the key attribute names of tables.
+type keySchemas struct {
+	mu   sync.RWMutex
+	keys map[string][]string // table name → key attribute names
+}
+
+// learn records the key attribute names of table.
+func (c *itemCache) learn(table string, names ...string) {
+	c.schema.mu.RLock()
+	known := len(c.schema.keys[table]) == len(names)
+	c.schema.mu.RUnlock()
+	if known {
+		return
+	}
+	c.schema.mu.Lock()
+	if c.schema.keys == nil {
+		c.schema.keys = make(map[string][]string)
+	}
+	c.schema.keys[table] = names
+	c.schema.mu.Unlock()
+}
+
+// learnKey records the key attribute names of table from key.
+func (c *itemCache) learnKey(table string, key map[string]*dynamodb.AttributeValue) {
+	names := make([]string, 0, len(key))
+	for name := range key {
+		names = append(names, name)
+	}
+	c.learn(table, names...)
+}
+
+// keyOf extracts the primary key of item in table.
+// Returns false if the key attributes of table aren't known or are missing.
+func (c *itemCache) keyOf(table string, item map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, bool) {
+	c.schema.mu.RLock()
+	names := c.schema.keys[table]
+	c.schema.mu.RUnlock()
+	if len(names) == 0 {
+		return nil, false
+	}
+	key := make(map[string]*dynamodb.AttributeValue, len(names))
+	for _, name := range names {
+		av, ok := item[name]
+		if !ok {
+			return nil, false
+		}
+		key[name] = av
+	}
+	return key, true
+}
+
+// get returns a copy of the cached item for key in table, if any.
+// A cached item that is empty but non-nil means the item is known not to exist.
+func (c *itemCache) get(table string, key map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, bool) {
+	item, ok := c.cache.Get(table, keyString(key))
+	if !ok {
+		return nil, false
+	}
+	return copyItem(item), true
+}
+
+// generation returns the invalidation generation of key in table.
+// Take it before reading an item, and pass it to set or setNotFound afterwards.
+// It also learns the key attributes of table, so that writes made during the read invalidate the key.
+func (c *itemCache) generation(table string, key map[string]*dynamodb.AttributeValue) uint64 {
+	c.learnKey(table, key)
+	n, unlock := c.gens.lock(table, keyString(key))
+	defer unlock()
+	return *n
+}
+
+// set caches a copy of item, unless key was invalidated since gen.
+func (c *itemCache) set(table string, key, item map[string]*dynamodb.AttributeValue, gen uint64) {
+	c.put(table, key, copyItem(item), c.ttl, gen)
+}
+
+// setNotFound caches that key doesn't exist in table, if enabled and key wasn't invalidated since gen.
+func (c *itemCache) setNotFound(table string, key map[string]*dynamodb.AttributeValue, gen uint64) {
+	if c.notFoundTTL > 0 {
+		c.put(table, key, map[string]*dynamodb.AttributeValue{}, c.notFoundTTL, gen)
+	}
+}
+
+func (c *itemCache) put(table string, key, item map[string]*dynamodb.AttributeValue, ttl time.Duration, gen uint64) {
+	id := keyString(key)
+	n, unlock := c.gens.lock(table, id)
+	defer unlock()
+	if *n != gen {
+		// written while it was being read, so item might be stale
+		return
+	}
+	c.cache.Set(table, id, item, ttl)
+}
+
+// invalidate removes the item with the given key from the cache.
+func (c *itemCache) invalidate(table string, key map[string]*dynamodb.AttributeValue) {
+	id := keyString(key)
+	n, unlock := c.gens.lock(table, id)
+	defer unlock()
+	*n++
+	c.cache.Invalidate(table, id)
+}
+
+// invalidateItem removes item from the cache, as long as its key attributes are known.
+// If they aren't, nothing from table can have been cached.
+func (c *itemCache) invalidateItem(table string, item map[string]*dynamodb.AttributeValue) {
+	if key, ok := c.keyOf(table, item); ok {
+		c.invalidate(table, key)
+	}
+}
+
+// invalidateWrites removes the items affected by write requests from the cache.
+func (c *itemCache) invalidateWrites(table string, wrs []*dynamodb.WriteRequest) {
+	for _, wr := range wrs {
+		switch {
+		case wr.PutRequest != nil:
+			c.invalidateItem(table, wr.PutRequest.Item)
+		case wr.DeleteRequest != nil:
+			c.invalidate(table, wr.DeleteRequest.Key)
+		}
+	}
+}
+
+// invalidateTx removes the items affected by a write transaction from the cache.
+func (c *itemCache) invalidateTx(items []*dynamodb.TransactWriteItem) {
+	for _, item := range items {
+		switch {
+		case item.Put != nil:
+			c.invalidateItem(aws.StringValue(item.Put.TableName), item.Put.Item)
+		case item.Update != nil:
+			c.invalidate(aws.StringValue(item.Update.TableName), item.Update.Key)
+		case item.Delete != nil:
+			c.invalidate(aws.StringValue(item.Delete.TableName), item.Delete.Key)
+		}
+	}
+}
+
+// copyItem returns a deep copy of item, keeping nil and empty items apart.
+func copyItem(item map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	if item == nil {
+		return nil
+	}
+	cp := make(map[string]*dynamodb.AttributeValue, len(item))
+	for name, av := range item {
+		cp[name] = copyAV(av)
+	}
+	return cp
+}
+
+func copyAV(av *dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	if av == nil {
+		return nil
+	}
+	cp := &dynamodb.AttributeValue{
+		B:    copyBytes(av.B),
+		BOOL: copyBool(av.BOOL),
+		M:    copyItem(av.M),
+		N:    copyString(av.N),
+		NULL: copyBool(av.NULL),
+		NS:   copyStrings(av.NS),
+		S:    copyString(av.S),
+		SS:   copyStrings(av.SS),
+	}
+	if av.BS != nil {
+		cp.BS = make([][]byte, len(av.BS))
+		for i, b := range av.BS {
+			cp.BS[i] = copyBytes(b)
+		}
+	}
+	if av.L != nil {
+		cp.L = make([]*dynamodb.AttributeValue, len(av.L))
+		for i, v := range av.L {
+			cp.L[i] = copyAV(v)
+		}
+	}
+	return cp
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+func copyBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	cp := *b
+	return &cp
+}
+
+func copyString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	cp := *s
+	return &cp
+}
+
+func copyStrings(ss []*string) []*string {
+	if ss == nil {
+		return nil
+	}
+	cp := make([]*string, len(ss))
+	for i, s := range ss {
+		cp[i] = copyString(s)
+	}
+	return cp
+}
+
+// LRUCache is an in-memory Cache that evicts the least recently used items once it is full.
+type LRUCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // of *lruEntry, most recently used first
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	id      string
+	item    map[string]*dynamodb.AttributeValue
+	expires time.Time
+}
+
+// NewLRUCache creates a new LRUCache that holds up to size items.
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+// Get returns the cached item for key in table, if any.
+func (c *LRUCache) Get(table, key string) (map[string]*dynamodb.AttributeValue, bool) {
+	id := lruID(table, key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[id]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.item, true
+}
+
+// Set caches item for key in table. If ttl is non-zero, the item expires after ttl.
+func (c *LRUCache) Set(table, key string, item map[string]*dynamodb.AttributeValue, ttl time.Duration) {
+	entry := &lruEntry{
+		id:   lruID(table, key),
+		item: item,
+	}
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[entry.id]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[entry.id] = c.order.PushFront(entry)
+	for c.size > 0 && c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// Invalidate removes key in table from the cache.
+func (c *LRUCache) Invalidate(table, key string) {
+	id := lruID(table, key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[id]; ok {
+		c.remove(elem)
+	}
+}
+
+// Len returns the number of items in the cache, including expired items that haven't been evicted yet.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRUCache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*lruEntry).id)
+}
+
+func lruID(table, key string) string {
+	return table + "\x00" + key
+}
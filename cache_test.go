@@ -0,0 +1,282 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// memClient is a fake client that stores items keyed by UserID in memory and counts reads.
+type memClient struct {
+	dynamodbiface.DynamoDBAPI
+	items map[string]map[string]*dynamodb.AttributeValue
+	gets  int
+	keys  int // keys requested by BatchGetItem
+}
+
+func newMemClient() *memClient {
+	return &memClient{items: make(map[string]map[string]*dynamodb.AttributeValue)}
+}
+
+func (c *memClient) GetItemWithContext(_ aws.Context, input *dynamodb.GetItemInput, _ ...request.Option) (*dynamodb.GetItemOutput, error) {
+	c.gets++
+	return &dynamodb.GetItemOutput{Item: c.items[keyString(input.Key)]}, nil
+}
+
+func (c *memClient) PutItemWithContext(_ aws.Context, input *dynamodb.PutItemInput, _ ...request.Option) (*dynamodb.PutItemOutput, error) {
+	key := map[string]*dynamodb.AttributeValue{"UserID": input.Item["UserID"]}
+	c.items[keyString(key)] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *memClient) DeleteItemWithContext(_ aws.Context, input *dynamodb.DeleteItemInput, _ ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	old := c.items[keyString(input.Key)]
+	delete(c.items, keyString(input.Key))
+	if aws.StringValue(input.ReturnValues) == dynamodb.ReturnValueAllOld {
+		return &dynamodb.DeleteItemOutput{Attributes: old}, nil
+	}
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (c *memClient) BatchGetItemWithContext(_ aws.Context, input *dynamodb.BatchGetItemInput, _ ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	out := &dynamodb.BatchGetItemOutput{
+		Responses: make(map[string][]map[string]*dynamodb.AttributeValue),
+	}
+	for table, kas := range input.RequestItems {
+		for _, key := range kas.Keys {
+			c.keys++
+			if item, ok := c.items[keyString(key)]; ok {
+				out.Responses[table] = append(out.Responses[table], item)
+			}
+		}
+	}
+	return out, nil
+}
+
+func TestLRUCache(t *testing.T) {
+	cache := NewLRUCache(2)
+	item := map[string]*dynamodb.AttributeValue{"Msg": {S: aws.String("hello")}}
+
+	cache.Set("table", "a", item, 0)
+	cache.Set("table", "b", item, 0)
+	cache.Get("table", "a")
+	cache.Set("table", "c", item, 0)
+	if _, ok := cache.Get("table", "b"); ok {
+		t.Error("least recently used item wasn't evicted")
+	}
+	if _, ok := cache.Get("table", "a"); !ok {
+		t.Error("recently used item was evicted")
+	}
+	if _, ok := cache.Get("other", "a"); ok {
+		t.Error("item leaked across tables")
+	}
+
+	cache.Invalidate("table", "a")
+	if _, ok := cache.Get("table", "a"); ok {
+		t.Error("invalidated item still cached")
+	}
+
+	cache.Set("table", "d", item, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok := cache.Get("table", "d"); ok {
+		t.Error("expired item still cached")
+	}
+	if cache.Len() != 1 {
+		t.Error("expected 1 item, got", cache.Len())
+	}
+}
+
+func TestDBWithCache(t *testing.T) {
+	client := newMemClient()
+	db := NewFromIface(client).WithCache(NewLRUCache(100), time.Minute)
+	table := db.Table(testTable)
+
+	if err := table.Put(widget{UserID: 1, Msg: "first"}).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	var w widget
+	for i := 0; i < 2; i++ {
+		if err := table.Get("UserID", 1).One(&w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if client.gets != 1 {
+		t.Error("expected 1 GetItem call, got", client.gets)
+	}
+
+	if err := table.Put(widget{UserID: 1, Msg: "second"}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Get("UserID", 1).One(&w); err != nil {
+		t.Fatal(err)
+	}
+	if w.Msg != "second" {
+		t.Error("stale item after put:", w)
+	}
+	if client.gets != 2 {
+		t.Error("expected 2 GetItem calls, got", client.gets)
+	}
+
+	if err := table.Get("UserID", 1).Consistent(true).One(&w); err != nil {
+		t.Fatal(err)
+	}
+	if client.gets != 3 {
+		t.Error("consistent read should bypass the cache")
+	}
+
+	if err := table.Put(widget{UserID: 2, Msg: "other"}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	var results []widget
+	if err := table.Batch("UserID").Get(Keys{1}, Keys{2}).All(&results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Error("expected 2 results, got", results)
+	}
+	if client.keys != 1 {
+		t.Error("expected only the uncached key to be requested, got", client.keys)
+	}
+
+	// everything is cached now
+	results = nil
+	if err := table.Batch("UserID").Get(Keys{1}, Keys{2}).All(&results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || client.keys != 1 {
+		t.Error("expected results from cache, got", results, client.keys)
+	}
+}
+
+func TestDBCacheNotFound(t *testing.T) {
+	client := newMemClient()
+	db := NewFromIface(client).WithCache(NewLRUCache(100), time.Minute).CacheNotFound(time.Minute)
+	table := db.Table(testTable)
+
+	var w widget
+	for i := 0; i < 2; i++ {
+		if err := table.Get("UserID", 1).One(&w); err != ErrNotFound {
+			t.Fatal("expected ErrNotFound, got", err)
+		}
+	}
+	if client.gets != 1 {
+		t.Error("expected 1 GetItem call, got", client.gets)
+	}
+
+	var results []widget
+	if err := table.Batch("UserID").Get(Keys{1}).All(&results); err != ErrNotFound {
+		t.Error("expected ErrNotFound, got", err)
+	}
+	if client.keys != 0 {
+		t.Error("expected no keys to be requested, got", client.keys)
+	}
+
+	// keys known not to exist are still missing
+	if err := table.Put(widget{UserID: 2, Msg: "two"}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	results = nil
+	err := table.Batch("UserID").Get(Keys{1}, Keys{2}).RequireAll(true).All(&results)
+	missing, ok := err.(*MissingKeysError)
+	if !ok {
+		t.Fatal("expected MissingKeysError, got", err)
+	}
+	if expected := []Keyed{Keys{1}}; !reflect.DeepEqual(missing.Keys, expected) {
+		t.Error("bad missing keys:", missing.Keys, "≠", expected)
+	}
+	if len(results) != 1 {
+		t.Error("expected 1 result, got", results)
+	}
+
+	if err := table.Put(widget{UserID: 1, Msg: "exists"}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Get("UserID", 1).One(&w); err != nil {
+		t.Fatal("not-found result wasn't invalidated:", err)
+	}
+	if w.Msg != "exists" {
+		t.Error("bad result:", w)
+	}
+}
+
+func TestDBCacheCopies(t *testing.T) {
+	client := newMemClient()
+	table := NewFromIface(client).WithCache(NewLRUCache(100), time.Minute).Table(testTable)
+	if err := table.Put(widget{UserID: 1, Msg: "first"}).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first read caches the item, the second is served from the cache
+	for i := 0; i < 2; i++ {
+		var item map[string]*dynamodb.AttributeValue
+		if err := table.Get("UserID", 1).One(&item); err != nil {
+			t.Fatal(err)
+		}
+		if msg := aws.StringValue(item["Msg"].S); msg != "first" {
+			t.Fatal("cached item was modified:", msg)
+		}
+		item["Msg"].S = aws.String("changed")
+		*item["UserID"].N = "2"
+		delete(item, "Time")
+	}
+	var w widget
+	if err := table.Get("UserID", 1).One(&w); err != nil {
+		t.Fatal(err)
+	}
+	if w.UserID != 1 || w.Msg != "first" {
+		t.Error("cached item was modified:", w)
+	}
+	if client.gets != 1 {
+		t.Error("expected 1 GetItem call, got", client.gets)
+	}
+}
+
+// overlappingPutClient is a memClient that puts an item through db while a GetItem request is in flight,
+// returning what it read before the put.
+type overlappingPutClient struct {
+	*memClient
+	put func()
+}
+
+func (c *overlappingPutClient) GetItemWithContext(ctx aws.Context, input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	out, err := c.memClient.GetItemWithContext(ctx, input, opts...)
+	if c.put != nil {
+		put := c.put
+		c.put = nil
+		put()
+	}
+	return out, err
+}
+
+func TestDBCacheOverlappingWrite(t *testing.T) {
+	client := &overlappingPutClient{memClient: newMemClient()}
+	table := NewFromIface(client).WithCache(NewLRUCache(100), time.Minute).Table(testTable)
+	if err := table.Put(widget{UserID: 1, Msg: "old"}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	client.put = func() {
+		if err := table.Put(widget{UserID: 1, Msg: "new"}).Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var w widget
+	if err := table.Get("UserID", 1).One(&w); err != nil {
+		t.Fatal(err)
+	}
+	if w.Msg != "old" {
+		t.Fatal("expected the item read before the put, got", w)
+	}
+	if err := table.Get("UserID", 1).One(&w); err != nil {
+		t.Fatal(err)
+	}
+	if w.Msg != "new" {
+		t.Error("stale item was cached:", w)
+	}
+}
@@ -0,0 +1,153 @@
+package dynamo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Checkpoint makes this scan save its progress to store after each page, so that a long scan can resume
+// where it left off when it is run again, such as after a restart.
+// Progress is saved for this scan's segment (see Segment), or segment 0 if it isn't segmented,
+// once every item of a page has been read from the scan's iterator.
+// A scan resumed from a checkpoint starts after the last page saved, instead of at StartFrom,
+// and a scan whose segment was finished returns no results.
+// Items of a page that was only partly read are read again when resuming.
+func (s *Scan) Checkpoint(store CheckpointStore) *Scan {
+	s.checkpoint = store
+	return s
+}
+
+// resume starts this iterator from the scan's checkpoint, if any,
+// returning false if the scan's segment is already finished or if loading the checkpoint failed.
+func (itr *scanIter) resume(ctx aws.Context) bool {
+	if itr.scan.checkpoint == nil {
+		return true
+	}
+	key, done, err := itr.scan.checkpoint.LoadCheckpoint(ctx, int(itr.scan.segment))
+	if err != nil {
+		itr.err = err
+		return false
+	}
+	if done {
+		// pretend the scan ended, so later calls don't start it again
+		itr.output = &dynamodb.ScanOutput{}
+		itr.checkpointed = true
+		return false
+	}
+	if key != nil {
+		itr.input.ExclusiveStartKey = key
+	}
+	return true
+}
+
+// saveCheckpoint saves the progress of this iterator after the current page has been read, if the scan is checkpointed.
+// It returns false if saving failed.
+func (itr *scanIter) saveCheckpoint(ctx aws.Context) bool {
+	if itr.scan.checkpoint == nil || itr.checkpointed {
+		return true
+	}
+	key := itr.output.LastEvaluatedKey
+	done := key == nil
+	if itr.err = itr.scan.checkpoint.SaveCheckpoint(ctx, int(itr.scan.segment), key, done); itr.err != nil {
+		return false
+	}
+	itr.checkpointed = done
+	return true
+}
+
+// FileCheckpoints returns a CheckpointStore that saves progress in a JSON file at path, with an entry for each segment.
+// The file is created if it doesn't exist, and is replaced atomically whenever progress is saved.
+// It is safe for concurrent use by the segments of a single process.
+func FileCheckpoints(path string) CheckpointStore {
+	return &fileCheckpoints{path: path}
+}
+
+type fileCheckpoints struct {
+	path string
+	mu   sync.Mutex
+}
+
+// fileCheckpoint is the progress of a segment saved by fileCheckpoints.
+type fileCheckpoint struct {
+	Key  string `json:"key,omitempty"`
+	Done bool   `json:"done,omitempty"`
+}
+
+// plainCursors encodes keys for fileCheckpoints, without signing them.
+var plainCursors = &DB{}
+
+func (fc *fileCheckpoints) LoadCheckpoint(_ aws.Context, segment int) (PagingKey, bool, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	segments, err := fc.read()
+	if err != nil {
+		return nil, false, err
+	}
+	cp, ok := segments[strconv.Itoa(segment)]
+	if !ok {
+		return nil, false, nil
+	}
+	if cp.Done || cp.Key == "" {
+		return nil, cp.Done, nil
+	}
+	key, err := plainCursors.decodeCursor("", cp.Key)
+	if err != nil {
+		return nil, false, err
+	}
+	return key, false, nil
+}
+
+func (fc *fileCheckpoints) SaveCheckpoint(_ aws.Context, segment int, key PagingKey, done bool) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	segments, err := fc.read()
+	if err != nil {
+		return err
+	}
+	token, err := plainCursors.encodeCursor("", key)
+	if err != nil {
+		return err
+	}
+	segments[strconv.Itoa(segment)] = fileCheckpoint{Key: token, Done: done}
+	data, err := json.MarshalIndent(segments, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(fc.path), filepath.Base(fc.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), fc.path)
+}
+
+// read returns the saved checkpoints by segment number.
+func (fc *fileCheckpoints) read() (map[string]fileCheckpoint, error) {
+	segments := make(map[string]fileCheckpoint)
+	data, err := ioutil.ReadFile(fc.path)
+	if os.IsNotExist(err) {
+		return segments, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &segments); err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
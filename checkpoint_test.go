@@ -0,0 +1,84 @@
+package dynamo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// smallPagesClient is a backfillClient that returns two items per page.
+type smallPagesClient struct {
+	*backfillClient
+}
+
+func (c smallPagesClient) ScanWithContext(ctx aws.Context, input *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error) {
+	if input.Limit == nil {
+		input.Limit = aws.Int64(2)
+	}
+	return c.backfillClient.ScanWithContext(ctx, input, opts...)
+}
+
+func TestScanCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dynamo-checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := FileCheckpoints(filepath.Join(dir, "scan.json"))
+	table := NewFromIface(smallPagesClient{newBackfillClient(5)}).Table("Backfill")
+
+	type item struct {
+		ID string
+	}
+	// read the first page and part of the second, then give up
+	itr := table.Scan().Segment(1, 2).Checkpoint(store).Iter()
+	var got item
+	for i := 0; i < 3; i++ {
+		if !itr.Next(&got) {
+			t.Fatal("scan ended early:", itr.Err())
+		}
+	}
+
+	// only the first page was fully read, so the second is read again
+	var rest []item
+	if err := table.Scan().Segment(1, 2).Checkpoint(store).All(&rest); err != nil {
+		t.Fatal(err)
+	}
+	if expect := []item{{"2"}, {"3"}, {"4"}}; !reflect.DeepEqual(rest, expect) {
+		t.Error("bad resumed results:", rest, "≠", expect)
+	}
+
+	// finished segments stay finished
+	rest = nil
+	if err := table.Scan().Segment(1, 2).Checkpoint(store).All(&rest); err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Error("finished segment scanned again:", rest)
+	}
+	if _, done, err := store.LoadCheckpoint(aws.BackgroundContext(), 1); err != nil || !done {
+		t.Error("segment not saved as done:", done, err)
+	}
+
+	// other segments are independent
+	key, done, err := store.LoadCheckpoint(aws.BackgroundContext(), 0)
+	if err != nil || key != nil || done {
+		t.Error("unexpected checkpoint for segment 0:", key, done, err)
+	}
+	if err := store.SaveCheckpoint(aws.BackgroundContext(), 0, PagingKey{"ID": {S: aws.String("3")}}, false); err != nil {
+		t.Fatal(err)
+	}
+	rest = nil
+	if err := table.Scan().Checkpoint(FileCheckpoints(filepath.Join(dir, "scan.json"))).All(&rest); err != nil {
+		t.Fatal(err)
+	}
+	if expect := []item{{"4"}}; !reflect.DeepEqual(rest, expect) {
+		t.Error("bad results from saved key:", rest, "≠", expect)
+	}
+}
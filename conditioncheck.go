@@ -27,8 +27,9 @@ func (table Table) Check(hashKey string, value interface{}) *ConditionCheck {
 	check := &ConditionCheck{
 		table:   table,
 		hashKey: hashKey,
+		subber:  subber{codec: table.db.codec()},
 	}
-	check.hashValue, check.err = marshal(value, "")
+	check.hashValue, check.err = table.db.codec().marshal(value, "")
 	return check
 }
 
@@ -36,7 +37,7 @@ func (table Table) Check(hashKey string, value interface{}) *ConditionCheck {
 func (check *ConditionCheck) Range(rangeKey string, value interface{}) *ConditionCheck {
 	check.rangeKey = rangeKey
 	var err error
-	check.rangeValue, err = marshal(value, "")
+	check.rangeValue, err = check.table.db.codec().marshal(value, "")
 	check.setError(err)
 	return check
 }
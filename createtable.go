@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -52,7 +53,9 @@ type CreateTable struct {
 	streamView    StreamView
 	ondemand      bool
 	tags          []*dynamodb.Tag
+	checkLimits   bool
 	err           error
+	timeout       time.Duration
 }
 
 // CreateTable begins a new operation to create a table with the given name.
@@ -81,7 +84,7 @@ func (db *DB) CreateTable(name string, from interface{}) *CreateTable {
 		tags:          []*dynamodb.Tag{},
 	}
 	rv := reflect.ValueOf(from)
-	ct.setError(ct.from(rv))
+	ct.setError(ct.from(rv, ""))
 	return ct
 }
 
@@ -200,6 +203,24 @@ func (ct *CreateTable) Index(index Index) *CreateTable {
 	return ct
 }
 
+// LocalIndex adds a local secondary index to this table, which shares the table's hash key
+// and sorts items by rangeKey instead of the table's range key.
+// Local secondary indexes can only be created along with their table.
+// When using IncludeProjection, you must specify the additional attributes to include via includeAttribs.
+func (ct *CreateTable) LocalIndex(name, rangeKey string, rangeKeyType KeyType, projection IndexProjection, includeAttribs ...string) *CreateTable {
+	ct.add(rangeKey, string(rangeKeyType))
+	idx := ct.localIndices[name]
+	idx.KeySchema = []*dynamodb.KeySchemaElement{{
+		AttributeName: aws.String(rangeKey),
+		KeyType:       aws.String(dynamodb.KeyTypeRange),
+	}}
+	ct.localIndices[name] = idx
+	if projection != "" {
+		ct.Project(name, projection, includeAttribs...)
+	}
+	return ct
+}
+
 // Tag specifies a metadata tag for this table. Multiple tags may be specified.
 func (ct *CreateTable) Tag(key, value string) *CreateTable {
 	for _, tag := range ct.tags {
@@ -216,30 +237,61 @@ func (ct *CreateTable) Tag(key, value string) *CreateTable {
 	return ct
 }
 
+// CheckLimits specifies that the provisioned throughput of this table and its global secondary indexes
+// should be checked against the account's limits, as reported by DescribeLimits, before creating the table.
+// This catches requests that would fail only after DynamoDB begins processing them.
+func (ct *CreateTable) CheckLimits() *CreateTable {
+	ct.checkLimits = true
+	return ct
+}
+
+// Timeout limits the total amount of time this request may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (ct *CreateTable) Timeout(timeout time.Duration) *CreateTable {
+	ct.timeout = timeout
+	return ct
+}
+
 // Run creates this table or returns and error.
 func (ct *CreateTable) Run() error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(ct.timeout)
 	defer cancel()
 	return ct.RunWithContext(ctx)
 }
 
 func (ct *CreateTable) RunWithContext(ctx aws.Context) error {
+	ctx, cancel := withTimeout(ctx, ct.timeout)
+	defer cancel()
 	if ct.err != nil {
 		return ct.err
 	}
 
 	input := ct.input()
+	if ct.checkLimits && !ct.ondemand {
+		limits, err := ct.db.DescribeLimits().RunWithContext(ctx)
+		if err != nil {
+			return err
+		}
+		indexes := make(map[string]*dynamodb.ProvisionedThroughput, len(input.GlobalSecondaryIndexes))
+		for _, idx := range input.GlobalSecondaryIndexes {
+			indexes[*idx.IndexName] = idx.ProvisionedThroughput
+		}
+		if err := checkThroughput(limits, "create table", input.ProvisionedThroughput, indexes); err != nil {
+			return err
+		}
+	}
 	return retry(ctx, func() error {
-		_, err := ct.db.client.CreateTableWithContext(ctx, input)
+		_, err := ct.db.client.CreateTableWithContext(ctx, input, ct.db.opts...)
 		return err
 	})
 }
 
-func (ct *CreateTable) from(rv reflect.Value) error {
+// from adds the keys and indexes tagged in the struct rv, prefixing attribute names with prefix.
+func (ct *CreateTable) from(rv reflect.Value, prefix string) error {
 	switch rv.Kind() {
 	case reflect.Struct: // ok
 	case reflect.Ptr:
-		return ct.from(rv.Elem())
+		return ct.from(rv.Elem(), prefix)
 	default:
 		return fmt.Errorf("dynamo: CreateTable example must be a struct")
 	}
@@ -248,15 +300,16 @@ func (ct *CreateTable) from(rv reflect.Value) error {
 		field := rv.Type().Field(i)
 		fv := rv.Field(i)
 
-		name, _, _ := fieldInfo(field)
+		name, _, _ := ct.db.codec().fieldInfo(field)
 		if name == "-" {
 			// skip
 			continue
 		}
+		name = prefix + name
 
-		// inspect anonymous structs
-		if fv.Type().Kind() == reflect.Struct && field.Anonymous {
-			if err := ct.from(fv); err != nil {
+		// inspect anonymous and flattened structs
+		if flatten, inner := flattening(field); flatten {
+			if err := ct.from(fv, prefix+inner); err != nil {
 				return err
 			}
 		}
@@ -274,8 +327,10 @@ func (ct *CreateTable) from(rv reflect.Value) error {
 		if gsi, ok := tagLookup(string(field.Tag), "index"); ok {
 			for _, index := range gsi {
 				ct.add(name, typeOf(fv, field.Tag.Get("dynamo")))
-				keyType := keyTypeFromTag(index)
-				indexName := index[:len(index)-len(keyType)-1]
+				keyType, indexName, err := indexKeyFromTag(field.Name, index)
+				if err != nil {
+					return err
+				}
 				idx := ct.globalIndices[indexName]
 				idx.KeySchema = append(idx.KeySchema, &dynamodb.KeySchemaElement{
 					AttributeName: &name,
@@ -289,8 +344,10 @@ func (ct *CreateTable) from(rv reflect.Value) error {
 		if lsi, ok := tagLookup(string(field.Tag), "localIndex"); ok {
 			for _, localIndex := range lsi {
 				ct.add(name, typeOf(fv, field.Tag.Get("dynamo")))
-				keyType := keyTypeFromTag(localIndex)
-				indexName := localIndex[:len(localIndex)-len(keyType)-1]
+				keyType, indexName, err := indexKeyFromTag(field.Name, localIndex)
+				if err != nil {
+					return err
+				}
 				idx := ct.localIndices[indexName]
 				idx.KeySchema = append(idx.KeySchema, &dynamodb.KeySchemaElement{
 					AttributeName: &name,
@@ -463,6 +520,15 @@ func keyTypeFromTag(tag string) string {
 	return ""
 }
 
+// indexKeyFromTag parses an index tag option such as "GSI1,hash" of the given field.
+func indexKeyFromTag(field, tag string) (keyType, index string, err error) {
+	keyType = keyTypeFromTag(tag)
+	if keyType == "" {
+		return "", "", fmt.Errorf("dynamo: field %s: index %q must specify hash or range", field, tag)
+	}
+	return keyType, tag[:strings.LastIndex(tag, ",")], nil
+}
+
 func sortKeySchemas(schemas []*dynamodb.KeySchemaElement) {
 	if *schemas[0].KeyType == dynamodb.KeyTypeRange {
 		schemas[0], schemas[1] = schemas[1], schemas[0]
@@ -155,3 +155,115 @@ func TestCreateTableUintUnixTime(t *testing.T) {
 		t.Error("unexpected input (unixtime tag)", input2)
 	}
 }
+
+func TestKeySchemaOf(t *testing.T) {
+	ks, err := KeySchemaOf(&UserAction{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := KeySchema{HashKey: "ID", HashKeyType: StringType, RangeKey: "Time", RangeKeyType: StringType}
+	if ks != want {
+		t.Errorf("bad key schema. want: %+v got: %+v", want, ks)
+	}
+
+	ks, err = KeySchemaOf(Metric{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ks.HashKeyType != NumberType || ks.RangeKeyType != NumberType {
+		t.Errorf("bad key types: %+v", ks)
+	}
+
+	if _, err := KeySchemaOf(widget{}); err == nil {
+		t.Error("expected error for model without a hash key tag")
+	}
+	if _, err := KeySchemaOf(map[string]interface{}{}); err == nil {
+		t.Error("expected error for map")
+	}
+}
+
+func TestIndexSchemaOf(t *testing.T) {
+	ks, err := IndexSchemaOf(UserAction{}, "ID-Seq-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (KeySchema{HashKey: "ID", HashKeyType: StringType, RangeKey: "Seq", RangeKeyType: NumberType}); ks != want {
+		t.Errorf("bad local index schema. want: %+v got: %+v", want, ks)
+	}
+	ks, err = IndexSchemaOf(UserAction{}, "Embedded-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (KeySchema{HashKey: "Embedded", HashKeyType: BinaryType}); ks != want {
+		t.Errorf("bad global index schema. want: %+v got: %+v", want, ks)
+	}
+	if _, err := IndexSchemaOf(UserAction{}, "nope"); err == nil {
+		t.Error("expected error for missing index")
+	}
+
+	type synonyms struct {
+		ID  string `dynamo:",partition" index:"GSI1,sort"`
+		Seq int64  `index:"GSI1,partition"`
+	}
+	ks, err = IndexSchemaOf(synonyms{}, "GSI1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ks.HashKey != "Seq" || ks.RangeKey != "ID" {
+		t.Errorf("bad index schema: %+v", ks)
+	}
+
+	type untyped struct {
+		ID string `dynamo:",hash" index:"GSI1"`
+	}
+	if _, err := KeySchemaOf(untyped{}); err == nil {
+		t.Error("expected error for index tag without a key type")
+	}
+}
+
+func TestCreateTableLocalIndex(t *testing.T) {
+	input := testDB.CreateTable("Metrics", Metric{}).
+		LocalIndex("ID-Value-index", "Value", NumberType, KeysOnlyProjection).
+		LocalIndex("ID-Name-index", "Name", StringType, IncludeProjection, "Value").
+		input()
+
+	if len(input.LocalSecondaryIndexes) != 2 {
+		t.Fatal("expected 2 local indexes, got", len(input.LocalSecondaryIndexes))
+	}
+	for _, idx := range input.LocalSecondaryIndexes {
+		var expected *dynamodb.LocalSecondaryIndex
+		switch *idx.IndexName {
+		case "ID-Value-index":
+			expected = &dynamodb.LocalSecondaryIndex{
+				IndexName: aws.String("ID-Value-index"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("ID"), KeyType: aws.String("HASH")},
+					{AttributeName: aws.String("Value"), KeyType: aws.String("RANGE")},
+				},
+				Projection: &dynamodb.Projection{ProjectionType: aws.String("KEYS_ONLY")},
+			}
+		case "ID-Name-index":
+			expected = &dynamodb.LocalSecondaryIndex{
+				IndexName: aws.String("ID-Name-index"),
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("ID"), KeyType: aws.String("HASH")},
+					{AttributeName: aws.String("Name"), KeyType: aws.String("RANGE")},
+				},
+				Projection: &dynamodb.Projection{
+					ProjectionType:   aws.String("INCLUDE"),
+					NonKeyAttributes: []*string{aws.String("Value")},
+				},
+			}
+		}
+		if !reflect.DeepEqual(idx, expected) {
+			t.Errorf("bad local index. want: %v got: %v", expected, idx)
+		}
+	}
+	var names []string
+	for _, attr := range input.AttributeDefinitions {
+		names = append(names, *attr.AttributeName)
+	}
+	if expected := []string{"ID", "Time", "Value", "Name"}; !reflect.DeepEqual(names, expected) {
+		t.Error("bad attribute definitions:", names, "≠", expected)
+	}
+}
@@ -0,0 +1,78 @@
+package dynamo
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// WithCredentials returns a copy of this DB that signs its requests with creds.
+// This is useful for accessing tables in other accounts without creating a new DB for each.
+// To use different credentials for a single request, make the request with a table from the returned DB.
+// Because the returned DB may access a different account, it does not share the cache,
+// GetItem coalescing, or circuit breakers of this DB, but they can be enabled again.
+func (db *DB) WithCredentials(creds *credentials.Credentials) *DB {
+	cp := db.isolated()
+	cp.opts = append(cp.opts, func(r *request.Request) {
+		r.Config.Credentials = creds
+	})
+	return cp
+}
+
+// WithRole returns a copy of this DB that uses temporary credentials from assuming the given IAM role.
+// Credentials are requested from STS using the configuration this DB was created with,
+// and are cached and refreshed automatically, so calling WithRole for every request is cheap.
+// It only works with DBs created by New. See WithCredentials for details.
+func (db *DB) WithRole(roleARN string) *DB {
+	if db.roles == nil {
+		cp := db.isolated()
+		cp.opts = append(cp.opts, func(r *request.Request) {
+			r.Error = errors.New("dynamo: WithRole requires a DB created with New")
+		})
+		return cp
+	}
+	return db.WithCredentials(db.roles.get(roleARN))
+}
+
+// isolated returns a copy of this DB without any state that could leak between accounts.
+func (db *DB) isolated() *DB {
+	cp := *db
+	cp.opts = append([]request.Option(nil), db.opts...)
+	cp.cache = nil
+	if db.gets != nil {
+		cp.gets = new(flightGroup)
+	}
+	if db.breakers != nil {
+		cp.breakers = newBreakers(db.breakers.cfg)
+	}
+	if db.descs != nil {
+		cp.descs = &descCache{ttl: db.descs.ttl}
+	}
+	return &cp
+}
+
+// roleCache caches assumed role credentials by role ARN.
+type roleCache struct {
+	provider client.ConfigProvider
+
+	mu    sync.Mutex
+	creds map[string]*credentials.Credentials
+}
+
+func (rc *roleCache) get(roleARN string) *credentials.Credentials {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if creds, ok := rc.creds[roleARN]; ok {
+		return creds
+	}
+	if rc.creds == nil {
+		rc.creds = make(map[string]*credentials.Credentials)
+	}
+	creds := stscreds.NewCredentials(rc.provider, roleARN)
+	rc.creds[roleARN] = creds
+	return creds
+}
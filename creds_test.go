@@ -0,0 +1,111 @@
+package dynamo
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// optsClient is a fake client that records the config of PutItem requests after applying their options.
+type optsClient struct {
+	dynamodbiface.DynamoDBAPI
+	last aws.Config
+	url  *url.URL
+}
+
+func (c *optsClient) PutItemWithContext(_ aws.Context, _ *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	r := &request.Request{
+		HTTPRequest: &http.Request{URL: &url.URL{Scheme: "https", Host: "dynamodb.us-east-1.amazonaws.com", Path: "/"}},
+	}
+	r.ApplyOptions(opts...)
+	c.last = r.Config
+	c.url = r.HTTPRequest.URL
+	return &dynamodb.PutItemOutput{}, r.Error
+}
+
+func TestWithCredentials(t *testing.T) {
+	client := &optsClient{}
+	db := NewFromIface(client)
+	creds := credentials.NewStaticCredentials("id", "secret", "")
+
+	if err := db.WithCredentials(creds).Table(testTable).Put(widget{UserID: 1}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if client.last.Credentials != creds {
+		t.Error("credentials not used")
+	}
+
+	if err := db.Table(testTable).Put(widget{UserID: 1}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if client.last.Credentials != nil {
+		t.Error("credentials leaked into original DB")
+	}
+
+	if err := db.WithRole("arn:aws:iam::123456789012:role/test").Table(testTable).Put(widget{UserID: 1}).Run(); err == nil {
+		t.Error("expected error using WithRole without New")
+	}
+}
+
+func TestWithRole(t *testing.T) {
+	sess := session.Must(session.NewSession(&aws.Config{Region: aws.String("us-east-1")}))
+	db := New(sess).WithCache(NewLRUCache(10), 0)
+
+	const arn = "arn:aws:iam::123456789012:role/test"
+	a, b := db.WithRole(arn), db.WithRole(arn)
+	if a.roles.get(arn) != b.roles.get(arn) {
+		t.Error("role credentials not reused")
+	}
+	if a.cache != nil {
+		t.Error("cache shared with other account")
+	}
+}
+
+func TestWithEndpoint(t *testing.T) {
+	client := &optsClient{}
+	db := NewFromIface(client)
+
+	if err := db.WithEndpoint("http://localhost:8000").Table(testTable).Put(widget{UserID: 1}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.url.String(); got != "http://localhost:8000/" {
+		t.Error("bad url:", got)
+	}
+
+	if err := db.WithEndpoint("localhost").Table(testTable).Put(widget{UserID: 1}).Run(); err == nil {
+		t.Error("expected error for endpoint without scheme")
+	}
+}
+
+func TestNewLocal(t *testing.T) {
+	db := NewLocal("localhost:8000")
+	cfg := db.Client().(*dynamodb.DynamoDB).Config
+	if got := aws.StringValue(cfg.Endpoint); got != "http://localhost:8000" {
+		t.Error("bad endpoint:", got)
+	}
+	if cfg.Credentials == nil || aws.StringValue(cfg.Region) == "" {
+		t.Error("missing placeholder credentials or region")
+	}
+}
+
+func TestHTTPConfig(t *testing.T) {
+	cfg := HTTPConfig(HTTPOptions{MaxIdleConns: 50, Timeout: time.Second})
+	if cfg.HTTPClient.Timeout != time.Second {
+		t.Error("bad timeout:", cfg.HTTPClient.Timeout)
+	}
+	transport := cfg.HTTPClient.Transport.(*http.Transport)
+	if transport.MaxIdleConns != 50 || transport.MaxIdleConnsPerHost != 50 {
+		t.Error("bad idle conns:", transport.MaxIdleConns, transport.MaxIdleConnsPerHost)
+	}
+	if transport.IdleConnTimeout == 0 || transport.TLSHandshakeTimeout == 0 {
+		t.Error("defaults not set")
+	}
+}
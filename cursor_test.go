@@ -0,0 +1,98 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestCursor(t *testing.T) {
+	db := NewFromIface(fakeQueryClient{pages: fakePages(t, 2, 3)})
+	table := db.Table(testTable)
+
+	var page []widget
+	itr := table.Get("UserID", 42).Iter().(*queryIter)
+	if !itr.NextPage(&page) {
+		t.Fatal("no first page:", itr.Err())
+	}
+	token, err := itr.Cursor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token == "" {
+		t.Fatal("empty cursor")
+	}
+
+	q := table.Get("UserID", 42).Cursor(token)
+	if q.err != nil {
+		t.Fatal(q.err)
+	}
+	if keyString(q.startKey) != keyString(itr.LastEvaluatedKey()) {
+		t.Error("bad start key:", q.startKey, "≠", itr.LastEvaluatedKey())
+	}
+	page = nil
+	if err := q.All(&page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 3 || page[0].Msg != "1-0" {
+		t.Error("bad results from cursor:", page)
+	}
+
+	itr = q.Iter().(*queryIter)
+	for itr.Next(&widget{}) {
+	}
+	if token, err := itr.Cursor(); token != "" || err != nil {
+		t.Error("expected empty cursor at the end, got", token, err)
+	}
+
+	if q := table.Get("UserID", 42).Cursor("not a cursor"); q.err != ErrInvalidCursor {
+		t.Error("expected ErrInvalidCursor, got", q.err)
+	}
+}
+
+func TestSignedCursor(t *testing.T) {
+	db := NewFromIface(nil).SignCursors([]byte("secret"))
+	key := PagingKey{
+		"UserID": {N: aws.String("42")},
+		"Time":   {S: aws.String("2019-01-01T00:00:00Z")},
+		"Blob":   {B: []byte{0, 1, 2}},
+	}
+	token, err := db.encodeCursor(testTable, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := db.decodeCursor(testTable, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, key) {
+		t.Error("bad round trip:", decoded, "≠", key)
+	}
+
+	unsigned, _ := NewFromIface(nil).encodeCursor(testTable, key)
+	for name, bad := range map[string]string{
+		"tampered":  "x" + token,
+		"unsigned":  unsigned,
+		"other key": mustCursor(t, NewFromIface(nil).SignCursors([]byte("other")), key),
+	} {
+		if scan := db.Table(testTable).Scan().Cursor(bad); scan.err != ErrInvalidCursor {
+			t.Error(name, "cursor: expected ErrInvalidCursor, got", scan.err)
+		}
+	}
+	if q := db.Table("OtherTable").Get("UserID", 42).Cursor(token); q.err != ErrInvalidCursor {
+		t.Error("cursor for another table: expected ErrInvalidCursor, got", q.err)
+	}
+
+	if _, err := db.encodeCursor(testTable, PagingKey{"Bad": {BOOL: aws.Bool(true)}}); err == nil {
+		t.Error("expected error for unsupported key type")
+	}
+}
+
+func mustCursor(t *testing.T, db *DB, key PagingKey) string {
+	token, err := db.encodeCursor(testTable, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
@@ -3,29 +3,119 @@ package dynamo
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 // DB is a DynamoDB client.
 type DB struct {
-	client dynamodbiface.DynamoDBAPI
+	client   dynamodbiface.DynamoDBAPI
+	gets     *flightGroup
+	cache    *itemCache
+	breakers *breakers
+	roles    *roleCache
+	opts     []request.Option
+	before   []func(WriteEvent) error
+	after    []func(WriteEvent)
+	soft     *SoftDelete
+	clock    *Clock
+	names    FieldNameMapper
+	encoders map[reflect.Type]EncoderFunc
+	decoders map[reflect.Type]DecoderFunc
+	blobs    map[string]BlobCodec
+
+	unprocessed []func(UnprocessedEvent)
+
+	cursorKey   []byte
+	forbidScans map[string]bool
+	readOnly    bool
+	consistent  bool
+	defaults    requestDefaults
+	descs       *descCache
 }
 
 // New creates a new client with the given configuration.
 func New(p client.ConfigProvider, cfgs ...*aws.Config) *DB {
 	db := &DB{
-		dynamodb.New(p, cfgs...),
+		client: dynamodb.New(p, cfgs...),
+		roles:  &roleCache{provider: p},
 	}
 	return db
 }
 
+// NewLocal creates a new client for DynamoDB Local or LocalStack listening on addr,
+// such as "localhost:8000" or "http://localhost:4566". If addr has no scheme, HTTP is used.
+// It uses a placeholder region and credentials, and an HTTP client with a timeout
+// so requests fail quickly if nothing is listening. cfgs can override these settings.
+func NewLocal(addr string, cfgs ...*aws.Config) *DB {
+	endpoint := addr
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	local := &aws.Config{
+		Endpoint:    aws.String(endpoint),
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("local", "local", ""),
+		HTTPClient:  &http.Client{Timeout: 30 * time.Second},
+	}
+	return New(session.New(), append([]*aws.Config{local}, cfgs...)...)
+}
+
+// WithEndpoint returns a copy of this DB that sends its requests to endpoint, a URL such as "http://localhost:8000".
+// Like WithCredentials, the returned DB does not share the cache, GetItem coalescing, or circuit breakers of this DB.
+func (db *DB) WithEndpoint(endpoint string) *DB {
+	u, err := url.Parse(endpoint)
+	if err == nil && (u.Scheme == "" || u.Host == "") {
+		err = fmt.Errorf("dynamo: invalid endpoint: %q", endpoint)
+	}
+	cp := db.isolated()
+	cp.opts = append(cp.opts, func(r *request.Request) {
+		if err != nil {
+			r.Error = err
+			return
+		}
+		r.ClientInfo.Endpoint = endpoint
+		if r.HTTPRequest != nil && r.HTTPRequest.URL != nil {
+			r.HTTPRequest.URL.Scheme = u.Scheme
+			r.HTTPRequest.URL.Host = u.Host
+		}
+	})
+	return cp
+}
+
 // NewFromIface creates a new client with the given interface.
 func NewFromIface(client dynamodbiface.DynamoDBAPI) *DB {
-	return &DB{client}
+	return &DB{client: client}
+}
+
+// CoalesceGets returns a copy of this DB that coalesces concurrent identical GetItem requests,
+// such as those made by Query.One, into a single round trip.
+// Callers waiting on a shared request receive the same result, including its consumed capacity.
+func (db *DB) CoalesceGets() *DB {
+	cp := *db
+	cp.gets = new(flightGroup)
+	return &cp
+}
+
+// ConsistentReads returns a copy of this DB whose reads are strongly consistent by default if on is true.
+// This applies to Get, Query, Scan, and BatchGet, and individual requests can override it with Consistent.
+// Global secondary indexes don't support consistent reads, so it doesn't apply to queries and scans of secondary indexes;
+// use Consistent to read a local secondary index consistently.
+func (db *DB) ConsistentReads(on bool) *DB {
+	cp := *db
+	cp.consistent = on
+	return &cp
 }
 
 // Client returns this DB's internal client used to make API requests.
@@ -36,7 +126,8 @@ func (db *DB) Client() dynamodbiface.DynamoDBAPI {
 // ListTables is a request to list tables.
 // See: http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_ListTables.html
 type ListTables struct {
-	db *DB
+	db      *DB
+	timeout time.Duration
 }
 
 // ListTables begins a new request to list all tables.
@@ -44,15 +135,24 @@ func (db *DB) ListTables() *ListTables {
 	return &ListTables{db: db}
 }
 
+// Timeout limits the total amount of time this request may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (lt *ListTables) Timeout(timeout time.Duration) *ListTables {
+	lt.timeout = timeout
+	return lt
+}
+
 // All returns every table or an error.
 func (lt *ListTables) All() ([]string, error) {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(lt.timeout)
 	defer cancel()
 	return lt.AllWithContext(ctx)
 }
 
 // AllWithContext returns every table or an error.
 func (lt *ListTables) AllWithContext(ctx aws.Context) ([]string, error) {
+	ctx, cancel := withTimeout(ctx, lt.timeout)
+	defer cancel()
 	var tables []string
 	itr := lt.Iter()
 	var name string
@@ -76,12 +176,14 @@ func (lt *ListTables) Iter() Iter {
 }
 
 func (itr *ltIter) Next(out interface{}) bool {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(itr.lt.timeout)
 	defer cancel()
 	return itr.NextWithContext(ctx, out)
 }
 
 func (itr *ltIter) NextWithContext(ctx aws.Context, out interface{}) bool {
+	ctx, cancel := withTimeout(ctx, itr.lt.timeout)
+	defer cancel()
 	if itr.err != nil {
 		return false
 	}
@@ -105,7 +207,7 @@ func (itr *ltIter) NextWithContext(ctx aws.Context, out interface{}) bool {
 	}
 
 	itr.err = retry(ctx, func() error {
-		res, err := itr.lt.db.client.ListTables(itr.input())
+		res, err := itr.lt.db.client.ListTablesWithContext(ctx, itr.input(), itr.lt.db.opts...)
 		if err != nil {
 			return err
 		}
@@ -159,6 +261,64 @@ type PagingIter interface {
 	LastEvaluatedKey() PagingKey
 }
 
+// PageIter is an iterator whose results can also be read a page at a time.
+// The iterators returned by Query, Scan, and BatchGet implement it:
+//	iter := table.Scan().Iter().(dynamo.PageIter)
+type PageIter interface {
+	Iter
+	// NextPage unmarshals the rest of the current page of results into out, which must be a pointer to a slice.
+	// For Query and Scan, LastEvaluatedKey will return the key of the page that was just read.
+	// Returns false when it is complete or if it runs into an error.
+	NextPage(out interface{}) bool
+	// NextPageWithContext unmarshals the rest of the current page of results into out, which must be a pointer to a slice.
+	// For Query and Scan, LastEvaluatedKey will return the key of the page that was just read.
+	// Returns false when it is complete or if it runs into an error.
+	NextPageWithContext(ctx aws.Context, out interface{}) bool
+}
+
+// CursorIter is an iterator that can return its position as an opaque token.
+// The iterators returned by Query and Scan implement it.
+type CursorIter interface {
+	PagingIter
+	// Cursor returns LastEvaluatedKey as an opaque token that can be passed to Cursor in Query or Scan,
+	// which is safe to hand to clients of paginated APIs. See DB.SignCursors.
+	// Returns an empty string when there are no more results.
+	Cursor() (string, error)
+}
+
+// StatsIter is an iterator that reports statistics about the pages it has fetched.
+// The iterators returned by Query, Scan, and BatchGet implement it.
+type StatsIter interface {
+	Iter
+	// Stats returns statistics about the pages fetched so far,
+	// such as how many items the latest page read before filtering and the capacity it consumed.
+	Stats() PageStats
+	// RequestID returns the ID of the latest request sent to DynamoDB, even if it failed.
+	// It is useful for correlating with AWS support cases and server-side logs.
+	RequestID() string
+}
+
+// RawIter is an iterator that can return results without decoding them.
+// The iterators returned by Query, Scan, and BatchGet implement it.
+type RawIter interface {
+	Iter
+	// NextRaw returns the next result without decoding it, so it can be decoded lazily. See RawItem.
+	// Returns false when it is complete or if it runs into an error.
+	NextRaw() (RawItem, bool)
+	// NextRawWithContext returns the next result without decoding it, so it can be decoded lazily. See RawItem.
+	// Returns false when it is complete or if it runs into an error.
+	NextRawWithContext(ctx aws.Context) (RawItem, bool)
+}
+
+// BatchGetIter is an iterator of BatchGet results that can report the keys left unprocessed.
+// The iterator returned by BatchGet implements it, as well as PageIter, StatsIter, and RawIter.
+type BatchGetIter interface {
+	Iter
+	// UnprocessedKeys returns the keys DynamoDB left unprocessed in the last response.
+	// They are requested again automatically.
+	UnprocessedKeys() []map[string]*dynamodb.AttributeValue
+}
+
 // PagingKey is a key used for splitting up partial results.
 // Get a PagingKey from a PagingIter and pass it to StartFrom in Query or Scan.
 type PagingKey map[string]*dynamodb.AttributeValue
@@ -2,9 +2,11 @@ package dynamo
 
 import (
 	"encoding"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -18,6 +20,11 @@ type Unmarshaler interface {
 }
 
 // Unmarshal decodes a DynamoDB item into out, which must be a pointer.
+// Items can be decoded into structs, maps with string keys, or interface{}.
+// When decoding into interface{}, or maps or slices of it, values are decoded as follows:
+// S as string, N as float64, B as []byte, BOOL as bool, NULL as nil, L as []interface{},
+// M as map[string]interface{}, SS as []string, NS as []float64, and BS as [][]byte.
+// Items themselves are decoded into interface{} as map[string]interface{}.
 func UnmarshalItem(item map[string]*dynamodb.AttributeValue, out interface{}) error {
 	return unmarshalItem(item, out)
 }
@@ -36,6 +43,14 @@ var tumType = reflect.TypeOf(&nilTum).Elem()
 
 // unmarshals one value
 func unmarshalReflect(av *dynamodb.AttributeValue, rv reflect.Value) error {
+	return codec{}.unmarshalReflect(av, rv)
+}
+
+func (c codec) unmarshalReflect(av *dynamodb.AttributeValue, rv reflect.Value) error {
+	if ok, err := c.decodeRegistered(av, rv); ok {
+		return err
+	}
+
 	// first try interface unmarshal stuff
 	if rv.CanInterface() {
 		var iface interface{}
@@ -45,6 +60,10 @@ func unmarshalReflect(av *dynamodb.AttributeValue, rv reflect.Value) error {
 			iface = rv.Interface()
 		}
 
+		if x, ok := iface.(*json.RawMessage); ok {
+			return unmarshalRawJSON(av, x)
+		}
+
 		if x, ok := iface.(*time.Time); ok && av.N != nil {
 			// implicit unixtime
 			// TODO(guregu): have unixtime unmarshal explicitly check struct tags
@@ -82,7 +101,7 @@ func unmarshalReflect(av *dynamodb.AttributeValue, rv reflect.Value) error {
 		pt := reflect.New(rv.Type().Elem())
 		rv.Set(pt)
 		if av.NULL == nil || !(*av.NULL) {
-			return unmarshalReflect(av, rv.Elem())
+			return c.unmarshalReflect(av, rv.Elem())
 		}
 		return nil
 	case reflect.Bool:
@@ -131,7 +150,7 @@ func unmarshalReflect(av *dynamodb.AttributeValue, rv reflect.Value) error {
 		if av.M == nil {
 			return fmt.Errorf("dynamo: cannot unmarshal %s data into struct", avTypeName(av))
 		}
-		if err := unmarshalItem(av.M, rv.Addr().Interface()); err != nil {
+		if err := c.unmarshalItem(av.M, rv.Addr().Interface()); err != nil {
 			return err
 		}
 		return nil
@@ -161,7 +180,7 @@ func unmarshalReflect(av *dynamodb.AttributeValue, rv reflect.Value) error {
 			kv := kp.Elem()
 			for k, v := range av.M {
 				innerRV := reflect.New(rv.Type().Elem())
-				if err := unmarshalReflect(v, innerRV.Elem()); err != nil {
+				if err := c.unmarshalReflect(v, innerRV.Elem()); err != nil {
 					return err
 				}
 				if kp.Type().Implements(tumType) {
@@ -193,7 +212,7 @@ func unmarshalReflect(av *dynamodb.AttributeValue, rv reflect.Value) error {
 		case av.NS != nil:
 			kv := reflect.New(rv.Type().Key()).Elem()
 			for _, n := range av.NS {
-				if err := unmarshalReflect(&dynamodb.AttributeValue{N: n}, kv); err != nil {
+				if err := c.unmarshalReflect(&dynamodb.AttributeValue{N: n}, kv); err != nil {
 					return nil
 				}
 				rv.SetMapIndex(kv, truthy)
@@ -211,7 +230,7 @@ func unmarshalReflect(av *dynamodb.AttributeValue, rv reflect.Value) error {
 		}
 		return fmt.Errorf("dynamo: cannot unmarshal %s data into map", avTypeName(av))
 	case reflect.Slice:
-		return unmarshalSlice(av, rv)
+		return c.unmarshalSlice(av, rv)
 	case reflect.Array:
 		arr := reflect.New(rv.Type()).Elem()
 		elemtype := arr.Type().Elem()
@@ -225,7 +244,7 @@ func unmarshalReflect(av *dynamodb.AttributeValue, rv reflect.Value) error {
 		case av.L != nil:
 			for i, innerAV := range av.L {
 				innerRV := reflect.New(elemtype).Elem()
-				if err := unmarshalReflect(innerAV, innerRV); err != nil {
+				if err := c.unmarshalReflect(innerAV, innerRV); err != nil {
 					return err
 				}
 				arr.Index(i).Set(innerRV)
@@ -254,7 +273,7 @@ func unmarshalReflect(av *dynamodb.AttributeValue, rv reflect.Value) error {
 }
 
 // unmarshal for when rv's Kind is Slice
-func unmarshalSlice(av *dynamodb.AttributeValue, rv reflect.Value) error {
+func (c codec) unmarshalSlice(av *dynamodb.AttributeValue, rv reflect.Value) error {
 	switch {
 	case av.B != nil:
 		rv.SetBytes(av.B)
@@ -264,7 +283,7 @@ func unmarshalSlice(av *dynamodb.AttributeValue, rv reflect.Value) error {
 		slicev := reflect.MakeSlice(rv.Type(), 0, len(av.L))
 		for _, innerAV := range av.L {
 			innerRV := reflect.New(rv.Type().Elem()).Elem()
-			if err := unmarshalReflect(innerAV, innerRV); err != nil {
+			if err := c.unmarshalReflect(innerAV, innerRV); err != nil {
 				return err
 			}
 			slicev = reflect.Append(slicev, innerRV)
@@ -277,7 +296,7 @@ func unmarshalSlice(av *dynamodb.AttributeValue, rv reflect.Value) error {
 		slicev := reflect.MakeSlice(rv.Type(), 0, len(av.L))
 		for _, b := range av.BS {
 			innerRV := reflect.New(rv.Type().Elem()).Elem()
-			if err := unmarshalReflect(&dynamodb.AttributeValue{B: b}, innerRV); err != nil {
+			if err := c.unmarshalReflect(&dynamodb.AttributeValue{B: b}, innerRV); err != nil {
 				return err
 			}
 			slicev = reflect.Append(slicev, innerRV)
@@ -288,7 +307,7 @@ func unmarshalSlice(av *dynamodb.AttributeValue, rv reflect.Value) error {
 		slicev := reflect.MakeSlice(rv.Type(), 0, len(av.L))
 		for _, str := range av.SS {
 			innerRV := reflect.New(rv.Type().Elem()).Elem()
-			if err := unmarshalReflect(&dynamodb.AttributeValue{S: str}, innerRV); err != nil {
+			if err := c.unmarshalReflect(&dynamodb.AttributeValue{S: str}, innerRV); err != nil {
 				return err
 			}
 			slicev = reflect.Append(slicev, innerRV)
@@ -299,7 +318,7 @@ func unmarshalSlice(av *dynamodb.AttributeValue, rv reflect.Value) error {
 		slicev := reflect.MakeSlice(rv.Type(), 0, len(av.L))
 		for _, n := range av.NS {
 			innerRV := reflect.New(rv.Type().Elem()).Elem()
-			if err := unmarshalReflect(&dynamodb.AttributeValue{N: n}, innerRV); err != nil {
+			if err := c.unmarshalReflect(&dynamodb.AttributeValue{N: n}, innerRV); err != nil {
 				return err
 			}
 			slicev = reflect.Append(slicev, innerRV)
@@ -310,42 +329,68 @@ func unmarshalSlice(av *dynamodb.AttributeValue, rv reflect.Value) error {
 	return fmt.Errorf("dynamo: cannot unmarshal %s data into slice", avTypeName(av))
 }
 
-func fieldsInStruct(rv reflect.Value) map[string]reflect.Value {
+// structField is a field to decode an attribute into, along with its special tag option.
+type structField struct {
+	value   reflect.Value
+	special string
+}
+
+// structFieldsPool recycles the field maps made by fieldsInStruct, which would otherwise be allocated for every item decoded.
+var structFieldsPool = sync.Pool{
+	New: func() interface{} {
+		return make(map[string]structField)
+	},
+}
+
+// releaseFields empties fields, so it doesn't keep the decoded struct alive, and returns it to structFieldsPool.
+func releaseFields(fields map[string]structField) {
+	for k := range fields {
+		delete(fields, k)
+	}
+	structFieldsPool.Put(fields)
+}
+
+func (c codec) fieldsInStruct(rv reflect.Value) map[string]structField {
 	if rv.Kind() == reflect.Ptr {
-		return fieldsInStruct(rv.Elem())
+		return c.fieldsInStruct(rv.Elem())
 	}
 
-	fields := make(map[string]reflect.Value)
+	fields := structFieldsPool.Get().(map[string]structField)
 	for i := 0; i < rv.Type().NumField(); i++ {
 		field := rv.Type().Field(i)
 		fv := rv.Field(i)
 
-		name, _, _ := fieldInfo(field)
+		name, special, _ := c.fieldInfo(field)
 		if name == "-" {
 			// skip
 			continue
 		}
 
-		// embed anonymous structs
-		if fv.Type().Kind() == reflect.Struct && field.Anonymous {
-			innerFields := fieldsInStruct(fv)
+		// embed anonymous and flattened structs
+		if flatten, prefix := flattening(field); flatten {
+			innerFields := c.fieldsInStruct(fv)
 			for k, v := range innerFields {
 				// don't clobber top-level fields
-				if _, exists := fields[k]; exists {
+				if _, exists := fields[prefix+k]; exists {
 					continue
 				}
-				fields[k] = v
+				fields[prefix+k] = v
 			}
+			releaseFields(innerFields)
 			continue
 		}
 
-		fields[name] = fv
+		fields[name] = structField{value: fv, special: special}
 	}
 	return fields
 }
 
 // unmarshals a struct
 func unmarshalItem(item map[string]*dynamodb.AttributeValue, out interface{}) error {
+	return codec{}.unmarshalItem(item, out)
+}
+
+func (c codec) unmarshalItem(item map[string]*dynamodb.AttributeValue, out interface{}) error {
 	if out, ok := out.(*map[string]*dynamodb.AttributeValue); ok {
 		*out = item
 		return nil
@@ -359,18 +404,27 @@ func unmarshalItem(item map[string]*dynamodb.AttributeValue, out interface{}) er
 	switch rv.Elem().Kind() {
 	case reflect.Ptr:
 		rv.Elem().Set(reflect.New(rv.Elem().Type().Elem()))
-		return unmarshalItem(item, rv.Elem().Interface())
+		return c.unmarshalItem(item, rv.Elem().Interface())
 	case reflect.Struct:
 		var err error
 		rv.Elem().Set(reflect.Zero(rv.Type().Elem()))
-		fields := fieldsInStruct(rv.Elem())
-		for name, fv := range fields {
-			if av, ok := item[name]; ok {
-				if innerErr := unmarshalReflect(av, fv); innerErr != nil {
-					err = innerErr
-				}
+		fields := c.fieldsInStruct(rv.Elem())
+		for name, field := range fields {
+			av, ok := item[name]
+			if !ok {
+				continue
+			}
+			var innerErr error
+			if blob, ok := c.blobs[field.special]; ok {
+				innerErr = unmarshalBlob(blob, av, field.value)
+			} else {
+				innerErr = c.unmarshalReflect(av, field.value)
+			}
+			if innerErr != nil {
+				err = innerErr
 			}
 		}
+		releaseFields(fields)
 		return err
 	case reflect.Map:
 		mapv := rv.Elem()
@@ -383,17 +437,31 @@ func unmarshalItem(item map[string]*dynamodb.AttributeValue, out interface{}) er
 
 		for k, av := range item {
 			innerRV := reflect.New(mapv.Type().Elem()).Elem()
-			if err := unmarshalReflect(av, innerRV); err != nil {
+			if err := c.unmarshalReflect(av, innerRV); err != nil {
 				return err
 			}
 			mapv.SetMapIndex(reflect.ValueOf(k), innerRV)
 		}
 		return nil
+	case reflect.Interface:
+		if rv.Elem().NumMethod() != 0 {
+			break
+		}
+		m := make(map[string]interface{}, len(item))
+		if err := c.unmarshalItem(item, &m); err != nil {
+			return err
+		}
+		rv.Elem().Set(reflect.ValueOf(m))
+		return nil
 	}
 	return fmt.Errorf("dynamo: unmarshal: unsupported type: %T", out)
 }
 
 func unmarshalAppend(item map[string]*dynamodb.AttributeValue, out interface{}) error {
+	return codec{}.unmarshalAppend(item, out)
+}
+
+func (c codec) unmarshalAppend(item map[string]*dynamodb.AttributeValue, out interface{}) error {
 	rv := reflect.ValueOf(out)
 	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
 		return fmt.Errorf("dynamo: unmarshal append: result argument must be a slice pointer")
@@ -401,7 +469,7 @@ func unmarshalAppend(item map[string]*dynamodb.AttributeValue, out interface{})
 
 	slicev := rv.Elem()
 	innerRV := reflect.New(slicev.Type().Elem())
-	if err := unmarshalItem(item, innerRV.Interface()); err != nil {
+	if err := c.unmarshalItem(item, innerRV.Interface()); err != nil {
 		return err
 	}
 	slicev = reflect.Append(slicev, innerRV.Elem())
@@ -410,6 +478,16 @@ func unmarshalAppend(item map[string]*dynamodb.AttributeValue, out interface{})
 	return nil
 }
 
+// resetSlice sets out, which must be a pointer to a slice, to an empty slice.
+func resetSlice(out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dynamo: unmarshal page: result argument must be a slice pointer")
+	}
+	rv.Elem().Set(reflect.MakeSlice(rv.Elem().Type(), 0, 0))
+	return nil
+}
+
 // av2iface converts an av into interface{}.
 func av2iface(av *dynamodb.AttributeValue) (interface{}, error) {
 	switch {
@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
@@ -54,6 +55,37 @@ func TestUnmarshalAppend(t *testing.T) {
 	}
 }
 
+func TestUnmarshalItemInterface(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"UserID": {N: aws.String("42")},
+		"Msg":    {S: aws.String("hello")},
+		"Meta":   {M: map[string]*dynamodb.AttributeValue{"ok": {BOOL: aws.Bool(true)}}},
+		"Tags":   {SS: []*string{aws.String("a")}},
+	}
+	expected := map[string]interface{}{
+		"UserID": 42.0,
+		"Msg":    "hello",
+		"Meta":   map[string]interface{}{"ok": true},
+		"Tags":   []string{"a"},
+	}
+
+	var iface interface{}
+	if err := unmarshalItem(item, &iface); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(iface, expected) {
+		t.Error("bad interface{} result:", iface)
+	}
+
+	var list []interface{}
+	if err := unmarshalAppend(item, &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || !reflect.DeepEqual(list[0], expected) {
+		t.Error("bad []interface{} result:", list)
+	}
+}
+
 func TestUnmarshal(t *testing.T) {
 	for _, tc := range encodingTests {
 		rv := reflect.New(reflect.TypeOf(tc.in))
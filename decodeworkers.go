@@ -0,0 +1,114 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// DecodeWorkers sets the number of goroutines that decode pages of results for All,
+// so that decoding a huge scan happens in parallel while the next pages are being fetched.
+// Results are still returned in order. By default, or if n is 1 or less, results are decoded as they are fetched.
+// Registered decoders, blob codecs, and Unmarshaler implementations must be safe for concurrent use.
+// Iterators always decode serially.
+func (s *Scan) DecodeWorkers(n int) *Scan {
+	s.decodeWorkers = n
+	return s
+}
+
+// DecodeWorkers sets the number of goroutines that decode pages of results for All. See Scan.DecodeWorkers.
+func (bg *BatchGet) DecodeWorkers(n int) *BatchGet {
+	bg.decodeWorkers = n
+	return bg
+}
+
+// pageSource is an iterator that can hand over the rest of its current page of raw items.
+type pageSource interface {
+	// nextItems returns the rest of the current page, fetching the next page if needed,
+	// and marks them as read. It returns false when there are no more results or if it runs into an error.
+	nextItems(ctx aws.Context) ([]map[string]*dynamodb.AttributeValue, bool)
+}
+
+// decodeJob is a page of items to decode, and its position in the results.
+type decodeJob struct {
+	idx   int
+	items []map[string]*dynamodb.AttributeValue
+}
+
+// decodePages fetches every page from src and decodes them using workers goroutines,
+// appending the results in order to out, which must be a pointer to a slice.
+// Errors from src are left for the caller to check.
+func (c codec) decodePages(ctx aws.Context, src pageSource, workers int, out interface{}) error {
+	// check out before fetching anything
+	if rv := reflect.ValueOf(out); rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("dynamo: unmarshal append: result argument must be a slice pointer")
+	}
+	sliceType := reflect.TypeOf(out).Elem()
+
+	var (
+		mu      sync.Mutex
+		decoded []reflect.Value
+		failErr error
+		failed  = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failErr == nil {
+			failErr = err
+			close(failed)
+		}
+	}
+	jobs := make(chan decodeJob, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				page := reflect.New(sliceType)
+				page.Elem().Set(reflect.MakeSlice(sliceType, 0, len(job.items)))
+				for _, item := range job.items {
+					if err := c.unmarshalAppend(item, page.Interface()); err != nil {
+						fail(err)
+						break
+					}
+				}
+				mu.Lock()
+				decoded[job.idx] = page.Elem()
+				mu.Unlock()
+			}
+		}()
+	}
+
+fetch:
+	for idx := 0; ; idx++ {
+		items, ok := src.nextItems(ctx)
+		if !ok {
+			break
+		}
+		mu.Lock()
+		decoded = append(decoded, reflect.Value{})
+		mu.Unlock()
+		select {
+		case jobs <- decodeJob{idx: idx, items: items}:
+		case <-failed:
+			break fetch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if failErr != nil {
+		return failErr
+	}
+
+	slice := reflect.ValueOf(out).Elem()
+	for _, page := range decoded {
+		slice = reflect.AppendSlice(slice, page)
+	}
+	reflect.ValueOf(out).Elem().Set(slice)
+	return nil
+}
@@ -0,0 +1,83 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestScanDecodeWorkers(t *testing.T) {
+	table := NewFromIface(fakeScanClient{pages: fakePages(t, 3, 0, 4, 2)}).Table(testTable)
+
+	var serial, parallel []widget
+	if err := table.Scan().All(&serial); err != nil {
+		t.Fatal(err)
+	}
+	var stats PageStats
+	if err := table.Scan().DecodeWorkers(3).Stats(&stats).All(&parallel); err != nil {
+		t.Fatal(err)
+	}
+	if len(parallel) != 9 || !reflect.DeepEqual(parallel, serial) {
+		t.Error("bad results:", parallel, "≠", serial)
+	}
+	if stats.Pages != 4 {
+		t.Error("bad pages:", stats.Pages)
+	}
+
+	var limited []widget
+	if err := table.Scan().DecodeWorkers(3).Limit(5).All(&limited); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(limited, serial[:5]) {
+		t.Error("bad limited results:", limited, "≠", serial[:5])
+	}
+
+	// results are appended to
+	existing := []widget{{Msg: "existing"}}
+	if err := table.Scan().DecodeWorkers(2).All(&existing); err != nil {
+		t.Fatal(err)
+	}
+	if len(existing) != 10 || existing[0].Msg != "existing" {
+		t.Error("bad appended results:", existing)
+	}
+
+	var bad []struct{ Msg int }
+	if err := table.Scan().DecodeWorkers(2).All(&bad); err == nil {
+		t.Error("expected decode error")
+	}
+	if err := table.Scan().DecodeWorkers(2).All(serial); err == nil {
+		t.Error("expected error for non-pointer")
+	}
+}
+
+func TestBatchGetDecodeWorkers(t *testing.T) {
+	var items []map[string]*dynamodb.AttributeValue
+	var keys []Keyed
+	for i := 0; i < 150; i++ {
+		item, err := marshalItem(widget{UserID: i, Msg: "hello"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+		keys = append(keys, Keys{i})
+	}
+	batch := NewFromIface(fakeBatchGetClient{items: items}).Table(testTable).Batch("UserID")
+
+	var results []widget
+	if err := batch.Get(keys...).DecodeWorkers(4).RequireAll(true).All(&results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 150 {
+		t.Fatal("expected 150 results, got", len(results))
+	}
+	for i, w := range results {
+		if w.UserID != i {
+			t.Fatal("results out of order at", i, w.UserID)
+		}
+	}
+
+	if err := batch.Get(Keys{1000}).DecodeWorkers(4).All(&results); err != ErrNotFound {
+		t.Error("expected ErrNotFound, got", err)
+	}
+}
@@ -0,0 +1,40 @@
+package dynamo
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// ItemKey returns a key function for Dedupe that identifies items by the given attributes,
+// typically the table's hash and range keys.
+func ItemKey(names ...string) func(item map[string]*dynamodb.AttributeValue) string {
+	return func(item map[string]*dynamodb.AttributeValue) string {
+		key := make(map[string]*dynamodb.AttributeValue, len(names))
+		for _, name := range names {
+			key[name] = item[name]
+		}
+		return keyString(key)
+	}
+}
+
+// deduper remembers the keys of items it has seen.
+type deduper struct {
+	key  func(item map[string]*dynamodb.AttributeValue) string
+	seen map[string]struct{}
+}
+
+// dup returns true if an item with the same key as item was seen before.
+// It always returns false if there is no key function.
+func (d *deduper) dup(item map[string]*dynamodb.AttributeValue) bool {
+	if d.key == nil {
+		return false
+	}
+	if d.seen == nil {
+		d.seen = make(map[string]struct{})
+	}
+	k := d.key(item)
+	if _, ok := d.seen[k]; ok {
+		return true
+	}
+	d.seen[k] = struct{}{}
+	return false
+}
@@ -0,0 +1,61 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestDedupe(t *testing.T) {
+	type point struct {
+		PK   string
+		ID   string
+		Time int64
+	}
+	// "x" and "y" are in both partitions, like an item being copied between them
+	client := partitionClient{parts: make(map[string][]map[string]*dynamodb.AttributeValue)}
+	for _, p := range []point{
+		{"a", "w", 1}, {"a", "x", 2}, {"b", "x", 2}, {"a", "y", 3}, {"b", "y", 3}, {"b", "z", 4},
+	} {
+		item, err := marshalItem(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.parts[p.PK] = append(client.parts[p.PK], item)
+	}
+	table := NewFromIface(client).Table("Points")
+	want := []string{"w", "x", "y", "z"}
+	ids := func(points []point) []string {
+		var ids []string
+		for _, p := range points {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	var results []point
+	err := table.Get("PK", "ignored").Range("Time", GreaterOrEqual, 0).HashValues("a", "b").
+		Dedupe(ItemKey("ID")).
+		All(&results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(results); !reflect.DeepEqual(got, want) {
+		t.Error("bad bucket query results:", got, "≠", want)
+	}
+
+	iter := MergeIters(OrderBy("Time", Ascending), table.Get("PK", "a").Iter(), table.Get("PK", "b").Iter()).
+		Dedupe(ItemKey("ID"))
+	results = nil
+	var p point
+	for iter.Next(&p) {
+		results = append(results, p)
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(results); !reflect.DeepEqual(got, want) {
+		t.Error("bad merged results:", got, "≠", want)
+	}
+}
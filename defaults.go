@@ -0,0 +1,87 @@
+package dynamo
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// DefaultOption sets a default for the requests made by a DB. See DB.WithDefaults.
+type DefaultOption func(*DB)
+
+// WithDefaults returns a copy of this DB whose requests use the given defaults,
+// instead of setting them on every request. Tables of the returned DB and requests made from them inherit them,
+// and individual requests can override them with their own options.
+//	db = db.WithDefaults(
+//		dynamo.DefaultConsistentReads(true),
+//		dynamo.DefaultTimeout(5*time.Second),
+//		dynamo.DefaultRetryLimit(3, time.Second),
+//	)
+// Timeouts, consumed capacity, and retry limits apply to Get, Query, Scan, Put, Update, Delete, BatchGet, BatchWrite,
+// GetTx, and WriteTx requests, but not to requests that manage tables, such as CreateTable.
+func (db *DB) WithDefaults(opts ...DefaultOption) *DB {
+	cp := *db
+	for _, opt := range opts {
+		opt(&cp)
+	}
+	return &cp
+}
+
+// DefaultConsistentReads makes reads strongly consistent by default if on is true. See DB.ConsistentReads.
+func DefaultConsistentReads(on bool) DefaultOption {
+	return func(db *DB) {
+		db.consistent = on
+	}
+}
+
+// DefaultTimeout limits the time requests may take, including all retries and backoff, like their Timeout methods.
+// Requests override it with Timeout.
+func DefaultTimeout(timeout time.Duration) DefaultOption {
+	return func(db *DB) {
+		db.defaults.timeout = timeout
+	}
+}
+
+// DefaultConsumedCapacity makes requests add the capacity they consume to cc.
+// Requests override it with ConsumedCapacity.
+// Concurrent requests can share cc, but it should only be read while none are running.
+func DefaultConsumedCapacity(cc *ConsumedCapacity) DefaultOption {
+	return func(db *DB) {
+		db.defaults.cc = cc
+	}
+}
+
+// DefaultRetryLimit limits how much each request may retry: once it has retried retries times,
+// or waited a total of wait between retries, it fails with its last error instead of retrying again.
+// A limit of zero means no limit.
+// Requests made with a context that has a budget from WithRetryBudget use that budget instead.
+func DefaultRetryLimit(retries int, wait time.Duration) DefaultOption {
+	return func(db *DB) {
+		db.defaults.retries = retries
+		db.defaults.wait = wait
+	}
+}
+
+// requestDefaults are the options of a DB's requests that aren't set on the request itself.
+type requestDefaults struct {
+	timeout time.Duration
+	cc      *ConsumedCapacity
+	retries int
+	wait    time.Duration
+}
+
+func (db *DB) requestDefaults() requestDefaults {
+	if db == nil {
+		return requestDefaults{}
+	}
+	return db.defaults
+}
+
+// budgeted returns ctx limited by this DB's default retry limit, unless it has a retry budget of its own.
+func (db *DB) budgeted(ctx aws.Context) aws.Context {
+	defs := db.requestDefaults()
+	if (defs.retries == 0 && defs.wait == 0) || budgetFrom(ctx) != nil {
+		return ctx
+	}
+	return WithRetryBudget(ctx, defs.retries, defs.wait)
+}
@@ -0,0 +1,91 @@
+package dynamo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// capacityClient is a fake client whose puts consume one capacity unit.
+type capacityClient struct {
+	*memClient
+}
+
+func (c capacityClient) PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	out, err := c.memClient.PutItemWithContext(ctx, input, opts...)
+	if out != nil && input.ReturnConsumedCapacity != nil {
+		out.ConsumedCapacity = &dynamodb.ConsumedCapacity{CapacityUnits: aws.Float64(1)}
+	}
+	return out, err
+}
+
+func TestWithDefaults(t *testing.T) {
+	var cc ConsumedCapacity
+	db := NewFromIface(capacityClient{newMemClient()}).WithDefaults(
+		DefaultConsistentReads(true),
+		DefaultTimeout(5*time.Second),
+		DefaultConsumedCapacity(&cc),
+	)
+	table := db.Table(testTable)
+
+	q := table.Get("UserID", 42)
+	if !q.consistentRead() {
+		t.Error("default consistency not applied")
+	}
+	if q.timeout != 5*time.Second {
+		t.Error("default timeout not applied:", q.timeout)
+	}
+	if q.Timeout(time.Second).timeout != time.Second {
+		t.Error("timeout not overridden")
+	}
+	if table.Scan().Consistent(false).consistentRead() {
+		t.Error("consistency not overridden")
+	}
+
+	if err := table.Put(widget{UserID: 42}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Put(widget{UserID: 43}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	var own ConsumedCapacity
+	if err := table.Put(widget{UserID: 44}).ConsumedCapacity(&own).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if cc.Total != 2 {
+		t.Error("bad default consumed capacity:", cc.Total)
+	}
+	if own.Total != 1 {
+		t.Error("bad overridden consumed capacity:", own.Total)
+	}
+
+	// the original DB is unaffected
+	if plain := NewFromIface(nil).Table(testTable).Get("UserID", 42); plain.timeout != 0 || plain.cc != nil {
+		t.Error("defaults leaked:", plain.timeout, plain.cc)
+	}
+}
+
+func TestDefaultRetryLimit(t *testing.T) {
+	client := &flakyClient{fail: true}
+	table := NewFromIface(client).WithDefaults(DefaultRetryLimit(0, time.Nanosecond)).Table(testTable)
+
+	if err := table.Put(widget{UserID: 42}).Run(); err == nil {
+		t.Fatal("expected error")
+	}
+	if client.calls != 1 {
+		t.Error("expected 1 call, got", client.calls)
+	}
+
+	// a budget of the request's own wins
+	client.calls = 0
+	ctx := WithRetryBudget(aws.BackgroundContext(), 1, 0)
+	if err := table.Put(widget{UserID: 42}).RunWithContext(ctx); err == nil {
+		t.Fatal("expected error")
+	}
+	if client.calls != 2 {
+		t.Error("expected 2 calls, got", client.calls)
+	}
+}
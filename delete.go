@@ -1,6 +1,8 @@
 package dynamo
 
 import (
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
@@ -20,19 +22,26 @@ type Delete struct {
 	subber
 	condition string
 
-	err error
-	cc  *ConsumedCapacity
+	err     error
+	cc      *ConsumedCapacity
+	icm     *ItemCollectionMetrics
+	reqID   *string
+	timeout time.Duration
 }
 
 // Delete creates a new request to delete an item.
 // Key is the name of the hash key (a.k.a. partition key).
 // Value is the value of the hash key.
 func (table Table) Delete(name string, value interface{}) *Delete {
+	defs := table.db.requestDefaults()
 	d := &Delete{
 		table:   table,
 		hashKey: name,
+		subber:  subber{codec: table.db.codec()},
+		cc:      defs.cc,
+		timeout: defs.timeout,
 	}
-	d.hashValue, d.err = marshal(value, "")
+	d.hashValue, d.err = table.db.codec().marshal(value, "")
 	return d
 }
 
@@ -42,7 +51,7 @@ func (table Table) Delete(name string, value interface{}) *Delete {
 func (d *Delete) Range(name string, value interface{}) *Delete {
 	var err error
 	d.rangeKey = name
-	d.rangeValue, err = marshal(value, "")
+	d.rangeValue, err = d.table.db.codec().marshal(value, "")
 	d.setError(err)
 	return d
 }
@@ -69,9 +78,30 @@ func (d *Delete) ConsumedCapacity(cc *ConsumedCapacity) *Delete {
 	return d
 }
 
+// RequestID will record the ID of the last request sent by this delete in id, even if it failed.
+// It is useful for correlating with AWS support cases and server-side logs.
+func (d *Delete) RequestID(id *string) *Delete {
+	d.reqID = id
+	return d
+}
+
+// ItemCollectionMetrics will record the estimated size of the item collection affected by this delete in icm.
+// This is only reported for tables with local secondary indexes.
+func (d *Delete) ItemCollectionMetrics(icm *ItemCollectionMetrics) *Delete {
+	d.icm = icm
+	return d
+}
+
+// Timeout limits the total amount of time this delete may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (d *Delete) Timeout(timeout time.Duration) *Delete {
+	d.timeout = timeout
+	return d
+}
+
 // Run executes this delete request.
 func (d *Delete) Run() error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(d.timeout)
 	defer cancel()
 	return d.RunWithContext(ctx)
 }
@@ -85,7 +115,7 @@ func (d *Delete) RunWithContext(ctx aws.Context) error {
 // OldValue executes this delete request, unmarshaling the previous value to out.
 // Returns ErrNotFound is there was no previous value.
 func (d *Delete) OldValue(out interface{}) error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(d.timeout)
 	defer cancel()
 	return d.OldValueWithContext(ctx, out)
 }
@@ -99,24 +129,64 @@ func (d *Delete) OldValueWithContext(ctx aws.Context, out interface{}) error {
 	case output.Attributes == nil:
 		return ErrNotFound
 	}
-	return unmarshalItem(output.Attributes, out)
+	return d.table.db.codec().unmarshalItem(output.Attributes, out)
+}
+
+// Existed executes this delete request, returning true if there was an item to delete.
+// Deleting an item that doesn't exist is not an error, so use this to tell the two cases apart.
+func (d *Delete) Existed() (bool, error) {
+	ctx, cancel := timeoutContext(d.timeout)
+	defer cancel()
+	return d.ExistedWithContext(ctx)
+}
+
+func (d *Delete) ExistedWithContext(ctx aws.Context) (bool, error) {
+	d.returnType = "ALL_OLD"
+	output, err := d.run(ctx)
+	if err != nil {
+		return false, err
+	}
+	return output.Attributes != nil, nil
 }
 
-func (d *Delete) run(ctx aws.Context) (*dynamodb.DeleteItemOutput, error) {
+func (d *Delete) run(ctx aws.Context) (output *dynamodb.DeleteItemOutput, err error) {
+	ctx, cancel := withTimeout(ctx, d.timeout)
+	defer cancel()
 	if d.err != nil {
 		return nil, d.err
 	}
 
 	input := d.deleteInput()
-	var output *dynamodb.DeleteItemOutput
-	err := retry(ctx, func() error {
-		var err error
-		output, err = d.table.db.client.DeleteItemWithContext(ctx, input)
-		return err
-	})
-	if d.cc != nil {
+	if db := d.table.db; db.hasWriteHooks() {
+		ev := WriteEvent{Op: WriteDelete, Table: d.table.Name(), Key: input.Key}
+		if err := db.beforeWrite(ev); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if output != nil {
+				ev.Item = output.Attributes
+			}
+			db.afterWrite(err, ev)
+		}()
+	}
+	if d.table.db.soft != nil {
+		output, err = d.softDelete(ctx)
+	} else {
+		err = d.table.db.retry(ctx, d.table.Name(), func() error {
+			var err error
+			output, err = d.table.db.client.DeleteItemWithContext(ctx, input, d.table.db.requestOptions(d.reqID)...)
+			return err
+		})
+	}
+	if c := d.table.db.cache; c != nil {
+		c.invalidate(d.table.Name(), input.Key)
+	}
+	if d.cc != nil && output != nil {
 		addConsumedCapacity(d.cc, output.ConsumedCapacity)
 	}
+	if d.icm != nil && output != nil {
+		setItemCollectionMetrics(d.icm, d.table.name, output.ItemCollectionMetrics)
+	}
 	return output, err
 }
 
@@ -134,6 +204,9 @@ func (d *Delete) deleteInput() *dynamodb.DeleteItemInput {
 	if d.cc != nil {
 		input.ReturnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityIndexes)
 	}
+	if d.icm != nil {
+		input.ReturnItemCollectionMetrics = aws.String(dynamodb.ReturnItemCollectionMetricsSize)
+	}
 	return input
 }
 
@@ -50,3 +50,25 @@ func TestDelete(t *testing.T) {
 		t.Error("invalid ConsumedCapacity", cc)
 	}
 }
+
+func TestDeleteExisted(t *testing.T) {
+	table := NewFromIface(newMemClient()).Table(testTable)
+	if err := table.Put(widget{UserID: 42, Msg: "hello"}).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	existed, err := table.Delete("UserID", 42).Existed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !existed {
+		t.Error("expected item to have existed")
+	}
+	existed, err = table.Delete("UserID", 42).Existed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if existed {
+		t.Error("expected item to be gone")
+	}
+}
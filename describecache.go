@@ -0,0 +1,79 @@
+package dynamo
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// CacheDescriptions returns a copy of this DB that remembers table descriptions for ttl,
+// so that features which need a table's key schema or indexes, such as AllKeys, Hydrate, Partition.DeleteAll,
+// Explain, Backfill, and ValidateModel, don't describe the table on every call.
+// Tables are described lazily, the first time their description is needed.
+// Running Table.Describe always fetches a fresh description, refreshing the cache,
+// and UpdateTable and DeleteTable requests made through the returned DB invalidate it.
+// A ttl of zero keeps descriptions until they are refreshed.
+func (db *DB) CacheDescriptions(ttl time.Duration) *DB {
+	cp := *db
+	cp.descs = &descCache{ttl: ttl}
+	return &cp
+}
+
+// descCache holds table descriptions by table name.
+type descCache struct {
+	ttl    time.Duration
+	mu     sync.Mutex
+	tables map[string]cachedDesc
+}
+
+type cachedDesc struct {
+	desc    Description
+	expires time.Time
+}
+
+func (c *descCache) get(table string, now time.Time) (Description, bool) {
+	if c == nil {
+		return Description{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.tables[table]
+	if !ok || (!cached.expires.IsZero() && !now.Before(cached.expires)) {
+		return Description{}, false
+	}
+	return cached.desc, true
+}
+
+func (c *descCache) set(table string, desc Description, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tables == nil {
+		c.tables = make(map[string]cachedDesc)
+	}
+	cached := cachedDesc{desc: desc}
+	if c.ttl > 0 {
+		cached.expires = now.Add(c.ttl)
+	}
+	c.tables[table] = cached
+}
+
+func (c *descCache) forget(table string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	delete(c.tables, table)
+	c.mu.Unlock()
+}
+
+// description returns this table's description, from the DB's cache if possible. See DB.CacheDescriptions.
+func (table Table) description(ctx aws.Context) (Description, error) {
+	if desc, ok := table.db.descs.get(table.Name(), table.db.now()); ok {
+		return desc, nil
+	}
+	return table.Describe().RunWithContext(ctx)
+}
@@ -0,0 +1,83 @@
+package dynamo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// deletableClient is a keysClient whose table can be deleted.
+type deletableClient struct {
+	*keysClient
+}
+
+func (deletableClient) DeleteTableWithContext(aws.Context, *dynamodb.DeleteTableInput, ...request.Option) (*dynamodb.DeleteTableOutput, error) {
+	return &dynamodb.DeleteTableOutput{}, nil
+}
+
+func TestCacheDescriptions(t *testing.T) {
+	client := deletableClient{&keysClient{
+		describeClient: newDescribeClient(&dynamodb.TableDescription{
+			TableName: aws.String(testTable),
+			KeySchema: keySchema("UserID", "Time"),
+		}),
+	}}
+	now := time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+	db := NewFromIface(client).WithClock(Clock{Now: func() time.Time { return now }}).CacheDescriptions(time.Minute)
+	table := db.Table(testTable)
+
+	for i := 0; i < 3; i++ {
+		if _, err := table.Scan().AllKeys(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if client.describes != 1 {
+		t.Error("expected 1 describe, got", client.describes)
+	}
+
+	// Describe always refreshes
+	if _, err := table.Describe().Run(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Scan().AllKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if client.describes != 2 {
+		t.Error("expected 2 describes, got", client.describes)
+	}
+
+	// descriptions expire
+	now = now.Add(time.Minute)
+	if _, err := table.Scan().AllKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if client.describes != 3 {
+		t.Error("expected 3 describes after expiry, got", client.describes)
+	}
+
+	// deleting the table forgets it
+	if err := table.DeleteTable().Run(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Scan().AllKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if client.describes != 4 {
+		t.Error("expected 4 describes after delete, got", client.describes)
+	}
+
+	// without the cache, every call describes
+	client.describes = 0
+	table = NewFromIface(client).Table(testTable)
+	for i := 0; i < 2; i++ {
+		if _, err := table.Scan().AllKeys(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if client.describes != 2 {
+		t.Error("expected 2 describes without cache, got", client.describes)
+	}
+}
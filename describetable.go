@@ -191,7 +191,8 @@ func newDescription(table *dynamodb.TableDescription) Description {
 // DescribeTable is a request for information about a table and its indexes.
 // See: http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_DescribeTable.html
 type DescribeTable struct {
-	table Table
+	table   Table
+	timeout time.Duration
 }
 
 // Describe begins a new request to describe this table.
@@ -199,27 +200,38 @@ func (table Table) Describe() *DescribeTable {
 	return &DescribeTable{table: table}
 }
 
+// Timeout limits the total amount of time this request may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (dt *DescribeTable) Timeout(timeout time.Duration) *DescribeTable {
+	dt.timeout = timeout
+	return dt
+}
+
 // Run executes this request and describe the table.
 func (dt *DescribeTable) Run() (Description, error) {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(dt.timeout)
 	defer cancel()
 	return dt.RunWithContext(ctx)
 }
 
 func (dt *DescribeTable) RunWithContext(ctx aws.Context) (Description, error) {
+	ctx, cancel := withTimeout(ctx, dt.timeout)
+	defer cancel()
 	input := dt.input()
 
 	var result *dynamodb.DescribeTableOutput
 	err := retry(ctx, func() error {
 		var err error
-		result, err = dt.table.db.client.DescribeTableWithContext(ctx, input)
+		result, err = dt.table.db.client.DescribeTableWithContext(ctx, input, dt.table.db.opts...)
 		return err
 	})
 	if err != nil {
 		return Description{}, err
 	}
 
-	return newDescription(result.Table), nil
+	desc := newDescription(result.Table)
+	dt.table.db.descs.set(dt.table.Name(), desc, dt.table.db.now())
+	return desc, nil
 }
 
 func (dt *DescribeTable) input() *dynamodb.DescribeTableInput {
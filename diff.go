@@ -0,0 +1,202 @@
+package dynamo
+
+import (
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// ChangeType is the kind of difference described by a Change.
+type ChangeType string
+
+const (
+	// ChangeAdded means the attribute was not present in the old item.
+	ChangeAdded ChangeType = "added"
+	// ChangeRemoved means the attribute is not present in the new item.
+	ChangeRemoved ChangeType = "removed"
+	// ChangeModified means the attribute is present in both items with different values.
+	ChangeModified ChangeType = "changed"
+)
+
+// Change is a difference between two items, as returned by Diff.
+type Change struct {
+	// Type is the kind of change.
+	Type ChangeType
+	// Path is the document path of the changed attribute, such as "Meta.color".
+	// Attributes are compared recursively through maps, but lists and sets are compared as a whole.
+	Path string
+	// Old is the previous value, or nil if it was added.
+	Old *dynamodb.AttributeValue
+	// New is the new value, or nil if it was removed.
+	New *dynamodb.AttributeValue
+
+	// path is Path split into attribute names.
+	path []string
+}
+
+// Diff returns the changes needed to turn old into new, sorted by path.
+// old and new can be structs, maps, or items (map[string]*dynamodb.AttributeValue), and are marshaled first.
+// A nil old or new is treated as an empty item.
+// Sets are compared regardless of order, and numbers are compared by value.
+func Diff(old, new interface{}) ([]Change, error) {
+	return codec{}.diff(old, new)
+}
+
+func (c codec) diff(old, new interface{}) ([]Change, error) {
+	oldItem, err := c.diffItem(old)
+	if err != nil {
+		return nil, err
+	}
+	newItem, err := c.diffItem(new)
+	if err != nil {
+		return nil, err
+	}
+	changes := diffMaps(nil, oldItem, newItem, nil)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+func (c codec) diffItem(v interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	switch x := v.(type) {
+	case nil:
+		return nil, nil
+	case map[string]*dynamodb.AttributeValue:
+		return x, nil
+	}
+	return c.marshalItem(v)
+}
+
+func diffMaps(path []string, old, new map[string]*dynamodb.AttributeValue, changes []Change) []Change {
+	for name, ov := range old {
+		p := appendPath(path, name)
+		nv, ok := new[name]
+		switch {
+		case !ok:
+			changes = append(changes, newChange(ChangeRemoved, p, ov, nil))
+		case ov.M != nil && nv.M != nil:
+			changes = diffMaps(p, ov.M, nv.M, changes)
+		case !avEqual(ov, nv):
+			changes = append(changes, newChange(ChangeModified, p, ov, nv))
+		}
+	}
+	for name, nv := range new {
+		if _, ok := old[name]; !ok {
+			changes = append(changes, newChange(ChangeAdded, appendPath(path, name), nil, nv))
+		}
+	}
+	return changes
+}
+
+func appendPath(path []string, name string) []string {
+	p := make([]string, len(path)+1)
+	copy(p, path)
+	p[len(path)] = name
+	return p
+}
+
+func newChange(typ ChangeType, path []string, old, new *dynamodb.AttributeValue) Change {
+	return Change{
+		Type: typ,
+		Path: strings.Join(path, "."),
+		Old:  old,
+		New:  new,
+		path: path,
+	}
+}
+
+// avEqual returns true if a and b represent the same value.
+func avEqual(a, b *dynamodb.AttributeValue) bool {
+	switch {
+	case a == nil || b == nil:
+		return a == b
+	case a.S != nil:
+		return b.S != nil && *a.S == *b.S
+	case a.N != nil:
+		return b.N != nil && numEqual(*a.N, *b.N)
+	case a.B != nil:
+		return b.B != nil && string(a.B) == string(b.B)
+	case a.BOOL != nil:
+		return b.BOOL != nil && *a.BOOL == *b.BOOL
+	case a.NULL != nil:
+		return b.NULL != nil && *a.NULL == *b.NULL
+	case a.L != nil:
+		if b.L == nil || len(a.L) != len(b.L) {
+			return false
+		}
+		for i := range a.L {
+			if !avEqual(a.L[i], b.L[i]) {
+				return false
+			}
+		}
+		return true
+	case a.M != nil:
+		if b.M == nil || len(a.M) != len(b.M) {
+			return false
+		}
+		for k, av := range a.M {
+			if !avEqual(av, b.M[k]) {
+				return false
+			}
+		}
+		return true
+	case a.SS != nil:
+		return b.SS != nil && setEqual(a.SS, b.SS, func(x, y string) bool { return x == y })
+	case a.NS != nil:
+		return b.NS != nil && setEqual(a.NS, b.NS, numEqual)
+	case a.BS != nil:
+		if b.BS == nil || len(a.BS) != len(b.BS) {
+			return false
+		}
+		seen := make(map[string]int, len(a.BS))
+		for _, x := range a.BS {
+			seen[string(x)]++
+		}
+		for _, y := range b.BS {
+			if seen[string(y)] == 0 {
+				return false
+			}
+			seen[string(y)]--
+		}
+		return true
+	}
+	// empty AVs
+	return reflect.DeepEqual(a, b)
+}
+
+func numEqual(a, b string) bool {
+	if a == b {
+		return true
+	}
+	x, ok := new(big.Float).SetString(a)
+	if !ok {
+		return false
+	}
+	y, ok := new(big.Float).SetString(b)
+	if !ok {
+		return false
+	}
+	return x.Cmp(y) == 0
+}
+
+func setEqual(a, b []*string, eq func(x, y string) bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	used := make([]bool, len(b))
+outer:
+	for _, x := range a {
+		for i, y := range b {
+			if !used[i] && x != nil && y != nil && eq(*x, *y) {
+				used[i] = true
+				continue outer
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestDiff(t *testing.T) {
+	type item struct {
+		ID    int
+		Name  string
+		Count int      `dynamo:",omitempty"`
+		Tags  []string `dynamo:",set"`
+		Meta  map[string]string
+		Extra string `dynamo:",omitempty"`
+	}
+	old := item{
+		ID:    1,
+		Name:  "old",
+		Count: 5,
+		Tags:  []string{"a", "b"},
+		Meta:  map[string]string{"color": "red", "size": "L"},
+	}
+	new := item{
+		ID:    1,
+		Name:  "new",
+		Tags:  []string{"b", "a"},
+		Meta:  map[string]string{"color": "blue", "size": "L"},
+		Extra: "hello",
+	}
+
+	changes, err := Diff(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, string(c.Type)+" "+c.Path)
+	}
+	expected := []string{
+		"removed Count",
+		"added Extra",
+		"changed Meta.color",
+		"changed Name",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Error("bad changes:", got, "≠", expected)
+	}
+	if *changes[2].Old.S != "red" || *changes[2].New.S != "blue" {
+		t.Error("bad values:", changes[2])
+	}
+
+	// numbers are compared by value; nil is an empty item
+	a := map[string]*dynamodb.AttributeValue{"N": {N: aws.String("1.0")}}
+	b := map[string]*dynamodb.AttributeValue{"N": {N: aws.String("1")}}
+	if changes, err := Diff(a, b); err != nil || len(changes) != 0 {
+		t.Error("expected no changes, got", changes, err)
+	}
+	if changes, err := Diff(nil, b); err != nil || len(changes) != 1 || changes[0].Type != ChangeAdded {
+		t.Error("expected one addition, got", changes, err)
+	}
+}
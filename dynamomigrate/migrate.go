@@ -0,0 +1,299 @@
+// Package dynamomigrate runs versioned schema and data migrations for DynamoDB tables.
+//
+// Migrations are applied in version order and recorded in a state table,
+// so each one runs once. A lock item in the state table keeps concurrent
+// deployments from running migrations at the same time.
+//
+//	m := dynamomigrate.New(db, "Migrations",
+//		dynamomigrate.Migration{
+//			Version: 1,
+//			Name:    "create users table",
+//			Up:      dynamomigrate.CreateTable("Users", User{}),
+//			Down:    dynamomigrate.DeleteTable("Users"),
+//		},
+//	)
+//	err := m.Up()
+package dynamomigrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/guregu/dynamo"
+	"golang.org/x/net/context"
+)
+
+// ErrLocked is returned when another migrator holds the lock.
+var ErrLocked = errors.New("dynamomigrate: migrations are locked by another process")
+
+// Step is an action taken by a migration.
+type Step func(ctx aws.Context, db *dynamo.DB) error
+
+// Migration is a versioned change.
+type Migration struct {
+	// Version orders migrations. It must be positive and unique.
+	Version int
+	// Name describes this migration.
+	Name string
+	// Up applies this migration.
+	Up Step
+	// Down reverts this migration. If nil, the migration can't be reverted.
+	Down Step
+}
+
+// Record is an applied migration, as stored in the state table.
+type Record struct {
+	Version int
+	Name    string
+	Applied time.Time
+}
+
+// item is the state table's item, used for records and the lock.
+type item struct {
+	ID      string    `dynamo:",hash"`
+	Version int       `dynamo:",omitempty"`
+	Name    string    `dynamo:",omitempty"`
+	Applied time.Time `dynamo:",omitempty"`
+	Owner   string    `dynamo:",omitempty"`
+	Expires int64     `dynamo:",omitempty"`
+}
+
+const (
+	lockID       = "lock"
+	recordPrefix = "migration#"
+)
+
+func recordID(version int) string {
+	return fmt.Sprintf("%s%010d", recordPrefix, version)
+}
+
+// Migrator applies and reverts migrations.
+type Migrator struct {
+	db         *dynamo.DB
+	state      dynamo.Table
+	migrations []Migration
+	owner      string
+	lockTTL    time.Duration
+	err        error
+}
+
+// New returns a Migrator for the given migrations, recording its state in the table stateTable.
+// Use Init to create the state table.
+func New(db *dynamo.DB, stateTable string, migrations ...Migration) *Migrator {
+	m := &Migrator{
+		db:         db,
+		state:      db.Table(stateTable),
+		migrations: append([]Migration(nil), migrations...),
+		lockTTL:    15 * time.Minute,
+	}
+	host, _ := os.Hostname()
+	m.owner = host + ":" + strconv.Itoa(os.Getpid()) + ":" + strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+	for i, mig := range m.migrations {
+		switch {
+		case mig.Version <= 0:
+			m.setError(fmt.Errorf("dynamomigrate: migration %q has invalid version %d", mig.Name, mig.Version))
+		case i > 0 && m.migrations[i-1].Version == mig.Version:
+			m.setError(fmt.Errorf("dynamomigrate: duplicate migration version %d", mig.Version))
+		case mig.Up == nil:
+			m.setError(fmt.Errorf("dynamomigrate: migration %d has no Up step", mig.Version))
+		}
+	}
+	return m
+}
+
+// LockTTL sets how long the lock is held without being renewed, after which another process may take it over.
+// The lock is renewed before each migration, so this should be longer than the slowest migration.
+// The default is 15 minutes.
+func (m *Migrator) LockTTL(ttl time.Duration) *Migrator {
+	m.lockTTL = ttl
+	return m
+}
+
+// Init creates the state table if it doesn't exist, using on-demand billing.
+func (m *Migrator) Init() error {
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return m.InitWithContext(ctx)
+}
+
+// InitWithContext creates the state table if it doesn't exist, using on-demand billing.
+func (m *Migrator) InitWithContext(ctx aws.Context) error {
+	if _, err := m.state.Describe().RunWithContext(ctx); err == nil {
+		return nil
+	} else if !isCode(err, dynamodb.ErrCodeResourceNotFoundException) {
+		return err
+	}
+	return CreateTable(m.state.Name(), item{}, func(ct *dynamo.CreateTable) {
+		ct.OnDemand(true)
+	})(ctx, m.db)
+}
+
+// Applied returns the applied migrations, in version order.
+func (m *Migrator) Applied() ([]Record, error) {
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return m.AppliedWithContext(ctx)
+}
+
+// AppliedWithContext returns the applied migrations, in version order.
+func (m *Migrator) AppliedWithContext(ctx aws.Context) ([]Record, error) {
+	var items []item
+	if err := m.state.Scan().Consistent(true).AllWithContext(ctx, &items); err != nil {
+		return nil, err
+	}
+	var records []Record
+	for _, it := range items {
+		if strings.HasPrefix(it.ID, recordPrefix) {
+			records = append(records, Record{Version: it.Version, Name: it.Name, Applied: it.Applied})
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Version < records[j].Version
+	})
+	return records, nil
+}
+
+// Up applies all pending migrations in version order.
+// It stops at the first migration that fails.
+func (m *Migrator) Up() error {
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return m.UpWithContext(ctx)
+}
+
+// UpWithContext applies all pending migrations in version order.
+// It stops at the first migration that fails.
+func (m *Migrator) UpWithContext(ctx aws.Context) error {
+	return m.locked(ctx, func(applied map[int]bool) error {
+		for _, mig := range m.migrations {
+			if applied[mig.Version] {
+				continue
+			}
+			if err := m.lock(ctx, true); err != nil {
+				return err
+			}
+			if err := mig.Up(ctx, m.db); err != nil {
+				return fmt.Errorf("dynamomigrate: migration %d (%s) failed: %v", mig.Version, mig.Name, err)
+			}
+			rec := item{ID: recordID(mig.Version), Version: mig.Version, Name: mig.Name, Applied: time.Now().UTC()}
+			if err := m.state.Put(rec).RunWithContext(ctx); err != nil {
+				return fmt.Errorf("dynamomigrate: recording migration %d: %v", mig.Version, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Down reverts the applied migrations with versions greater than version, newest first.
+// Use 0 to revert every migration.
+func (m *Migrator) Down(version int) error {
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return m.DownWithContext(ctx, version)
+}
+
+// DownWithContext reverts the applied migrations with versions greater than version, newest first.
+// Use 0 to revert every migration.
+func (m *Migrator) DownWithContext(ctx aws.Context, version int) error {
+	return m.locked(ctx, func(applied map[int]bool) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if mig.Version <= version || !applied[mig.Version] {
+				continue
+			}
+			if mig.Down == nil {
+				return fmt.Errorf("dynamomigrate: migration %d (%s) can't be reverted", mig.Version, mig.Name)
+			}
+			if err := m.lock(ctx, true); err != nil {
+				return err
+			}
+			if err := mig.Down(ctx, m.db); err != nil {
+				return fmt.Errorf("dynamomigrate: reverting migration %d (%s) failed: %v", mig.Version, mig.Name, err)
+			}
+			if err := m.state.Delete("ID", recordID(mig.Version)).RunWithContext(ctx); err != nil {
+				return fmt.Errorf("dynamomigrate: removing record of migration %d: %v", mig.Version, err)
+			}
+		}
+		return nil
+	})
+}
+
+// locked runs fn while holding the lock, passing it the set of applied versions.
+func (m *Migrator) locked(ctx aws.Context, fn func(applied map[int]bool) error) (err error) {
+	if m.err != nil {
+		return m.err
+	}
+	if err := m.lock(ctx, false); err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := m.unlock(ctx); err == nil {
+			err = unlockErr
+		}
+	}()
+
+	records, err := m.AppliedWithContext(ctx)
+	if err != nil {
+		return err
+	}
+	applied := make(map[int]bool, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = true
+	}
+	return fn(applied)
+}
+
+// lock takes the lock, or renews it if we already hold it.
+func (m *Migrator) lock(ctx aws.Context, renew bool) error {
+	now := time.Now()
+	lock := item{ID: lockID, Owner: m.owner, Expires: now.Add(m.lockTTL).Unix()}
+	put := m.state.Put(lock)
+	if renew {
+		put.If("'Owner' = ?", m.owner)
+	} else {
+		put.If("attribute_not_exists('ID') OR 'Expires' < ?", now.Unix())
+	}
+	err := put.RunWithContext(ctx)
+	if isCode(err, dynamodb.ErrCodeConditionalCheckFailedException) {
+		return ErrLocked
+	}
+	return err
+}
+
+func (m *Migrator) unlock(ctx aws.Context) error {
+	err := m.state.Delete("ID", lockID).If("'Owner' = ?", m.owner).RunWithContext(ctx)
+	if isCode(err, dynamodb.ErrCodeConditionalCheckFailedException) {
+		// someone took over our expired lock
+		return ErrLocked
+	}
+	return err
+}
+
+func (m *Migrator) setError(err error) {
+	if m.err == nil {
+		m.err = err
+	}
+}
+
+func isCode(err error, code string) bool {
+	ae, ok := err.(awserr.Error)
+	return ok && ae.Code() == code
+}
+
+// defaultContext returns a context for methods that don't take one.
+// Migrations can be slow, so it isn't bounded by dynamo.RetryTimeout.
+func defaultContext() (aws.Context, context.CancelFunc) {
+	return context.WithCancel(aws.BackgroundContext())
+}
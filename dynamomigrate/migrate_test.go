@@ -0,0 +1,188 @@
+package dynamomigrate
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/guregu/dynamo"
+)
+
+// stateClient is a fake client holding a state table in memory.
+// It understands the lock conditions used by Migrator.
+type stateClient struct {
+	dynamodbiface.DynamoDBAPI
+	items map[string]map[string]*dynamodb.AttributeValue
+}
+
+var errCondition = awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "condition failed", nil)
+
+func (c *stateClient) check(id string, cond *string, values map[string]*dynamodb.AttributeValue) error {
+	if cond == nil {
+		return nil
+	}
+	var arg *dynamodb.AttributeValue
+	for _, v := range values {
+		arg = v
+	}
+	lock := c.items[id]
+	if strings.Contains(*cond, "attribute_not_exists") {
+		if lock == nil {
+			return nil
+		}
+		expires, _ := strconv.ParseInt(*lock["Expires"].N, 10, 64)
+		now, _ := strconv.ParseInt(*arg.N, 10, 64)
+		if expires < now {
+			return nil
+		}
+		return errCondition
+	}
+	if lock == nil || *lock["Owner"].S != *arg.S {
+		return errCondition
+	}
+	return nil
+}
+
+func (c *stateClient) PutItemWithContext(_ aws.Context, input *dynamodb.PutItemInput, _ ...request.Option) (*dynamodb.PutItemOutput, error) {
+	id := *input.Item["ID"].S
+	if err := c.check(id, input.ConditionExpression, input.ExpressionAttributeValues); err != nil {
+		return nil, err
+	}
+	c.items[id] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *stateClient) DeleteItemWithContext(_ aws.Context, input *dynamodb.DeleteItemInput, _ ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	id := *input.Key["ID"].S
+	if err := c.check(id, input.ConditionExpression, input.ExpressionAttributeValues); err != nil {
+		return nil, err
+	}
+	delete(c.items, id)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (c *stateClient) ScanWithContext(_ aws.Context, input *dynamodb.ScanInput, _ ...request.Option) (*dynamodb.ScanOutput, error) {
+	out := &dynamodb.ScanOutput{}
+	for _, item := range c.items {
+		out.Items = append(out.Items, item)
+	}
+	return out, nil
+}
+
+func TestMigrator(t *testing.T) {
+	client := &stateClient{items: make(map[string]map[string]*dynamodb.AttributeValue)}
+	db := dynamo.NewFromIface(client)
+
+	var ran []string
+	step := func(name string) Step {
+		return func(aws.Context, *dynamo.DB) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+	migrations := []Migration{
+		{Version: 2, Name: "second", Up: step("up 2"), Down: step("down 2")},
+		{Version: 1, Name: "first", Up: step("up 1"), Down: step("down 1")},
+	}
+
+	m := New(db, "Migrations", migrations...)
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"up 1", "up 2"}; !reflect.DeepEqual(ran, expected) {
+		t.Error("bad up order:", ran, "≠", expected)
+	}
+	if _, locked := client.items[lockID]; locked {
+		t.Error("lock not released")
+	}
+
+	records, err := m.Applied()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Name != "first" || records[1].Version != 2 {
+		t.Error("bad records:", records)
+	}
+
+	// pending migrations only
+	ran = nil
+	more := append(migrations, Migration{Version: 3, Name: "third", Up: step("up 3")})
+	if err := New(db, "Migrations", more...).Up(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"up 3"}; !reflect.DeepEqual(ran, expected) {
+		t.Error("bad pending migrations:", ran, "≠", expected)
+	}
+
+	// irreversible
+	ran = nil
+	if err := New(db, "Migrations", more...).Down(0); err == nil {
+		t.Error("expected error reverting migration without Down")
+	}
+	if len(ran) != 0 {
+		t.Error("unexpected steps:", ran)
+	}
+
+	ran = nil
+	delete(client.items, recordID(3))
+	if err := m.Down(0); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"down 2", "down 1"}; !reflect.DeepEqual(ran, expected) {
+		t.Error("bad down order:", ran, "≠", expected)
+	}
+	if len(client.items) != 0 {
+		t.Error("leftover items:", client.items)
+	}
+}
+
+func TestMigratorLock(t *testing.T) {
+	client := &stateClient{items: make(map[string]map[string]*dynamodb.AttributeValue)}
+	db := dynamo.NewFromIface(client)
+	mig := Migration{Version: 1, Name: "noop", Up: func(aws.Context, *dynamo.DB) error { return nil }}
+
+	other := item{ID: lockID, Owner: "someone else", Expires: time.Now().Add(time.Hour).Unix()}
+	if err := db.Table("Migrations").Put(other).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := New(db, "Migrations", mig).Up(); err != ErrLocked {
+		t.Error("expected ErrLocked, got:", err)
+	}
+
+	// expired lock can be taken over
+	other.Expires = time.Now().Add(-time.Minute).Unix()
+	if err := db.Table("Migrations").Put(other).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := New(db, "Migrations", mig).Up(); err != nil {
+		t.Error("unexpected error:", err)
+	}
+
+	// failing migrations release the lock
+	fail := Migration{Version: 2, Name: "fail", Up: func(aws.Context, *dynamo.DB) error { return errors.New("oops") }}
+	if err := New(db, "Migrations", mig, fail).Up(); err == nil {
+		t.Error("expected error")
+	}
+	if _, locked := client.items[lockID]; locked {
+		t.Error("lock not released")
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	noop := func(aws.Context, *dynamo.DB) error { return nil }
+	db := dynamo.NewFromIface(&stateClient{})
+	if err := New(db, "Migrations", Migration{Version: 1, Up: noop}, Migration{Version: 1, Up: noop}).Up(); err == nil {
+		t.Error("expected duplicate version error")
+	}
+	if err := New(db, "Migrations", Migration{Version: 1}).Up(); err == nil {
+		t.Error("expected missing Up error")
+	}
+}
@@ -0,0 +1,120 @@
+package dynamomigrate
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/guregu/dynamo"
+)
+
+// Steps returns a step that runs the given steps in order, stopping at the first error.
+func Steps(steps ...Step) Step {
+	return func(ctx aws.Context, db *dynamo.DB) error {
+		for _, step := range steps {
+			if err := step(ctx, db); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// CreateTable returns a step that creates a table from model, as in dynamo.DB.CreateTable,
+// and waits for it to become active.
+// The configure functions can adjust the request, for example to set its throughput.
+func CreateTable(name string, model interface{}, configure ...func(*dynamo.CreateTable)) Step {
+	return func(ctx aws.Context, db *dynamo.DB) error {
+		ct := db.CreateTable(name, model)
+		for _, fn := range configure {
+			fn(ct)
+		}
+		if err := ct.RunWithContext(ctx); err != nil {
+			return err
+		}
+		return db.Table(name).WaitForTable().MaxInterval(10 * time.Second).RunWithContext(ctx)
+	}
+}
+
+// DeleteTable returns a step that deletes a table.
+func DeleteTable(name string) Step {
+	return func(ctx aws.Context, db *dynamo.DB) error {
+		return db.Table(name).DeleteTable().RunWithContext(ctx)
+	}
+}
+
+// AddIndex returns a step that adds a global secondary index to a table
+// and waits for it to finish backfilling.
+func AddIndex(table string, index dynamo.Index) Step {
+	return func(ctx aws.Context, db *dynamo.DB) error {
+		if _, err := db.Table(table).UpdateTable().CreateIndex(index).RunWithContext(ctx); err != nil {
+			return err
+		}
+		return db.Table(table).WaitForIndex(index.Name).RunWithContext(ctx)
+	}
+}
+
+// DeleteIndex returns a step that deletes a global secondary index from a table.
+func DeleteIndex(table, name string) Step {
+	return func(ctx aws.Context, db *dynamo.DB) error {
+		_, err := db.Table(table).UpdateTable().DeleteIndex(name).RunWithContext(ctx)
+		return err
+	}
+}
+
+// Transform changes an item during a backfill.
+// It returns the new item to write, or nil to leave the item unchanged.
+type Transform func(item map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, error)
+
+// Backfill returns a step that rewrites every item of a table with fn,
+// using a parallel scan with the given number of segments and batch writes.
+func Backfill(table string, segments int, fn Transform) Step {
+	if segments < 1 {
+		segments = 1
+	}
+	return func(ctx aws.Context, db *dynamo.DB) error {
+		tbl := db.Table(table)
+		errs := make([]error, segments)
+		var wg sync.WaitGroup
+		for seg := 0; seg < segments; seg++ {
+			wg.Add(1)
+			go func(seg int) {
+				defer wg.Done()
+				errs[seg] = backfillSegment(ctx, tbl, int64(seg), int64(segments), fn)
+			}(seg)
+		}
+		wg.Wait()
+		for seg, err := range errs {
+			if err != nil {
+				return fmt.Errorf("backfill of %s, segment %d: %v", table, seg, err)
+			}
+		}
+		return nil
+	}
+}
+
+func backfillSegment(ctx aws.Context, table dynamo.Table, segment, total int64, fn Transform) error {
+	iter := table.Scan().Segment(segment, total).Consistent(true).Iter().(dynamo.PageIter)
+	var page []map[string]*dynamodb.AttributeValue
+	for iter.NextPageWithContext(ctx, &page) {
+		var writes []interface{}
+		for _, item := range page {
+			changed, err := fn(item)
+			if err != nil {
+				return err
+			}
+			if changed != nil {
+				writes = append(writes, changed)
+			}
+		}
+		if len(writes) == 0 {
+			continue
+		}
+		if _, err := table.Batch().Write().Put(writes...).RunWithContext(ctx); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
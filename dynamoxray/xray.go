@@ -0,0 +1,179 @@
+// Package dynamoxray instruments dynamo with AWS X-Ray, recording a subsegment for each DynamoDB request.
+//
+// To avoid depending on the X-Ray SDK, it takes a function that begins subsegments.
+// With github.com/aws/aws-xray-sdk-go/xray:
+//
+//	db := dynamoxray.New(sess, func(ctx aws.Context, name string) (aws.Context, dynamoxray.Segment) {
+//		return xray.BeginSubsegment(ctx, name)
+//	})
+//
+// Requests must be made with a context that carries a segment, such as one from xray.Handler,
+// by using the WithContext variants of dynamo's methods.
+package dynamoxray
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/guregu/dynamo"
+)
+
+// Segment is the part of an X-Ray subsegment used for instrumentation.
+// *xray.Segment satisfies it.
+type Segment interface {
+	AddAnnotation(key string, value interface{}) error
+	AddMetadata(key string, value interface{}) error
+	Close(err error)
+}
+
+// BeginFunc begins a subsegment with the given name as a child of the segment in ctx,
+// returning a context that carries it.
+type BeginFunc func(ctx aws.Context, name string) (aws.Context, Segment)
+
+// Annotation keys set on each subsegment.
+const (
+	AnnotationTable            = "table"
+	AnnotationOperation        = "operation"
+	AnnotationRetries          = "retries"
+	AnnotationConsumedCapacity = "consumed_capacity"
+)
+
+// New creates a new DB whose requests are recorded as X-Ray subsegments, as in dynamo.New.
+// Consumed capacity is only annotated for requests that ask for it, such as those using ConsumedCapacity.
+// Copies made with DB.WithRole use new clients, which aren't instrumented.
+func New(p client.ConfigProvider, begin BeginFunc, cfgs ...*aws.Config) *dynamo.DB {
+	db := dynamo.New(p, cfgs...)
+	Instrument(db.Client().(*dynamodb.DynamoDB), begin)
+	return db
+}
+
+// Instrument adds handlers to c that record its requests as X-Ray subsegments.
+func Instrument(c *dynamodb.DynamoDB, begin BeginFunc) {
+	c.Handlers.Build.PushFrontNamed(request.NamedHandler{
+		Name: "dynamoxray.Begin",
+		Fn: func(r *request.Request) {
+			ctx, seg := begin(r.Context(), "dynamodb")
+			if seg == nil {
+				return
+			}
+			r.SetContext(ctx)
+			r.Handlers.Complete.PushBack(func(r *request.Request) {
+				annotate(seg, r)
+				seg.Close(r.Error)
+			})
+		},
+	})
+}
+
+func annotate(seg Segment, r *request.Request) {
+	if r.Operation != nil {
+		seg.AddAnnotation(AnnotationOperation, r.Operation.Name)
+	}
+	if tables := tableNames(r.Params); len(tables) > 0 {
+		seg.AddAnnotation(AnnotationTable, strings.Join(tables, ","))
+	}
+	seg.AddAnnotation(AnnotationRetries, r.RetryCount)
+	if cc, ok := consumedCapacity(r.Data); ok {
+		seg.AddAnnotation(AnnotationConsumedCapacity, cc)
+	}
+	if r.RequestID != "" {
+		seg.AddMetadata("request_id", r.RequestID)
+	}
+}
+
+// tableNames returns the names of the tables in a request's input, sorted.
+func tableNames(params interface{}) []string {
+	switch x := params.(type) {
+	case *dynamodb.BatchGetItemInput:
+		return mapKeys(reflect.ValueOf(x.RequestItems))
+	case *dynamodb.BatchWriteItemInput:
+		return mapKeys(reflect.ValueOf(x.RequestItems))
+	case *dynamodb.TransactGetItemsInput:
+		var names []string
+		for _, item := range x.TransactItems {
+			if item.Get != nil {
+				names = append(names, aws.StringValue(item.Get.TableName))
+			}
+		}
+		return dedupe(names)
+	case *dynamodb.TransactWriteItemsInput:
+		var names []string
+		for _, item := range x.TransactItems {
+			switch {
+			case item.Put != nil:
+				names = append(names, aws.StringValue(item.Put.TableName))
+			case item.Update != nil:
+				names = append(names, aws.StringValue(item.Update.TableName))
+			case item.Delete != nil:
+				names = append(names, aws.StringValue(item.Delete.TableName))
+			case item.ConditionCheck != nil:
+				names = append(names, aws.StringValue(item.ConditionCheck.TableName))
+			}
+		}
+		return dedupe(names)
+	}
+	rv := reflect.ValueOf(params)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	field := rv.Elem().FieldByName("TableName")
+	if !field.IsValid() {
+		return nil
+	}
+	if name, ok := field.Interface().(*string); ok && name != nil {
+		return []string{*name}
+	}
+	return nil
+}
+
+// consumedCapacity returns the total capacity units consumed according to a request's output.
+func consumedCapacity(data interface{}) (float64, bool) {
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return 0, false
+	}
+	field := rv.Elem().FieldByName("ConsumedCapacity")
+	if !field.IsValid() {
+		return 0, false
+	}
+	switch cc := field.Interface().(type) {
+	case *dynamodb.ConsumedCapacity:
+		if cc != nil {
+			return aws.Float64Value(cc.CapacityUnits), true
+		}
+	case []*dynamodb.ConsumedCapacity:
+		if len(cc) > 0 {
+			var total float64
+			for _, c := range cc {
+				total += aws.Float64Value(c.CapacityUnits)
+			}
+			return total, true
+		}
+	}
+	return 0, false
+}
+
+func mapKeys(rv reflect.Value) []string {
+	keys := make([]string, 0, rv.Len())
+	for _, k := range rv.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func dedupe(names []string) []string {
+	sort.Strings(names)
+	out := names[:0]
+	for i, name := range names {
+		if i == 0 || names[i-1] != name {
+			out = append(out, name)
+		}
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package dynamoxray
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/guregu/dynamo"
+)
+
+type fakeSegment struct {
+	annotations map[string]interface{}
+	closed      bool
+	err         error
+}
+
+func (s *fakeSegment) AddAnnotation(key string, value interface{}) error {
+	s.annotations[key] = value
+	return nil
+}
+
+func (s *fakeSegment) AddMetadata(string, interface{}) error { return nil }
+
+func (s *fakeSegment) Close(err error) {
+	s.closed = true
+	s.err = err
+}
+
+func TestInstrument(t *testing.T) {
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+	var segs []*fakeSegment
+	db := New(sess, func(ctx aws.Context, name string) (aws.Context, Segment) {
+		seg := &fakeSegment{annotations: make(map[string]interface{})}
+		segs = append(segs, seg)
+		return ctx, seg
+	})
+
+	// answer every request locally
+	client := db.Client().(*dynamodb.DynamoDB)
+	client.Handlers.Send.Clear()
+	client.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"X-Amzn-Requestid": []string{"req-1"}},
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"ConsumedCapacity":{"TableName":"Widgets","CapacityUnits":1.5}}`))),
+		}
+	})
+
+	var cc dynamo.ConsumedCapacity
+	if err := db.Table("Widgets").Put(map[string]interface{}{"ID": 1}).ConsumedCapacity(&cc).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) != 1 || !segs[0].closed {
+		t.Fatal("subsegment not recorded:", segs)
+	}
+	expected := map[string]interface{}{
+		AnnotationOperation:        "PutItem",
+		AnnotationTable:            "Widgets",
+		AnnotationRetries:          0,
+		AnnotationConsumedCapacity: 1.5,
+	}
+	if !reflect.DeepEqual(segs[0].annotations, expected) {
+		t.Error("bad annotations:", segs[0].annotations, "≠", expected)
+	}
+}
+
+func TestTableNames(t *testing.T) {
+	input := &dynamodb.TransactWriteItemsInput{TransactItems: []*dynamodb.TransactWriteItem{
+		{Put: &dynamodb.Put{TableName: aws.String("B")}},
+		{Delete: &dynamodb.Delete{TableName: aws.String("A")}},
+		{Update: &dynamodb.Update{TableName: aws.String("B")}},
+	}}
+	if got, expected := tableNames(input), []string{"A", "B"}; !reflect.DeepEqual(got, expected) {
+		t.Error("bad table names:", got, "≠", expected)
+	}
+	if got := tableNames(&dynamodb.ListTablesInput{}); got != nil {
+		t.Error("unexpected table names:", got)
+	}
+}
@@ -3,6 +3,7 @@ package dynamo
 import (
 	"bytes"
 	"encoding"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -21,26 +22,37 @@ type Marshaler interface {
 }
 
 // MarshalItem converts the given struct into a DynamoDB item.
+// The fields of embedded structs are flattened into the item, unless tagged with the nested option,
+// while other struct fields are stored as maps, unless tagged with flatten or prefix=Name_,
+// which flatten them with their attribute names prefixed by Name_.
+// Empty values, such as nil pointers and empty strings, are omitted, as are zero values of fields tagged omitempty;
+// fields tagged null are written as NULL instead.
+// json.RawMessage fields are stored as binary, or as a string or the equivalent document
+// if tagged with jsonString or jsonDocument.
 func MarshalItem(v interface{}) (map[string]*dynamodb.AttributeValue, error) {
 	return marshalItem(v)
 }
 
 func marshalItem(v interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	return codec{}.marshalItem(v)
+}
+
+func (c codec) marshalItem(v interface{}) (map[string]*dynamodb.AttributeValue, error) {
 	rv := reflect.ValueOf(v)
 	switch rv.Type().Kind() {
 	case reflect.Ptr:
-		return marshalItem(rv.Elem().Interface())
+		return c.marshalItem(rv.Elem().Interface())
 	case reflect.Struct:
-		return marshalStruct(rv)
+		return c.marshalStruct(rv)
 	case reflect.Map:
-		return marshalMap(rv.Interface())
+		return c.marshalMap(rv.Interface())
 	}
 	return nil, fmt.Errorf("dynamo: marshal item: unsupported type %T: %v", rv.Interface(), rv.Interface())
 }
 
-func marshalMap(v interface{}) (map[string]*dynamodb.AttributeValue, error) {
+func (c codec) marshalMap(v interface{}) (map[string]*dynamodb.AttributeValue, error) {
 	// TODO: maybe unify this with the map stuff in marshal
-	av, err := marshal(v, "")
+	av, err := c.marshal(v, "")
 	if err != nil {
 		return nil, err
 	}
@@ -50,7 +62,7 @@ func marshalMap(v interface{}) (map[string]*dynamodb.AttributeValue, error) {
 	return av.M, nil
 }
 
-func marshalStruct(rv reflect.Value) (map[string]*dynamodb.AttributeValue, error) {
+func (c codec) marshalStruct(rv reflect.Value) (map[string]*dynamodb.AttributeValue, error) {
 	item := make(map[string]*dynamodb.AttributeValue)
 	var err error
 
@@ -58,43 +70,49 @@ func marshalStruct(rv reflect.Value) (map[string]*dynamodb.AttributeValue, error
 		field := rv.Type().Field(i)
 		fv := rv.Field(i)
 
-		name, special, omitempty := fieldInfo(field)
-		anonStruct := fv.Type().Kind() == reflect.Struct && field.Anonymous
+		name, special, omitempty := c.fieldInfo(field)
+		flatten, prefix := flattening(field)
+		null := nullable(field)
 		switch {
 		case !fv.CanInterface():
-			if !anonStruct {
+			if !flatten {
 				continue
 			}
 		case name == "-":
 			continue
 		case omitempty:
 			if isZero(fv) {
+				if null {
+					item[name] = nullAV()
+				}
 				continue
 			}
 		}
 
-		// embed anonymous structs
-		if anonStruct {
-			avs, err := marshalStruct(fv)
+		// embed anonymous and flattened structs
+		if flatten {
+			avs, err := c.marshalStruct(fv)
 			if err != nil {
 				return nil, err
 			}
 			for k, v := range avs {
 				// don't clobber pre-existing fields
-				if _, exists := item[k]; exists {
+				if _, exists := item[prefix+k]; exists {
 					continue
 				}
-				item[k] = v
+				item[prefix+k] = v
 			}
 			continue
 		}
 
-		av, err := marshal(fv.Interface(), special)
+		av, err := c.marshal(fv.Interface(), special)
 		if err != nil {
 			return nil, err
 		}
 		if av != nil {
 			item[name] = av
+		} else if null {
+			item[name] = nullAV()
 		}
 	}
 	return item, err
@@ -106,12 +124,35 @@ func Marshal(v interface{}) (*dynamodb.AttributeValue, error) {
 }
 
 func marshal(v interface{}, special string) (*dynamodb.AttributeValue, error) {
+	return codec{}.marshal(v, special)
+}
+
+func (c codec) marshal(v interface{}, special string) (*dynamodb.AttributeValue, error) {
+	if av, ok, err := c.encodeRegistered(v); ok {
+		return av, err
+	}
+	if blob, ok := c.blobs[special]; ok {
+		return marshalBlob(blob, v)
+	}
+
 	// encoders with precedence over interfaces
+	switch x := v.(type) {
+	case json.RawMessage:
+		if av, ok, err := marshalRawJSON(x, special); ok {
+			return av, err
+		}
+	case *json.RawMessage:
+		if x != nil {
+			if av, ok, err := marshalRawJSON(*x, special); ok {
+				return av, err
+			}
+		}
+	}
 	if special == "unixtime" {
 		switch x := v.(type) {
 		case *time.Time:
 			if x != nil {
-				return marshal(*x, special)
+				return c.marshal(*x, special)
 			}
 		case time.Time:
 			if x.IsZero() {
@@ -164,19 +205,19 @@ func marshal(v interface{}, special string) (*dynamodb.AttributeValue, error) {
 	case nil:
 		return nil, nil
 	}
-	return marshalReflect(rv, special)
+	return c.marshalReflect(rv, special)
 }
 
 var nilTm encoding.TextMarshaler
 var tmType = reflect.TypeOf(&nilTm).Elem()
 
-func marshalReflect(rv reflect.Value, special string) (*dynamodb.AttributeValue, error) {
+func (c codec) marshalReflect(rv reflect.Value, special string) (*dynamodb.AttributeValue, error) {
 	switch rv.Kind() {
 	case reflect.Ptr:
 		if rv.IsNil() {
 			return nil, nil
 		}
-		return marshal(rv.Elem().Interface(), special)
+		return c.marshal(rv.Elem().Interface(), special)
 	case reflect.Bool:
 		return &dynamodb.AttributeValue{BOOL: aws.Bool(rv.Bool())}, nil
 	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
@@ -225,7 +266,7 @@ func marshalReflect(rv reflect.Value, special string) (*dynamodb.AttributeValue,
 
 		avs := make(map[string]*dynamodb.AttributeValue)
 		for _, key := range rv.MapKeys() {
-			v, err := marshal(rv.MapIndex(key).Interface(), "")
+			v, err := c.marshal(rv.MapIndex(key).Interface(), "")
 			if err != nil {
 				return nil, err
 			}
@@ -239,7 +280,7 @@ func marshalReflect(rv reflect.Value, special string) (*dynamodb.AttributeValue,
 		}
 		return &dynamodb.AttributeValue{M: avs}, nil
 	case reflect.Struct:
-		avs, err := marshalStruct(rv)
+		avs, err := c.marshalStruct(rv)
 		if err != nil {
 			return nil, err
 		}
@@ -276,7 +317,7 @@ func marshalReflect(rv reflect.Value, special string) (*dynamodb.AttributeValue,
 		avs := make([]*dynamodb.AttributeValue, 0, rv.Len())
 		for i := 0; i < rv.Len(); i++ {
 			innerVal := rv.Index(i)
-			av, err := marshal(innerVal.Interface(), "")
+			av, err := c.marshal(innerVal.Interface(), "")
 			if err != nil {
 				return nil, err
 			}
@@ -420,9 +461,13 @@ func marshalSet(rv reflect.Value) (*dynamodb.AttributeValue, error) {
 var emptyStructType = reflect.TypeOf(struct{}{})
 
 func marshalSlice(values []interface{}) ([]*dynamodb.AttributeValue, error) {
+	return codec{}.marshalSlice(values)
+}
+
+func (c codec) marshalSlice(values []interface{}) ([]*dynamodb.AttributeValue, error) {
 	avs := make([]*dynamodb.AttributeValue, 0, len(values))
 	for _, v := range values {
-		av, err := marshal(v, "")
+		av, err := c.marshal(v, "")
 		if err != nil {
 			return nil, err
 		}
@@ -433,21 +478,23 @@ func marshalSlice(values []interface{}) ([]*dynamodb.AttributeValue, error) {
 	return avs, nil
 }
 
-func fieldInfo(field reflect.StructField) (name, special string, omitempty bool) {
+func (c codec) fieldInfo(field reflect.StructField) (name, special string, omitempty bool) {
 	tags := strings.Split(field.Tag.Get("dynamo"), ",")
 	if len(tags) == 0 {
-		return field.Name, "", false
+		return c.fieldName(field.Name), "", false
 	}
 
 	name = tags[0]
 	if name == "" {
-		name = field.Name
+		name = c.fieldName(field.Name)
 	}
 
 	for _, t := range tags[1:] {
-		if t == "omitempty" {
+		switch {
+		case t == "omitempty":
 			omitempty = true
-		} else {
+		case isStructOption(t), isAutoOption(t), t == "null":
+		default:
 			special = t
 		}
 	}
@@ -0,0 +1,205 @@
+package dynamo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// Plan describes how DynamoDB will execute a Query or Scan, as reported by Explain.
+// It is meant as a lint for expensive access patterns.
+type Plan struct {
+	// Operation is the API call that will be made, "Query" or "Scan".
+	Operation string
+	// Table is the name of the table.
+	Table string
+	// Index is the name of the secondary index that will be read, or blank for the base table.
+	Index string
+	// Filter is the filter expression, with attribute name placeholders replaced by the names they stand for.
+	// Filters are applied after items are read, so they don't reduce the capacity consumed.
+	Filter string
+	// FullPartition is true if a query will read every item with its hash key, because it has no range key condition.
+	FullPartition bool
+	// EstimatedPages is a rough estimate of the number of requests needed, based on the size of the table or index
+	// reported by DescribeTable, which DynamoDB only updates about every six hours.
+	// It is zero if it can't be estimated, such as for queries, whose partition sizes are unknown.
+	EstimatedPages int64
+	// Substitutions maps the placeholders that will be sent instead of attribute names,
+	// such as reserved words, to the names they stand for.
+	Substitutions map[string]string
+	// Warnings describes potentially expensive or invalid parts of the request.
+	Warnings []string
+}
+
+// DynamoDB returns at most 1 MB per page.
+const maxPageSize = 1 << 20
+
+// Explain describes how this query will be executed.
+// It makes a DescribeTable request to check the index and estimate its size.
+func (q *Query) Explain() (*Plan, error) {
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return q.ExplainWithContext(ctx)
+}
+
+// ExplainWithContext describes how this query will be executed.
+// It makes a DescribeTable request to check the index and estimate its size.
+func (q *Query) ExplainWithContext(ctx aws.Context) (*Plan, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	input := q.queryInput()
+	plan := &Plan{
+		Operation:     "Query",
+		Table:         q.table.Name(),
+		Index:         q.index,
+		Filter:        unsubstitute(aws.StringValue(input.FilterExpression), q.nameExpr),
+		FullPartition: q.rangeOp == "",
+		Substitutions: substitutions(q.nameExpr),
+	}
+	if q.searchLimit > 0 {
+		plan.EstimatedPages = 1
+	}
+	if plan.FullPartition && plan.Filter != "" {
+		plan.warn("filter without a range key condition reads the whole partition before filtering")
+	}
+
+	desc, err := q.table.description(ctx)
+	if err != nil {
+		return nil, err
+	}
+	target, hashKey, rangeKey := "table", desc.HashKey, desc.RangeKey
+	if q.index != "" {
+		idx, ok := findIndex(desc, q.index)
+		switch {
+		case !ok:
+			plan.warn("index %s doesn't exist", q.index)
+			return plan, nil
+		case q.consistentRead() && !idx.Local:
+			plan.warn("consistent reads aren't supported on global secondary index %s", q.index)
+		}
+		target, hashKey, rangeKey = "index "+q.index, idx.HashKey, idx.RangeKey
+	}
+	if q.hashKey != hashKey {
+		plan.warn("%s is not the hash key of the %s, %s is", q.hashKey, target, hashKey)
+	}
+	if q.rangeKey != "" && q.rangeKey != rangeKey {
+		plan.warn("%s is not the range key of the %s", q.rangeKey, target)
+	}
+	return plan, nil
+}
+
+// Explain describes how this scan will be executed.
+// It makes a DescribeTable request to check the index and estimate its size.
+func (s *Scan) Explain() (*Plan, error) {
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return s.ExplainWithContext(ctx)
+}
+
+// ExplainWithContext describes how this scan will be executed.
+// It makes a DescribeTable request to check the index and estimate its size.
+func (s *Scan) ExplainWithContext(ctx aws.Context) (*Plan, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	input := s.scanInput()
+	plan := &Plan{
+		Operation:     "Scan",
+		Table:         s.table.Name(),
+		Index:         s.index,
+		Filter:        unsubstitute(aws.StringValue(input.FilterExpression), s.nameExpr),
+		Substitutions: substitutions(s.nameExpr),
+	}
+	target := "table"
+	if s.index != "" {
+		target = "index"
+	}
+	if s.totalSegments > 0 {
+		plan.warn("scan reads every item of segment %d of %d of the %s", s.segment, s.totalSegments, target)
+	} else {
+		plan.warn("scan reads every item of the %s", target)
+	}
+	if plan.Filter != "" {
+		plan.warn("filter is applied after reading, so it doesn't reduce the capacity consumed")
+	}
+
+	desc, err := s.table.description(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items, size := desc.Items, desc.Size
+	if s.index != "" {
+		idx, ok := findIndex(desc, s.index)
+		switch {
+		case !ok:
+			plan.warn("index %s doesn't exist", s.index)
+			return plan, nil
+		case s.consistentRead() && !idx.Local:
+			plan.warn("consistent reads aren't supported on global secondary index %s", s.index)
+		}
+		items, size = idx.Items, idx.Size
+	}
+	if s.totalSegments > 0 {
+		items /= s.totalSegments
+		size /= s.totalSegments
+	}
+	pages := (size + maxPageSize - 1) / maxPageSize
+	if s.limit > 0 && plan.Filter == "" && items > 0 && s.limit < items {
+		// only part of the table will be read
+		pages = (s.limit*size/items + maxPageSize - 1) / maxPageSize
+	}
+	if pages == 0 {
+		pages = 1
+	}
+	if s.searchLimit > 0 {
+		pages = 1
+	}
+	plan.EstimatedPages = pages
+	return plan, nil
+}
+
+func (p *Plan) warn(format string, args ...interface{}) {
+	p.Warnings = append(p.Warnings, fmt.Sprintf(format, args...))
+}
+
+// findIndex returns the secondary index with the given name.
+func findIndex(desc Description, name string) (Index, bool) {
+	if idx, ok := findGSI(desc, name); ok {
+		return idx, true
+	}
+	return findLSI(desc, name)
+}
+
+func substitutions(names map[string]*string) map[string]string {
+	if len(names) == 0 {
+		return nil
+	}
+	subs := make(map[string]string, len(names))
+	for placeholder, name := range names {
+		subs[placeholder] = aws.StringValue(name)
+	}
+	return subs
+}
+
+// unsubstitute replaces the name placeholders in expr with the names they stand for.
+func unsubstitute(expr string, names map[string]*string) string {
+	if expr == "" || len(names) == 0 {
+		return expr
+	}
+	placeholders := make([]string, 0, len(names))
+	for placeholder := range names {
+		placeholders = append(placeholders, placeholder)
+	}
+	// longest first, in case one placeholder is a prefix of another
+	sort.Slice(placeholders, func(i, j int) bool {
+		return len(placeholders[i]) > len(placeholders[j])
+	})
+	pairs := make([]string, 0, len(placeholders)*2)
+	for _, placeholder := range placeholders {
+		pairs = append(pairs, placeholder, aws.StringValue(names[placeholder]))
+	}
+	return strings.NewReplacer(pairs...).Replace(expr)
+}
@@ -0,0 +1,83 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestExplain(t *testing.T) {
+	desc := &dynamodb.TableDescription{
+		TableName:      aws.String("UserActions"),
+		TableArn:       aws.String("arn"),
+		KeySchema:      keySchema("UserID", "Time"),
+		ItemCount:      aws.Int64(10000),
+		TableSizeBytes: aws.Int64(10 << 20),
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{{
+			IndexName:      aws.String("Msg-index"),
+			IndexArn:       aws.String("arn"),
+			IndexStatus:    aws.String(dynamodb.IndexStatusActive),
+			KeySchema:      keySchema("Msg", ""),
+			ItemCount:      aws.Int64(100),
+			IndexSizeBytes: aws.Int64(1 << 20),
+		}},
+	}
+	table := NewFromIface(newDescribeClient(desc)).Table("UserActions")
+
+	plan, err := table.Get("UserID", 42).Filter("'Count' > ?", 1).Explain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.FullPartition || plan.Filter != "(Count > :v0)" || plan.Index != "" {
+		t.Error("bad query plan:", plan)
+	}
+	if expected := map[string]string{"#s" + encodeName("Count"): "Count"}; !reflect.DeepEqual(plan.Substitutions, expected) {
+		t.Error("bad substitutions:", plan.Substitutions, "≠", expected)
+	}
+	if len(plan.Warnings) != 1 {
+		t.Error("expected a full partition warning:", plan.Warnings)
+	}
+
+	plan, err = table.Get("Msg", "hello").Index("Msg-index").Consistent(true).Explain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"consistent reads aren't supported on global secondary index Msg-index"}; !reflect.DeepEqual(plan.Warnings, expected) {
+		t.Error("bad warnings:", plan.Warnings, "≠", expected)
+	}
+
+	plan, err = table.Get("UserID", 42).Index("Msg-index").Range("Time", Greater, 0).Explain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"UserID is not the hash key of the index Msg-index, Msg is", "Time is not the range key of the index Msg-index"}
+	if !reflect.DeepEqual(plan.Warnings, expected) {
+		t.Error("bad warnings:", plan.Warnings, "≠", expected)
+	}
+
+	plan, err = table.Scan().Explain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Operation != "Scan" || plan.EstimatedPages != 10 {
+		t.Error("bad scan plan:", plan)
+	}
+
+	plan, err = table.Scan().Segment(0, 5).Limit(1000).Explain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.EstimatedPages != 1 {
+		t.Error("bad segment estimate:", plan.EstimatedPages)
+	}
+
+	plan, err = table.Scan().Index("nope").Explain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := plan.Warnings[len(plan.Warnings)-1]; last != "index nope doesn't exist" {
+		t.Error("bad warnings:", plan.Warnings)
+	}
+}
@@ -0,0 +1,135 @@
+package dynamo
+
+import (
+	"encoding"
+	"fmt"
+	"strings"
+
+	"github.com/guregu/dynamo/internal/exprs"
+)
+
+// exprKeywords are reserved words that are part of the expression syntax, rather than attribute names.
+var exprKeywords = map[string]bool{
+	"AND":     true,
+	"OR":      true,
+	"NOT":     true,
+	"BETWEEN": true,
+	"IN":      true,
+	"SET":     true,
+	"REMOVE":  true,
+	"ADD":     true,
+	"DELETE":  true,
+}
+
+// CheckExpr validates a dynamo-flavored expression, such as one passed to Query.Filter or Update.If,
+// without sending it to DynamoDB.
+// It checks that the number of placeholders matches the number of args and that each arg can be substituted,
+// that quotes, parentheses, and brackets are balanced,
+// and that reserved words aren't used as unquoted attribute names.
+// Use it to validate expressions built from user input before using them.
+func CheckExpr(expr string, args ...interface{}) error {
+	lexed, err := exprs.Parse(expr)
+	if err != nil {
+		return err
+	}
+
+	var placeholders int
+	var open []rune
+	var openPos []int
+	for _, item := range lexed.Items {
+		start := item.Pos - len(item.Val)
+		switch item.Type {
+		case exprs.ItemNamePlaceholder, exprs.ItemValuePlaceholder, exprs.ItemMagicLiteral:
+			if placeholders < len(args) {
+				if err := checkExprArg(item, args[placeholders]); err != nil {
+					return err
+				}
+			}
+			placeholders++
+		case exprs.ItemText:
+			for i, r := range item.Val {
+				switch r {
+				case '(', '[':
+					open = append(open, r)
+					openPos = append(openPos, start+i)
+				case ')', ']':
+					want := '('
+					if r == ']' {
+						want = '['
+					}
+					if len(open) == 0 || open[len(open)-1] != want {
+						return fmt.Errorf("dynamo: expression has unbalanced %q at position %d", r, start+i)
+					}
+					open, openPos = open[:len(open)-1], openPos[:len(openPos)-1]
+				}
+			}
+			if err := checkReservedWords(item.Val, start); err != nil {
+				return err
+			}
+		}
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("dynamo: expression has unclosed %q at position %d", open[len(open)-1], openPos[len(openPos)-1])
+	}
+	if placeholders != len(args) {
+		return fmt.Errorf("dynamo: expression has %d placeholders but %d args", placeholders, len(args))
+	}
+	return nil
+}
+
+// checkExprArg checks that arg can be substituted for the placeholder item.
+func checkExprArg(item exprs.Item, arg interface{}) error {
+	pos := item.Pos - len(item.Val)
+	switch item.Type {
+	case exprs.ItemNamePlaceholder:
+		switch arg.(type) {
+		case string, int, int64, encoding.TextMarshaler:
+			return nil
+		}
+		return fmt.Errorf("dynamo: type of argument for $ at position %d must be string, int, or int64 (got %T)", pos, arg)
+	case exprs.ItemValuePlaceholder:
+		if _, err := marshal(arg, ""); err != nil {
+			return fmt.Errorf("dynamo: argument for ? at position %d: %v", pos, err)
+		}
+	case exprs.ItemMagicLiteral:
+		if _, ok := arg.(string); !ok {
+			return fmt.Errorf("dynamo: argument for literal at position %d must be a string (got %T)", pos, arg)
+		}
+	}
+	return nil
+}
+
+// checkReservedWords returns an error if text, the unquoted part of an expression starting at offset,
+// uses a reserved word as an attribute name.
+// Words that are placeholders (#name, :value), numbers, keywords, or function names are allowed.
+func checkReservedWords(text string, offset int) error {
+	isWordRune := func(r byte) bool {
+		return r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+	}
+	for i := 0; i < len(text); {
+		if !isWordRune(text[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(text) && isWordRune(text[i]) {
+			i++
+		}
+		word := text[start:i]
+		upper := strings.ToUpper(word)
+		switch {
+		case start > 0 && (text[start-1] == '#' || text[start-1] == ':'):
+			continue
+		case word[0] >= '0' && word[0] <= '9':
+			continue
+		case exprKeywords[upper]:
+			continue
+		case strings.HasPrefix(strings.TrimLeft(text[i:], " \t\n"), "("):
+			// function call
+			continue
+		case reserved[upper]:
+			return fmt.Errorf("dynamo: %q at position %d is a reserved word, use quotes ('%s') or a $ placeholder", word, offset+start, word)
+		}
+	}
+	return nil
+}
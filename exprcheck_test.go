@@ -0,0 +1,47 @@
+package dynamo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckExpr(t *testing.T) {
+	valid := []struct {
+		expr string
+		args []interface{}
+	}{
+		{"UserID = ?", []interface{}{1}},
+		{"$ = ? AND 'Count' > ?", []interface{}{"Msg", "hello", 2}},
+		{"attribute_exists($) OR (size(Tags) > ? AND begins_with(Msg, ?))", []interface{}{"UserID", 3, "hi"}},
+		{"Meta.foo[0] BETWEEN ? AND ?", []interface{}{1, 10}},
+		{"NOT contains(Msg, ?) AND Msg IN (?, ?)", []interface{}{"a", "b", "c"}},
+	}
+	for _, tc := range valid {
+		if err := CheckExpr(tc.expr, tc.args...); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.expr, err)
+		}
+	}
+
+	invalid := []struct {
+		expr string
+		args []interface{}
+		want string
+	}{
+		{"UserID = ?", nil, "1 placeholders but 0 args"},
+		{"UserID = ?", []interface{}{1, 2}, "1 placeholders but 2 args"},
+		{"$ = ?", []interface{}{3.5, 1}, "must be string, int, or int64"},
+		{"UserID = ?", []interface{}{make(chan int)}, "argument for ?"},
+		{"(UserID = ? AND (Msg = ?)", []interface{}{1, "a"}, "unclosed '('"},
+		{"UserID = ?) AND Msg = ?", []interface{}{1, "a"}, "unbalanced ')'"},
+		{"Tags[0) = ?", []interface{}{1}, "unbalanced ')'"},
+		{"'Count = ?", []interface{}{1}, "unterminated"},
+		{"Count > ?", []interface{}{1}, `"Count" at position 0 is a reserved word`},
+		{"Msg = ? AND Meta.Data = ?", []interface{}{1, 2}, `"Data" at position 17 is a reserved word`},
+	}
+	for _, tc := range invalid {
+		err := CheckExpr(tc.expr, tc.args...)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got: %v", tc.expr, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package dynamo
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// FieldNameMapper converts a struct field's name into an attribute name.
+// It is used for fields whose struct tags don't give a name. See DB.WithFieldNameMapper.
+type FieldNameMapper func(field string) string
+
+// SnakeCase is a FieldNameMapper that converts field names to snake_case: UserID becomes user_id.
+func SnakeCase(field string) string {
+	runes := []rune(field)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// LowerCamelCase is a FieldNameMapper that converts field names to lowerCamelCase: UserID becomes userID,
+// and HTTPStatus becomes httpStatus.
+func LowerCamelCase(field string) string {
+	runes := []rune(field)
+	for i := 0; i < len(runes) && unicode.IsUpper(runes[i]); i++ {
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			// the start of the next word
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// WithFieldNameMapper returns a copy of this DB that names the attributes of struct fields without an explicitly tagged name
+// using mapper, so that models can follow a naming convention without tagging every field.
+//	db := dynamo.New(sess).WithFieldNameMapper(dynamo.SnakeCase)
+//	type User struct {
+//		UserID    string `dynamo:",hash"` // user_id
+//		CreatedAt time.Time               // created_at
+//		Nick      string `dynamo:"nickname"`
+//	}
+// It applies to items and values encoded and decoded by this DB's requests, including nested structs,
+// and to struct tags read by CreateTable and ValidateModel.
+// It doesn't apply to attribute names in expressions, or to the package-level MarshalItem and UnmarshalItem;
+// use DB.MarshalItem and DB.UnmarshalItem instead.
+func (db *DB) WithFieldNameMapper(mapper FieldNameMapper) *DB {
+	cp := *db
+	cp.names = mapper
+	return &cp
+}
+
+// MarshalItem converts the given struct into a DynamoDB item, using this DB's FieldNameMapper and registered encoders.
+func (db *DB) MarshalItem(v interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	return db.codec().marshalItem(v)
+}
+
+// UnmarshalItem decodes a DynamoDB item into out, using this DB's FieldNameMapper and registered decoders. See UnmarshalItem.
+func (db *DB) UnmarshalItem(item map[string]*dynamodb.AttributeValue, out interface{}) error {
+	return db.codec().unmarshalItem(item, out)
+}
+
+// codec encodes and decodes items with the naming options and registered types of a DB.
+// The zero value uses the struct field's name for fields without a tagged name.
+type codec struct {
+	names    FieldNameMapper
+	encoders map[reflect.Type]EncoderFunc
+	decoders map[reflect.Type]DecoderFunc
+	blobs    map[string]BlobCodec
+}
+
+func (db *DB) codec() codec {
+	if db == nil {
+		return codec{}
+	}
+	return codec{names: db.names, encoders: db.encoders, decoders: db.decoders, blobs: db.blobs}
+}
+
+// fieldName returns the attribute name of a struct field without a tagged name.
+func (c codec) fieldName(field string) string {
+	if c.names == nil {
+		return field
+	}
+	return c.names(field)
+}
@@ -0,0 +1,122 @@
+package dynamo
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+func TestFieldNameMappers(t *testing.T) {
+	table := []struct {
+		in, snake, camel string
+	}{
+		{"UserID", "user_id", "userID"},
+		{"HTTPStatus", "http_status", "httpStatus"},
+		{"CreatedAt", "created_at", "createdAt"},
+		{"ID", "id", "id"},
+		{"Count2", "count2", "count2"},
+		{"already_snake", "already_snake", "already_snake"},
+	}
+	for _, tc := range table {
+		if out := SnakeCase(tc.in); out != tc.snake {
+			t.Errorf("SnakeCase(%q) = %q, want %q", tc.in, out, tc.snake)
+		}
+		if out := LowerCamelCase(tc.in); out != tc.camel {
+			t.Errorf("LowerCamelCase(%q) = %q, want %q", tc.in, out, tc.camel)
+		}
+	}
+}
+
+// lastItemClient is a fake client that stores the last item put, and returns it for every get.
+type lastItemClient struct {
+	dynamodbiface.DynamoDBAPI
+	item   map[string]*dynamodb.AttributeValue
+	update *dynamodb.UpdateItemInput
+}
+
+func (c *lastItemClient) PutItemWithContext(_ aws.Context, input *dynamodb.PutItemInput, _ ...request.Option) (*dynamodb.PutItemOutput, error) {
+	c.item = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *lastItemClient) GetItemWithContext(_ aws.Context, input *dynamodb.GetItemInput, _ ...request.Option) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: c.item}, nil
+}
+
+func (c *lastItemClient) UpdateItemWithContext(_ aws.Context, input *dynamodb.UpdateItemInput, _ ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	c.update = input
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func TestWithFieldNameMapper(t *testing.T) {
+	type Address struct {
+		StreetName string
+	}
+	type User struct {
+		UserID    string `dynamo:",hash"`
+		CreatedAt time.Time
+		Nick      string `dynamo:"nickname"`
+		Home      Address
+		Version   int `dynamo:",version"`
+	}
+	client := &lastItemClient{}
+	db := NewFromIface(client).WithFieldNameMapper(SnakeCase)
+	table := db.Table("Users")
+
+	now := time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+	user := User{UserID: "u1", CreatedAt: now, Nick: "nick", Home: Address{StreetName: "Main"}}
+	if err := table.Put(user).Run(); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range client.item {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if expect := []string{"created_at", "home", "nickname", "user_id", "version"}; !reflect.DeepEqual(names, expect) {
+		t.Error("bad attribute names:", names, "≠", expect)
+	}
+	if _, ok := client.item["home"].M["street_name"]; !ok {
+		t.Error("nested struct not mapped:", client.item["home"])
+	}
+
+	var got User
+	if err := table.Get("user_id", "u1").One(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, user) {
+		t.Errorf("bad result: %+v ≠ %+v", got, user)
+	}
+
+	err := table.Update("user_id", "u1").Set("home", Address{StreetName: "Side"}).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := client.update.ExpressionAttributeValues[":v0"].M["street_name"]; !ok {
+		t.Error("update value not mapped:", client.update.ExpressionAttributeValues)
+	}
+	var home Address
+	if err := db.UnmarshalItem(client.update.ExpressionAttributeValues[":v0"].M, &home); err != nil || home.StreetName != "Side" {
+		t.Error("bad update value:", client.update.ExpressionAttributeValues, err)
+	}
+
+	input := db.CreateTable("Users", User{}).input()
+	if name := *input.KeySchema[0].AttributeName; name != "user_id" {
+		t.Error("bad hash key:", name)
+	}
+
+	// the package-level functions don't use it
+	item, err := MarshalItem(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := item["UserID"]; !ok {
+		t.Error("MarshalItem shouldn't map names:", item)
+	}
+}
@@ -0,0 +1,37 @@
+package dynamo
+
+import (
+	"reflect"
+	"strings"
+)
+
+// flattening returns whether the struct stored in field is flattened into its parent's item,
+// rather than stored as a nested map, along with the prefix added to the names of its attributes.
+// Embedded structs are flattened unless tagged with the nested option,
+// and other struct fields are flattened if tagged with the flatten or prefix=... options:
+//	Address Address `dynamo:",flatten"`     // Street, City
+//	Billing Address `dynamo:",prefix=Bill_"` // Bill_Street, Bill_City
+//	Base    `dynamo:"Base,nested"`           // Base: {...}
+func flattening(field reflect.StructField) (flatten bool, prefix string) {
+	if field.Type.Kind() != reflect.Struct || (field.PkgPath != "" && !field.Anonymous) {
+		return false, ""
+	}
+	flatten = field.Anonymous
+	for _, opt := range strings.Split(field.Tag.Get("dynamo"), ",")[1:] {
+		switch {
+		case opt == "flatten":
+			flatten = true
+		case opt == "nested":
+			flatten = false
+		case strings.HasPrefix(opt, "prefix="):
+			flatten = true
+			prefix = strings.TrimPrefix(opt, "prefix=")
+		}
+	}
+	return flatten, prefix
+}
+
+// isStructOption returns whether opt is a struct tag option handled by flattening.
+func isStructOption(opt string) bool {
+	return opt == "flatten" || opt == "nested" || strings.HasPrefix(opt, "prefix=")
+}
@@ -0,0 +1,97 @@
+package dynamo
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestFlatten(t *testing.T) {
+	type Address struct {
+		Street string
+		City   string
+	}
+	type Base struct {
+		ID      string    `dynamo:",hash"`
+		Updated time.Time `dynamo:",updatedTime"`
+	}
+	type Meta struct {
+		Note string
+	}
+	type Customer struct {
+		Base
+		Meta    `dynamo:"Meta,nested"`
+		Home    Address `dynamo:",flatten"`
+		Billing Address `dynamo:",prefix=Bill_"`
+		Work    Address
+	}
+
+	now := time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+	in := Customer{
+		Base:    Base{ID: "c1", Updated: now},
+		Meta:    Meta{Note: "vip"},
+		Home:    Address{Street: "1 Main", City: "Springfield"},
+		Billing: Address{Street: "2 Side", City: "Shelbyville"},
+		Work:    Address{Street: "3 Office", City: "Capital"},
+	}
+	item, err := marshalItem(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range item {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	expect := []string{"Bill_City", "Bill_Street", "City", "ID", "Meta", "Street", "Updated", "Work"}
+	if !reflect.DeepEqual(names, expect) {
+		t.Error("bad attribute names:", names, "≠", expect)
+	}
+	if item["Meta"].M == nil || item["Work"].M == nil {
+		t.Error("nested structs should be maps:", item["Meta"], item["Work"])
+	}
+
+	var out Customer
+	if err := unmarshalItem(item, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("bad result: %+v ≠ %+v", out, in)
+	}
+
+	fields, err := codec{}.autoFields(reflect.TypeOf(Customer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stamped []string
+	for _, f := range fields {
+		stamped = append(stamped, f.name)
+	}
+	if !reflect.DeepEqual(stamped, []string{"Updated"}) {
+		t.Error("bad auto fields:", stamped)
+	}
+}
+
+func TestCreateTableFlatten(t *testing.T) {
+	type Key struct {
+		ID   string    `dynamo:",hash"`
+		Time time.Time `dynamo:",range" index:"Time-index,hash"`
+	}
+	type Event struct {
+		Key Key `dynamo:",prefix=Event"`
+	}
+	input := NewFromIface(nil).CreateTable("Events", Event{}).input()
+	hashKey, rangeKey := schemaKeys(input.KeySchema)
+	if hashKey != "EventID" || rangeKey != "EventTime" {
+		t.Error("bad keys:", hashKey, rangeKey)
+	}
+	if len(input.GlobalSecondaryIndexes) != 1 || *input.GlobalSecondaryIndexes[0].KeySchema[0].AttributeName != "EventTime" {
+		t.Error("bad index:", input.GlobalSecondaryIndexes)
+	}
+	if typ := lookupADType(input.AttributeDefinitions, "EventTime"); typ != KeyType(dynamodb.ScalarAttributeTypeS) {
+		t.Error("bad attribute type:", typ)
+	}
+}
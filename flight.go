@@ -0,0 +1,133 @@
+package dynamo
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// flightGroup coalesces concurrent duplicate requests, so that only one of them is in flight at a time.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+type flight struct {
+	done     chan struct{}
+	out      interface{}
+	err      error
+	canceled bool
+}
+
+// do calls f, unless there is already a call in flight for key, in which case it waits for that call's result.
+// If the call in flight is canceled by its own context, do tries again with ctx.
+func (g *flightGroup) do(ctx aws.Context, key string, f func(aws.Context) (interface{}, error)) (interface{}, error) {
+	for {
+		g.mu.Lock()
+		if g.flights == nil {
+			g.flights = make(map[string]*flight)
+		}
+		if fl, ok := g.flights[key]; ok {
+			g.mu.Unlock()
+			select {
+			case <-fl.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if fl.canceled {
+				continue
+			}
+			return fl.out, fl.err
+		}
+		fl := &flight{done: make(chan struct{})}
+		g.flights[key] = fl
+		g.mu.Unlock()
+
+		fl.out, fl.err = f(ctx)
+		fl.canceled = fl.err != nil && ctx.Err() != nil
+
+		g.mu.Lock()
+		delete(g.flights, key)
+		g.mu.Unlock()
+		close(fl.done)
+		return fl.out, fl.err
+	}
+}
+
+// getItem sends a GetItem request, using the cache and coalescing it with identical requests in flight if enabled.
+// Hedged requests aren't coalesced, because waiting on the request they hedge would defeat their purpose.
+func (db *DB) getItem(ctx aws.Context, input *dynamodb.GetItemInput, opts []request.Option) (*dynamodb.GetItemOutput, error) {
+	table := aws.StringValue(input.TableName)
+	cacheable := db.cache != nil && input.ProjectionExpression == nil
+	var gen uint64
+	if cacheable {
+		gen = db.cache.generation(table, input.Key)
+	}
+	if cacheable && !aws.BoolValue(input.ConsistentRead) {
+		if item, ok := db.cache.get(table, input.Key); ok {
+			if len(item) == 0 {
+				// known not to exist
+				return &dynamodb.GetItemOutput{}, nil
+			}
+			return &dynamodb.GetItemOutput{Item: item}, nil
+		}
+	}
+
+	var output *dynamodb.GetItemOutput
+	if db.gets == nil || isHedged(ctx) {
+		var err error
+		if output, err = db.client.GetItemWithContext(ctx, input, opts...); err != nil {
+			return nil, err
+		}
+	} else {
+		out, err := db.gets.do(ctx, getItemKey(input), func(ctx aws.Context) (interface{}, error) {
+			return db.client.GetItemWithContext(ctx, input, opts...)
+		})
+		if err != nil {
+			return nil, err
+		}
+		// every waiter gets the same output, so each needs its own item
+		shared := out.(*dynamodb.GetItemOutput)
+		output = &dynamodb.GetItemOutput{Item: copyItem(shared.Item), ConsumedCapacity: shared.ConsumedCapacity}
+	}
+
+	switch {
+	case !cacheable:
+	case output.Item != nil:
+		db.cache.set(table, input.Key, output.Item, gen)
+	default:
+		db.cache.setNotFound(table, input.Key, gen)
+	}
+	return output, nil
+}
+
+// getItemKey returns a string identifying everything that could change the results of input.
+func getItemKey(input *dynamodb.GetItemInput) string {
+	var key strings.Builder
+	key.WriteString(aws.StringValue(input.TableName))
+	key.WriteByte(0)
+	key.WriteString(keyString(input.Key))
+	key.WriteByte(0)
+	key.WriteString(strconv.FormatBool(aws.BoolValue(input.ConsistentRead)))
+	key.WriteByte(0)
+	key.WriteString(aws.StringValue(input.ReturnConsumedCapacity))
+	key.WriteByte(0)
+	key.WriteString(aws.StringValue(input.ProjectionExpression))
+	names := make([]string, 0, len(input.ExpressionAttributeNames))
+	for k := range input.ExpressionAttributeNames {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		key.WriteByte(0)
+		key.WriteString(k)
+		key.WriteByte('=')
+		key.WriteString(aws.StringValue(input.ExpressionAttributeNames[k]))
+	}
+	return key.String()
+}
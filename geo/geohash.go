@@ -0,0 +1,205 @@
+// Package geo stores and queries locations in DynamoDB tables using geohashes.
+//
+// Items are partitioned by a short geohash prefix, and their range key is their full geohash,
+// so nearby items share partitions and range key prefixes.
+// Radius and bounding box searches query the smallest set of geohash prefixes covering the area,
+// then filter the results precisely.
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// MaxPrecision is the length of the most precise geohashes, about 3.7cm by 1.9cm.
+const MaxPrecision = 12
+
+// Point is a location in degrees.
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Box is an area between two corners.
+// If Min.Lng is greater than Max.Lng, the box crosses the antimeridian.
+type Box struct {
+	// Min is the southwest corner.
+	Min Point
+	// Max is the northeast corner.
+	Max Point
+}
+
+// Contains returns true if p is within this box.
+func (b Box) Contains(p Point) bool {
+	if p.Lat < b.Min.Lat || p.Lat > b.Max.Lat {
+		return false
+	}
+	if b.Min.Lng <= b.Max.Lng {
+		return p.Lng >= b.Min.Lng && p.Lng <= b.Max.Lng
+	}
+	return p.Lng >= b.Min.Lng || p.Lng <= b.Max.Lng
+}
+
+// Center returns the middle of this box.
+func (b Box) Center() Point {
+	return Point{Lat: (b.Min.Lat + b.Max.Lat) / 2, Lng: (b.Min.Lng + b.Max.Lng) / 2}
+}
+
+// Encode returns the geohash of p with the given number of characters, up to MaxPrecision.
+func Encode(p Point, precision int) string {
+	if precision < 1 || precision > MaxPrecision {
+		precision = MaxPrecision
+	}
+	lat, lng := [2]float64{-90, 90}, [2]float64{-180, 180}
+	var b strings.Builder
+	var ch, bit int
+	even := true
+	for b.Len() < precision {
+		rng, v := &lat, p.Lat
+		if even {
+			rng, v = &lng, p.Lng
+		}
+		mid := (rng[0] + rng[1]) / 2
+		ch <<= 1
+		if v >= mid {
+			ch |= 1
+			rng[0] = mid
+		} else {
+			rng[1] = mid
+		}
+		even = !even
+		if bit++; bit == 5 {
+			b.WriteByte(base32[ch])
+			ch, bit = 0, 0
+		}
+	}
+	return b.String()
+}
+
+// Decode returns the area covered by the given geohash.
+func Decode(hash string) (Box, error) {
+	lat, lng := [2]float64{-90, 90}, [2]float64{-180, 180}
+	even := true
+	for _, r := range strings.ToLower(hash) {
+		ch := strings.IndexRune(base32, r)
+		if ch < 0 {
+			return Box{}, fmt.Errorf("geo: invalid geohash %q", hash)
+		}
+		for i := 4; i >= 0; i-- {
+			rng := &lat
+			if even {
+				rng = &lng
+			}
+			mid := (rng[0] + rng[1]) / 2
+			if ch&(1<<uint(i)) != 0 {
+				rng[0] = mid
+			} else {
+				rng[1] = mid
+			}
+			even = !even
+		}
+	}
+	return Box{Min: Point{Lat: lat[0], Lng: lng[0]}, Max: Point{Lat: lat[1], Lng: lng[1]}}, nil
+}
+
+// cellSize returns the height and width in degrees of geohash cells with the given precision.
+func cellSize(precision int) (lat, lng float64) {
+	bits := uint(5 * precision)
+	lngBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 180 / float64(uint64(1)<<latBits), 360 / float64(uint64(1)<<lngBits)
+}
+
+// countCells returns the number of geohash cells with the given precision needed to cover b.
+func countCells(b Box, precision int) int {
+	if b.Min.Lng > b.Max.Lng {
+		west, east := b, b
+		west.Max.Lng, east.Min.Lng = 180, -180
+		return countCells(west, precision) + countCells(east, precision)
+	}
+	h, w := cellSize(precision)
+	rows := cellIndex(b.Max.Lat+90, h, 180) - cellIndex(b.Min.Lat+90, h, 180) + 1
+	cols := cellIndex(b.Max.Lng+180, w, 360) - cellIndex(b.Min.Lng+180, w, 360) + 1
+	return rows * cols
+}
+
+// cells returns the geohashes with the given precision that cover b.
+func cells(b Box, precision int) []string {
+	if b.Min.Lng > b.Max.Lng {
+		west, east := b, b
+		west.Max.Lng, east.Min.Lng = 180, -180
+		return append(cells(west, precision), cells(east, precision)...)
+	}
+	h, w := cellSize(precision)
+	var hashes []string
+	for row := cellIndex(b.Min.Lat+90, h, 180); row <= cellIndex(b.Max.Lat+90, h, 180); row++ {
+		for col := cellIndex(b.Min.Lng+180, w, 360); col <= cellIndex(b.Max.Lng+180, w, 360); col++ {
+			center := Point{
+				Lat: -90 + (float64(row)+0.5)*h,
+				Lng: -180 + (float64(col)+0.5)*w,
+			}
+			hashes = append(hashes, Encode(center, precision))
+		}
+	}
+	return hashes
+}
+
+// cellIndex returns the index of the cell of the given size containing offset, within [0, max].
+func cellIndex(offset, size, max float64) int {
+	i := int(math.Floor(offset / size))
+	if last := int(max/size) - 1; i > last {
+		return last
+	}
+	if i < 0 {
+		return 0
+	}
+	return i
+}
+
+const earthRadius = 6371008.8 // meters
+
+// Distance returns the great-circle distance between a and b in meters.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat, dLng := lat2-lat1, radians(b.Lng-a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Around returns a box containing the circle of the given radius in meters around center.
+func Around(center Point, meters float64) Box {
+	dLat := degrees(meters / earthRadius)
+	b := Box{
+		Min: Point{Lat: math.Max(-90, center.Lat-dLat)},
+		Max: Point{Lat: math.Min(90, center.Lat+dLat)},
+	}
+	cos := math.Cos(radians(math.Max(math.Abs(b.Min.Lat), math.Abs(b.Max.Lat))))
+	if b.Min.Lat == -90 || b.Max.Lat == 90 || cos < 1e-9 || dLat/cos >= 180 {
+		b.Min.Lng, b.Max.Lng = -180, 180
+		return b
+	}
+	dLng := dLat / cos
+	b.Min.Lng, b.Max.Lng = wrap(center.Lng-dLng), wrap(center.Lng+dLng)
+	return b
+}
+
+func wrap(lng float64) float64 {
+	switch {
+	case lng < -180:
+		return lng + 360
+	case lng > 180:
+		return lng - 360
+	}
+	return lng
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
@@ -0,0 +1,47 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	p := Point{Lat: 57.64911, Lng: 10.40744}
+	if hash := Encode(p, 11); hash != "u4pruydqqvj" {
+		t.Error("bad geohash:", hash)
+	}
+	box, err := Decode("u4pruydqqvj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !box.Contains(p) {
+		t.Error("decoded box doesn't contain point:", box, p)
+	}
+	if _, err := Decode("u4pa"); err == nil {
+		t.Error("expected error for invalid geohash")
+	}
+}
+
+func TestDistance(t *testing.T) {
+	london := Point{Lat: 51.5074, Lng: -0.1278}
+	paris := Point{Lat: 48.8566, Lng: 2.3522}
+	if d := Distance(london, paris); math.Abs(d-343500) > 1000 {
+		t.Error("bad distance:", d)
+	}
+}
+
+func TestAround(t *testing.T) {
+	box := Around(Point{Lat: 0, Lng: 179.99}, 10000)
+	if box.Min.Lng < box.Max.Lng {
+		t.Error("box should cross the antimeridian:", box)
+	}
+	if !box.Contains(Point{Lat: 0, Lng: -179.99}) {
+		t.Error("box should contain the other side of the antimeridian:", box)
+	}
+	if n := countCells(box, 5); n != len(cells(box, 5)) {
+		t.Error("count mismatch:", n, len(cells(box, 5)))
+	}
+	if polar := Around(Point{Lat: 89.99, Lng: 0}, 10000); polar.Min.Lng != -180 || polar.Max.Lng != 180 {
+		t.Error("polar box should span all longitudes:", polar)
+	}
+}
@@ -0,0 +1,258 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/guregu/dynamo"
+	"golang.org/x/net/context"
+)
+
+// Index stores locations in a table whose partition key is a short geohash prefix
+// and whose range key is the full geohash followed by "#" and an ID, like "9q8yyk8ytpxr#store-42".
+type Index struct {
+	table      dynamo.Table
+	hashKey    string
+	rangeKey   string
+	partition  int
+	maxQueries int
+}
+
+// NewIndex returns an Index for the given table, with string partition and range keys.
+// Partitions cover geohashes of 4 characters (about 39km by 20km), see Partition.
+func NewIndex(table dynamo.Table, hashKey, rangeKey string) Index {
+	return Index{
+		table:      table,
+		hashKey:    hashKey,
+		rangeKey:   rangeKey,
+		partition:  4,
+		maxQueries: 8,
+	}
+}
+
+// Partition returns a copy of this Index whose partition keys are geohashes with the given number of characters.
+// Longer partition keys spread items across more partitions, but searches of large areas need more queries.
+// It must not be changed once the table has items.
+func (ix Index) Partition(precision int) Index {
+	if precision >= 1 && precision <= MaxPrecision {
+		ix.partition = precision
+	}
+	return ix
+}
+
+// MaxQueries returns a copy of this Index that aims to use at most n queries per search.
+// Fewer queries cover a larger area, so more items are read and filtered out.
+// Searches of areas larger than a partition always query every partition they touch.
+// The default is 8.
+func (ix Index) MaxQueries(n int) Index {
+	if n > 0 {
+		ix.maxQueries = n
+	}
+	return ix
+}
+
+// Keys returns the partition and range key values for the item id at p.
+func (ix Index) Keys(p Point, id string) (hashValue, rangeValue string) {
+	hash := Encode(p, MaxPrecision)
+	return hash[:ix.partition], hash + "#" + id
+}
+
+// Put creates a new request to put item, with keys for its location p and its ID.
+func (ix Index) Put(p Point, id string, item interface{}) *dynamo.Put {
+	encoded, err := ix.table.DB().MarshalItem(item)
+	if err != nil {
+		// let Put report the error
+		return ix.table.Put(item)
+	}
+	hashValue, rangeValue := ix.Keys(p, id)
+	encoded[ix.hashKey] = &dynamodb.AttributeValue{S: aws.String(hashValue)}
+	encoded[ix.rangeKey] = &dynamodb.AttributeValue{S: aws.String(rangeValue)}
+	return ix.table.Put(encoded)
+}
+
+// Delete creates a new request to delete the item id at p.
+func (ix Index) Delete(p Point, id string) *dynamo.Delete {
+	hashValue, rangeValue := ix.Keys(p, id)
+	return ix.table.Delete(ix.hashKey, hashValue).Range(ix.rangeKey, rangeValue)
+}
+
+// Radius creates a new search for items within the given distance in meters of center.
+// Results are sorted by distance, nearest first.
+func (ix Index) Radius(center Point, meters float64) *Search {
+	return &Search{
+		index:  ix,
+		area:   Around(center, meters),
+		center: &center,
+		radius: meters,
+	}
+}
+
+// Box creates a new search for items within the given box.
+func (ix Index) Box(box Box) *Search {
+	return &Search{
+		index: ix,
+		area:  box,
+	}
+}
+
+// Search is a request to find the items of an Index within an area.
+type Search struct {
+	index  Index
+	area   Box
+	center *Point
+	radius float64
+
+	filters    []filter
+	consistent bool
+}
+
+type filter struct {
+	expr string
+	args []interface{}
+}
+
+// Filter takes an expression that all results will be evaluated against, as in dynamo.Query.Filter.
+// Multiple calls to Filter will be combined with AND.
+func (s *Search) Filter(expr string, args ...interface{}) *Search {
+	s.filters = append(s.filters, filter{expr: expr, args: args})
+	return s
+}
+
+// Consistent will, if on is true, make this search strongly consistent.
+func (s *Search) Consistent(on bool) *Search {
+	s.consistent = on
+	return s
+}
+
+// All executes this search and unmarshals the items within its area to out, which must be a pointer to a slice.
+func (s *Search) All(out interface{}) error {
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return s.AllWithContext(ctx, out)
+}
+
+// AllWithContext executes this search and unmarshals the items within its area to out, which must be a pointer to a slice.
+func (s *Search) AllWithContext(ctx aws.Context, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return errors.New("geo: search: result argument must be a slice pointer")
+	}
+
+	queries := s.queries()
+	results := make([][]map[string]*dynamodb.AttributeValue, len(queries))
+	errs := make([]error, len(queries))
+	var wg sync.WaitGroup
+	for i, q := range queries {
+		wg.Add(1)
+		go func(i int, q *dynamo.Query) {
+			defer wg.Done()
+			errs[i] = q.AllWithContext(ctx, &results[i])
+		}(i, q)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	type match struct {
+		item     map[string]*dynamodb.AttributeValue
+		distance float64
+	}
+	var matches []match
+	for _, items := range results {
+		for _, item := range items {
+			p, err := s.index.locate(item)
+			if err != nil {
+				return err
+			}
+			if !s.area.Contains(p) {
+				continue
+			}
+			m := match{item: item}
+			if s.center != nil {
+				if m.distance = Distance(*s.center, p); m.distance > s.radius {
+					continue
+				}
+			}
+			matches = append(matches, m)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+
+	slice := rv.Elem()
+	for _, m := range matches {
+		elem := reflect.New(slice.Type().Elem())
+		if err := s.index.table.DB().UnmarshalItem(m.item, elem.Interface()); err != nil {
+			return err
+		}
+		slice = reflect.Append(slice, elem.Elem())
+	}
+	rv.Elem().Set(slice)
+	return nil
+}
+
+// queries returns the queries covering this search's area.
+func (s *Search) queries() []*dynamo.Query {
+	ix := s.index
+	// use the most precise prefixes that stay within the query budget
+	precision := ix.partition
+	for p := MaxPrecision; p > ix.partition; p-- {
+		if countCells(s.area, p) <= ix.maxQueries {
+			precision = p
+			break
+		}
+	}
+
+	seen := make(map[string]bool)
+	var queries []*dynamo.Query
+	for _, cell := range cells(s.area, precision) {
+		if seen[cell] {
+			continue
+		}
+		seen[cell] = true
+		q := ix.table.Get(ix.hashKey, cell[:ix.partition]).Consistent(s.consistent)
+		if len(cell) > ix.partition {
+			q.Range(ix.rangeKey, dynamo.BeginsWith, cell)
+		}
+		for _, f := range s.filters {
+			q.Filter(f.expr, f.args...)
+		}
+		queries = append(queries, q)
+	}
+	return queries
+}
+
+// defaultContext returns a context bounded by dynamo.RetryTimeout, like methods of package dynamo that don't take one.
+func defaultContext() (aws.Context, context.CancelFunc) {
+	if dynamo.RetryTimeout == 0 {
+		return aws.BackgroundContext(), func() {}
+	}
+	return context.WithTimeout(aws.BackgroundContext(), dynamo.RetryTimeout)
+}
+
+// locate returns the location of item, from its range key.
+func (ix Index) locate(item map[string]*dynamodb.AttributeValue) (Point, error) {
+	rk := item[ix.rangeKey]
+	if rk == nil || rk.S == nil {
+		return Point{}, fmt.Errorf("geo: item is missing range key %s", ix.rangeKey)
+	}
+	hash := *rk.S
+	if i := strings.IndexByte(hash, '#'); i >= 0 {
+		hash = hash[:i]
+	}
+	box, err := Decode(hash)
+	if err != nil {
+		return Point{}, err
+	}
+	return box.Center(), nil
+}
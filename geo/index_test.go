@@ -0,0 +1,118 @@
+package geo
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/guregu/dynamo"
+)
+
+// geoClient is a fake client that stores items in memory and serves queries on them.
+type geoClient struct {
+	dynamodbiface.DynamoDBAPI
+	items   []map[string]*dynamodb.AttributeValue
+	queries int
+	mu      sync.Mutex
+}
+
+func (c *geoClient) PutItemWithContext(_ aws.Context, input *dynamodb.PutItemInput, _ ...request.Option) (*dynamodb.PutItemOutput, error) {
+	c.items = append(c.items, input.Item)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *geoClient) QueryWithContext(_ aws.Context, input *dynamodb.QueryInput, _ ...request.Option) (*dynamodb.QueryOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries++
+	pk := *input.KeyConditions["Cell"].AttributeValueList[0].S
+	var prefix string
+	if cond := input.KeyConditions["Hash"]; cond != nil {
+		prefix = *cond.AttributeValueList[0].S
+	}
+	out := &dynamodb.QueryOutput{}
+	for _, item := range c.items {
+		if *item["Cell"].S == pk && strings.HasPrefix(*item["Hash"].S, prefix) {
+			out.Items = append(out.Items, item)
+		}
+	}
+	return out, nil
+}
+
+type place struct {
+	Name string
+}
+
+func TestIndexSearch(t *testing.T) {
+	client := &geoClient{}
+	ix := NewIndex(dynamo.NewFromIface(client).Table("Places"), "Cell", "Hash")
+
+	center := Point{Lat: 35.6812, Lng: 139.7671} // Tokyo Station
+	places := map[string]Point{
+		"near":   {Lat: 35.6852, Lng: 139.7528}, // ~1.4km
+		"nearer": {Lat: 35.6800, Lng: 139.7690}, // ~0.2km
+		"far":    {Lat: 35.6586, Lng: 139.7454}, // ~3.2km
+		"osaka":  {Lat: 34.7025, Lng: 135.4959},
+	}
+	for name, p := range places {
+		if err := ix.Put(p, name, place{Name: name}).Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var found []place
+	if err := ix.Radius(center, 2000).All(&found); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []place{{"nearer"}, {"near"}}; !reflect.DeepEqual(found, expected) {
+		t.Error("bad radius results:", found, "≠", expected)
+	}
+	if client.queries > 8 {
+		t.Error("too many queries:", client.queries)
+	}
+
+	found = nil
+	box := Box{Min: Point{Lat: 34, Lng: 135}, Max: Point{Lat: 36, Lng: 140}}
+	if err := ix.Box(box).All(&found); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range found {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	if expected := []string{"far", "near", "nearer", "osaka"}; !reflect.DeepEqual(names, expected) {
+		t.Error("bad box results:", names, "≠", expected)
+	}
+}
+
+func TestIndexFieldNameMapper(t *testing.T) {
+	type landmark struct {
+		PlaceName string
+	}
+	client := &geoClient{}
+	db := dynamo.NewFromIface(client).WithFieldNameMapper(dynamo.SnakeCase)
+	ix := NewIndex(db.Table("Places"), "Cell", "Hash")
+
+	p := Point{Lat: 35.6812, Lng: 139.7671}
+	if err := ix.Put(p, "station", landmark{PlaceName: "Tokyo Station"}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := client.items[0]["place_name"]; !ok {
+		t.Error("expected the DB's field names, got", client.items[0])
+	}
+
+	var found []landmark
+	if err := ix.Radius(p, 100).All(&found); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []landmark{{"Tokyo Station"}}; !reflect.DeepEqual(found, expected) {
+		t.Error("bad results:", found, "≠", expected)
+	}
+}
@@ -0,0 +1,153 @@
+package dynamo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Graph stores relations between items using the adjacency list pattern.
+// Each edge is an item whose partition key is the node it starts from,
+// and whose range key is the edge's type and the node it points to, like "follows#user-2".
+// Edges can carry other attributes too.
+// A global secondary index with the range key as its partition key and the partition key as its range key
+// can be used to find edges pointing to a node. See ReverseIndex.
+// Edge types must not contain the separator "#".
+// See: https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/bp-adjacency-graphs.html
+type Graph struct {
+	table   Table
+	nodeKey string
+	edgeKey string
+	reverse string
+}
+
+// Edge is a relation of a given type from one node to another.
+type Edge struct {
+	From string
+	Type string
+	To   string
+}
+
+const edgeSep = "#"
+
+// Graph returns a Graph storing edges in this table, with the partition key nodeKey and the range key edgeKey.
+func (table Table) Graph(nodeKey, edgeKey string) Graph {
+	return Graph{
+		table:   table,
+		nodeKey: nodeKey,
+		edgeKey: edgeKey,
+	}
+}
+
+// ReverseIndex returns a copy of this Graph that uses the given inverted index for In.
+func (g Graph) ReverseIndex(name string) Graph {
+	g.reverse = name
+	return g
+}
+
+// EdgeKey returns the range key of edges of type typ pointing to the node to.
+func EdgeKey(typ, to string) string {
+	return typ + edgeSep + to
+}
+
+// Link creates a new request to put the edge e.
+// If item is not nil, its other attributes are stored with the edge.
+func (g Graph) Link(e Edge, item interface{}) *Put {
+	if item == nil {
+		item = map[string]*dynamodb.AttributeValue{}
+	}
+	p := g.table.Put(item)
+	if err := e.validate(); err != nil {
+		p.setError(err)
+		return p
+	}
+	if p.err == nil {
+		p.item[g.nodeKey] = &dynamodb.AttributeValue{S: aws.String(e.From)}
+		p.item[g.edgeKey] = &dynamodb.AttributeValue{S: aws.String(EdgeKey(e.Type, e.To))}
+	}
+	return p
+}
+
+// Unlink creates a new request to delete the edge e.
+func (g Graph) Unlink(e Edge) *Delete {
+	d := g.table.Delete(g.nodeKey, e.From).Range(g.edgeKey, EdgeKey(e.Type, e.To))
+	d.setError(e.validate())
+	return d
+}
+
+// Out creates a new request to query the edges starting from the node from.
+// If typ is not empty, only edges of that type are returned.
+func (g Graph) Out(from, typ string) *Query {
+	q := g.table.Get(g.nodeKey, from)
+	if typ != "" {
+		q.Range(g.edgeKey, BeginsWith, typ+edgeSep)
+	}
+	return q
+}
+
+// In creates a new request to query the edges of type typ pointing to the node to, using the reverse index.
+func (g Graph) In(to, typ string) *Query {
+	q := g.table.Get(g.edgeKey, EdgeKey(typ, to)).Index(g.reverse)
+	if g.reverse == "" {
+		q.setError(errors.New("dynamo: graph: In requires a reverse index"))
+	}
+	if typ == "" {
+		q.setError(errors.New("dynamo: graph: In requires an edge type"))
+	}
+	return q
+}
+
+// Edges runs q, which should query this graph, and returns its results as edges.
+func (g Graph) Edges(q *Query) ([]Edge, error) {
+	ctx, cancel := timeoutContext(q.timeout)
+	defer cancel()
+	return g.EdgesWithContext(ctx, q)
+}
+
+// EdgesWithContext runs q, which should query this graph, and returns its results as edges.
+func (g Graph) EdgesWithContext(ctx aws.Context, q *Query) ([]Edge, error) {
+	var edges []Edge
+	iter := &queryIter{
+		query:     q,
+		unmarshal: unmarshalRaw,
+		err:       q.err,
+		progress:  pageTracker{fn: q.onPage},
+	}
+	var item map[string]*dynamodb.AttributeValue
+	for iter.NextWithContext(ctx, &item) {
+		e, err := g.edge(item)
+		if err != nil {
+			return edges, err
+		}
+		edges = append(edges, e)
+	}
+	return edges, iter.Err()
+}
+
+// edge returns the edge stored as item.
+func (g Graph) edge(item map[string]*dynamodb.AttributeValue) (Edge, error) {
+	from, key := item[g.nodeKey], item[g.edgeKey]
+	if from == nil || from.S == nil || key == nil || key.S == nil {
+		return Edge{}, fmt.Errorf("dynamo: graph: item is missing string attributes %s and %s", g.nodeKey, g.edgeKey)
+	}
+	parts := strings.SplitN(*key.S, edgeSep, 2)
+	if len(parts) != 2 {
+		return Edge{}, fmt.Errorf("dynamo: graph: invalid edge key %q", *key.S)
+	}
+	return Edge{From: *from.S, Type: parts[0], To: parts[1]}, nil
+}
+
+func (e Edge) validate() error {
+	switch {
+	case e.From == "" || e.To == "":
+		return errors.New("dynamo: graph: edge is missing a node")
+	case e.Type == "":
+		return errors.New("dynamo: graph: edge is missing a type")
+	case strings.Contains(e.Type, edgeSep):
+		return fmt.Errorf("dynamo: graph: edge type %q can't contain %q", e.Type, edgeSep)
+	}
+	return nil
+}
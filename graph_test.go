@@ -0,0 +1,60 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestGraph(t *testing.T) {
+	edgeItem := func(from, key string) map[string]*dynamodb.AttributeValue {
+		return map[string]*dynamodb.AttributeValue{
+			"PK": {S: aws.String(from)},
+			"SK": {S: aws.String(key)},
+		}
+	}
+	client := fakeQueryClient{pages: [][]map[string]*dynamodb.AttributeValue{
+		{edgeItem("user#1", "follows#user#2"), edgeItem("user#1", "likes#post#3")},
+	}}
+	g := NewFromIface(client).Table("Social").Graph("PK", "SK").ReverseIndex("SK-PK-index")
+
+	link := g.Link(Edge{From: "user#1", Type: "follows", To: "user#2"}, struct{ Since int }{2019})
+	if link.err != nil {
+		t.Fatal(link.err)
+	}
+	if *link.item["SK"].S != "follows#user#2" || *link.item["Since"].N != "2019" {
+		t.Error("bad edge item:", link.item)
+	}
+	if link := g.Link(Edge{From: "a", Type: "follows", To: "b"}, nil); link.err != nil || len(link.item) != 2 {
+		t.Error("bad bare edge:", link.item, link.err)
+	}
+	if link := g.Link(Edge{From: "a", Type: "a#b", To: "b"}, nil); link.err == nil {
+		t.Error("expected error for type containing separator")
+	}
+
+	input := g.Out("user#1", "follows").queryInput()
+	if cond := input.KeyConditions["SK"]; cond == nil || *cond.AttributeValueList[0].S != "follows#" {
+		t.Error("bad edge type condition:", input.KeyConditions)
+	}
+	input = g.In("user#2", "follows").queryInput()
+	if *input.IndexName != "SK-PK-index" || *input.KeyConditions["SK"].AttributeValueList[0].S != "follows#user#2" {
+		t.Error("bad reverse query:", input)
+	}
+	if _, err := g.Edges(g.In("user#2", "")); err == nil {
+		t.Error("expected error for reverse query without type")
+	}
+
+	edges, err := g.Edges(g.Out("user#1", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Edge{
+		{From: "user#1", Type: "follows", To: "user#2"},
+		{From: "user#1", Type: "likes", To: "post#3"},
+	}
+	if !reflect.DeepEqual(edges, expected) {
+		t.Error("bad edges:", edges, "≠", expected)
+	}
+}
@@ -0,0 +1,60 @@
+package dynamo
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"golang.org/x/net/context"
+)
+
+// hedge calls f, and if it hasn't returned after delay, calls it again concurrently.
+// The first successful response wins and the other request is canceled.
+// If delay is zero, f is simply called once.
+// The hedged call's context is marked, so that it isn't coalesced with the call it hedges. See isHedged.
+func hedge(ctx aws.Context, delay time.Duration, f func(aws.Context) (interface{}, error)) (interface{}, error) {
+	if delay <= 0 {
+		return f(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		out interface{}
+		err error
+	}
+	results := make(chan result, 2)
+	call := func(ctx aws.Context) {
+		out, err := f(ctx)
+		results <- result{out, err}
+	}
+
+	go call(ctx)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	pending, hedged := 1, false
+	for {
+		select {
+		case res := <-results:
+			pending--
+			// an error is only final if there's no other request that could still succeed
+			if res.err == nil || pending == 0 {
+				return res.out, res.err
+			}
+		case <-timer.C:
+			if !hedged {
+				hedged = true
+				pending++
+				go call(context.WithValue(ctx, hedgedKey{}, true))
+			}
+		}
+	}
+}
+
+type hedgedKey struct{}
+
+// isHedged returns true if ctx is that of a hedged call.
+func isHedged(ctx aws.Context) bool {
+	hedged, _ := ctx.Value(hedgedKey{}).(bool)
+	return hedged
+}
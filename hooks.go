@@ -0,0 +1,111 @@
+package dynamo
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// WriteOp is the kind of write described by a WriteEvent.
+type WriteOp string
+
+const (
+	// WritePut is a Put, or a put in a BatchWrite or WriteTx.
+	WritePut WriteOp = "put"
+	// WriteUpdate is an Update, or an update in a WriteTx.
+	WriteUpdate WriteOp = "update"
+	// WriteDelete is a Delete, or a delete in a BatchWrite or WriteTx.
+	WriteDelete WriteOp = "delete"
+)
+
+// WriteEvent describes a write made through a DB. See DB.BeforeWrite and DB.AfterWrite.
+type WriteEvent struct {
+	// Op is the kind of write.
+	Op WriteOp
+	// Table is the name of the table written to.
+	Table string
+	// Key is the primary key of the written item.
+	// It is nil for puts, because the key schema of the table isn't known; the key is part of Item instead.
+	Key map[string]*dynamodb.AttributeValue
+	// Item is the item being put. For updates and deletes, it is only set in AfterWrite,
+	// to the values returned by DynamoDB, if any were requested (for example, with Update.Value or Delete.OldValue).
+	Item map[string]*dynamodb.AttributeValue
+	// Err is the result of the write. It is only set in AfterWrite.
+	Err error
+}
+
+// BeforeWrite returns a copy of this DB that calls fn before each write it makes:
+// every Put, Update, and Delete, and each item of a BatchWrite or WriteTx.
+// If fn returns an error, the write is not sent and that error is returned instead.
+// For batches and transactions, an error cancels the whole request.
+func (db *DB) BeforeWrite(fn func(WriteEvent) error) *DB {
+	cp := *db
+	cp.before = append(db.before[:len(db.before):len(db.before)], fn)
+	return &cp
+}
+
+// AfterWrite returns a copy of this DB that calls fn after each write it makes, with its result.
+// It is called whether or not the write succeeded.
+// For batches and transactions, fn is called for each item once the whole request has finished.
+func (db *DB) AfterWrite(fn func(WriteEvent)) *DB {
+	cp := *db
+	cp.after = append(db.after[:len(db.after):len(db.after)], fn)
+	return &cp
+}
+
+func (db *DB) beforeWrite(events ...WriteEvent) error {
+	if db.readOnly {
+		return ErrReadOnly
+	}
+	for _, ev := range events {
+		for _, fn := range db.before {
+			if err := fn(ev); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (db *DB) afterWrite(err error, events ...WriteEvent) {
+	for _, ev := range events {
+		ev.Err = err
+		for _, fn := range db.after {
+			fn(ev)
+		}
+	}
+}
+
+// hasWriteHooks returns true if any write hooks are set or the DB is read-only, so events can be skipped otherwise.
+func (db *DB) hasWriteHooks() bool {
+	return len(db.before) > 0 || len(db.after) > 0 || db.readOnly
+}
+
+// batchWriteEvents returns events for the write requests of a batch.
+func batchWriteEvents(table string, wrs []*dynamodb.WriteRequest) []WriteEvent {
+	events := make([]WriteEvent, 0, len(wrs))
+	for _, wr := range wrs {
+		switch {
+		case wr.PutRequest != nil:
+			events = append(events, WriteEvent{Op: WritePut, Table: table, Item: wr.PutRequest.Item})
+		case wr.DeleteRequest != nil:
+			events = append(events, WriteEvent{Op: WriteDelete, Table: table, Key: wr.DeleteRequest.Key})
+		}
+	}
+	return events
+}
+
+// txWriteEvents returns events for the writes of a transaction. Condition checks are skipped.
+func txWriteEvents(items []*dynamodb.TransactWriteItem) []WriteEvent {
+	events := make([]WriteEvent, 0, len(items))
+	for _, item := range items {
+		switch {
+		case item.Put != nil:
+			events = append(events, WriteEvent{Op: WritePut, Table: aws.StringValue(item.Put.TableName), Item: item.Put.Item})
+		case item.Update != nil:
+			events = append(events, WriteEvent{Op: WriteUpdate, Table: aws.StringValue(item.Update.TableName), Key: item.Update.Key})
+		case item.Delete != nil:
+			events = append(events, WriteEvent{Op: WriteDelete, Table: aws.StringValue(item.Delete.TableName), Key: item.Delete.Key})
+		}
+	}
+	return events
+}
@@ -0,0 +1,43 @@
+package dynamo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWriteHooks(t *testing.T) {
+	var events []WriteEvent
+	denied := errors.New("denied")
+	db := NewFromIface(newMemClient()).
+		BeforeWrite(func(ev WriteEvent) error {
+			if ev.Op == WritePut && *ev.Item["Msg"].S == "forbidden" {
+				return denied
+			}
+			return nil
+		}).
+		AfterWrite(func(ev WriteEvent) {
+			events = append(events, ev)
+		})
+	table := db.Table(testTable)
+
+	if err := table.Put(widget{UserID: 1, Msg: "hello"}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Put(widget{UserID: 2, Msg: "forbidden"}).Run(); err != denied {
+		t.Error("expected hook error, got", err)
+	}
+	var old widget
+	if err := table.Delete("UserID", 1).OldValue(&old); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 {
+		t.Fatal("expected 2 events, got", events)
+	}
+	if ev := events[0]; ev.Op != WritePut || ev.Table != testTable || *ev.Item["Msg"].S != "hello" || ev.Err != nil {
+		t.Error("bad put event:", ev)
+	}
+	if ev := events[1]; ev.Op != WriteDelete || *ev.Key["UserID"].N != "1" || *ev.Item["Msg"].S != "hello" {
+		t.Error("bad delete event:", ev)
+	}
+}
@@ -0,0 +1,79 @@
+package dynamo
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// HTTPOptions configures the HTTP client used to talk to DynamoDB. See HTTPConfig.
+// Zero values are replaced with defaults.
+type HTTPOptions struct {
+	// Timeout limits the time of a single HTTP request, including reading the response.
+	// Zero means no limit, leaving it to contexts and RetryTimeout.
+	Timeout time.Duration
+	// DialTimeout limits the time spent establishing a connection. Defaults to 10 seconds.
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout limits the time spent on the TLS handshake. Defaults to 10 seconds.
+	TLSHandshakeTimeout time.Duration
+	// KeepAlive is the interval between TCP keep-alive probes. Defaults to 30 seconds.
+	KeepAlive time.Duration
+	// IdleConnTimeout is how long idle connections are kept open. Defaults to 90 seconds.
+	IdleConnTimeout time.Duration
+	// MaxIdleConns limits the number of idle connections in total. Defaults to 100.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost limits the number of idle connections kept for each host.
+	// Defaults to MaxIdleConns. Go's default of 2 causes connections to be closed and reopened
+	// constantly when many requests run concurrently, such as with parallel batches.
+	MaxIdleConnsPerHost int
+	// TLSConfig is the TLS configuration for connections. If nil, the default configuration is used.
+	TLSConfig *tls.Config
+}
+
+// HTTPConfig returns configuration that makes requests with an HTTP client tuned by opts.
+// Pass it to New, for example:
+//
+//	db := dynamo.New(sess, dynamo.HTTPConfig(dynamo.HTTPOptions{MaxIdleConnsPerHost: 256}))
+func HTTPConfig(opts HTTPOptions) *aws.Config {
+	if opts.DialTimeout == 0 {
+		opts.DialTimeout = 10 * time.Second
+	}
+	if opts.TLSHandshakeTimeout == 0 {
+		opts.TLSHandshakeTimeout = 10 * time.Second
+	}
+	if opts.KeepAlive == 0 {
+		opts.KeepAlive = 30 * time.Second
+	}
+	if opts.IdleConnTimeout == 0 {
+		opts.IdleConnTimeout = 90 * time.Second
+	}
+	if opts.MaxIdleConns == 0 {
+		opts.MaxIdleConns = 100
+	}
+	if opts.MaxIdleConnsPerHost == 0 {
+		opts.MaxIdleConnsPerHost = opts.MaxIdleConns
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   opts.DialTimeout,
+			KeepAlive: opts.KeepAlive,
+		}).DialContext,
+		TLSClientConfig:       opts.TLSConfig,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	return &aws.Config{
+		HTTPClient: &http.Client{
+			Transport: transport,
+			Timeout:   opts.Timeout,
+		},
+	}
+}
@@ -0,0 +1,132 @@
+package dynamo
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Hydrate makes this query of a secondary index return the full items from the base table,
+// for indexes that only project keys or some attributes.
+// Each page of index results is followed by a BatchGet of the base table, and results keep the index's order.
+// Items deleted in between are skipped.
+// The BatchGet is strongly consistent if Consistent(true) is set or the table reads consistently by default;
+// for global secondary indexes, which don't support consistent reads, only the BatchGet is.
+// Filters are evaluated against the index, before hydration, and Project can't be used.
+// The table's keys are found with DescribeTable.
+func (q *Query) Hydrate() *Query {
+	q.hydrate = true
+	return q
+}
+
+// hydrator fetches the base table items of index query results.
+type hydrator struct {
+	hashKey  string
+	rangeKey string
+	global   bool
+}
+
+func (q *Query) newHydrator(ctx aws.Context) (*hydrator, error) {
+	if q.index == "" {
+		return nil, errors.New("dynamo: Hydrate requires querying an index")
+	}
+	if q.projection != "" {
+		return nil, errors.New("dynamo: Hydrate can't be used with Project")
+	}
+	desc, err := q.table.description(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h := &hydrator{hashKey: desc.HashKey, rangeKey: desc.RangeKey}
+	for _, index := range desc.GSI {
+		if index.Name == q.index {
+			h.global = true
+			return h, nil
+		}
+	}
+	for _, index := range desc.LSI {
+		if index.Name == q.index {
+			return h, nil
+		}
+	}
+	return nil, fmt.Errorf("dynamo: Hydrate: table %s has no index %s", q.table.Name(), q.index)
+}
+
+// input returns the input for querying the index, which only needs the base table's keys.
+func (h *hydrator) input(q *Query) *dynamodb.QueryInput {
+	cp := *q
+	cp.projection = cp.projectKeys(h.hashKey, h.rangeKey)
+	input := cp.queryInput()
+	if h.global {
+		input.ConsistentRead = nil
+	}
+	return input
+}
+
+// hydrate gets the base table items of the given index items, in the same order.
+func (h *hydrator) hydrate(ctx aws.Context, q *Query, items []map[string]*dynamodb.AttributeValue) ([]map[string]*dynamodb.AttributeValue, error) {
+	if len(items) == 0 {
+		return items, nil
+	}
+	keyNames := []string{h.hashKey}
+	if h.rangeKey != "" {
+		keyNames = append(keyNames, h.rangeKey)
+	}
+	keys := make([]Keyed, 0, len(items))
+	for _, item := range items {
+		if h.rangeKey != "" {
+			keys = append(keys, Keys{item[h.hashKey], item[h.rangeKey]})
+		} else {
+			keys = append(keys, Keys{item[h.hashKey]})
+		}
+	}
+	bg := q.table.Batch(keyNames...).Get(keys...).AllowEmpty(true)
+	if q.consistent != nil {
+		bg.Consistent(*q.consistent)
+	}
+	if q.includeDeleted {
+		bg.IncludeDeleted()
+	}
+	if q.cc != nil {
+		bg.ConsumedCapacity(q.cc)
+	}
+	var found []map[string]*dynamodb.AttributeValue
+	if err := bg.AllWithContext(ctx, &found); err != nil {
+		return nil, err
+	}
+
+	keyOf := ItemKey(keyNames...)
+	byKey := make(map[string]map[string]*dynamodb.AttributeValue, len(found))
+	for _, item := range found {
+		byKey[keyOf(item)] = item
+	}
+	hydrated := make([]map[string]*dynamodb.AttributeValue, 0, len(found))
+	for _, item := range items {
+		if full, ok := byKey[keyOf(item)]; ok {
+			hydrated = append(hydrated, full)
+		}
+	}
+	return hydrated, nil
+}
+
+// oneHydrated is OneWithContext for queries with Hydrate.
+func (q *Query) oneHydrated(ctx aws.Context, out interface{}) error {
+	iter := q.Iter()
+	var item map[string]*dynamodb.AttributeValue
+	if !iter.NextWithContext(ctx, &item) {
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+	var extra map[string]*dynamodb.AttributeValue
+	if iter.NextWithContext(ctx, &extra) {
+		return ErrTooMany
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	return q.table.db.codec().unmarshalItem(item, out)
+}
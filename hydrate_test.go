@@ -0,0 +1,120 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// hydrateClient is a fake client that describes a table, queries an index in a single page of keys,
+// and batch gets the full items from the base table.
+type hydrateClient struct {
+	*describeClient
+	keys       []map[string]*dynamodb.AttributeValue
+	items      []map[string]*dynamodb.AttributeValue
+	query      *dynamodb.QueryInput
+	projection string
+	consistent *bool
+}
+
+func (c *hydrateClient) QueryWithContext(_ aws.Context, input *dynamodb.QueryInput, _ ...request.Option) (*dynamodb.QueryOutput, error) {
+	c.query = input
+	c.projection = unsubstitute(aws.StringValue(input.ProjectionExpression), input.ExpressionAttributeNames)
+	return &dynamodb.QueryOutput{Items: c.keys, Count: aws.Int64(int64(len(c.keys)))}, nil
+}
+
+func (c *hydrateClient) BatchGetItemWithContext(_ aws.Context, input *dynamodb.BatchGetItemInput, _ ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	var found []map[string]*dynamodb.AttributeValue
+	for _, ka := range input.RequestItems {
+		c.consistent = ka.ConsistentRead
+		// answer in reverse, as DynamoDB doesn't keep the order of keys
+		for i := len(ka.Keys) - 1; i >= 0; i-- {
+			for _, item := range c.items {
+				if keyString(ka.Keys[i]) == keyString(map[string]*dynamodb.AttributeValue{"UserID": item["UserID"], "Time": item["Time"]}) {
+					found = append(found, item)
+				}
+			}
+		}
+	}
+	return &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]*dynamodb.AttributeValue{testTable: found}}, nil
+}
+
+func TestQueryHydrate(t *testing.T) {
+	now := time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+	widgets := []widget{
+		{UserID: 1, Time: now, Msg: "first"},
+		{UserID: 2, Time: now.Add(time.Second), Msg: "deleted"},
+		{UserID: 3, Time: now.Add(2 * time.Second), Msg: "third"},
+	}
+	client := &hydrateClient{
+		describeClient: newDescribeClient(&dynamodb.TableDescription{
+			TableName: aws.String(testTable),
+			KeySchema: keySchema("UserID", "Time"),
+			GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{{
+				IndexName:   aws.String("Msg-index"),
+				IndexArn:    aws.String("arn"),
+				IndexStatus: aws.String("ACTIVE"),
+				KeySchema:   keySchema("Msg", ""),
+			}},
+		}),
+	}
+	for i, w := range widgets {
+		item, err := marshalItem(w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.keys = append(client.keys, map[string]*dynamodb.AttributeValue{"UserID": item["UserID"], "Time": item["Time"]})
+		if i != 1 {
+			client.items = append(client.items, item)
+		}
+	}
+	table := NewFromIface(client).Table(testTable)
+
+	var result []widget
+	err := table.Get("Msg", "x").Index("Msg-index").Consistent(true).Hydrate().All(&result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []widget{widgets[0], widgets[2]}
+	if !reflect.DeepEqual(result, expect) {
+		t.Errorf("bad result: %+v ≠ %+v", result, expect)
+	}
+	if client.query.ConsistentRead != nil {
+		t.Error("global index query shouldn't be consistent:", *client.query.ConsistentRead)
+	}
+	if client.projection != "UserID, Time" {
+		t.Error("bad projection:", client.projection)
+	}
+	if !aws.BoolValue(client.consistent) {
+		t.Error("batch get should be consistent")
+	}
+
+	var one widget
+	err = table.Get("Msg", "x").Index("Msg-index").Hydrate().One(&one)
+	if err != ErrTooMany {
+		t.Error("expected ErrTooMany, got", err)
+	}
+
+	t.Run("no index", func(t *testing.T) {
+		err := table.Get("UserID", 1).Hydrate().All(&result)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+	t.Run("unknown index", func(t *testing.T) {
+		err := table.Get("Msg", "x").Index("Nope-index").Hydrate().All(&result)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+	t.Run("project", func(t *testing.T) {
+		err := table.Get("Msg", "x").Index("Msg-index").Project("Msg").Hydrate().All(&result)
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
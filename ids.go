@@ -0,0 +1,244 @@
+package dynamo
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ULID is a Universally Unique Lexicographically Sortable Identifier.
+// It holds a millisecond timestamp followed by 80 random bits,
+// and sorts in time order as a string, making it a good range key for time-ordered items.
+// ULIDs are encoded as 26 character strings using Crockford's base32.
+// Tag a string or ULID field with ulid, like `dynamo:",ulid"`, to generate one on Put when the field is empty.
+// See: https://github.com/ulid/spec
+type ULID [16]byte
+
+const ulidAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID generates a new ULID for the current time.
+// It panics if the system's secure random number generator fails.
+func NewULID() ULID {
+	id, err := newULID(time.Now())
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// ULIDAt returns the smallest ULID with the given time.
+// It is useful for querying ranges of ULIDs, for example:
+//	table.Get("UserID", 42).Range("ID", dynamo.Between, dynamo.ULIDAt(start), dynamo.ULIDAt(end))
+func ULIDAt(t time.Time) ULID {
+	var id ULID
+	putULIDTime(&id, t)
+	return id
+}
+
+func newULID(t time.Time) (ULID, error) {
+	var id ULID
+	putULIDTime(&id, t)
+	if _, err := rand.Read(id[6:]); err != nil {
+		return ULID{}, fmt.Errorf("dynamo: generating ULID: %v", err)
+	}
+	return id, nil
+}
+
+func putULIDTime(id *ULID, t time.Time) {
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	for i := 5; i >= 0; i-- {
+		id[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+// ParseULID parses a ULID from its string form. Lowercase letters are accepted.
+func ParseULID(s string) (ULID, error) {
+	var id ULID
+	if len(s) != 26 {
+		return id, fmt.Errorf("dynamo: invalid ULID %q: must be 26 characters", s)
+	}
+	if err := decodeID(id[:], strings.ToUpper(s), ulidAlphabet); err != nil {
+		return ULID{}, fmt.Errorf("dynamo: invalid ULID %q: %v", s, err)
+	}
+	return id, nil
+}
+
+// Time returns the timestamp of this ULID, with millisecond precision.
+func (id ULID) Time() time.Time {
+	var ms int64
+	for _, b := range id[:6] {
+		ms = ms<<8 | int64(b)
+	}
+	return time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond))
+}
+
+// IsZero returns true if this is the zero ULID.
+func (id ULID) IsZero() bool {
+	return id == ULID{}
+}
+
+func (id ULID) String() string {
+	return encodeID(id[:], ulidAlphabet, 26)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (id ULID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (id *ULID) UnmarshalText(text []byte) error {
+	parsed, err := ParseULID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// KSUID is a K-Sortable Unique Identifier.
+// It holds a timestamp in seconds followed by 128 random bits,
+// and sorts in time order as a string.
+// KSUIDs are encoded as 27 character strings using base62.
+// Tag a string or KSUID field with ksuid, like `dynamo:",ksuid"`, to generate one on Put when the field is empty.
+// See: https://github.com/segmentio/ksuid
+type KSUID [20]byte
+
+const (
+	ksuidAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	ksuidEpoch    = 1400000000
+)
+
+// NewKSUID generates a new KSUID for the current time.
+// It panics if the system's secure random number generator fails.
+func NewKSUID() KSUID {
+	id, err := newKSUID(time.Now())
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// KSUIDAt returns the smallest KSUID with the given time.
+// It is useful for querying ranges of KSUIDs.
+func KSUIDAt(t time.Time) KSUID {
+	var id KSUID
+	binary.BigEndian.PutUint32(id[:4], uint32(t.Unix()-ksuidEpoch))
+	return id
+}
+
+func newKSUID(t time.Time) (KSUID, error) {
+	id := KSUIDAt(t)
+	if _, err := rand.Read(id[4:]); err != nil {
+		return KSUID{}, fmt.Errorf("dynamo: generating KSUID: %v", err)
+	}
+	return id, nil
+}
+
+// ParseKSUID parses a KSUID from its string form.
+func ParseKSUID(s string) (KSUID, error) {
+	var id KSUID
+	if len(s) != 27 {
+		return id, fmt.Errorf("dynamo: invalid KSUID %q: must be 27 characters", s)
+	}
+	if err := decodeID(id[:], s, ksuidAlphabet); err != nil {
+		return KSUID{}, fmt.Errorf("dynamo: invalid KSUID %q: %v", s, err)
+	}
+	return id, nil
+}
+
+// Time returns the timestamp of this KSUID, with second precision.
+func (id KSUID) Time() time.Time {
+	return time.Unix(int64(binary.BigEndian.Uint32(id[:4]))+ksuidEpoch, 0)
+}
+
+// IsZero returns true if this is the zero KSUID.
+func (id KSUID) IsZero() bool {
+	return id == KSUID{}
+}
+
+func (id KSUID) String() string {
+	return encodeID(id[:], ksuidAlphabet, 27)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (id KSUID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (id *KSUID) UnmarshalText(text []byte) error {
+	parsed, err := ParseKSUID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// encodeID encodes b as a fixed-length, zero-padded number in the given alphabet.
+func encodeID(b []byte, alphabet string, length int) string {
+	n := new(big.Int).SetBytes(b)
+	base := big.NewInt(int64(len(alphabet)))
+	mod := new(big.Int)
+	out := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = alphabet[mod.Int64()]
+	}
+	return string(out)
+}
+
+// decodeID decodes s, a number in the given alphabet, into b.
+func decodeID(b []byte, s, alphabet string) error {
+	n := new(big.Int)
+	base := big.NewInt(int64(len(alphabet)))
+	for _, r := range s {
+		i := strings.IndexRune(alphabet, r)
+		if i < 0 {
+			return fmt.Errorf("invalid character %q", r)
+		}
+		n.Mul(n, base)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+	if n.BitLen() > len(b)*8 {
+		return fmt.Errorf("value out of range")
+	}
+	raw := n.Bytes()
+	copy(b[len(b)-len(raw):], raw)
+	return nil
+}
+
+var (
+	ulidType  = reflect.TypeOf(ULID{})
+	ksuidType = reflect.TypeOf(KSUID{})
+)
+
+// newID generates a ULID or KSUID, as specified by special, for a field of type rt.
+func newID(special string, rt reflect.Type, now time.Time) (reflect.Value, error) {
+	var id fmt.Stringer
+	var idType reflect.Type
+	var err error
+	switch special {
+	case "ulid":
+		id, err = newULID(now)
+		idType = ulidType
+	case "ksuid":
+		id, err = newKSUID(now)
+		idType = ksuidType
+	}
+	switch {
+	case err != nil:
+		return reflect.Value{}, err
+	case rt == idType:
+		return reflect.ValueOf(id), nil
+	case rt.Kind() == reflect.String:
+		return reflect.ValueOf(id.String()).Convert(rt), nil
+	}
+	return reflect.Value{}, fmt.Errorf("dynamo: %s field must be a string or %v, not %v", special, idType, rt)
+}
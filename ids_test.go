@@ -0,0 +1,89 @@
+package dynamo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestULID(t *testing.T) {
+	const example = "01ARZ3NDEKTSV4RRFFQ69G5FAV"
+	id, err := ParseULID(example)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.String() != example {
+		t.Error("bad round trip:", id, "≠", example)
+	}
+	if ms := id.Time().UnixNano() / int64(time.Millisecond); ms != 1469922850259 {
+		t.Error("bad time:", ms)
+	}
+	if lower, err := ParseULID("01arz3ndektsv4rrffq69g5fav"); err != nil || lower != id {
+		t.Error("lowercase parse failed:", lower, err)
+	}
+	for _, bad := range []string{"", "01ARZ3NDEKTSV4RRFFQ69G5FA", "81ARZ3NDEKTSV4RRFFQ69G5FAV", "01ARZ3NDEKTSV4RRFFQ69G5FAU"} {
+		if _, err := ParseULID(bad); err == nil {
+			t.Error("expected error parsing", bad)
+		}
+	}
+
+	now := time.Now()
+	a, b := ULIDAt(now), NewULID()
+	if !(a.String() <= b.String()) || !(b.String() < ULIDAt(now.Add(time.Second)).String()) {
+		t.Error("ULIDs not sorted by time:", a, b)
+	}
+}
+
+func TestKSUID(t *testing.T) {
+	const example = "0ujtsYcgvSTl8PAuAdqWYSMnLOv"
+	id, err := ParseKSUID(example)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.String() != example {
+		t.Error("bad round trip:", id, "≠", example)
+	}
+	if ts := id.Time().Unix() - ksuidEpoch; ts != 107608047 {
+		t.Error("bad timestamp:", ts)
+	}
+	if _, err := ParseKSUID("zzzzzzzzzzzzzzzzzzzzzzzzzzz"); err == nil {
+		t.Error("expected out of range error")
+	}
+
+	now := time.Now()
+	if a, b := NewKSUID(), KSUIDAt(now.Add(time.Hour)); !(a.String() < b.String()) {
+		t.Error("KSUIDs not sorted by time:", a, b)
+	}
+}
+
+func TestIDTags(t *testing.T) {
+	type event struct {
+		UserID int
+		ID     ULID   `dynamo:",ulid"`
+		Ref    string `dynamo:",ksuid"`
+		Keep   string `dynamo:",ulid"`
+	}
+	table := NewFromIface(newMemClient()).Table(testTable)
+
+	e := event{UserID: 42, Keep: "existing"}
+	put := table.Put(&e)
+	if put.err != nil {
+		t.Fatal(put.err)
+	}
+	if e.ID.IsZero() || len(e.Ref) != 27 || e.Keep != "existing" {
+		t.Error("bad generated IDs:", e)
+	}
+	var decoded event
+	if err := unmarshalItem(put.item, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != e {
+		t.Error("encoded item doesn't match:", decoded, "≠", e)
+	}
+
+	if put := table.Put(struct {
+		UserID int
+		ID     int `dynamo:",ulid"`
+	}{UserID: 1}); put.err == nil {
+		t.Error("expected error for int ULID field")
+	}
+}
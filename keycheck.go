@@ -0,0 +1,169 @@
+package dynamo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// ValidateKeys makes this batch check its keys against the table's key schema before requesting them,
+// describing the table if necessary (see DB.CacheDescriptions).
+// Keys missing a key attribute, with extra attributes, or whose values don't match the type of the table's keys
+// fail with an error naming the offending key, instead of DynamoDB rejecting the whole request with a ValidationException.
+func (bg *BatchGet) ValidateKeys() *BatchGet {
+	bg.validateKeys = true
+	return bg
+}
+
+// ValidateKeys makes this batch check the keys of its puts and deletes against the table's key schema before writing any of them.
+// See BatchGet.ValidateKeys.
+func (bw *BatchWrite) ValidateKeys() *BatchWrite {
+	bw.validateKeys = true
+	return bw
+}
+
+// keyChecker checks keys against the key schema of a table.
+type keyChecker struct {
+	desc   Description
+	schema map[string]KeyType
+}
+
+func newKeyChecker(ctx aws.Context, table Table) (keyChecker, error) {
+	desc, err := table.description(ctx)
+	if err != nil {
+		return keyChecker{}, err
+	}
+	schema := map[string]KeyType{desc.HashKey: desc.HashKeyType}
+	if desc.RangeKey != "" {
+		schema[desc.RangeKey] = desc.RangeKeyType
+	}
+	return keyChecker{desc: desc, schema: schema}, nil
+}
+
+// check returns an error naming key, the ith of its batch, if it doesn't match the key schema.
+// If exact is false, key is an item, which may have other attributes.
+func (kc keyChecker) check(key map[string]*dynamodb.AttributeValue, i int, exact bool) error {
+	if err := kc.mismatch(key, exact); err != nil {
+		return fmt.Errorf("dynamo: batch: %s: %v", kc.label(key, i), err)
+	}
+	return nil
+}
+
+// mismatch describes how key doesn't match the key schema, if it doesn't.
+func (kc keyChecker) mismatch(key map[string]*dynamodb.AttributeValue, exact bool) error {
+	desc := kc.desc
+	for _, name := range []string{desc.HashKey, desc.RangeKey} {
+		if name == "" {
+			continue
+		}
+		kind := "hash"
+		if name == desc.RangeKey {
+			kind = "range"
+		}
+		av, ok := key[name]
+		if !ok || av == nil {
+			return fmt.Errorf("missing %s key %s", kind, name)
+		}
+		if want := kc.schema[name]; want != NoneType && keyTypeOf(av) != want {
+			return fmt.Errorf("%s key %s must be %s, got %s", kind, name, keyTypeName(want), avTypeName(av))
+		}
+	}
+	if exact {
+		for name := range key {
+			if _, ok := kc.schema[name]; !ok {
+				return fmt.Errorf("%s is not a key attribute of table %s", name, desc.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// label identifies the key at index i by its key attributes, if it has any, such as: key UserID 1, Time "a".
+func (kc keyChecker) label(key map[string]*dynamodb.AttributeValue, i int) string {
+	var attrs []string
+	for _, name := range []string{kc.desc.HashKey, kc.desc.RangeKey} {
+		av, ok := key[name]
+		if name == "" || !ok || av == nil {
+			continue
+		}
+		switch {
+		case av.S != nil:
+			attrs = append(attrs, fmt.Sprintf("%s %q", name, *av.S))
+		case av.N != nil:
+			attrs = append(attrs, name+" "+*av.N)
+		default:
+			attrs = append(attrs, name+" ("+avTypeName(av)+")")
+		}
+	}
+	if len(attrs) == 0 {
+		return fmt.Sprintf("item %d", i)
+	}
+	return "key " + strings.Join(attrs, ", ")
+}
+
+// keyTypeOf returns the key type of av, or NoneType if it can't be a key.
+func keyTypeOf(av *dynamodb.AttributeValue) KeyType {
+	switch {
+	case av.S != nil:
+		return StringType
+	case av.N != nil:
+		return NumberType
+	case av.B != nil:
+		return BinaryType
+	}
+	return NoneType
+}
+
+func keyTypeName(kt KeyType) string {
+	switch kt {
+	case StringType:
+		return "string"
+	case NumberType:
+		return "number"
+	case BinaryType:
+		return "binary"
+	}
+	return string(kt)
+}
+
+// validate checks the keys of this batch's puts and deletes, if ValidateKeys is set.
+func (bw *BatchWrite) validate(ctx aws.Context) error {
+	if !bw.validateKeys {
+		return nil
+	}
+	kc, err := newKeyChecker(ctx, bw.batch.table)
+	if err != nil {
+		return err
+	}
+	for i, op := range bw.ops {
+		switch {
+		case op.PutRequest != nil:
+			err = kc.check(op.PutRequest.Item, i, false)
+		case op.DeleteRequest != nil:
+			err = kc.check(op.DeleteRequest.Key, i, true)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate checks the keys of this batch, if ValidateKeys is set.
+func (bg *BatchGet) validate(ctx aws.Context) error {
+	if !bg.validateKeys {
+		return nil
+	}
+	kc, err := newKeyChecker(ctx, bg.batch.table)
+	if err != nil {
+		return err
+	}
+	for i, get := range bg.reqs {
+		if err := kc.check(get.key, i, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
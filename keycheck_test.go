@@ -0,0 +1,90 @@
+package dynamo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// keyCheckClient is a fake client that describes a table and counts the batch requests sent to it.
+type keyCheckClient struct {
+	*describeClient
+	batches int
+}
+
+func (c *keyCheckClient) BatchGetItemWithContext(aws.Context, *dynamodb.BatchGetItemInput, ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	c.batches++
+	return &dynamodb.BatchGetItemOutput{}, nil
+}
+
+func (c *keyCheckClient) BatchWriteItemWithContext(aws.Context, *dynamodb.BatchWriteItemInput, ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	c.batches++
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func TestBatchValidateKeys(t *testing.T) {
+	client := &keyCheckClient{describeClient: newDescribeClient(&dynamodb.TableDescription{
+		TableName: aws.String(testTable),
+		KeySchema: keySchema("UserID", "Time"),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("UserID"), AttributeType: aws.String("N")},
+			{AttributeName: aws.String("Time"), AttributeType: aws.String("S")},
+		},
+	})}
+	table := NewFromIface(client).Table(testTable)
+
+	var results []widget
+	err := table.Batch("UserID", "Time").Get(Keys{1, "a"}, Keys{"2", "b"}).ValidateKeys().All(&results)
+	if expect := `dynamo: batch: key UserID "2", Time "b": hash key UserID must be number, got string`; err == nil || err.Error() != expect {
+		t.Errorf("bad error: %v ≠ %s", err, expect)
+	}
+	err = table.Batch("UserID").Get(Keys{1}).ValidateKeys().All(&results)
+	if expect := `dynamo: batch: key UserID 1: missing range key Time`; err == nil || err.Error() != expect {
+		t.Errorf("bad error: %v ≠ %s", err, expect)
+	}
+	err = table.Batch("ID", "Time").Get(Keys{1, "a"}).ValidateKeys().All(&results)
+	if expect := `dynamo: batch: key Time "a": missing hash key UserID`; err == nil || err.Error() != expect {
+		t.Errorf("bad error: %v ≠ %s", err, expect)
+	}
+	if client.batches != 0 {
+		t.Error("invalid batches were sent:", client.batches)
+	}
+	err = table.Batch("UserID", "Time").Get(Keys{1, "a"}).ValidateKeys().All(&results)
+	if err != ErrNotFound {
+		t.Error("expected ErrNotFound, got", err)
+	}
+	if client.batches != 1 {
+		t.Error("valid batch wasn't sent:", client.batches)
+	}
+
+	client.batches = 0
+	type badTime struct {
+		UserID int
+		Time   int
+	}
+	_, err = table.Batch("UserID", "Time").Write().
+		Put(widget{UserID: 1, Time: time.Unix(0, 0)}).
+		Put(badTime{UserID: 2, Time: 3}).
+		ValidateKeys().Run()
+	if expect := `dynamo: batch: key UserID 2, Time 3: range key Time must be string, got number`; err == nil || err.Error() != expect {
+		t.Errorf("bad error: %v ≠ %s", err, expect)
+	}
+	_, err = table.Batch("UserID").Write().Delete(Keys{1}).ValidateKeys().Run()
+	if expect := `dynamo: batch: key UserID 1: missing range key Time`; err == nil || err.Error() != expect {
+		t.Errorf("bad error: %v ≠ %s", err, expect)
+	}
+	if client.batches != 0 {
+		t.Error("invalid batches were sent:", client.batches)
+	}
+
+	// off by default
+	if _, err := table.Batch("UserID").Write().Delete(Keys{1}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if client.batches != 1 {
+		t.Error("batch wasn't sent:", client.batches)
+	}
+}
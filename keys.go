@@ -1,5 +1,14 @@
 package dynamo
 
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
 // KeyType is used to specify the type of hash and range keys for tables and indexes.
 type KeyType string
 
@@ -18,6 +27,7 @@ type Keyed interface {
 }
 
 // Keys provides an easy way to specify the hash and range keys.
+// Its values are positional, so consider Key to avoid mixing them up.
 //	table.Batch("ID", "Month").
 //		Get([]dynamo.Keys{{1, "2015-10"}, {42, "2015-12"}, {42, "1992-02"}}...).
 //		All(&results)
@@ -28,3 +38,126 @@ func (k Keys) HashKey() interface{} { return k[0] }
 
 // RangeKey returns the range key's value.
 func (k Keys) RangeKey() interface{} { return k[1] }
+
+// Key specifies the hash and range keys by name, preventing them from being swapped by mistake.
+// Leave Range nil for tables without a range key.
+//	table.Batch("ID", "Month").
+//		Get(dynamo.Key{Hash: 1, Range: "2015-10"}, dynamo.HashRangeKey(42, "2015-12")).
+//		All(&results)
+type Key struct {
+	Hash  interface{}
+	Range interface{}
+}
+
+// HashKey returns a key with only a hash key, for tables without a range key.
+func HashKey(hash interface{}) Key {
+	return Key{Hash: hash}
+}
+
+// HashRangeKey returns a key with the given hash key and range key.
+func HashRangeKey(hash, rangeKey interface{}) Key {
+	return Key{Hash: hash, Range: rangeKey}
+}
+
+// HashKey returns the hash key's value.
+func (k Key) HashKey() interface{} { return k.Hash }
+
+// RangeKey returns the range key's value.
+func (k Key) RangeKey() interface{} { return k.Range }
+
+// KeySchema describes the primary key of a table, as tagged in a model. See KeySchemaOf.
+type KeySchema struct {
+	// HashKey is the attribute name of the hash key (a.k.a. partition key).
+	HashKey     string
+	HashKeyType KeyType
+	// RangeKey is the attribute name of the range key (a.k.a. sort key), or blank if there is none.
+	RangeKey     string
+	RangeKeyType KeyType
+}
+
+// KeySchemaOf returns the hash and range keys tagged in model, which must be a struct or a pointer to one.
+// Keys are found from the same struct tags as used by CreateTable, such as `dynamo:"ID,hash"` and `dynamo:",range"`.
+// It returns an error if model doesn't tag a hash key.
+func KeySchemaOf(model interface{}) (KeySchema, error) {
+	ct, err := modelSchema(model)
+	if err != nil {
+		return KeySchema{}, err
+	}
+	ks := keySchemaFrom(ct.attribs, ct.schema)
+	if ks.HashKey == "" {
+		return KeySchema{}, fmt.Errorf("dynamo: key schema: %T has no hash key tag", model)
+	}
+	return ks, nil
+}
+
+// IndexSchemaOf returns the hash and range keys of the given secondary index tagged in model,
+// such as with `index:"GSI1,hash"` or `localIndex:"LSI1,range"`, as used by CreateTable.
+// Local secondary indexes share the table's hash key, so it only needs to be tagged on the table.
+// It returns an error if model doesn't tag the index.
+func IndexSchemaOf(model interface{}, index string) (KeySchema, error) {
+	ct, err := modelSchema(model)
+	if err != nil {
+		return KeySchema{}, err
+	}
+	if gsi, ok := ct.globalIndices[index]; ok {
+		return keySchemaFrom(ct.attribs, gsi.KeySchema), nil
+	}
+	if lsi, ok := ct.localIndices[index]; ok {
+		ks := keySchemaFrom(ct.attribs, lsi.KeySchema)
+		if ks.HashKey == "" {
+			table := keySchemaFrom(ct.attribs, ct.schema)
+			ks.HashKey, ks.HashKeyType = table.HashKey, table.HashKeyType
+		}
+		return ks, nil
+	}
+	return KeySchema{}, fmt.Errorf("dynamo: key schema: %T has no index %s", model, index)
+}
+
+// modelSchema returns a CreateTable holding the keys and indexes tagged in model.
+func modelSchema(model interface{}) (*CreateTable, error) {
+	rv := reflect.ValueOf(model)
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("dynamo: key schema: model must be a struct, not %T", model)
+	}
+	ct := &CreateTable{
+		globalIndices: make(map[string]dynamodb.GlobalSecondaryIndex),
+		localIndices:  make(map[string]dynamodb.LocalSecondaryIndex),
+	}
+	if err := ct.from(rv, ""); err != nil {
+		return nil, err
+	}
+	return ct, nil
+}
+
+func keySchemaFrom(attribs []*dynamodb.AttributeDefinition, schema []*dynamodb.KeySchemaElement) KeySchema {
+	var ks KeySchema
+	ks.HashKey, ks.RangeKey = schemaKeys(schema)
+	ks.HashKeyType = lookupADType(attribs, ks.HashKey)
+	ks.RangeKeyType = lookupADType(attribs, ks.RangeKey)
+	return ks
+}
+
+// marshalKey marshals a key value, skipping reflection for the types keys usually are.
+// Anything else, including named types that might implement Marshaler, is marshaled as usual.
+func (c codec) marshalKey(v interface{}) (*dynamodb.AttributeValue, error) {
+	if av, ok, err := c.encodeRegistered(v); ok {
+		return av, err
+	}
+	switch x := v.(type) {
+	case *dynamodb.AttributeValue:
+		return x, nil
+	case string:
+		if x == "" {
+			return nil, nil
+		}
+		return &dynamodb.AttributeValue{S: aws.String(x)}, nil
+	case int:
+		return &dynamodb.AttributeValue{N: aws.String(strconv.Itoa(x))}, nil
+	case int64:
+		return &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(x, 10))}, nil
+	}
+	return c.marshal(v, "")
+}
@@ -0,0 +1,85 @@
+package dynamo
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// AllKeys executes this request and returns the primary keys of every result.
+// Only the table's key attributes, as reported by DescribeTable, are requested.
+// Key values are returned as *dynamodb.AttributeValue, so they can be passed back to Delete, Update, or Batch unchanged.
+// This is useful for pipelines that only need keys, such as bulk deletes.
+func (q *Query) AllKeys() ([]Keys, error) {
+	ctx, cancel := timeoutContext(q.timeout)
+	defer cancel()
+	return q.AllKeysWithContext(ctx)
+}
+
+// AllKeysWithContext executes this request and returns the primary keys of every result.
+// See AllKeys.
+func (q *Query) AllKeysWithContext(ctx aws.Context) ([]Keys, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	desc, err := q.table.description(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cp := *q
+	cp.projection = cp.projectKeys(desc.HashKey, desc.RangeKey)
+	return collectKeys(ctx, cp.Iter(), desc.HashKey, desc.RangeKey)
+}
+
+// AllKeys executes this request and returns the primary keys of every result.
+// Only the table's key attributes, as reported by DescribeTable, are requested.
+// Key values are returned as *dynamodb.AttributeValue, so they can be passed back to Delete, Update, or Batch unchanged.
+// This is useful for pipelines that only need keys, such as bulk deletes.
+func (s *Scan) AllKeys() ([]Keys, error) {
+	ctx, cancel := timeoutContext(s.timeout)
+	defer cancel()
+	return s.AllKeysWithContext(ctx)
+}
+
+// AllKeysWithContext executes this request and returns the primary keys of every result.
+// See AllKeys.
+func (s *Scan) AllKeysWithContext(ctx aws.Context) ([]Keys, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	desc, err := s.table.description(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cp := *s
+	cp.projection = cp.projectKeys(desc.HashKey, desc.RangeKey)
+	return collectKeys(ctx, cp.Iter(), desc.HashKey, desc.RangeKey)
+}
+
+// projectKeys returns a projection of the given keys.
+// It copies the substituted names first, so the request it was copied from is left untouched.
+func (s *subber) projectKeys(hashKey, rangeKey string) string {
+	names := make(map[string]*string, len(s.nameExpr)+2)
+	for k, v := range s.nameExpr {
+		names[k] = v
+	}
+	s.nameExpr = names
+	expr := s.subName(hashKey)
+	if rangeKey != "" {
+		expr += ", " + s.subName(rangeKey)
+	}
+	return expr
+}
+
+func collectKeys(ctx aws.Context, iter Iter, hashKey, rangeKey string) ([]Keys, error) {
+	var keys []Keys
+	var item map[string]*dynamodb.AttributeValue
+	for iter.NextWithContext(ctx, &item) {
+		key := Keys{item[hashKey]}
+		if rangeKey != "" {
+			key[1] = item[rangeKey]
+		}
+		keys = append(keys, key)
+		item = nil
+	}
+	return keys, iter.Err()
+}
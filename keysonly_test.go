@@ -0,0 +1,67 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// keysClient is a fake client that describes a table and scans it in a single page,
+// recording the projection requested.
+type keysClient struct {
+	*describeClient
+	items      []map[string]*dynamodb.AttributeValue
+	projection string
+}
+
+func (c *keysClient) ScanWithContext(_ aws.Context, input *dynamodb.ScanInput, _ ...request.Option) (*dynamodb.ScanOutput, error) {
+	c.projection = unsubstitute(aws.StringValue(input.ProjectionExpression), input.ExpressionAttributeNames)
+	return &dynamodb.ScanOutput{Items: c.items}, nil
+}
+
+func TestScanAllKeys(t *testing.T) {
+	client := &keysClient{
+		describeClient: newDescribeClient(&dynamodb.TableDescription{
+			TableName: aws.String(testTable),
+			KeySchema: keySchema("UserID", "Time"),
+		}),
+	}
+	for _, w := range []widget{{UserID: 1, Msg: "a"}, {UserID: 2, Msg: "b"}} {
+		item, err := marshalItem(w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.items = append(client.items, item)
+	}
+	table := NewFromIface(client).Table(testTable)
+
+	scan := table.Scan().Filter("'Count' = ?", 0)
+	keys, err := scan.AllKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.projection != "UserID, Time" {
+		t.Error("expected key-only projection, got", client.projection)
+	}
+	if len(keys) != 2 {
+		t.Fatal("expected 2 keys, got", len(keys))
+	}
+	if !reflect.DeepEqual(keys[1].HashKey(), client.items[1]["UserID"]) || !reflect.DeepEqual(keys[1].RangeKey(), client.items[1]["Time"]) {
+		t.Error("bad keys:", keys[1])
+	}
+	if len(scan.nameExpr) != 1 {
+		t.Error("AllKeys modified the scan:", scan.nameExpr)
+	}
+
+	// keys can be passed back unchanged
+	del := table.Delete("UserID", keys[0].HashKey()).Range("Time", keys[0].RangeKey())
+	if del.err != nil {
+		t.Error(del.err)
+	}
+	if !reflect.DeepEqual(del.hashValue, client.items[0]["UserID"]) {
+		t.Error("bad delete key:", del.hashValue)
+	}
+}
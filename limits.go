@@ -0,0 +1,110 @@
+package dynamo
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Limits are the maximum provisioned throughput capacity units allowed for the current account and region.
+// See: http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_DescribeLimits.html
+type Limits struct {
+	// AccountMaxRead and AccountMaxWrite are the maximum total capacity units of all tables and indexes.
+	AccountMaxRead  int64
+	AccountMaxWrite int64
+	// TableMaxRead and TableMaxWrite are the maximum capacity units of a single table or index.
+	TableMaxRead  int64
+	TableMaxWrite int64
+}
+
+// DescribeLimits is a request to describe the provisioned throughput limits of the account.
+type DescribeLimits struct {
+	db      *DB
+	timeout time.Duration
+}
+
+// DescribeLimits begins a new request to describe the account's provisioned throughput limits.
+func (db *DB) DescribeLimits() *DescribeLimits {
+	return &DescribeLimits{db: db}
+}
+
+// Timeout limits the total amount of time this request may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (dl *DescribeLimits) Timeout(timeout time.Duration) *DescribeLimits {
+	dl.timeout = timeout
+	return dl
+}
+
+// Run executes this request and returns the limits.
+func (dl *DescribeLimits) Run() (Limits, error) {
+	ctx, cancel := timeoutContext(dl.timeout)
+	defer cancel()
+	return dl.RunWithContext(ctx)
+}
+
+// RunWithContext executes this request and returns the limits.
+func (dl *DescribeLimits) RunWithContext(ctx aws.Context) (Limits, error) {
+	ctx, cancel := withTimeout(ctx, dl.timeout)
+	defer cancel()
+
+	var result *dynamodb.DescribeLimitsOutput
+	err := retry(ctx, func() error {
+		var err error
+		result, err = dl.db.client.DescribeLimitsWithContext(ctx, &dynamodb.DescribeLimitsInput{}, dl.db.opts...)
+		return err
+	})
+	if err != nil {
+		return Limits{}, err
+	}
+	return Limits{
+		AccountMaxRead:  aws.Int64Value(result.AccountMaxReadCapacityUnits),
+		AccountMaxWrite: aws.Int64Value(result.AccountMaxWriteCapacityUnits),
+		TableMaxRead:    aws.Int64Value(result.TableMaxReadCapacityUnits),
+		TableMaxWrite:   aws.Int64Value(result.TableMaxWriteCapacityUnits),
+	}, nil
+}
+
+// checkThroughput checks the provisioned throughput of a table and its indexes against limits.
+// Other tables aren't counted towards the account limits, so this only catches requests that could never succeed.
+func checkThroughput(limits Limits, op string, table *dynamodb.ProvisionedThroughput, indexes map[string]*dynamodb.ProvisionedThroughput) error {
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var totalRead, totalWrite int64
+	check := func(what string, pt *dynamodb.ProvisionedThroughput) error {
+		if pt == nil {
+			return nil
+		}
+		read, write := aws.Int64Value(pt.ReadCapacityUnits), aws.Int64Value(pt.WriteCapacityUnits)
+		switch {
+		case limits.TableMaxRead > 0 && read > limits.TableMaxRead:
+			return fmt.Errorf("dynamo: %s: %s read capacity of %d exceeds the limit of %d", op, what, read, limits.TableMaxRead)
+		case limits.TableMaxWrite > 0 && write > limits.TableMaxWrite:
+			return fmt.Errorf("dynamo: %s: %s write capacity of %d exceeds the limit of %d", op, what, write, limits.TableMaxWrite)
+		}
+		totalRead += read
+		totalWrite += write
+		return nil
+	}
+	if err := check("table", table); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := check("index "+name, indexes[name]); err != nil {
+			return err
+		}
+	}
+	switch {
+	case limits.AccountMaxRead > 0 && totalRead > limits.AccountMaxRead:
+		return fmt.Errorf("dynamo: %s: total read capacity of %d exceeds the account limit of %d", op, totalRead, limits.AccountMaxRead)
+	case limits.AccountMaxWrite > 0 && totalWrite > limits.AccountMaxWrite:
+		return fmt.Errorf("dynamo: %s: total write capacity of %d exceeds the account limit of %d", op, totalWrite, limits.AccountMaxWrite)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package dynamo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// limitsClient is a fake client with low throughput limits that counts table changes.
+type limitsClient struct {
+	dynamodbiface.DynamoDBAPI
+	changes int
+}
+
+func (limitsClient) DescribeLimitsWithContext(aws.Context, *dynamodb.DescribeLimitsInput, ...request.Option) (*dynamodb.DescribeLimitsOutput, error) {
+	return &dynamodb.DescribeLimitsOutput{
+		AccountMaxReadCapacityUnits:  aws.Int64(100),
+		AccountMaxWriteCapacityUnits: aws.Int64(100),
+		TableMaxReadCapacityUnits:    aws.Int64(40),
+		TableMaxWriteCapacityUnits:   aws.Int64(40),
+	}, nil
+}
+
+func (c *limitsClient) CreateTableWithContext(aws.Context, *dynamodb.CreateTableInput, ...request.Option) (*dynamodb.CreateTableOutput, error) {
+	c.changes++
+	return &dynamodb.CreateTableOutput{}, nil
+}
+
+func (c *limitsClient) UpdateTableWithContext(_ aws.Context, input *dynamodb.UpdateTableInput, _ ...request.Option) (*dynamodb.UpdateTableOutput, error) {
+	c.changes++
+	return &dynamodb.UpdateTableOutput{TableDescription: &dynamodb.TableDescription{TableName: input.TableName}}, nil
+}
+
+func TestCheckLimits(t *testing.T) {
+	client := &limitsClient{}
+	db := NewFromIface(client)
+
+	limits, err := db.DescribeLimits().Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits.TableMaxRead != 40 || limits.AccountMaxWrite != 100 {
+		t.Error("bad limits:", limits)
+	}
+
+	err = db.CreateTable("UserActions", UserAction{}).Provision(10, 10).ProvisionIndex("Embedded-index", 50, 1).CheckLimits().Run()
+	if err == nil || !strings.Contains(err.Error(), "index Embedded-index read capacity of 50 exceeds the limit of 40") {
+		t.Error("expected index limit error, got", err)
+	}
+	if err := db.CreateTable("UserActions", UserAction{}).Provision(10, 10).CheckLimits().Run(); err != nil {
+		t.Error("unexpected error:", err)
+	}
+	if err := db.CreateTable("UserActions", UserAction{}).Provision(50, 50).OnDemand(true).CheckLimits().Run(); err != nil {
+		t.Error("on-demand tables shouldn't be checked:", err)
+	}
+
+	_, err = db.Table("UserActions").UpdateTable().Provision(40, 40).
+		ProvisionIndex("A", 40, 40).
+		CreateIndex(Index{Name: "B", HashKey: "B", HashKeyType: StringType, ProjectionType: KeysOnlyProjection, Throughput: Throughput{Read: 40, Write: 1}}).
+		CheckLimits().Run()
+	if err == nil || !strings.Contains(err.Error(), "total read capacity of 120 exceeds the account limit of 100") {
+		t.Error("expected account limit error, got", err)
+	}
+	if client.changes != 2 {
+		t.Error("expected 2 table changes, got", client.changes)
+	}
+}
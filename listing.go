@@ -0,0 +1,80 @@
+package dynamo
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// Listing pages through the items of a global secondary index designed for listing,
+// where every item of a kind shares a hash key and is sorted by its range key, such as Type → Created.
+// Each page is a single query, and the position of the next page is returned as a cursor,
+// so it can back a typical "list items of a type sorted by date" API:
+//	func listOrders(cursor string) ([]Order, string, error) {
+//		var orders []Order
+//		next, err := table.List("Type-Created-index", "Type", "order").
+//			PageSize(50).
+//			Order(Descending).
+//			Page(cursor, &orders)
+//		return orders, next, err
+//	}
+// Use Table.List to create one.
+type Listing struct {
+	table    Table
+	index    string
+	hashKey  string
+	value    interface{}
+	pageSize int64
+	order    *Order
+}
+
+// List returns a listing of the items in the given index whose hash key hashKey equals value.
+func (table Table) List(index, hashKey string, value interface{}) *Listing {
+	return &Listing{
+		table:   table,
+		index:   index,
+		hashKey: hashKey,
+		value:   value,
+	}
+}
+
+// PageSize sets the maximum number of items evaluated for each page.
+// Pages might have fewer items, for example when soft-deleted items are filtered out.
+// By default, each page holds up to 1MB of items.
+func (l *Listing) PageSize(size int64) *Listing {
+	l.pageSize = size
+	return l
+}
+
+// Order specifies the order of the items by the index's range key. By default, they are listed in ascending order.
+func (l *Listing) Order(order Order) *Listing {
+	l.order = &order
+	return l
+}
+
+// Page retrieves the page of items starting at cursor, unmarshaling them into out, which must be a pointer to a slice.
+// An empty cursor starts from the first page.
+// It returns the cursor of the next page, which is empty when there are no more items.
+func (l *Listing) Page(cursor string, out interface{}) (next string, err error) {
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return l.PageWithContext(ctx, cursor, out)
+}
+
+// PageWithContext retrieves the page of items starting at cursor, unmarshaling them into out. See Page.
+func (l *Listing) PageWithContext(ctx aws.Context, cursor string, out interface{}) (next string, err error) {
+	iter := l.query(cursor).Iter().(*queryIter)
+	if !iter.NextPageWithContext(ctx, out) && iter.Err() != nil {
+		return "", iter.Err()
+	}
+	return iter.Cursor()
+}
+
+func (l *Listing) query(cursor string) *Query {
+	q := l.table.Get(l.hashKey, l.value).Index(l.index).Cursor(cursor)
+	if l.pageSize > 0 {
+		q.SearchLimit(l.pageSize)
+	}
+	if l.order != nil {
+		q.Order(*l.order)
+	}
+	return q
+}
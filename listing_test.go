@@ -0,0 +1,92 @@
+package dynamo
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// listingClient is a fake client that pages through items sorted by Time,
+// honoring Limit and ExclusiveStartKey.
+type listingClient struct {
+	dynamodbiface.DynamoDBAPI
+	items  []map[string]*dynamodb.AttributeValue
+	inputs []*dynamodb.QueryInput
+}
+
+func (c *listingClient) QueryWithContext(_ aws.Context, input *dynamodb.QueryInput, _ ...request.Option) (*dynamodb.QueryOutput, error) {
+	c.inputs = append(c.inputs, input)
+	start := 0
+	if input.ExclusiveStartKey != nil {
+		start, _ = strconv.Atoi(*input.ExclusiveStartKey["Time"].N)
+	}
+	end := len(c.items)
+	if input.Limit != nil && start+int(*input.Limit) < end {
+		end = start + int(*input.Limit)
+	}
+	out := &dynamodb.QueryOutput{Items: c.items[start:end]}
+	if end < len(c.items) {
+		out.LastEvaluatedKey = map[string]*dynamodb.AttributeValue{
+			"Msg":  c.items[end-1]["Msg"],
+			"Time": {N: aws.String(strconv.Itoa(end))},
+		}
+	}
+	return out, nil
+}
+
+func TestListing(t *testing.T) {
+	client := &listingClient{}
+	for i := 0; i < 5; i++ {
+		client.items = append(client.items, map[string]*dynamodb.AttributeValue{
+			"Msg":  {S: aws.String("widget")},
+			"Time": {N: aws.String(strconv.Itoa(i))},
+		})
+	}
+	list := NewFromIface(client).Table(testTable).List("Msg-Time-index", "Msg", "widget").PageSize(2).Order(Descending)
+
+	type item struct {
+		Msg  string
+		Time int
+	}
+	var got []int
+	var cursor string
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("too many pages")
+		}
+		var page []item
+		next, err := list.Page(cursor, &page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) > 2 {
+			t.Error("page too big:", page)
+		}
+		for _, it := range page {
+			got = append(got, it.Time)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if expect := []int{0, 1, 2, 3, 4}; !reflect.DeepEqual(got, expect) {
+		t.Error("bad items:", got, "≠", expect)
+	}
+	if len(client.inputs) != 3 {
+		t.Error("expected 3 queries, got", len(client.inputs))
+	}
+	input := client.inputs[0]
+	if aws.StringValue(input.IndexName) != "Msg-Time-index" || aws.BoolValue(input.ScanIndexForward) || aws.Int64Value(input.Limit) != 2 {
+		t.Error("bad input:", input)
+	}
+
+	if _, err := list.Page("not a cursor", &[]item{}); err != ErrInvalidCursor {
+		t.Error("expected ErrInvalidCursor, got", err)
+	}
+}
@@ -0,0 +1,143 @@
+package dynamo
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// MergeIters returns an iterator that yields the results of iters in sorted order, according to less.
+// Each iterator must already yield its results in that order, like a query of a single partition sorted by its range key,
+// or a segment of a scan sorted by an index.
+// The first result of every iterator is fetched concurrently, and after that only as needed.
+// Use OrderBy to sort by an attribute.
+//	iter := dynamo.MergeIters(dynamo.OrderBy("Time", dynamo.Ascending),
+//		table.Get("ID", "device#0").Iter(),
+//		table.Get("ID", "device#1").Iter())
+func MergeIters(less func(a, b map[string]*dynamodb.AttributeValue) bool, iters ...Iter) *MergedIter {
+	itr := &MergedIter{less: less, iters: iters}
+	for _, iter := range iters {
+		// decode results like the DB they came from
+		if from, ok := iter.(interface{ codec() codec }); ok {
+			itr.codec = from.codec()
+			break
+		}
+	}
+	return itr
+}
+
+// OrderBy returns a less function for MergeIters that sorts items by the given attribute,
+// comparing values as DynamoDB sorts range keys.
+func OrderBy(name string, order Order) func(a, b map[string]*dynamodb.AttributeValue) bool {
+	return func(a, b map[string]*dynamodb.AttributeValue) bool {
+		cmp := compareAV(a[name], b[name])
+		if order == Descending {
+			return cmp > 0
+		}
+		return cmp < 0
+	}
+}
+
+// MergedIter merges the results of several sorted iterators. See MergeIters.
+type MergedIter struct {
+	less   func(a, b map[string]*dynamodb.AttributeValue) bool
+	iters  []Iter
+	heads  []map[string]*dynamodb.AttributeValue
+	dedupe deduper
+	codec  codec
+	err    error
+}
+
+// Dedupe skips results with the same key, as returned by keyFunc, as an earlier result.
+// Use it when the merged iterators can overlap, such as while a GSI is being backfilled.
+// See ItemKey. The keys of every result are kept in memory until iteration is done.
+// It must be called before iterating.
+func (itr *MergedIter) Dedupe(keyFunc func(item map[string]*dynamodb.AttributeValue) string) *MergedIter {
+	itr.dedupe = deduper{key: keyFunc}
+	return itr
+}
+
+// Next tries to unmarshal the next result into out.
+// Returns false when it is complete or if it runs into an error.
+func (itr *MergedIter) Next(out interface{}) bool {
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return itr.NextWithContext(ctx, out)
+}
+
+// NextWithContext tries to unmarshal the next result into out.
+// Returns false when it is complete or if it runs into an error.
+func (itr *MergedIter) NextWithContext(ctx aws.Context, out interface{}) bool {
+	if itr.err != nil {
+		return false
+	}
+	if itr.heads == nil {
+		itr.start(ctx)
+		if itr.err != nil {
+			return false
+		}
+	}
+
+	for {
+		next := -1
+		for i, item := range itr.heads {
+			if item == nil {
+				continue
+			}
+			if next == -1 || itr.less(item, itr.heads[next]) {
+				next = i
+			}
+		}
+		if next == -1 {
+			return false
+		}
+
+		item := itr.heads[next]
+		if !itr.advance(ctx, next) {
+			return false
+		}
+		if itr.dedupe.dup(item) {
+			continue
+		}
+		itr.err = itr.codec.unmarshalItem(item, out)
+		return itr.err == nil
+	}
+}
+
+// start fetches the first result of every iterator concurrently.
+func (itr *MergedIter) start(ctx aws.Context) {
+	itr.heads = make([]map[string]*dynamodb.AttributeValue, len(itr.iters))
+	var wg sync.WaitGroup
+	for i := range itr.iters {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			itr.iters[i].NextWithContext(ctx, &itr.heads[i])
+		}(i)
+	}
+	wg.Wait()
+	for _, iter := range itr.iters {
+		if err := iter.Err(); err != nil {
+			itr.err = err
+			return
+		}
+	}
+}
+
+// advance fetches the next result of the i-th iterator.
+func (itr *MergedIter) advance(ctx aws.Context, i int) bool {
+	var item map[string]*dynamodb.AttributeValue
+	if itr.iters[i].NextWithContext(ctx, &item) {
+		itr.heads[i] = item
+		return true
+	}
+	itr.heads[i] = nil
+	itr.err = itr.iters[i].Err()
+	return itr.err == nil
+}
+
+// Err returns the error encountered, if any.
+func (itr *MergedIter) Err() error {
+	return itr.err
+}
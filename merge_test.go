@@ -0,0 +1,65 @@
+package dynamo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// failingIter is an iterator that fails immediately.
+type failingIter struct{ err error }
+
+func (itr failingIter) Next(out interface{}) bool { return false }
+func (itr failingIter) NextWithContext(ctx aws.Context, out interface{}) bool {
+	return false
+}
+func (itr failingIter) Err() error { return itr.err }
+
+func TestMergeIters(t *testing.T) {
+	type point struct {
+		PK   string
+		Time int64
+	}
+	client := partitionClient{parts: make(map[string][]map[string]*dynamodb.AttributeValue)}
+	for i, pk := range []string{"a", "b", "a", "c", "b", "a", "c"} {
+		item, err := marshalItem(point{PK: pk, Time: int64(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.parts[pk] = append(client.parts[pk], item)
+	}
+	table := NewFromIface(client).Table("Points")
+
+	collect := func(order Order) []int64 {
+		iter := MergeIters(OrderBy("Time", order),
+			table.Get("PK", "a").Order(order).Iter(),
+			table.Get("PK", "b").Order(order).Iter(),
+			table.Get("PK", "c").Order(order).Iter(),
+			table.Get("PK", "empty").Order(order).Iter())
+		var times []int64
+		var p point
+		for iter.Next(&p) {
+			times = append(times, p.Time)
+		}
+		if err := iter.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return times
+	}
+	if times, want := collect(Ascending), []int64{0, 1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(times, want) {
+		t.Error("bad ascending order:", times, "≠", want)
+	}
+	if times, want := collect(Descending), []int64{6, 5, 4, 3, 2, 1, 0}; !reflect.DeepEqual(times, want) {
+		t.Error("bad descending order:", times, "≠", want)
+	}
+
+	oops := errors.New("oops")
+	iter := MergeIters(OrderBy("Time", Ascending), table.Get("PK", "a").Iter(), failingIter{oops})
+	var p point
+	if iter.Next(&p) || iter.Err() != oops {
+		t.Error("expected error, got", iter.Err())
+	}
+}
@@ -0,0 +1,27 @@
+package dynamo
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// nullable returns whether field is tagged with the null option, which writes its empty values as NULL
+// rather than omitting them:
+//	Deleted *time.Time `dynamo:",null"`           // nil is NULL
+//	Count   int        `dynamo:",omitempty,null"` // 0 is NULL
+// When updating with Patch, a null field that becomes empty is set to NULL instead of removed.
+func nullable(field reflect.StructField) bool {
+	for _, opt := range strings.Split(field.Tag.Get("dynamo"), ",")[1:] {
+		if opt == "null" {
+			return true
+		}
+	}
+	return false
+}
+
+func nullAV() *dynamodb.AttributeValue {
+	return &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+}
@@ -0,0 +1,95 @@
+package dynamo
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestMarshalNull(t *testing.T) {
+	type Item struct {
+		ID      string     `dynamo:",hash"`
+		Deleted *time.Time `dynamo:",null"`
+		Note    string     `dynamo:",null"`
+		Count   int        `dynamo:",omitempty,null"`
+		Skipped *int
+		Kept    int
+	}
+	item, err := MarshalItem(Item{ID: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Deleted", "Note", "Count"} {
+		if av, ok := item[name]; !ok || av.NULL == nil || !*av.NULL {
+			t.Error(name, "should be NULL, got:", item[name])
+		}
+	}
+	if _, ok := item["Skipped"]; ok {
+		t.Error("Skipped should be omitted:", item["Skipped"])
+	}
+	if av := item["Kept"]; av == nil || av.N == nil || *av.N != "0" {
+		t.Error("Kept should be 0:", av)
+	}
+
+	now := time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+	full := Item{ID: "a", Deleted: &now, Note: "hi", Count: 3}
+	item, err = MarshalItem(full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if av := item["Count"]; av.N == nil || *av.N != "3" {
+		t.Error("bad Count:", av)
+	}
+
+	// NULL decodes to the zero value
+	null, err := MarshalItem(Item{ID: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := full
+	if err := UnmarshalItem(null, &got); err != nil {
+		t.Fatal(err)
+	}
+	if expect := (Item{ID: "a"}); !reflect.DeepEqual(got, expect) {
+		t.Errorf("bad result: %+v ≠ %+v", got, expect)
+	}
+}
+
+func TestUpdateNull(t *testing.T) {
+	type Item struct {
+		ID      string `dynamo:",hash"`
+		Deleted *int   `dynamo:",null"`
+		Note    string
+	}
+	table := NewFromIface(&lastItemClient{}).Table("Items")
+
+	two := 2
+	old := Item{ID: "a", Deleted: &two, Note: "hi"}
+	u := table.Update("ID", "a").Patch(old, Item{ID: "a"})
+	if u.err != nil {
+		t.Fatal("unexpected error:", u.err)
+	}
+	var removed []string
+	for path := range u.remove {
+		removed = append(removed, unsubstitute(path, u.nameExpr))
+	}
+	sort.Strings(removed)
+	if expect := []string{"Note"}; !reflect.DeepEqual(removed, expect) {
+		t.Error("bad removals:", removed, "≠", expect)
+	}
+	if len(u.set) != 1 || unsubstitute(u.set[0], u.nameExpr) != "Deleted = :v0" {
+		t.Fatal("bad sets:", u.set)
+	}
+	if av := u.valueExpr[":v0"]; av.NULL == nil || !*av.NULL {
+		t.Error("Deleted should be set to NULL:", av)
+	}
+
+	u = table.Update("ID", "a").SetNull("Deleted").Set("Note", nil)
+	if u.err != nil {
+		t.Fatal("unexpected error:", u.err)
+	}
+	if expr := unsubstitute(*u.updateExpr(), u.nameExpr); expr != "SET Deleted = :v0 REMOVE Note" {
+		t.Error("bad update expression:", expr)
+	}
+}
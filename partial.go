@@ -0,0 +1,71 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SetNonNil sets the attributes of partial's non-nil pointer fields, leaving the rest of the item as is.
+// This makes it easy to apply partial updates, such as the body of a PATCH request:
+//	type UserPatch struct {
+//		Name  *string
+//		Email *string `dynamo:"mail"`
+//		Age   *int
+//	}
+//	table.Update("ID", id).SetNonNil(UserPatch{Name: &name}) // SET Name = :v0
+// Fields that aren't pointers are ignored, and so are nil pointers.
+// A pointer to an empty value, such as an empty string, removes the attribute,
+// unless the field is tagged null, in which case it is set to NULL.
+// Struct fields are named and encoded as in MarshalItem, including embedded and flattened structs.
+// Setting the value of a key attribute is an error.
+func (u *Update) SetNonNil(partial interface{}) *Update {
+	rv := reflect.ValueOf(partial)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		u.setError(fmt.Errorf("dynamo: update: SetNonNil: expected struct, got %T", partial))
+		return u
+	}
+	u.setNonNil(rv, "")
+	return u
+}
+
+func (u *Update) setNonNil(rv reflect.Value, prefix string) {
+	for i := 0; i < rv.Type().NumField(); i++ {
+		field := rv.Type().Field(i)
+		fv := rv.Field(i)
+
+		if flatten, subprefix := flattening(field); flatten {
+			u.setNonNil(fv, prefix+subprefix)
+			continue
+		}
+		name, special, _ := u.codec.fieldInfo(field)
+		if name == "-" || field.PkgPath != "" || fv.Kind() != reflect.Ptr || fv.IsNil() {
+			continue
+		}
+		name = prefix + name
+		if name == u.hashKey || (u.rangeKey != "" && name == u.rangeKey) {
+			u.setError(fmt.Errorf("dynamo: update: SetNonNil: can't change key attribute %s", name))
+			return
+		}
+
+		av, err := u.codec.marshal(fv.Interface(), special)
+		if err != nil {
+			u.setError(err)
+			return
+		}
+		path := u.subName(name)
+		switch {
+		case av != nil:
+		case nullable(field):
+			av = nullAV()
+		default:
+			u.remove[path] = struct{}{}
+			continue
+		}
+		vsub, err := u.subValue(av, "")
+		u.setError(err)
+		u.set = append(u.set, path+" = "+vsub)
+	}
+}
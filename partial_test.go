@@ -0,0 +1,77 @@
+package dynamo
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestUpdateSetNonNil(t *testing.T) {
+	type Name struct {
+		First *string
+		Last  *string
+	}
+	type UserPatch struct {
+		ID      *string `dynamo:",hash"`
+		Email   *string `dynamo:"mail"`
+		Age     *int
+		Nick    *string
+		Deleted *string `dynamo:",null"`
+		Name    `dynamo:",prefix=Name_"`
+		Score   int
+		ignored *string
+	}
+	table := NewFromIface(&lastItemClient{}).Table("Users")
+
+	email, empty, first, age := "a@example.com", "", "Alice", 0
+	patch := UserPatch{
+		Email:   &email,
+		Age:     &age,
+		Nick:    &empty,
+		Deleted: &empty,
+		Name:    Name{First: &first},
+		Score:   10,
+		ignored: &email,
+	}
+	u := table.Update("ID", "u1").SetNonNil(&patch)
+	if u.err != nil {
+		t.Fatal("unexpected error:", u.err)
+	}
+
+	var got []string
+	for _, expr := range u.set {
+		expr = unsubstitute(expr, u.nameExpr)
+		for sub, av := range u.valueExpr {
+			if sub == expr[len(expr)-len(sub):] {
+				expr = expr[:len(expr)-len(sub)] + av.String()
+			}
+		}
+		got = append(got, expr)
+	}
+	for path := range u.remove {
+		got = append(got, "REMOVE "+unsubstitute(path, u.nameExpr))
+	}
+	for i := range got {
+		got[i] = strings.Join(strings.Fields(got[i]), " ")
+	}
+	sort.Strings(got)
+	expected := []string{
+		`Age = { N: "0" }`,
+		`Deleted = { NULL: true }`,
+		`Name_First = { S: "Alice" }`,
+		"REMOVE Nick",
+		`mail = { S: "a@example.com" }`,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Error("bad update:", got, "≠", expected)
+	}
+
+	id := "u2"
+	if err := table.Update("ID", "u1").SetNonNil(UserPatch{ID: &id}).err; err == nil {
+		t.Error("expected error when changing key")
+	}
+	if err := table.Update("ID", "u1").SetNonNil("nope").err; err == nil {
+		t.Error("expected error for non-struct")
+	}
+}
@@ -0,0 +1,102 @@
+package dynamo
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// Partition is an item collection: every item, across the table and its local secondary indexes,
+// that shares a hash key (a.k.a. partition key) value.
+// Use Table.Partition to create one.
+type Partition struct {
+	table   Table
+	hashKey string
+	value   interface{}
+}
+
+// Partition returns a handle to the item collection with the given hash key value.
+//	orders := table.Partition("CustomerID", id)
+//	err := orders.After("Created", since).Order(Descending).All(&recent)
+func (table Table) Partition(hashKey string, value interface{}) Partition {
+	return Partition{table: table, hashKey: hashKey, value: value}
+}
+
+// Query returns a query for every item in this partition, to which further options can be added.
+func (p Partition) Query() *Query {
+	return p.table.Get(p.hashKey, p.value)
+}
+
+// All retrieves every item in this partition, unmarshaling them into out, which must be a pointer to a slice.
+// Items are returned in ascending order of their range key.
+func (p Partition) All(out interface{}) error {
+	return p.Query().All(out)
+}
+
+// AllWithContext retrieves every item in this partition, unmarshaling them into out. See All.
+func (p Partition) AllWithContext(ctx aws.Context, out interface{}) error {
+	return p.Query().AllWithContext(ctx, out)
+}
+
+// Count returns the number of items in this partition.
+func (p Partition) Count() (int64, error) {
+	return p.Query().Count()
+}
+
+// CountWithContext returns the number of items in this partition.
+func (p Partition) CountWithContext(ctx aws.Context) (int64, error) {
+	return p.Query().CountWithContext(ctx)
+}
+
+// Between returns a query for the items in this partition whose range key rangeKey is between lower and upper, inclusive.
+func (p Partition) Between(rangeKey string, lower, upper interface{}) *Query {
+	return p.Query().Range(rangeKey, Between, lower, upper)
+}
+
+// BeginsWith returns a query for the items in this partition whose range key rangeKey begins with prefix.
+func (p Partition) BeginsWith(rangeKey string, prefix string) *Query {
+	return p.Query().Range(rangeKey, BeginsWith, prefix)
+}
+
+// Before returns a query for the items in this partition whose range key rangeKey is less than value.
+func (p Partition) Before(rangeKey string, value interface{}) *Query {
+	return p.Query().Range(rangeKey, Less, value)
+}
+
+// After returns a query for the items in this partition whose range key rangeKey is greater than value.
+func (p Partition) After(rangeKey string, value interface{}) *Query {
+	return p.Query().Range(rangeKey, Greater, value)
+}
+
+// DeleteAll deletes every item in this partition, returning the number of items deleted.
+// It queries for the keys of the items, including soft-deleted ones, and deletes them in batches,
+// so an error could indicate that some items have been deleted and some have not.
+func (p Partition) DeleteAll() (deleted int, err error) {
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return p.DeleteAllWithContext(ctx)
+}
+
+// DeleteAllWithContext deletes every item in this partition, returning the number of items deleted. See DeleteAll.
+func (p Partition) DeleteAllWithContext(ctx aws.Context) (deleted int, err error) {
+	desc, err := p.table.description(ctx)
+	if err != nil {
+		return 0, err
+	}
+	q := p.Query().IncludeDeleted()
+	if q.err != nil {
+		return 0, q.err
+	}
+	q.projection = q.projectKeys(desc.HashKey, desc.RangeKey)
+	keys, err := collectKeys(ctx, q.Iter(), desc.HashKey, desc.RangeKey)
+	if err != nil || len(keys) == 0 {
+		return 0, err
+	}
+	keyed := make([]Keyed, len(keys))
+	for i, key := range keys {
+		keyed[i] = key
+	}
+	batch := p.table.Batch(desc.HashKey)
+	if desc.RangeKey != "" {
+		batch = p.table.Batch(desc.HashKey, desc.RangeKey)
+	}
+	return batch.Write().Delete(keyed...).RunWithContext(ctx)
+}
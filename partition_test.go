@@ -0,0 +1,98 @@
+package dynamo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// collectionClient is a fake client for a single item collection,
+// which returns its items to every query and records batch deletes.
+type collectionClient struct {
+	*describeClient
+	items   []map[string]*dynamodb.AttributeValue
+	inputs  []*dynamodb.QueryInput
+	deleted []map[string]*dynamodb.AttributeValue
+}
+
+func (c *collectionClient) QueryWithContext(_ aws.Context, input *dynamodb.QueryInput, _ ...request.Option) (*dynamodb.QueryOutput, error) {
+	c.inputs = append(c.inputs, input)
+	if aws.StringValue(input.Select) == dynamodb.SelectCount {
+		return &dynamodb.QueryOutput{Count: aws.Int64(int64(len(c.items)))}, nil
+	}
+	return &dynamodb.QueryOutput{Items: c.items}, nil
+}
+
+func (c *collectionClient) BatchWriteItemWithContext(_ aws.Context, input *dynamodb.BatchWriteItemInput, _ ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	for _, reqs := range input.RequestItems {
+		for _, req := range reqs {
+			c.deleted = append(c.deleted, req.DeleteRequest.Key)
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func TestPartition(t *testing.T) {
+	client := &collectionClient{
+		describeClient: newDescribeClient(&dynamodb.TableDescription{
+			TableName: aws.String(testTable),
+			KeySchema: keySchema("UserID", "Time"),
+		}),
+	}
+	for _, w := range []widget{{UserID: 1, Msg: "a"}, {UserID: 1, Msg: "b"}, {UserID: 1, Msg: "c"}} {
+		item, err := marshalItem(w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.items = append(client.items, item)
+	}
+	part := NewFromIface(client).Table(testTable).Partition("UserID", 1)
+
+	var all []widget
+	if err := part.All(&all); err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[2].Msg != "c" {
+		t.Error("bad results:", all)
+	}
+	n, err := part.Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Error("bad count:", n)
+	}
+
+	q := part.Between("Time", 1, 2)
+	if q.err != nil || q.rangeKey != "Time" || q.rangeOp != Between || len(q.rangeValues) != 2 {
+		t.Error("bad range query:", q.rangeKey, q.rangeOp, q.rangeValues, q.err)
+	}
+	for op, q := range map[Operator]*Query{
+		Less:       part.Before("Time", 1),
+		Greater:    part.After("Time", 1),
+		BeginsWith: part.BeginsWith("Msg", "a"),
+	} {
+		if q.rangeOp != op || len(q.rangeValues) != 1 {
+			t.Error("bad range query:", op, q.rangeOp, q.rangeValues)
+		}
+	}
+
+	deleted, err := part.DeleteAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 3 || len(client.deleted) != 3 {
+		t.Fatal("expected 3 deletes, got:", deleted, client.deleted)
+	}
+	for _, key := range client.deleted {
+		if len(key) != 2 || key["UserID"] == nil || key["Time"] == nil {
+			t.Error("bad delete key:", key)
+		}
+	}
+	last := client.inputs[len(client.inputs)-1]
+	if proj := unsubstitute(aws.StringValue(last.ProjectionExpression), last.ExpressionAttributeNames); proj != "UserID, Time" {
+		t.Error("expected key-only projection, got", proj)
+	}
+}
@@ -0,0 +1,112 @@
+package dynamo
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// PageStats reports the progress of a Query, Scan, or BatchGet.
+// It is passed to OnPage callbacks after each page of results is fetched,
+// and iterators return the stats of their latest page from Stats.
+// To get a summary of a whole request run with All or Count, pass a PageStats to Query.Stats or Scan.Stats.
+type PageStats struct {
+	// Pages is the number of pages fetched so far, including this one.
+	Pages int
+	// Items is the number of items in this page.
+	Items int
+	// TotalItems is the number of items fetched so far, including this page.
+	TotalItems int
+	// Scanned is the number of items evaluated for this page, before filters were applied.
+	// A Scanned much greater than Items means a filter is discarding most of what is read and paid for.
+	// For BatchGet, it is the same as Items.
+	Scanned int
+	// TotalScanned is the number of items evaluated so far, including this page.
+	TotalScanned int
+	// Capacity is the number of capacity units consumed by this page.
+	// It is only reported when consumed capacity is requested, by ConsumedCapacity or OnPage.
+	Capacity float64
+	// TotalCapacity is the number of capacity units consumed so far, including this page.
+	TotalCapacity float64
+	// Elapsed is the time since the first page was requested.
+	Elapsed time.Duration
+	// Retries is the number of times requests have been retried so far, such as after being throttled.
+	Retries int
+	// Throttles is the number of requests rejected so far for exceeding provisioned throughput or request rate limits.
+	Throttles int
+}
+
+// pageTracker keeps track of progress for iterators and OnPage callbacks.
+type pageTracker struct {
+	fn    func(PageStats)
+	out   *PageStats
+	start time.Time
+	stats PageStats
+	// sent is whether a request for the next page has been sent already.
+	sent bool
+}
+
+// begin marks the start of the operation, if it hasn't started yet.
+func (pt *pageTracker) begin() {
+	if pt.start.IsZero() {
+		pt.start = time.Now()
+	}
+}
+
+// page records a fetched page and calls the callback, if any.
+func (pt *pageTracker) page(items int, scanned *int64, ccs ...*dynamodb.ConsumedCapacity) {
+	var capacity float64
+	for _, cc := range ccs {
+		if cc != nil && cc.CapacityUnits != nil {
+			capacity += *cc.CapacityUnits
+		}
+	}
+	pt.stats.Pages++
+	pt.stats.Items = items
+	pt.stats.TotalItems += items
+	pt.stats.Scanned = items
+	if scanned != nil {
+		pt.stats.Scanned = int(*scanned)
+	}
+	pt.stats.TotalScanned += pt.stats.Scanned
+	pt.stats.Capacity = capacity
+	pt.stats.TotalCapacity += capacity
+	pt.stats.Elapsed = time.Since(pt.start)
+	pt.sent = false
+	if pt.out != nil {
+		*pt.out = pt.stats
+	}
+	if pt.fn != nil {
+		pt.fn(pt.stats)
+	}
+}
+
+// attempt records a request for the next page that returned err.
+// Every request after the first for a page is a retry.
+func (pt *pageTracker) attempt(err error) {
+	if pt.sent {
+		pt.stats.Retries++
+	}
+	pt.sent = true
+	if isThrottle(err) {
+		pt.stats.Throttles++
+	}
+	pt.stats.Elapsed = time.Since(pt.start)
+	if pt.out != nil {
+		*pt.out = pt.stats
+	}
+}
+
+// isThrottle returns whether err is DynamoDB rejecting a request for exceeding its throughput or rate limits.
+func isThrottle(err error) bool {
+	ae, ok := err.(awserr.Error)
+	if !ok {
+		return false
+	}
+	switch ae.Code() {
+	case dynamodb.ErrCodeProvisionedThroughputExceededException, "ThrottlingException", dynamodb.ErrCodeRequestLimitExceeded:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package dynamo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// validateProjection checks that every path of a compiled projection expression is a valid document path,
+// and that no two paths overlap, which DynamoDB would otherwise reject with a ValidationException.
+// names are the expression attribute names used by expr.
+func validateProjection(expr string, names map[string]*string) error {
+	var paths [][]string
+	var shown []string
+	for _, path := range strings.Split(expr, ",") {
+		path = strings.TrimSpace(path)
+		elems, err := parseDocPath(path, names)
+		if err != nil {
+			return err
+		}
+		show := showDocPath(elems)
+		for i, other := range paths {
+			switch overlap, conflict := compareDocPaths(elems, other); {
+			case overlap:
+				return fmt.Errorf("dynamo: projection paths %s and %s overlap", shown[i], show)
+			case conflict:
+				return fmt.Errorf("dynamo: projection paths %s and %s conflict: one treats %s as a list, the other as a map", shown[i], show, showDocPath(commonPrefix(elems, other)))
+			}
+		}
+		paths = append(paths, elems)
+		shown = append(shown, show)
+	}
+	return nil
+}
+
+// parseDocPath splits a compiled document path into its elements,
+// which are either attribute names or list indexes like [0].
+func parseDocPath(path string, names map[string]*string) ([]string, error) {
+	if path == "" {
+		return nil, fmt.Errorf("dynamo: invalid projection: empty path")
+	}
+	bad := func(why string) error {
+		return fmt.Errorf("dynamo: invalid projection path %s: %s", path, why)
+	}
+	var elems []string
+	rest := path
+	for {
+		end := strings.IndexAny(rest, ".[")
+		if end == -1 {
+			end = len(rest)
+		}
+		name := rest[:end]
+		switch {
+		case name == "":
+			return nil, bad("missing attribute name")
+		case name[0] == '#':
+			sub, ok := names[name]
+			if !ok || sub == nil {
+				return nil, bad("undefined name placeholder " + name)
+			}
+			name = *sub
+		case strings.IndexFunc(name, invalidNameRune) != -1:
+			return nil, bad("invalid attribute name " + strconv.Quote(name) + ", use quotes or a placeholder")
+		case reserved[strings.ToUpper(name)]:
+			return nil, bad(strconv.Quote(name) + " is a reserved word, use quotes or a placeholder")
+		}
+		elems = append(elems, name)
+		rest = rest[end:]
+
+		for strings.HasPrefix(rest, "[") {
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, bad("unclosed [")
+			}
+			idx, err := strconv.Atoi(rest[1:end])
+			if err != nil || idx < 0 {
+				return nil, bad("list index " + rest[:end+1] + " must be a non-negative integer")
+			}
+			elems = append(elems, "["+strconv.Itoa(idx)+"]")
+			rest = rest[end+1:]
+		}
+
+		if rest == "" {
+			return elems, nil
+		}
+		if rest[0] != '.' {
+			return nil, bad("unexpected " + strconv.Quote(rest[:1]))
+		}
+		rest = rest[1:]
+	}
+}
+
+func invalidNameRune(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("]()',:#", r)
+}
+
+// compareDocPaths returns whether a and b overlap (one is the same as or inside the other),
+// or conflict (they use the same attribute as both a list and a map).
+func compareDocPaths(a, b []string) (overlap, conflict bool) {
+	n := len(commonPrefix(a, b))
+	if n == len(a) || n == len(b) {
+		return true, false
+	}
+	return false, isListIndex(a[n]) != isListIndex(b[n])
+}
+
+func commonPrefix(a, b []string) []string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
+
+func isListIndex(elem string) bool {
+	return strings.HasPrefix(elem, "[")
+}
+
+func showDocPath(elems []string) string {
+	var b strings.Builder
+	for i, elem := range elems {
+		if i > 0 && !isListIndex(elem) {
+			b.WriteByte('.')
+		}
+		b.WriteString(elem)
+	}
+	return b.String()
+}
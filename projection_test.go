@@ -0,0 +1,49 @@
+package dynamo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestValidateProjection(t *testing.T) {
+	names := map[string]*string{"#a": aws.String("Count"), "#b": aws.String("Info")}
+	valid := []string{
+		"UserID",
+		"UserID, Msg, Meta.foo",
+		"Tags[0], Tags[1].Label, #a",
+		"#b.Label, #b.Tags[2], Notes",
+	}
+	for _, expr := range valid {
+		if err := validateProjection(expr, names); err != nil {
+			t.Errorf("%s: unexpected error: %v", expr, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"":                     "empty path",
+		"UserID, ":             "empty path",
+		"Meta..foo":            "missing attribute name",
+		"Meta.":                "missing attribute name",
+		"Tags[x]":              "non-negative integer",
+		"Tags[-1]":             "non-negative integer",
+		"Tags[0":               "unclosed",
+		"Tags[0]x":             "unexpected",
+		"My Field":             "invalid attribute name",
+		"#missing":             "undefined name placeholder",
+		"Meta.Count":           "reserved word",
+		"Msg, Msg":             "overlap",
+		"#b, #b.Label":         "overlap",
+		"Tags[0], Tags[00]":    "overlap",
+		"Info.Label, #b.Label": "overlap",
+		"Meta[0], Meta.Label":  "conflict",
+		"Meta.A.B, Meta.A[1]":  "conflict",
+	}
+	for expr, want := range invalid {
+		err := validateProjection(expr, names)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected error containing %q, got: %v", expr, want, err)
+		}
+	}
+}
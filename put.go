@@ -1,6 +1,8 @@
 package dynamo
 
 import (
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
@@ -11,21 +13,36 @@ type Put struct {
 	table      Table
 	returnType string
 
-	item map[string]*dynamodb.AttributeValue
+	item      map[string]*dynamodb.AttributeValue
+	model     interface{}
+	versioned bool   // version condition added by checkVersion
+	bump      func() // increments the version field of model, see bumpVersion
 	subber
 	condition string
 
-	err error
-	cc  *ConsumedCapacity
+	err     error
+	cc      *ConsumedCapacity
+	icm     *ItemCollectionMetrics
+	reqID   *string
+	timeout time.Duration
 }
 
 // Put creates a new request to create or replace an item.
 func (table Table) Put(item interface{}) *Put {
-	encoded, err := marshalItem(item)
+	c := table.db.codec()
+	encoded, err := c.marshalItem(item)
+	if err == nil {
+		err = c.stampItem(item, encoded, table.db.now())
+	}
+	defs := table.db.requestDefaults()
 	return &Put{
-		table: table,
-		item:  encoded,
-		err:   err,
+		table:   table,
+		subber:  subber{codec: c},
+		item:    encoded,
+		model:   item,
+		err:     err,
+		cc:      defs.cc,
+		timeout: defs.timeout,
 	}
 }
 
@@ -45,15 +62,66 @@ func (p *Put) If(expr string, args ...interface{}) *Put {
 	return p
 }
 
+// IfNotExists specifies that this put must only create a new item, never replace an existing one.
+// The hash key is found from the item's struct tags, as used by CreateTable.
+// The put fails with a ConditionalCheckFailedException if the item already exists.
+func (p *Put) IfNotExists() *Put {
+	hashKey, err := p.hashKey()
+	if err != nil {
+		p.setError(err)
+		return p
+	}
+	return p.If("attribute_not_exists($)", hashKey)
+}
+
+// IfExists specifies that this put must only replace an existing item, never create a new one.
+// The hash key is found from the item's struct tags, as used by CreateTable.
+// The put fails with a ConditionalCheckFailedException if the item doesn't exist.
+func (p *Put) IfExists() *Put {
+	hashKey, err := p.hashKey()
+	if err != nil {
+		p.setError(err)
+		return p
+	}
+	return p.If("attribute_exists($)", hashKey)
+}
+
+// hashKey returns the name of the hash key tagged in this put's item.
+func (p *Put) hashKey() (string, error) {
+	ks, err := KeySchemaOf(p.model)
+	return ks.HashKey, err
+}
+
 // ConsumedCapacity will measure the throughput capacity consumed by this operation and add it to cc.
 func (p *Put) ConsumedCapacity(cc *ConsumedCapacity) *Put {
 	p.cc = cc
 	return p
 }
 
+// RequestID will record the ID of the last request sent by this put in id, even if it failed.
+// It is useful for correlating with AWS support cases and server-side logs.
+func (p *Put) RequestID(id *string) *Put {
+	p.reqID = id
+	return p
+}
+
+// ItemCollectionMetrics will record the estimated size of the item collection affected by this put in icm.
+// This is only reported for tables with local secondary indexes.
+func (p *Put) ItemCollectionMetrics(icm *ItemCollectionMetrics) *Put {
+	p.icm = icm
+	return p
+}
+
+// Timeout limits the total amount of time this put may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (p *Put) Timeout(timeout time.Duration) *Put {
+	p.timeout = timeout
+	return p
+}
+
 // Run executes this put.
 func (p *Put) Run() error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(p.timeout)
 	defer cancel()
 	return p.RunWithContext(ctx)
 }
@@ -68,7 +136,7 @@ func (p *Put) RunWithContext(ctx aws.Context) error {
 // OldValue executes this put, unmarshaling the previous value into out.
 // Returns ErrNotFound is there was no previous value.
 func (p *Put) OldValue(out interface{}) error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(p.timeout)
 	defer cancel()
 	return p.OldValueWithContext(ctx, out)
 }
@@ -84,22 +152,40 @@ func (p *Put) OldValueWithContext(ctx aws.Context, out interface{}) error {
 	case output.Attributes == nil:
 		return ErrNotFound
 	}
-	return unmarshalItem(output.Attributes, out)
+	return p.table.db.codec().unmarshalItem(output.Attributes, out)
 }
 
 func (p *Put) run(ctx aws.Context) (output *dynamodb.PutItemOutput, err error) {
+	ctx, cancel := withTimeout(ctx, p.timeout)
+	defer cancel()
 	if p.err != nil {
 		return nil, p.err
 	}
 
 	req := p.input()
-	retry(ctx, func() error {
-		output, err = p.table.db.client.PutItemWithContext(ctx, req)
+	if db := p.table.db; db.hasWriteHooks() {
+		ev := WriteEvent{Op: WritePut, Table: p.table.Name(), Item: req.Item}
+		if err := db.beforeWrite(ev); err != nil {
+			return nil, err
+		}
+		defer func() {
+			db.afterWrite(err, ev)
+		}()
+	}
+	err = p.table.db.retry(ctx, p.table.Name(), func() error {
+		var err error
+		output, err = p.table.db.client.PutItemWithContext(ctx, req, p.table.db.requestOptions(p.reqID)...)
 		return err
 	})
-	if p.cc != nil {
+	if c := p.table.db.cache; c != nil {
+		c.invalidateItem(p.table.Name(), req.Item)
+	}
+	if p.cc != nil && output != nil {
 		addConsumedCapacity(p.cc, output.ConsumedCapacity)
 	}
+	if p.icm != nil && output != nil {
+		setItemCollectionMetrics(p.icm, p.table.name, output.ItemCollectionMetrics)
+	}
 	return
 }
 
@@ -117,6 +203,9 @@ func (p *Put) input() *dynamodb.PutItemInput {
 	if p.cc != nil {
 		input.ReturnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityIndexes)
 	}
+	if p.icm != nil {
+		input.ReturnItemCollectionMetrics = aws.String(dynamodb.ReturnItemCollectionMetricsSize)
+	}
 	return input
 }
 
@@ -132,8 +221,6 @@ func (p *Put) writeTxItem() (*dynamodb.TransactWriteItem, error) {
 			ExpressionAttributeNames:  input.ExpressionAttributeNames,
 			ExpressionAttributeValues: input.ExpressionAttributeValues,
 			ConditionExpression:       input.ConditionExpression,
-			// TODO: add support when aws-sdk-go updates
-			// ReturnValuesOnConditionCheckFailure: aws.String(dynamodb.ReturnValuesOnConditionCheckFailureAllOld),
 		},
 	}
 	return item, nil
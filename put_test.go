@@ -4,6 +4,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
 )
 
 func TestPut(t *testing.T) {
@@ -50,3 +52,28 @@ func TestPut(t *testing.T) {
 		t.Error("expected ConditionalCheckFailedException, not", err)
 	}
 }
+
+func TestPutIfNotExists(t *testing.T) {
+	table := NewFromIface(newMemClient()).Table(testTable)
+	item := Metric{ID: 42, Value: 1}
+
+	put := table.Put(item).IfNotExists()
+	if put.err != nil {
+		t.Fatal(put.err)
+	}
+	if cond := unsubstitute(aws.StringValue(put.input().ConditionExpression), put.nameExpr); cond != "(attribute_not_exists(ID))" {
+		t.Error("bad condition:", cond)
+	}
+
+	put = table.Put(item).If("'Value' > ?", 0).IfExists()
+	if put.err != nil {
+		t.Fatal(put.err)
+	}
+	if cond := unsubstitute(aws.StringValue(put.input().ConditionExpression), put.nameExpr); cond != "(Value > :v0) AND (attribute_exists(ID))" {
+		t.Error("bad condition:", cond)
+	}
+
+	if err := table.Put(widget{UserID: 42}).IfNotExists().Run(); err == nil {
+		t.Error("expected error for item without a hash key tag")
+	}
+}
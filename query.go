@@ -3,8 +3,10 @@ package dynamo
 import (
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
@@ -26,15 +28,25 @@ type Query struct {
 
 	projection  string
 	filters     []string
-	consistent  bool
+	consistent  *bool
 	limit       int64
 	searchLimit int64
 	order       *Order
 
 	subber
 
-	err error
-	cc  *ConsumedCapacity
+	err     error
+	cc      *ConsumedCapacity
+	reqID   *string
+	timeout time.Duration
+	onPage  func(PageStats)
+	stats   *PageStats
+	hedge   time.Duration
+
+	includeDeleted bool
+	hydrate        bool
+	autoProject    bool
+	strict         bool
 }
 
 var (
@@ -78,14 +90,32 @@ var (
 // Name is the name of the hash key (a.k.a. partition key).
 // Value is the value of the hash key.
 func (table Table) Get(name string, value interface{}) *Query {
+	defs := table.db.requestDefaults()
 	q := &Query{
 		table:   table,
 		hashKey: name,
+		subber:  subber{codec: table.db.codec()},
+		cc:      defs.cc,
+		timeout: defs.timeout,
 	}
-	q.hashValue, q.err = marshal(value, "")
+	q.hashValue, q.err = table.db.codec().marshal(value, "")
 	return q
 }
 
+// GetAll queries for every item with the given hash key (a.k.a. partition key), unmarshaling them into out,
+// which must be a pointer to a slice.
+// Items are returned in ascending order of their range key.
+// It is shorthand for Get(name, value).All(out); use Get to specify a limit, order, or other options.
+func (table Table) GetAll(name string, value interface{}, out interface{}) error {
+	return table.Get(name, value).All(out)
+}
+
+// GetAllWithContext queries for every item with the given hash key, unmarshaling them into out.
+// See GetAll.
+func (table Table) GetAllWithContext(ctx aws.Context, name string, value interface{}, out interface{}) error {
+	return table.Get(name, value).AllWithContext(ctx, out)
+}
+
 // Range specifies the range key (a.k.a. sort key) or keys to get.
 // For single item requests using One, op must be Equal.
 // Name is the name of the range key.
@@ -94,7 +124,7 @@ func (q *Query) Range(name string, op Operator, values ...interface{}) *Query {
 	var err error
 	q.rangeKey = name
 	q.rangeOp = op
-	q.rangeValues, err = marshalSlice(values)
+	q.rangeValues, err = q.table.db.codec().marshalSlice(values)
 	q.setError(err)
 	return q
 }
@@ -106,6 +136,18 @@ func (q *Query) StartFrom(key PagingKey) *Query {
 	return q
 }
 
+// Cursor makes this query start from the position encoded in token, a cursor returned by CursorIter.Cursor.
+// An empty token starts from the beginning.
+func (q *Query) Cursor(token string) *Query {
+	if token == "" {
+		return q
+	}
+	key, err := q.table.db.decodeCursor(q.table.Name(), token)
+	q.setError(err)
+	q.startKey = key
+	return q
+}
+
 // Index specifies the name of the index that this query will operate on.
 func (q *Query) Index(name string) *Query {
 	q.index = name
@@ -152,13 +194,22 @@ func (q *Query) Filter(expr string, args ...interface{}) *Query {
 }
 
 // Consistent will, if on is true, make this query a strongly consistent read.
-// Queries are eventually consistent by default.
+// Queries are eventually consistent by default, unless changed with DB.ConsistentReads or Table.ConsistentReads.
 // Strongly consistent reads are more resource-heavy than eventually consistent reads.
 func (q *Query) Consistent(on bool) *Query {
-	q.consistent = on
+	q.consistent = &on
 	return q
 }
 
+// consistentRead returns true if this query should be strongly consistent.
+func (q *Query) consistentRead() bool {
+	if q.consistent != nil {
+		return *q.consistent
+	}
+	// global secondary indexes don't support consistent reads, so defaults only apply to the base table
+	return q.index == "" && q.table.consistentReads()
+}
+
 // Limit specifies the maximum amount of results to return.
 func (q *Query) Limit(limit int64) *Query {
 	q.limit = limit
@@ -186,31 +237,109 @@ func (q *Query) ConsumedCapacity(cc *ConsumedCapacity) *Query {
 	return q
 }
 
+// RequestID will record the ID of the last request sent by this query in id, even if it failed.
+// It is useful for correlating with AWS support cases and server-side logs.
+// Results served from the cache (see DB.WithCache), or shared with an identical request already in flight (see DB.CoalesceGets),
+// don't send a request of their own, so they leave id as is.
+func (q *Query) RequestID(id *string) *Query {
+	q.reqID = id
+	return q
+}
+
+// requestOptions returns the request options for this query's requests.
+// Hedged requests may complete concurrently, so the ID is recorded through a lastRequestID.
+func (q *Query) requestOptions() []request.Option {
+	if q.reqID == nil {
+		return q.table.db.opts
+	}
+	ids := &lastRequestID{out: q.reqID}
+	return ids.options(q.table.db.opts)
+}
+
+// Timeout limits the total amount of time this query may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+// For iterators, it applies to each call of Next or NextPage separately, rather than to the whole iteration;
+// to bound an iteration, pass NextWithContext a context with a deadline.
+func (q *Query) Timeout(timeout time.Duration) *Query {
+	q.timeout = timeout
+	return q
+}
+
+// OnPage sets fn to be called after each page of results is fetched, with the progress so far.
+// Consumed capacity is requested automatically if ConsumedCapacity isn't set.
+func (q *Query) OnPage(fn func(stats PageStats)) *Query {
+	q.onPage = fn
+	return q
+}
+
+// Stats sets stats to be updated with the progress of this query as pages are fetched,
+// so that once All, Count, or iteration has finished, it holds a summary of the whole run:
+// the pages, items, and capacity read, the retries and throttles along the way, and the time taken.
+func (q *Query) Stats(stats *PageStats) *Query {
+	q.stats = stats
+	return q
+}
+
+// Hedge enables hedged reads: if a request hasn't returned a response after delay,
+// an identical request is sent and whichever responds first is used.
+// Setting delay to around the 95th or 99th percentile latency of this table can reduce tail latency,
+// at the cost of extra read capacity for hedged requests. Capacity consumed by the losing request is not reported.
+// This is best used with eventually consistent reads.
+// With DB.CoalesceGets, the first request can be shared with identical ones in flight, but the hedged request is always sent.
+func (q *Query) Hedge(delay time.Duration) *Query {
+	q.hedge = delay
+	return q
+}
+
+// IncludeDeleted includes soft-deleted items in the results. See DB.WithSoftDelete.
+func (q *Query) IncludeDeleted() *Query {
+	q.includeDeleted = true
+	return q
+}
+
+// Strict makes One check that no other item matches, reading the rest of the results after a match if necessary.
+func (q *Query) Strict() *Query {
+	q.strict = true
+	return q
+}
+
 // One executes this query and retrieves a single result,
 // unmarshaling the result to out.
+// It returns ErrNotFound if the query matches no items, and ErrTooMany if the page with the first match has more than one.
+// One stops at the first page with a match, so more matches in later pages go unnoticed
+// unless Strict is set, in which case it returns ErrTooMany if the query matches more than one item in total.
+// Use First to take the first match without any check.
 func (q *Query) One(out interface{}) error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(q.timeout)
 	defer cancel()
 	return q.OneWithContext(ctx, out)
 }
 
 func (q *Query) OneWithContext(ctx aws.Context, out interface{}) error {
+	ctx, cancel := withTimeout(ctx, q.timeout)
+	defer cancel()
 	if q.err != nil {
 		return q.err
 	}
+	if q.hydrate {
+		return q.oneHydrated(ctx, out)
+	}
 
 	// Can we use the GetItem API?
 	if q.canGetItem() {
 		req := q.getItemInput()
+		opts := q.requestOptions()
 
 		var res *dynamodb.GetItemOutput
-		err := retry(ctx, func() error {
-			var err error
-			res, err = q.table.db.client.GetItemWithContext(ctx, req)
+		err := q.table.db.retry(ctx, q.table.Name(), func() error {
+			out, err := hedge(ctx, q.hedge, func(ctx aws.Context) (interface{}, error) {
+				return q.table.db.getItem(ctx, req, opts)
+			})
 			if err != nil {
 				return err
 			}
-			if res.Item == nil {
+			res = out.(*dynamodb.GetItemOutput)
+			if res.Item == nil || (!q.includeDeleted && q.table.db.softDeleted(res.Item)) {
 				return ErrNotFound
 			}
 			return nil
@@ -222,62 +351,153 @@ func (q *Query) OneWithContext(ctx aws.Context, out interface{}) error {
 			addConsumedCapacity(q.cc, res.ConsumedCapacity)
 		}
 
-		return unmarshalItem(res.Item, out)
+		return q.table.db.codec().unmarshalItem(res.Item, out)
 	}
 
 	// If not, try a Query.
 	req := q.queryInput()
+	opts := q.requestOptions()
 
-	var res *dynamodb.QueryOutput
-	err := retry(ctx, func() error {
-		var err error
-		res, err = q.table.db.client.QueryWithContext(ctx, req)
+	// the match found by a strict query, while it checks the remaining pages
+	var found map[string]*dynamodb.AttributeValue
+	for {
+		var res *dynamodb.QueryOutput
+		err := q.table.db.retry(ctx, q.table.Name(), func() error {
+			out, err := hedge(ctx, q.hedge, func(ctx aws.Context) (interface{}, error) {
+				return q.table.db.client.QueryWithContext(ctx, req, opts...)
+			})
+			if err != nil {
+				return err
+			}
+			res = out.(*dynamodb.QueryOutput)
+			return nil
+		})
 		if err != nil {
 			return err
 		}
+		if q.cc != nil {
+			addConsumedCapacity(q.cc, res.ConsumedCapacity)
+		}
 
 		switch {
-		case len(res.Items) == 0:
-			return ErrNotFound
-		case len(res.Items) > 1:
+		case len(res.Items) > 1, len(res.Items) == 1 && found != nil:
 			return ErrTooMany
-		case res.LastEvaluatedKey != nil && q.searchLimit != 0:
+		case len(res.Items) == 1 && res.LastEvaluatedKey != nil && q.searchLimit != 0:
 			return ErrTooMany
+		case len(res.Items) == 1 && (!q.strict || res.LastEvaluatedKey == nil):
+			return q.table.db.codec().unmarshalItem(res.Items[0], out)
+		case len(res.Items) == 1:
+			found = res.Items[0]
+		case res.LastEvaluatedKey == nil || q.searchLimit != 0:
+			if found != nil {
+				return q.table.db.codec().unmarshalItem(found, out)
+			}
+			return ErrNotFound
 		}
+		// every item in this page was filtered out, or a strict query must rule out other matches, so keep looking
+		req.ExclusiveStartKey = res.LastEvaluatedKey
+	}
+}
 
+// First executes this request and unmarshals the first result to out.
+// Unlike One, it doesn't check whether there are more results: it stops as soon as a match is found,
+// which is useful for existence-style lookups. It returns ErrNotFound if there are no results.
+func (q *Query) First(out interface{}) error {
+	ctx, cancel := timeoutContext(q.timeout)
+	defer cancel()
+	return q.FirstWithContext(ctx, out)
+}
+
+// FirstWithContext executes this request and unmarshals the first result to out.
+// Unlike One, it doesn't check whether there are more results: it stops as soon as a match is found,
+// which is useful for existence-style lookups. It returns ErrNotFound if there are no results.
+func (q *Query) FirstWithContext(ctx aws.Context, out interface{}) error {
+	// limit the request to one item, or, when filtering, page until one matches
+	first := *q
+	first.limit = 1
+	iter := first.Iter()
+	if iter.NextWithContext(ctx, out) {
 		return nil
-	})
-	if err != nil {
+	}
+	if err := iter.Err(); err != nil {
 		return err
 	}
-	if q.cc != nil {
-		addConsumedCapacity(q.cc, res.ConsumedCapacity)
+	return ErrNotFound
+}
+
+// Exists executes this query and returns true if it matches any item.
+// Only the key attributes are requested, so it is a cheap presence check.
+// Like One, it uses GetItem when possible, so the range key must be specified for tables that have one;
+// otherwise, it stops at the first match.
+func (q *Query) Exists() (bool, error) {
+	ctx, cancel := timeoutContext(q.timeout)
+	defer cancel()
+	return q.ExistsWithContext(ctx)
+}
+
+// ExistsWithContext executes this query and returns true if it matches any item.
+// Only the key attributes are requested, so it is a cheap presence check.
+// Like One, it uses GetItem when possible, so the range key must be specified for tables that have one;
+// otherwise, it stops at the first match.
+func (q *Query) ExistsWithContext(ctx aws.Context) (bool, error) {
+	if q.err != nil {
+		return false, q.err
+	}
+	exists := *q
+	// the projection adds names, which must not leak into q
+	exists.nameExpr = make(map[string]*string, len(q.nameExpr))
+	for k, v := range q.nameExpr {
+		exists.nameExpr[k] = v
 	}
+	keys := []string{q.hashKey}
+	if q.rangeKey != "" {
+		keys = append(keys, q.rangeKey)
+	}
+	exists.Project(keys...)
 
-	return unmarshalItem(res.Items[0], out)
+	var item map[string]*dynamodb.AttributeValue
+	var err error
+	if exists.canGetItem() {
+		err = exists.OneWithContext(ctx, &item)
+	} else {
+		err = exists.FirstWithContext(ctx, &item)
+	}
+	switch err {
+	case nil:
+		return true, nil
+	case ErrNotFound:
+		return false, nil
+	}
+	return false, err
 }
 
 // Count executes this request, returning the number of results.
 func (q *Query) Count() (int64, error) {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(q.timeout)
 	defer cancel()
 	return q.CountWithContext(ctx)
 }
 
 func (q *Query) CountWithContext(ctx aws.Context) (int64, error) {
+	ctx, cancel := withTimeout(ctx, q.timeout)
+	defer cancel()
 	if q.err != nil {
 		return 0, q.err
 	}
 
 	var count int64
 	var res *dynamodb.QueryOutput
+	progress := pageTracker{fn: q.onPage, out: q.stats}
+	opts := q.requestOptions()
 	for {
 		req := q.queryInput()
 		req.Select = selectCount
 
-		err := retry(ctx, func() error {
+		progress.begin()
+		err := q.table.db.retry(ctx, q.table.Name(), func() error {
 			var err error
-			res, err = q.table.db.client.QueryWithContext(ctx, req)
+			res, err = q.table.db.client.QueryWithContext(ctx, req, opts...)
+			progress.attempt(err)
 			if err != nil {
 				return err
 			}
@@ -293,6 +513,7 @@ func (q *Query) CountWithContext(ctx aws.Context) (int64, error) {
 		if q.cc != nil {
 			addConsumedCapacity(q.cc, res.ConsumedCapacity)
 		}
+		progress.page(int(*res.Count), res.ScannedCount, res.ConsumedCapacity)
 
 		q.startKey = res.LastEvaluatedKey
 		if res.LastEvaluatedKey == nil || q.searchLimit > 0 {
@@ -313,17 +534,22 @@ type queryIter struct {
 	n      int64
 
 	unmarshal unmarshalFunc
+	progress  pageTracker
+	reqID     lastRequestID
+	hydrator  *hydrator
 }
 
 // Next tries to unmarshal the next result into out.
 // Returns false when it is complete or if it runs into an error.
 func (itr *queryIter) Next(out interface{}) bool {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(itr.query.timeout)
 	defer cancel()
 	return itr.NextWithContext(ctx, out)
 }
 
 func (itr *queryIter) NextWithContext(ctx aws.Context, out interface{}) bool {
+	ctx, cancel := withTimeout(ctx, itr.query.timeout)
+	defer cancel()
 	// stop if we have an error
 	if itr.err != nil {
 		return false
@@ -335,54 +561,135 @@ func (itr *queryIter) NextWithContext(ctx aws.Context, out interface{}) bool {
 	}
 
 	// can we use results we already have?
-	if itr.output != nil && itr.idx < len(itr.output.Items) {
-		item := itr.output.Items[itr.idx]
-		itr.err = itr.unmarshal(item, out)
+	if !itr.buffered() && !itr.fetch(ctx) {
+		return false
+	}
+
+	itr.err = itr.unmarshal(itr.output.Items[itr.idx], out)
+	itr.idx++
+	itr.n++
+	return itr.err == nil
+}
+
+// NextPage unmarshals the rest of the current page of results into out, which must be a pointer to a slice.
+// If the current page has been consumed, the next page is requested.
+// out is replaced, not appended to. Returns false when it is complete or if it runs into an error.
+func (itr *queryIter) NextPage(out interface{}) bool {
+	ctx, cancel := timeoutContext(itr.query.timeout)
+	defer cancel()
+	return itr.NextPageWithContext(ctx, out)
+}
+
+func (itr *queryIter) NextPageWithContext(ctx aws.Context, out interface{}) bool {
+	ctx, cancel := withTimeout(ctx, itr.query.timeout)
+	defer cancel()
+	if itr.err != nil {
+		return false
+	}
+	if itr.query.limit > 0 && itr.n == itr.query.limit {
+		return false
+	}
+	if itr.err = resetSlice(out); itr.err != nil {
+		return false
+	}
+	if !itr.buffered() && !itr.fetch(ctx) {
+		return false
+	}
+
+	items := itr.output.Items[itr.idx:]
+	if itr.query.limit > 0 && int64(len(items)) > itr.query.limit-itr.n {
+		items = items[:itr.query.limit-itr.n]
+	}
+	for _, item := range items {
+		if itr.err = itr.query.table.db.codec().unmarshalAppend(item, out); itr.err != nil {
+			return false
+		}
 		itr.idx++
 		itr.n++
-		return itr.err == nil
 	}
+	return true
+}
 
-	// new query
-	if itr.input == nil {
-		itr.input = itr.query.queryInput()
+func (itr *queryIter) nextItems(ctx aws.Context) ([]map[string]*dynamodb.AttributeValue, bool) {
+	if itr.err != nil || (itr.query.limit > 0 && itr.n == itr.query.limit) {
+		return nil, false
 	}
-	if itr.output != nil && itr.idx >= len(itr.output.Items) {
-		// have we exhausted all results?
-		if itr.output.LastEvaluatedKey == nil || itr.query.searchLimit > 0 {
-			return false
+	if !itr.buffered() && !itr.fetch(ctx) {
+		return nil, false
+	}
+	items := itr.output.Items[itr.idx:]
+	if itr.query.limit > 0 && int64(len(items)) > itr.query.limit-itr.n {
+		items = items[:itr.query.limit-itr.n]
+	}
+	// hand over the page, so it can be freed as soon as the caller is done with it
+	itr.output.Items, itr.idx = nil, 0
+	itr.n += int64(len(items))
+	return items, true
+}
+
+// buffered returns true if there are unread results from the last request.
+func (itr *queryIter) buffered() bool {
+	return itr.output != nil && itr.idx < len(itr.output.Items)
+}
+
+// fetch requests pages until one with results is found.
+// Returns false when there are no more results or if it runs into an error.
+func (itr *queryIter) fetch(ctx aws.Context) bool {
+	for {
+		// new query
+		if itr.input == nil && itr.query.hydrate {
+			if itr.hydrator, itr.err = itr.query.newHydrator(ctx); itr.err != nil {
+				return false
+			}
+			itr.input = itr.hydrator.input(itr.query)
 		}
+		if itr.input == nil {
+			itr.input = itr.query.queryInput()
+		}
+		if itr.output != nil {
+			// have we exhausted all results?
+			if itr.output.LastEvaluatedKey == nil || itr.query.searchLimit > 0 {
+				return false
+			}
 
-		// no, prepare next request and reset index
-		itr.input.ExclusiveStartKey = itr.output.LastEvaluatedKey
+			// no, prepare next request and reset index
+			itr.input.ExclusiveStartKey = itr.output.LastEvaluatedKey
+		}
 		itr.idx = 0
-	}
+		if itr.query.reqID != nil {
+			itr.reqID.forward(itr.query.reqID)
+		}
 
-	itr.err = retry(ctx, func() error {
-		var err error
-		itr.output, err = itr.query.table.db.client.QueryWithContext(ctx, itr.input)
-		return err
-	})
+		itr.progress.begin()
+		itr.err = itr.query.table.db.retry(ctx, itr.query.table.Name(), func() error {
+			out, err := hedge(ctx, itr.query.hedge, func(ctx aws.Context) (interface{}, error) {
+				return itr.query.table.db.client.QueryWithContext(ctx, itr.input, itr.reqID.options(itr.query.table.db.opts)...)
+			})
+			itr.progress.attempt(err)
+			if err != nil {
+				return err
+			}
+			itr.output = out.(*dynamodb.QueryOutput)
+			return nil
+		})
+		if itr.err != nil {
+			return false
+		}
+		if itr.query.cc != nil {
+			addConsumedCapacity(itr.query.cc, itr.output.ConsumedCapacity)
+		}
+		itr.progress.page(len(itr.output.Items), itr.output.ScannedCount, itr.output.ConsumedCapacity)
+		if itr.hydrator != nil {
+			if itr.output.Items, itr.err = itr.hydrator.hydrate(ctx, itr.query, itr.output.Items); itr.err != nil {
+				return false
+			}
+		}
 
-	if itr.err != nil {
-		return false
-	}
-	if itr.query.cc != nil {
-		addConsumedCapacity(itr.query.cc, itr.output.ConsumedCapacity)
-	}
-	if len(itr.output.Items) == 0 {
-		if itr.output.LastEvaluatedKey != nil {
-			// we need to retry until we get some data
-			return itr.NextWithContext(ctx, out)
+		if len(itr.output.Items) > 0 {
+			return true
 		}
-		// we're done
-		return false
+		// keep going until we get some data
 	}
-
-	itr.err = itr.unmarshal(itr.output.Items[itr.idx], out)
-	itr.idx++
-	itr.n++
-	return itr.err == nil
 }
 
 // Err returns the error encountered, if any.
@@ -398,9 +705,24 @@ func (itr *queryIter) LastEvaluatedKey() PagingKey {
 	return nil
 }
 
+// Stats returns statistics about the pages fetched so far.
+func (itr *queryIter) Stats() PageStats {
+	return itr.progress.stats
+}
+
+// RequestID returns the ID of the latest request sent by this iterator.
+func (itr *queryIter) RequestID() string {
+	return itr.reqID.get()
+}
+
+// Cursor returns LastEvaluatedKey as an opaque token.
+func (itr *queryIter) Cursor() (string, error) {
+	return itr.query.table.db.encodeCursor(itr.query.table.Name(), itr.LastEvaluatedKey())
+}
+
 // All executes this request and unmarshals all results to out, which must be a pointer to a slice.
 func (q *Query) All(out interface{}) error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(q.timeout)
 	defer cancel()
 	return q.AllWithContext(ctx, out)
 }
@@ -413,28 +735,38 @@ func (q *Query) AllWithContext(ctx aws.Context, out interface{}) error {
 // AllWithLastEvaluatedKey executes this request and unmarshals all results to out, which must be a pointer to a slice.
 // This returns a PagingKey you can use with StartFrom to split up results.
 func (q *Query) AllWithLastEvaluatedKey(out interface{}) (PagingKey, error) {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(q.timeout)
 	defer cancel()
 	return q.AllWithLastEvaluatedKeyContext(ctx, out)
 }
 
 func (q *Query) AllWithLastEvaluatedKeyContext(ctx aws.Context, out interface{}) (PagingKey, error) {
+	ctx, cancel := withTimeout(ctx, q.timeout)
+	defer cancel()
+	q = q.autoProjected(out)
 	iter := &queryIter{
 		query:     q,
-		unmarshal: unmarshalAppend,
+		unmarshal: q.table.db.codec().unmarshalAppend,
 		err:       q.err,
+		progress:  pageTracker{fn: q.onPage, out: q.stats},
 	}
 	for iter.NextWithContext(ctx, out) {
 	}
 	return iter.LastEvaluatedKey(), iter.Err()
 }
 
+// codec returns the codec of this iterator's DB.
+func (itr *queryIter) codec() codec {
+	return itr.query.table.db.codec()
+}
+
 // Iter returns a results iterator for this request.
 func (q *Query) Iter() PagingIter {
 	iter := &queryIter{
 		query:     q,
-		unmarshal: unmarshalItem,
+		unmarshal: q.table.db.codec().unmarshalItem,
 		err:       q.err,
+		progress:  pageTracker{fn: q.onPage, out: q.stats},
 	}
 
 	return iter
@@ -461,11 +793,15 @@ func (q *Query) queryInput() *dynamodb.QueryInput {
 		ExpressionAttributeNames:  q.nameExpr,
 		ExpressionAttributeValues: q.valueExpr,
 	}
-	if q.consistent {
-		req.ConsistentRead = &q.consistent
+	if q.consistentRead() {
+		req.ConsistentRead = aws.Bool(true)
+	}
+	filters := q.filters
+	if !q.includeDeleted {
+		filters = withFilter(filters, q.table.db.softFilter(&q.subber))
 	}
 	if q.limit > 0 {
-		if len(q.filters) == 0 {
+		if len(filters) == 0 {
 			req.Limit = &q.limit
 		}
 	}
@@ -475,9 +811,11 @@ func (q *Query) queryInput() *dynamodb.QueryInput {
 	if q.projection != "" {
 		req.ProjectionExpression = &q.projection
 	}
-	if len(q.filters) > 0 {
-		filter := strings.Join(q.filters, " AND ")
+	if len(filters) > 0 {
+		filter := strings.Join(filters, " AND ")
 		req.FilterExpression = &filter
+		// the soft delete filter might have added a name
+		req.ExpressionAttributeNames = q.nameExpr
 	}
 	if q.index != "" {
 		req.IndexName = &q.index
@@ -487,6 +825,8 @@ func (q *Query) queryInput() *dynamodb.QueryInput {
 	}
 	if q.cc != nil {
 		req.ReturnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityIndexes)
+	} else if q.onPage != nil {
+		req.ReturnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityTotal)
 	}
 	return req
 }
@@ -513,11 +853,17 @@ func (q *Query) getItemInput() *dynamodb.GetItemInput {
 		Key:                      q.keys(),
 		ExpressionAttributeNames: q.nameExpr,
 	}
-	if q.consistent {
-		req.ConsistentRead = &q.consistent
+	if q.consistentRead() {
+		req.ConsistentRead = aws.Bool(true)
 	}
 	if q.projection != "" {
-		req.ProjectionExpression = &q.projection
+		proj := q.projection
+		if soft := q.table.db.soft; soft != nil && !q.includeDeleted {
+			// needed to tell if the item was deleted
+			proj += ", " + q.subName(soft.Attribute)
+			req.ExpressionAttributeNames = q.nameExpr
+		}
+		req.ProjectionExpression = &proj
 	}
 	if q.cc != nil {
 		req.ReturnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityIndexes)
@@ -550,18 +896,6 @@ func (q *Query) keys() map[string]*dynamodb.AttributeValue {
 	return keys
 }
 
-func (q *Query) keysAndAttribs() *dynamodb.KeysAndAttributes {
-	kas := &dynamodb.KeysAndAttributes{
-		Keys:                     []map[string]*dynamodb.AttributeValue{q.keys()},
-		ExpressionAttributeNames: q.nameExpr,
-		ConsistentRead:           &q.consistent,
-	}
-	if q.projection != "" {
-		kas.ProjectionExpression = &q.projection
-	}
-	return kas
-}
-
 func (q *Query) setError(err error) {
 	if q.err == nil {
 		q.err = err
@@ -2,8 +2,17 @@ package dynamo
 
 import (
 	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 func TestGetAllCount(t *testing.T) {
@@ -162,3 +171,423 @@ func TestQueryPaging(t *testing.T) {
 		itr = table.Get("UserID", 1969).StartFrom(itr.LastEvaluatedKey()).SearchLimit(1).Iter()
 	}
 }
+
+// fakeQueryClient is a fake client that serves Query requests from pages,
+// using the page index as LastEvaluatedKey.
+type fakeQueryClient struct {
+	dynamodbiface.DynamoDBAPI
+	pages [][]map[string]*dynamodb.AttributeValue
+}
+
+func (c fakeQueryClient) QueryWithContext(_ aws.Context, input *dynamodb.QueryInput, _ ...request.Option) (*dynamodb.QueryOutput, error) {
+	var page int
+	if input.ExclusiveStartKey != nil {
+		page, _ = strconv.Atoi(*input.ExclusiveStartKey["Page"].N)
+	}
+	out := &dynamodb.QueryOutput{
+		Items: c.pages[page],
+	}
+	if input.ReturnConsumedCapacity != nil {
+		out.ConsumedCapacity = &dynamodb.ConsumedCapacity{CapacityUnits: aws.Float64(0.5)}
+	}
+	if page+1 < len(c.pages) {
+		out.LastEvaluatedKey = map[string]*dynamodb.AttributeValue{
+			"Page": {N: aws.String(strconv.Itoa(page + 1))},
+		}
+	}
+	return out, nil
+}
+
+// fakePages returns pages of widgets with the given sizes.
+func fakePages(t *testing.T, sizes ...int) [][]map[string]*dynamodb.AttributeValue {
+	var pages [][]map[string]*dynamodb.AttributeValue
+	for _, size := range sizes {
+		var page []map[string]*dynamodb.AttributeValue
+		for i := 0; i < size; i++ {
+			item, err := marshalItem(widget{UserID: 42, Msg: strconv.Itoa(len(pages)) + "-" + strconv.Itoa(i)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			page = append(page, item)
+		}
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+func TestQueryNextPage(t *testing.T) {
+	db := NewFromIface(fakeQueryClient{pages: fakePages(t, 2, 0, 3)})
+	table := db.Table(testTable)
+
+	var sizes []int
+	var results []widget
+	itr := table.Get("UserID", 42).Iter().(*queryIter)
+	for itr.NextPage(&results) {
+		sizes = append(sizes, len(results))
+	}
+	if err := itr.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{2, 3}; !reflect.DeepEqual(sizes, expected) {
+		t.Error("bad page sizes:", sizes, "≠", expected)
+	}
+	if itr.LastEvaluatedKey() != nil {
+		t.Error("expected nil LastEvaluatedKey, got", itr.LastEvaluatedKey())
+	}
+
+	// mixing Next and NextPage
+	var w widget
+	itr = table.Get("UserID", 42).Limit(4).Iter().(*queryIter)
+	if !itr.Next(&w) || w.Msg != "0-0" {
+		t.Fatal("bad first result:", w, itr.Err())
+	}
+	if !itr.NextPage(&results) || len(results) != 1 || results[0].Msg != "0-1" {
+		t.Error("bad rest of first page:", results)
+	}
+	if !itr.NextPage(&results) || len(results) != 2 {
+		t.Error("expected limited page of 2, got", results)
+	}
+	if itr.NextPage(&results) {
+		t.Error("expected no more pages after limit, got", results)
+	}
+
+	if table.Get("UserID", 42).Iter().(PageIter).NextPage(&w) {
+		t.Error("expected NextPage to reject non-slice")
+	}
+}
+
+func TestQueryOnPage(t *testing.T) {
+	db := NewFromIface(fakeQueryClient{pages: fakePages(t, 2, 0, 3)})
+
+	var stats []PageStats
+	var results []widget
+	err := db.Table(testTable).Get("UserID", 42).OnPage(func(ps PageStats) {
+		ps.Elapsed = 0
+		stats = append(stats, ps)
+	}).All(&results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []PageStats{
+		{Pages: 1, Items: 2, TotalItems: 2, Scanned: 2, TotalScanned: 2, Capacity: 0.5, TotalCapacity: 0.5},
+		{Pages: 2, Items: 0, TotalItems: 2, Scanned: 0, TotalScanned: 2, Capacity: 0.5, TotalCapacity: 1},
+		{Pages: 3, Items: 3, TotalItems: 5, Scanned: 3, TotalScanned: 5, Capacity: 0.5, TotalCapacity: 1.5},
+	}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Error("bad stats:", stats, "≠", expected)
+	}
+}
+
+// filteringClient is a fake client that reports scanning 10 items for each one returned.
+type filteringClient struct {
+	fakeQueryClient
+}
+
+func (c filteringClient) QueryWithContext(ctx aws.Context, input *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	out, err := c.fakeQueryClient.QueryWithContext(ctx, input, opts...)
+	if out != nil {
+		out.ScannedCount = aws.Int64(int64(len(out.Items)) * 10)
+	}
+	return out, err
+}
+
+func TestQueryStats(t *testing.T) {
+	db := NewFromIface(filteringClient{fakeQueryClient{pages: fakePages(t, 2, 1)}})
+	itr := db.Table(testTable).Get("UserID", 42).Filter("Msg <> ?", "").ConsumedCapacity(&ConsumedCapacity{}).Iter().(*queryIter)
+
+	var results []widget
+	if !itr.NextPage(&results) {
+		t.Fatal("no first page:", itr.Err())
+	}
+	if stats := itr.Stats(); stats.Items != 2 || stats.Scanned != 20 || stats.Capacity != 0.5 {
+		t.Error("bad first page stats:", stats)
+	}
+	if !itr.NextPage(&results) {
+		t.Fatal("no second page:", itr.Err())
+	}
+	if stats := itr.Stats(); stats.Pages != 2 || stats.Items != 1 || stats.Scanned != 10 || stats.TotalScanned != 30 || stats.TotalCapacity != 1 {
+		t.Error("bad second page stats:", stats)
+	}
+}
+
+// throttledOnceClient is a fake client whose first Query request is throttled.
+type throttledOnceClient struct {
+	fakeQueryClient
+	calls *int
+}
+
+func (c throttledOnceClient) QueryWithContext(ctx aws.Context, input *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	*c.calls++
+	if *c.calls == 1 {
+		return nil, awserr.NewRequestFailure(awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "throttled", nil), 400, "")
+	}
+	out, err := c.fakeQueryClient.QueryWithContext(ctx, input, opts...)
+	if out != nil {
+		out.Count = aws.Int64(int64(len(out.Items)))
+	}
+	return out, err
+}
+
+func TestQueryRunStats(t *testing.T) {
+	var calls int
+	db := NewFromIface(throttledOnceClient{fakeQueryClient{pages: fakePages(t, 2, 3)}, &calls})
+	table := db.Table(testTable)
+
+	var stats PageStats
+	var results []widget
+	if err := table.Get("UserID", 42).Stats(&stats).ConsumedCapacity(&ConsumedCapacity{}).All(&results); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Pages != 2 || stats.TotalItems != 5 || stats.TotalCapacity != 1 || stats.Retries != 1 || stats.Throttles != 1 {
+		t.Error("bad stats:", stats)
+	}
+	if stats.Elapsed <= 0 {
+		t.Error("expected elapsed time, got", stats.Elapsed)
+	}
+
+	stats = PageStats{}
+	if _, err := table.Get("UserID", 42).Stats(&stats).Count(); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Pages != 2 || stats.Retries != 0 || stats.Throttles != 0 {
+		t.Error("bad count stats:", stats)
+	}
+}
+
+// slowGetClient is a fake client whose first GetItem request hangs until canceled.
+type slowGetClient struct {
+	dynamodbiface.DynamoDBAPI
+	calls *int32
+	item  map[string]*dynamodb.AttributeValue
+}
+
+func (c slowGetClient) GetItemWithContext(ctx aws.Context, _ *dynamodb.GetItemInput, _ ...request.Option) (*dynamodb.GetItemOutput, error) {
+	if atomic.AddInt32(c.calls, 1) == 1 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &dynamodb.GetItemOutput{Item: c.item}, nil
+}
+
+func TestQueryHedge(t *testing.T) {
+	item, err := marshalItem(widget{UserID: 42, Msg: "hedged"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the hedged request mustn't wait on the one it hedges when coalescing gets
+	for _, coalesce := range []bool{false, true} {
+		var calls int32
+		db := NewFromIface(slowGetClient{calls: &calls, item: item})
+		if coalesce {
+			db = db.CoalesceGets()
+		}
+
+		var w widget
+		err = db.Table(testTable).Get("UserID", 42).Hedge(10 * time.Millisecond).Timeout(time.Second).One(&w)
+		if err != nil {
+			t.Fatal(coalesce, err)
+		}
+		if w.Msg != "hedged" {
+			t.Error("bad result:", w)
+		}
+		if n := atomic.LoadInt32(&calls); n != 2 {
+			t.Error("expected 2 calls, got", n)
+		}
+	}
+}
+
+// blockingGetClient is a fake client whose GetItem requests wait for release to be closed.
+type blockingGetClient struct {
+	dynamodbiface.DynamoDBAPI
+	calls   *int32
+	release chan struct{}
+	item    map[string]*dynamodb.AttributeValue
+}
+
+func (c blockingGetClient) GetItemWithContext(ctx aws.Context, _ *dynamodb.GetItemInput, _ ...request.Option) (*dynamodb.GetItemOutput, error) {
+	atomic.AddInt32(c.calls, 1)
+	<-c.release
+	return &dynamodb.GetItemOutput{Item: c.item}, nil
+}
+
+func TestCoalesceGets(t *testing.T) {
+	item, err := marshalItem(widget{UserID: 42, Msg: "shared"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls int32
+	release := make(chan struct{})
+	db := NewFromIface(blockingGetClient{calls: &calls, release: release, item: item}).CoalesceGets()
+	table := db.Table(testTable)
+
+	const n = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var w widget
+			if err := table.Get("UserID", 42).One(&w); err != nil {
+				errs <- err
+				return
+			}
+			if w.Msg != "shared" {
+				t.Error("bad result:", w)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Error("expected 1 call, got", got)
+	}
+
+	// different projections must not be shared
+	a := getItemKey(table.Get("UserID", 42).getItemInput())
+	b := getItemKey(table.Get("UserID", 42).Project("Msg").getItemInput())
+	if a == b {
+		t.Error("projected and unprojected requests have the same key:", a)
+	}
+}
+
+// countingQueryClient counts Query requests.
+type countingQueryClient struct {
+	fakeQueryClient
+	calls int
+}
+
+func (c *countingQueryClient) QueryWithContext(ctx aws.Context, input *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	c.calls++
+	return c.fakeQueryClient.QueryWithContext(ctx, input, opts...)
+}
+
+func TestQueryFirst(t *testing.T) {
+	client := &countingQueryClient{fakeQueryClient: fakeQueryClient{pages: fakePages(t, 0, 2, 1)}}
+	table := NewFromIface(client).Table(testTable)
+
+	var w widget
+	if err := table.Get("UserID", 42).Filter("Msg <> ?", "").First(&w); err != nil {
+		t.Fatal(err)
+	}
+	if w.Msg != "1-0" {
+		t.Error("bad first result:", w)
+	}
+	if client.calls != 2 {
+		t.Error("expected 2 requests, got", client.calls)
+	}
+
+	// One keeps looking past pages that were entirely filtered out
+	client.pages = fakePages(t, 0, 1)
+	if err := table.Get("UserID", 42).Filter("Msg <> ?", "").One(&w); err != nil {
+		t.Error("unexpected error:", err)
+	}
+	client.pages = fakePages(t, 0, 2)
+	if err := table.Get("UserID", 42).Filter("Msg <> ?", "").One(&w); err != ErrTooMany {
+		t.Error("expected ErrTooMany, got", err)
+	}
+
+	// One stops at the first page with a match, unless it's strict
+	client.pages, client.calls = fakePages(t, 1, 1), 0
+	if err := table.Get("UserID", 42).Filter("Msg <> ?", "").One(&w); err != nil {
+		t.Error("unexpected error:", err)
+	}
+	if client.calls != 1 {
+		t.Error("expected 1 request, got", client.calls)
+	}
+	if err := table.Get("UserID", 42).Filter("Msg <> ?", "").Strict().One(&w); err != ErrTooMany {
+		t.Error("expected ErrTooMany from strict query, got", err)
+	}
+	client.pages = fakePages(t, 1, 0, 0)
+	w = widget{}
+	if err := table.Get("UserID", 42).Filter("Msg <> ?", "").Strict().One(&w); err != nil {
+		t.Error("unexpected error:", err)
+	}
+	if w.Msg != "0-0" {
+		t.Error("bad strict result:", w)
+	}
+
+	client.pages = fakePages(t, 0, 0)
+	if err := table.Get("UserID", 42).Filter("Msg <> ?", "").First(&w); err != ErrNotFound {
+		t.Error("expected ErrNotFound, got", err)
+	}
+}
+
+// projectionClient records the projection of GetItem requests.
+type projectionClient struct {
+	*memClient
+	projection string
+}
+
+func (c *projectionClient) GetItemWithContext(ctx aws.Context, input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	c.projection = unsubstitute(aws.StringValue(input.ProjectionExpression), input.ExpressionAttributeNames)
+	return c.memClient.GetItemWithContext(ctx, input, opts...)
+}
+
+func TestQueryExists(t *testing.T) {
+	client := &projectionClient{memClient: newMemClient()}
+	table := NewFromIface(client).Table(testTable)
+	if err := table.Put(widget{UserID: 42, Msg: "hello"}).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	q := table.Get("UserID", 42)
+	exists, err := q.Exists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Error("expected item to exist")
+	}
+	if client.projection != "UserID" {
+		t.Error("expected key-only projection, got", client.projection)
+	}
+	if len(q.nameExpr) != 0 {
+		t.Error("Exists modified the query:", q.nameExpr)
+	}
+	exists, err = table.Get("UserID", 1).Exists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Error("expected item not to exist")
+	}
+
+	// queries stop at the first match
+	pages := &countingQueryClient{fakeQueryClient: fakeQueryClient{pages: fakePages(t, 0, 2, 1)}}
+	table = NewFromIface(pages).Table(testTable)
+	exists, err = table.Get("UserID", 42).Range("Time", Greater, 0).Exists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Error("expected a match")
+	}
+	if pages.calls != 2 {
+		t.Error("expected 2 requests, got", pages.calls)
+	}
+	pages.pages = fakePages(t, 0, 0)
+	exists, err = table.Get("UserID", 42).Range("Time", Greater, 0).Exists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Error("expected no match")
+	}
+}
+
+func TestTableGetAll(t *testing.T) {
+	table := NewFromIface(fakeQueryClient{pages: fakePages(t, 2, 0, 1)}).Table(testTable)
+	var results []widget
+	if err := table.GetAll("UserID", 42, &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Error("expected 3 results, got", len(results))
+	}
+}
@@ -0,0 +1,107 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// RawItem is a result that hasn't been decoded yet, returned by NextRaw.
+// Its attributes can be inspected or decoded one at a time, so pipelines that filter results by an attribute
+// only pay for decoding the whole item when they keep it:
+//	iter := table.Scan().Iter().(dynamo.RawIter)
+//	for raw, ok := iter.NextRaw(); ok; raw, ok = iter.NextRaw() {
+//		var status string
+//		if err := raw.UnmarshalAttr("Status", &status); err != nil || status != "active" {
+//			continue
+//		}
+//		var order Order
+//		err := raw.Unmarshal(&order)
+//		// ...
+//	}
+type RawItem struct {
+	item  map[string]*dynamodb.AttributeValue
+	codec codec
+}
+
+// Item returns the undecoded item.
+func (r RawItem) Item() map[string]*dynamodb.AttributeValue {
+	return r.item
+}
+
+// Attr returns the undecoded attribute name, or nil if the item doesn't have it.
+func (r RawItem) Attr(name string) *dynamodb.AttributeValue {
+	return r.item[name]
+}
+
+// UnmarshalAttr decodes the attribute name into out, as in Unmarshal.
+// It returns ErrNotFound if the item doesn't have the attribute.
+func (r RawItem) UnmarshalAttr(name string, out interface{}) error {
+	av, ok := r.item[name]
+	if !ok {
+		return ErrNotFound
+	}
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("dynamo: unmarshal: not a pointer: %T", out)
+	}
+	return r.codec.unmarshalReflect(av, rv.Elem())
+}
+
+// Unmarshal decodes the whole item into out, as in UnmarshalItem.
+func (r RawItem) Unmarshal(out interface{}) error {
+	return r.codec.unmarshalItem(r.item, out)
+}
+
+// NextRaw returns the next result without decoding it.
+// Returns false when it is complete or if it runs into an error.
+func (itr *queryIter) NextRaw() (RawItem, bool) {
+	ctx, cancel := timeoutContext(itr.query.timeout)
+	defer cancel()
+	return itr.NextRawWithContext(ctx)
+}
+
+// NextRawWithContext returns the next result without decoding it.
+func (itr *queryIter) NextRawWithContext(ctx aws.Context) (RawItem, bool) {
+	var item map[string]*dynamodb.AttributeValue
+	if !itr.NextWithContext(ctx, &item) {
+		return RawItem{}, false
+	}
+	return RawItem{item: item, codec: itr.codec()}, true
+}
+
+// NextRaw returns the next result without decoding it.
+// Returns false when it is complete or if it runs into an error.
+func (itr *scanIter) NextRaw() (RawItem, bool) {
+	ctx, cancel := timeoutContext(itr.scan.timeout)
+	defer cancel()
+	return itr.NextRawWithContext(ctx)
+}
+
+// NextRawWithContext returns the next result without decoding it.
+func (itr *scanIter) NextRawWithContext(ctx aws.Context) (RawItem, bool) {
+	var item map[string]*dynamodb.AttributeValue
+	if !itr.NextWithContext(ctx, &item) {
+		return RawItem{}, false
+	}
+	return RawItem{item: item, codec: itr.codec()}, true
+}
+
+// NextRaw returns the next result without decoding it.
+// Returns false when it is complete or if it runs into an error.
+func (itr *bgIter) NextRaw() (RawItem, bool) {
+	ctx, cancel := timeoutContext(itr.bg.timeout)
+	defer cancel()
+	return itr.NextRawWithContext(ctx)
+}
+
+// NextRawWithContext returns the next result without decoding it.
+func (itr *bgIter) NextRawWithContext(ctx aws.Context) (RawItem, bool) {
+	var item map[string]*dynamodb.AttributeValue
+	if !itr.NextWithContext(ctx, &item) {
+		return RawItem{}, false
+	}
+	return RawItem{item: item, codec: itr.bg.batch.table.db.codec()}, true
+}
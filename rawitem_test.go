@@ -0,0 +1,63 @@
+package dynamo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestNextRaw(t *testing.T) {
+	table := NewFromIface(fakeScanClient{pages: fakePages(t, 2, 0, 3)}).Table(testTable)
+
+	iter := table.Scan().Iter().(RawIter)
+	var kept []widget
+	var seen int
+	for raw, ok := iter.NextRaw(); ok; raw, ok = iter.NextRaw() {
+		seen++
+		var msg string
+		if err := raw.UnmarshalAttr("Msg", &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg[0] != '2' {
+			continue
+		}
+		var w widget
+		if err := raw.Unmarshal(&w); err != nil {
+			t.Fatal(err)
+		}
+		kept = append(kept, w)
+
+		if err := raw.UnmarshalAttr("Nope", &msg); err != ErrNotFound {
+			t.Error("expected ErrNotFound, got", err)
+		}
+		if err := raw.UnmarshalAttr("Msg", msg); err == nil {
+			t.Error("expected error for non-pointer")
+		}
+		if av := raw.Attr("UserID"); av == nil || *av.N != "42" {
+			t.Error("bad attribute:", av)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if seen != 5 || len(kept) != 3 || kept[0].Msg != "2-0" {
+		t.Error("bad results:", seen, kept)
+	}
+
+	item, err := marshalItem(widget{UserID: 1, Msg: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := NewFromIface(fakeBatchGetClient{items: []map[string]*dynamodb.AttributeValue{item}}).Table(testTable).Batch("UserID")
+	bgIter := batch.Get(Keys{1}).Iter().(RawIter)
+	raw, ok := bgIter.NextRaw()
+	if !ok {
+		t.Fatal("no result:", bgIter.Err())
+	}
+	if len(raw.Item()) != len(item) {
+		t.Error("bad item:", raw.Item())
+	}
+	if _, ok := bgIter.NextRaw(); ok {
+		t.Error("expected one result")
+	}
+}
@@ -0,0 +1,140 @@
+package dynamo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// marshalRawJSON encodes raw according to special, returning false if special isn't an option for raw JSON.
+// json.RawMessage fields are encoded as binary by default.
+// Tag them with jsonString to store them as a string, or with jsonDocument to store them
+// as the equivalent DynamoDB document, so that their contents can be used in expressions:
+//	Payload json.RawMessage `dynamo:",jsonString"`   // S: {"a":1}
+//	Details json.RawMessage `dynamo:",jsonDocument"` // M: {a: {N: 1}}
+func marshalRawJSON(raw json.RawMessage, special string) (*dynamodb.AttributeValue, bool, error) {
+	switch special {
+	case "jsonString":
+		if len(raw) == 0 {
+			return nil, true, nil
+		}
+		return &dynamodb.AttributeValue{S: aws.String(string(raw))}, true, nil
+	case "jsonDocument":
+		if len(raw) == 0 {
+			return nil, true, nil
+		}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var doc interface{}
+		if err := dec.Decode(&doc); err != nil {
+			return nil, true, fmt.Errorf("dynamo: marshal json document: %v", err)
+		}
+		return jsonToAV(doc), true, nil
+	}
+	return nil, false, nil
+}
+
+// jsonToAV converts a value decoded by encoding/json with UseNumber into a DynamoDB document.
+// Unlike other strings, empty strings are kept, so that documents are stored as is.
+func jsonToAV(v interface{}) *dynamodb.AttributeValue {
+	switch x := v.(type) {
+	case nil:
+		return &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+	case bool:
+		return &dynamodb.AttributeValue{BOOL: aws.Bool(x)}
+	case json.Number:
+		return &dynamodb.AttributeValue{N: aws.String(x.String())}
+	case string:
+		return &dynamodb.AttributeValue{S: aws.String(x)}
+	case []interface{}:
+		list := make([]*dynamodb.AttributeValue, 0, len(x))
+		for _, elem := range x {
+			list = append(list, jsonToAV(elem))
+		}
+		return &dynamodb.AttributeValue{L: list}
+	case map[string]interface{}:
+		m := make(map[string]*dynamodb.AttributeValue, len(x))
+		for k, elem := range x {
+			m[k] = jsonToAV(elem)
+		}
+		return &dynamodb.AttributeValue{M: m}
+	}
+	panic(fmt.Sprintf("dynamo: unexpected JSON value %T", v))
+}
+
+// unmarshalRawJSON decodes av into out. Strings and binary are copied as is,
+// and anything else is converted to JSON.
+func unmarshalRawJSON(av *dynamodb.AttributeValue, out *json.RawMessage) error {
+	switch {
+	case av.S != nil:
+		*out = append((*out)[:0], *av.S...)
+		return nil
+	case av.B != nil:
+		*out = append((*out)[:0], av.B...)
+		return nil
+	}
+	doc, err := avToJSON(av)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	*out = data
+	return nil
+}
+
+// avToJSON converts av into a value that encoding/json marshals into the equivalent JSON.
+// Numbers are kept as is, sets become arrays, and binary becomes base64 strings.
+func avToJSON(av *dynamodb.AttributeValue) (interface{}, error) {
+	switch {
+	case av.NULL != nil:
+		return nil, nil
+	case av.BOOL != nil:
+		return *av.BOOL, nil
+	case av.N != nil:
+		return json.Number(*av.N), nil
+	case av.S != nil:
+		return *av.S, nil
+	case av.B != nil:
+		return av.B, nil
+	case av.L != nil:
+		list := make([]interface{}, 0, len(av.L))
+		for _, elem := range av.L {
+			v, err := avToJSON(elem)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case av.M != nil:
+		m := make(map[string]interface{}, len(av.M))
+		for k, elem := range av.M {
+			v, err := avToJSON(elem)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = v
+		}
+		return m, nil
+	case av.SS != nil:
+		ss := aws.StringValueSlice(av.SS)
+		sort.Strings(ss)
+		return ss, nil
+	case av.NS != nil:
+		ns := make([]json.Number, 0, len(av.NS))
+		for _, n := range av.NS {
+			ns = append(ns, json.Number(*n))
+		}
+		return ns, nil
+	case av.BS != nil:
+		return av.BS, nil
+	}
+	return nil, fmt.Errorf("dynamo: cannot unmarshal %s data into json.RawMessage", avTypeName(av))
+}
@@ -0,0 +1,70 @@
+package dynamo
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestRawJSON(t *testing.T) {
+	type payload struct {
+		Raw    json.RawMessage
+		String json.RawMessage `dynamo:",jsonString"`
+		Doc    json.RawMessage `dynamo:",jsonDocument"`
+		Empty  json.RawMessage `dynamo:",jsonDocument"`
+	}
+	doc := `{"a":1.50,"b":[true,null,"x",""],"c":{}}`
+	in := payload{
+		Raw:    json.RawMessage(`{"raw":true}`),
+		String: json.RawMessage(`{"s":1}`),
+		Doc:    json.RawMessage(doc),
+	}
+	item, err := marshalItem(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]*dynamodb.AttributeValue{
+		"Raw":    {B: []byte(`{"raw":true}`)},
+		"String": {S: aws.String(`{"s":1}`)},
+		"Doc": {M: map[string]*dynamodb.AttributeValue{
+			"a": {N: aws.String("1.50")},
+			"b": {L: []*dynamodb.AttributeValue{
+				{BOOL: aws.Bool(true)},
+				{NULL: aws.Bool(true)},
+				{S: aws.String("x")},
+				{S: aws.String("")},
+			}},
+			"c": {M: map[string]*dynamodb.AttributeValue{}},
+		}},
+	}
+	if !reflect.DeepEqual(item, expect) {
+		t.Errorf("bad item: %v ≠ %v", item, expect)
+	}
+
+	var out payload
+	if err := unmarshalItem(item, &out); err != nil {
+		t.Fatal(err)
+	}
+	if string(out.Raw) != string(in.Raw) || string(out.String) != string(in.String) || string(out.Doc) != doc {
+		t.Errorf("bad result: %s, %s, %s", out.Raw, out.String, out.Doc)
+	}
+
+	var sets json.RawMessage
+	err = Unmarshal(&dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+		"ss": {SS: aws.StringSlice([]string{"b", "a"})},
+		"ns": {NS: aws.StringSlice([]string{"1", "2.5"})},
+	}}, &sets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(sets) != `{"ns":[1,2.5],"ss":["a","b"]}` {
+		t.Error("bad sets:", string(sets))
+	}
+
+	if _, err := marshalItem(payload{Doc: json.RawMessage(`{`)}); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
@@ -0,0 +1,18 @@
+package dynamo
+
+import (
+	"errors"
+)
+
+// ErrReadOnly is returned when writing with a DB made by ReadOnly.
+var ErrReadOnly = errors.New("dynamo: write to read-only DB")
+
+// ReadOnly returns a copy of this DB whose writes fail with ErrReadOnly without being sent:
+// every Put, Update, Delete, BatchWrite, and WriteTx.
+// Reads work as usual. This is useful for running business logic against live data
+// in contexts that must not modify it, such as analytics or disaster recovery drills.
+func (db *DB) ReadOnly() *DB {
+	cp := *db
+	cp.readOnly = true
+	return &cp
+}
@@ -0,0 +1,42 @@
+package dynamo
+
+import (
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	client := newMemClient()
+	db := NewFromIface(client)
+	if err := db.Table(testTable).Put(widget{UserID: 1, Msg: "hello"}).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	ro := db.ReadOnly()
+	table := ro.Table(testTable)
+	if err := table.Put(widget{UserID: 2}).Run(); err != ErrReadOnly {
+		t.Error("put: expected ErrReadOnly, got", err)
+	}
+	if err := table.Update("UserID", 1).Set("Msg", "bye").Run(); err != ErrReadOnly {
+		t.Error("update: expected ErrReadOnly, got", err)
+	}
+	if err := table.Delete("UserID", 1).Run(); err != ErrReadOnly {
+		t.Error("delete: expected ErrReadOnly, got", err)
+	}
+	if _, err := table.Batch("UserID").Write().Put(widget{UserID: 3}).Run(); err != ErrReadOnly {
+		t.Error("batch write: expected ErrReadOnly, got", err)
+	}
+	if err := ro.WriteTx().Put(table.Put(widget{UserID: 4})).Run(); err != ErrReadOnly {
+		t.Error("write tx: expected ErrReadOnly, got", err)
+	}
+	if len(client.items) != 1 {
+		t.Error("read-only DB wrote items:", len(client.items))
+	}
+
+	var w widget
+	if err := table.Get("UserID", 1).One(&w); err != nil || w.Msg != "hello" {
+		t.Error("read failed:", w, err)
+	}
+	if err := db.Table(testTable).Delete("UserID", 1).Run(); err != nil {
+		t.Error("original DB became read-only:", err)
+	}
+}
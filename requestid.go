@@ -0,0 +1,66 @@
+package dynamo
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// captureRequestID returns opts plus an option that calls set with the ID of each request once it completes,
+// whether or not it succeeded.
+func captureRequestID(opts []request.Option, set func(id string)) []request.Option {
+	capture := func(r *request.Request) {
+		r.Handlers.Complete.PushBack(func(r *request.Request) {
+			if r.RequestID != "" {
+				set(r.RequestID)
+			}
+		})
+	}
+	return append(opts[:len(opts):len(opts)], capture)
+}
+
+// requestOptions returns the request options for an operation,
+// recording the ID of its requests in id if it isn't nil.
+func (db *DB) requestOptions(id *string) []request.Option {
+	if id == nil {
+		return db.opts
+	}
+	return captureRequestID(db.opts, func(rid string) {
+		*id = rid
+	})
+}
+
+// lastRequestID records the ID of the latest request made by an iterator.
+// Hedged requests may complete concurrently, so it is safe for concurrent use.
+type lastRequestID struct {
+	mu sync.Mutex
+	id string
+	// out also receives the ID, if it isn't nil
+	out *string
+}
+
+func (r *lastRequestID) set(id string) {
+	r.mu.Lock()
+	r.id = id
+	if r.out != nil {
+		*r.out = id
+	}
+	r.mu.Unlock()
+}
+
+// forward makes later IDs also be recorded in out.
+func (r *lastRequestID) forward(out *string) {
+	r.mu.Lock()
+	r.out = out
+	r.mu.Unlock()
+}
+
+func (r *lastRequestID) get() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.id
+}
+
+func (r *lastRequestID) options(opts []request.Option) []request.Option {
+	return captureRequestID(opts, r.set)
+}
@@ -0,0 +1,130 @@
+package dynamo
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// requestIDClient is a fake client that completes each request with a new request ID,
+// running the handlers added by request options like the SDK would.
+type requestIDClient struct {
+	dynamodbiface.DynamoDBAPI
+	n    int
+	fail bool
+}
+
+func (c *requestIDClient) complete(opts []request.Option) error {
+	c.n++
+	r := &request.Request{RequestID: "req-" + strconv.Itoa(c.n)}
+	r.ApplyOptions(opts...)
+	if c.fail {
+		r.Error = awserr.NewRequestFailure(awserr.New("ValidationException", "invalid", nil), 400, r.RequestID)
+	}
+	r.Handlers.Complete.Run(r)
+	return r.Error
+}
+
+func (c *requestIDClient) PutItemWithContext(_ aws.Context, _ *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	return &dynamodb.PutItemOutput{}, c.complete(opts)
+}
+
+func (c *requestIDClient) ScanWithContext(_ aws.Context, input *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error) {
+	out := &dynamodb.ScanOutput{Items: []map[string]*dynamodb.AttributeValue{{"UserID": {N: aws.String("1")}}}}
+	if input.ExclusiveStartKey == nil {
+		out.LastEvaluatedKey = out.Items[0]
+	}
+	return out, c.complete(opts)
+}
+
+func (c *requestIDClient) GetItemWithContext(_ aws.Context, _ *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	out := &dynamodb.GetItemOutput{Item: map[string]*dynamodb.AttributeValue{"UserID": {N: aws.String("1")}}}
+	return out, c.complete(opts)
+}
+
+func (c *requestIDClient) QueryWithContext(_ aws.Context, _ *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	out := &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{{"UserID": {N: aws.String("1")}}}, Count: aws.Int64(1)}
+	return out, c.complete(opts)
+}
+
+func (c *requestIDClient) BatchWriteItemWithContext(_ aws.Context, _ *dynamodb.BatchWriteItemInput, opts ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	return &dynamodb.BatchWriteItemOutput{}, c.complete(opts)
+}
+
+func TestRequestID(t *testing.T) {
+	client := &requestIDClient{}
+	table := NewFromIface(client).Table(testTable)
+
+	var id string
+	if err := table.Put(widget{UserID: 1}).RequestID(&id).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if id != "req-1" {
+		t.Error("bad request ID:", id)
+	}
+
+	iter := table.Scan().Iter()
+	var results []widget
+	for iter.(PageIter).NextPage(&results) {
+	}
+	if err := iter.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := iter.(StatsIter).RequestID(); got != "req-3" {
+		t.Error("bad iterator request ID:", got)
+	}
+
+	client.fail = true
+	err := table.Put(widget{UserID: 1}).RequestID(&id).Run()
+	if id != "req-4" {
+		t.Error("bad request ID of failed request:", id)
+	}
+	if re, ok := err.(*RetryError); !ok || re.RequestID() != id {
+		t.Error("error doesn't have request ID:", err)
+	}
+}
+
+func TestRequestIDQueryAndBatchWrite(t *testing.T) {
+	client := &requestIDClient{}
+	table := NewFromIface(client).Table(testTable)
+
+	var id string
+	var w widget
+	if err := table.Get("UserID", 1).RequestID(&id).One(&w); err != nil {
+		t.Fatal(err)
+	}
+	if id != "req-1" {
+		t.Error("bad request ID of GetItem:", id)
+	}
+	if err := table.Get("UserID", 1).Filter("Msg <> ?", "").RequestID(&id).One(&w); err != nil {
+		t.Fatal(err)
+	}
+	if id != "req-2" {
+		t.Error("bad request ID of Query:", id)
+	}
+	if _, err := table.Get("UserID", 1).RequestID(&id).Count(); err != nil {
+		t.Fatal(err)
+	}
+	if id != "req-3" {
+		t.Error("bad request ID of Count:", id)
+	}
+	var results []widget
+	if err := table.Get("UserID", 1).Filter("Msg <> ?", "").RequestID(&id).All(&results); err != nil {
+		t.Fatal(err)
+	}
+	if id != "req-4" {
+		t.Error("bad request ID of All:", id)
+	}
+
+	if _, err := table.Batch("UserID").Write().Put(widget{UserID: 2}).RequestID(&id).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if id != "req-5" {
+		t.Error("bad request ID of BatchWrite:", id)
+	}
+}
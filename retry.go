@@ -1,6 +1,7 @@
 package dynamo
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -23,27 +24,93 @@ func defaultContext() (aws.Context, context.CancelFunc) {
 	return context.WithDeadline(aws.BackgroundContext(), time.Now().Add(RetryTimeout))
 }
 
+// timeoutContext is like defaultContext, but uses a request-specific timeout instead of RetryTimeout, if set.
+func timeoutContext(timeout time.Duration) (aws.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return defaultContext()
+	}
+	return context.WithTimeout(aws.BackgroundContext(), timeout)
+}
+
+// withTimeout bounds ctx by a request-specific timeout, if set.
+func withTimeout(ctx aws.Context, timeout time.Duration) (aws.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return ctx, (func() {})
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// RetryError is returned when DynamoDB rejects a request, after any retries.
+// It adds how many attempts were made to the error from the final attempt,
+// which it embeds so it can still be inspected as an awserr.RequestFailure.
+// Its message is that of the final error, followed by the attempts and backoff if the request was retried.
+type RetryError struct {
+	awserr.RequestFailure
+	// Attempts is the number of times the request was sent.
+	Attempts int
+	// Backoff is the total time spent waiting between attempts.
+	Backoff time.Duration
+}
+
+func (e *RetryError) Error() string {
+	if e.Attempts <= 1 {
+		return e.RequestFailure.Error()
+	}
+	return fmt.Sprintf("%s\n\tattempts: %d, backoff: %v", e.RequestFailure.Error(), e.Attempts, e.Backoff)
+}
+
+// Unwrap returns the error from the final attempt.
+func (e *RetryError) Unwrap() error {
+	return e.RequestFailure
+}
+
 func retry(ctx aws.Context, f func() error) error {
 	var err error
-	var next time.Duration
+	var next, waited time.Duration
 	b := backoff.WithContext(backoff.NewExponentialBackOff(), ctx)
-	for {
+	budget := budgetFrom(ctx)
+	for attempts := 1; ; attempts++ {
 		if err = f(); err == nil {
 			return nil
 		}
 
 		if !canRetry(err) {
-			return err
+			return wrapRetryError(err, attempts, waited)
 		}
 
-		if next = b.NextBackOff(); next == backoff.Stop {
-			return err
+		if next = b.NextBackOff(); next == backoff.Stop || !budget.spend(next) {
+			return wrapRetryError(err, attempts, waited)
 		}
 
 		if err = aws.SleepWithContext(ctx, next); err != nil {
 			return err
 		}
+		waited += next
+	}
+}
+
+// wrapRetryError adds attempt metadata to errors returned by DynamoDB.
+// Other errors, such as context cancelation, are returned as is.
+func wrapRetryError(err error, attempts int, waited time.Duration) error {
+	rf, ok := err.(awserr.RequestFailure)
+	if !ok {
+		return err
+	}
+	return &RetryError{RequestFailure: rf, Attempts: attempts, Backoff: waited}
+}
+
+// sleepBackoff waits for the next interval of b, returning early if ctx is canceled.
+// If b has given up, for example because waiting would exceed ctx's deadline,
+// it returns context.DeadlineExceeded without waiting.
+func sleepBackoff(ctx aws.Context, b backoff.BackOff) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	next := b.NextBackOff()
+	if next == backoff.Stop {
+		return context.DeadlineExceeded
 	}
+	return aws.SleepWithContext(ctx, next)
 }
 
 func canRetry(err error) bool {
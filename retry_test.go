@@ -0,0 +1,184 @@
+package dynamo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"golang.org/x/net/context"
+)
+
+// throttledClient is a fake client that is always throttled.
+type throttledClient struct {
+	dynamodbiface.DynamoDBAPI
+}
+
+func (throttledClient) PutItemWithContext(aws.Context, *dynamodb.PutItemInput, ...request.Option) (*dynamodb.PutItemOutput, error) {
+	return nil, awserr.NewRequestFailure(awserr.New("ThrottlingException", "throttled", nil), 400, "")
+}
+
+func TestTimeout(t *testing.T) {
+	db := NewFromIface(throttledClient{})
+	table := db.Table(testTable)
+
+	start := time.Now()
+	err := table.Put(widget{UserID: 42}).Timeout(50 * time.Millisecond).Run()
+	if err == nil {
+		t.Error("expected error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Error("timeout not respected, took:", elapsed)
+	}
+
+	// timeout should also bound a context without a deadline
+	start = time.Now()
+	err = table.Put(widget{UserID: 42}).Timeout(50 * time.Millisecond).RunWithContext(aws.BackgroundContext())
+	if err == nil {
+		t.Error("expected error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Error("timeout not respected with context, took:", elapsed)
+	}
+}
+
+// unprocessedClient is a fake client that never processes any batch requests.
+type unprocessedClient struct {
+	dynamodbiface.DynamoDBAPI
+	calls int
+}
+
+func (c *unprocessedClient) BatchWriteItemWithContext(_ aws.Context, input *dynamodb.BatchWriteItemInput, _ ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	c.calls++
+	return &dynamodb.BatchWriteItemOutput{UnprocessedItems: input.RequestItems}, nil
+}
+
+func (c *unprocessedClient) BatchGetItemWithContext(_ aws.Context, input *dynamodb.BatchGetItemInput, _ ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	c.calls++
+	return &dynamodb.BatchGetItemOutput{UnprocessedKeys: input.RequestItems}, nil
+}
+
+func TestBackoffContext(t *testing.T) {
+	client := &unprocessedClient{}
+	db := NewFromIface(client)
+	table := db.Table(testTable)
+
+	ctx, cancel := context.WithTimeout(aws.BackgroundContext(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := table.Batch("UserID").Write().Put(widget{UserID: 42}).RunWithContext(ctx)
+	if err == nil {
+		t.Error("expected error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Error("context not respected, took:", elapsed)
+	}
+	if client.calls > 2 {
+		t.Error("too many requests while backing off:", client.calls)
+	}
+
+	client.calls = 0
+	ctx, cancel = context.WithTimeout(aws.BackgroundContext(), 100*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	var results []widget
+	err = table.Batch("UserID").Get(Keys{42}).AllWithContext(ctx, &results)
+	if err == nil {
+		t.Error("expected error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Error("context not respected, took:", elapsed)
+	}
+}
+
+// rejectingClient is a fake client that is throttled once, then rejects the request.
+type rejectingClient struct {
+	dynamodbiface.DynamoDBAPI
+	calls int
+}
+
+func (c *rejectingClient) PutItemWithContext(aws.Context, *dynamodb.PutItemInput, ...request.Option) (*dynamodb.PutItemOutput, error) {
+	c.calls++
+	if c.calls == 1 {
+		return nil, awserr.NewRequestFailure(awserr.New("ThrottlingException", "throttled", nil), 400, "req-1")
+	}
+	return nil, awserr.NewRequestFailure(awserr.New("ValidationException", "invalid", nil), 400, "req-2")
+}
+
+func TestRetryError(t *testing.T) {
+	db := NewFromIface(&rejectingClient{})
+	err := db.Table(testTable).Put(widget{UserID: 42}).Run()
+	re, ok := err.(*RetryError)
+	if !ok {
+		t.Fatal("expected RetryError, got", err)
+	}
+	if re.Attempts != 2 || re.Backoff <= 0 {
+		t.Error("bad attempt metadata:", re.Attempts, re.Backoff)
+	}
+	if re.Code() != "ValidationException" || re.RequestID() != "req-2" {
+		t.Error("bad final error:", re.Code(), re.RequestID())
+	}
+	if _, ok := err.(awserr.RequestFailure); !ok {
+		t.Error("RetryError should be a RequestFailure")
+	}
+	if msg := err.Error(); !strings.HasSuffix(msg, "attempts: 2, backoff: "+re.Backoff.String()) {
+		t.Error("bad message:", msg)
+	}
+
+	// requests that weren't retried keep the original message
+	final := awserr.NewRequestFailure(awserr.New("ValidationException", "invalid", nil), 400, "req-3")
+	if msg := wrapRetryError(final, 1, 0).Error(); msg != final.Error() {
+		t.Error("bad message:", msg, "≠", final.Error())
+	}
+
+	// errors that aren't from DynamoDB are left alone
+	if err := wrapRetryError(ErrNotFound, 1, 0); err != ErrNotFound {
+		t.Error("unexpected wrapping:", err)
+	}
+}
+
+// countingThrottledClient is a fake client that is always throttled and counts attempts.
+type countingThrottledClient struct {
+	throttledClient
+	calls int
+}
+
+func (c *countingThrottledClient) PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	c.calls++
+	return c.throttledClient.PutItemWithContext(ctx, input, opts...)
+}
+
+func TestRetryBudget(t *testing.T) {
+	client := &countingThrottledClient{}
+	table := NewFromIface(client).Table(testTable)
+	ctx, cancel := context.WithTimeout(aws.BackgroundContext(), 10*time.Second)
+	defer cancel()
+	ctx = WithRetryBudget(ctx, 2, 0)
+
+	err := table.Put(widget{UserID: 42}).RunWithContext(ctx)
+	if re, ok := err.(*RetryError); !ok || re.Attempts != 3 {
+		t.Error("expected 3 attempts, got", err)
+	}
+	// the budget is shared, so the next request can't retry at all
+	err = table.Put(widget{UserID: 42}).RunWithContext(ctx)
+	if re, ok := err.(*RetryError); !ok || re.Attempts != 1 {
+		t.Error("expected 1 attempt, got", err)
+	}
+	if client.calls != 4 {
+		t.Error("expected 4 requests, got", client.calls)
+	}
+
+	// waiting is limited too
+	client.calls = 0
+	ctx = WithRetryBudget(ctx, 0, time.Millisecond)
+	if err := table.Put(widget{UserID: 42}).RunWithContext(ctx); err == nil {
+		t.Error("expected error")
+	}
+	if client.calls != 1 {
+		t.Error("expected 1 request, got", client.calls)
+	}
+}
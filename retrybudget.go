@@ -0,0 +1,52 @@
+package dynamo
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"golang.org/x/net/context"
+)
+
+// WithRetryBudget returns a copy of ctx that limits how much the requests made with it may retry, collectively.
+// Once they have retried retries times, or waited a total of wait between retries,
+// requests fail with their last error instead of retrying again.
+// This keeps a single logical operation, such as a transaction followed by fallback reads,
+// from retrying its way past a latency target. A limit of zero means no limit.
+// Budgets are shared by contexts derived from the returned context, unless they set their own.
+func WithRetryBudget(ctx aws.Context, retries int, wait time.Duration) aws.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, &retryBudget{retries: retries, wait: wait})
+}
+
+type retryBudgetKey struct{}
+
+type retryBudget struct {
+	mu      sync.Mutex
+	retries int
+	wait    time.Duration
+	spent   int
+	waited  time.Duration
+}
+
+func budgetFrom(ctx aws.Context) *retryBudget {
+	budget, _ := ctx.Value(retryBudgetKey{}).(*retryBudget)
+	return budget
+}
+
+// spend takes a retry that waits for next from the budget, returning false if it's exhausted.
+func (b *retryBudget) spend(next time.Duration) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.retries > 0 && b.spent >= b.retries {
+		return false
+	}
+	if b.wait > 0 && b.waited+next > b.wait {
+		return false
+	}
+	b.spent++
+	b.waited += next
+	return true
+}
@@ -0,0 +1,194 @@
+package dynamo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/guregu/dynamo/internal/exprs"
+)
+
+// safeFuncs are the functions allowed in a SafeExpr.
+var safeFuncs = map[string]bool{
+	"attribute_exists":     true,
+	"attribute_not_exists": true,
+	"attribute_type":       true,
+	"begins_with":          true,
+	"contains":             true,
+	"size":                 true,
+}
+
+// safeKeywords are the keywords allowed in a SafeExpr.
+var safeKeywords = map[string]bool{
+	"AND":     true,
+	"OR":      true,
+	"NOT":     true,
+	"BETWEEN": true,
+	"IN":      true,
+}
+
+// SafeExpr builds a condition or filter expression whose attribute names may come from untrusted input,
+// such as the query parameters of an API request.
+// Names may only be given with $ placeholders or quotes, and must be in the allowlist passed to NewSafeExpr.
+// Values may only be given with ? placeholders.
+// The expression text itself may only contain placeholders, operators, parentheses,
+// and the keywords and functions of condition expressions,
+// so arguments can't change the structure of the expression.
+//	filter := dynamo.NewSafeExpr("Status", "Price")
+//	filter.And("$ = ?", r.FormValue("field"), r.FormValue("value"))
+//	err := table.Scan().FilterSafe(filter).All(&results)
+type SafeExpr struct {
+	allowed map[string]bool
+	expr    string
+	args    []interface{}
+	err     error
+}
+
+// NewSafeExpr creates an empty SafeExpr that may only refer to the given attribute names.
+func NewSafeExpr(allowed ...string) *SafeExpr {
+	e := &SafeExpr{allowed: make(map[string]bool, len(allowed))}
+	for _, name := range allowed {
+		e.allowed[name] = true
+	}
+	return e
+}
+
+// And adds expr to this expression, combined with AND.
+func (e *SafeExpr) And(expr string, args ...interface{}) *SafeExpr {
+	return e.add("AND", expr, args)
+}
+
+// Or adds expr to this expression, combined with OR.
+// Everything added so far is grouped together, so
+//	e.And("$ = ?", ...).And("$ = ?", ...).Or("$ = ?", ...)
+// means (a AND b) OR c.
+func (e *SafeExpr) Or(expr string, args ...interface{}) *SafeExpr {
+	return e.add("OR", expr, args)
+}
+
+func (e *SafeExpr) add(op, expr string, args []interface{}) *SafeExpr {
+	if err := e.check(expr, args); err != nil {
+		e.setError(err)
+		return e
+	}
+	switch {
+	case e.expr == "":
+		e.expr = wrapExpr(expr)
+	case op == "OR":
+		e.expr = "(" + e.expr + ") OR " + wrapExpr(expr)
+	default:
+		e.expr += " AND " + wrapExpr(expr)
+	}
+	e.args = append(e.args, args...)
+	return e
+}
+
+// Build returns the expression and its arguments, suitable for Filter or If,
+// or the first error encountered while building it.
+func (e *SafeExpr) Build() (string, []interface{}, error) {
+	if e.err != nil {
+		return "", nil, e.err
+	}
+	if e.expr == "" {
+		return "", nil, fmt.Errorf("dynamo: SafeExpr: empty expression")
+	}
+	return e.expr, e.args, nil
+}
+
+// check validates one part of the expression.
+func (e *SafeExpr) check(expr string, args []interface{}) error {
+	if err := CheckExpr(expr, args...); err != nil {
+		return err
+	}
+	lexed, err := exprs.Parse(expr)
+	if err != nil {
+		return err
+	}
+	var idx int
+	for _, item := range lexed.Items {
+		switch item.Type {
+		case exprs.ItemText:
+			if err := checkSafeText(item.Val); err != nil {
+				return err
+			}
+		case exprs.ItemQuotedName:
+			if name := item.Val[1 : len(item.Val)-1]; !e.allowed[name] {
+				return fmt.Errorf("dynamo: SafeExpr: attribute name %q is not allowed", name)
+			}
+		case exprs.ItemNamePlaceholder:
+			name, ok := args[idx].(string)
+			if !ok {
+				return fmt.Errorf("dynamo: SafeExpr: argument for $ must be a string (got %T)", args[idx])
+			}
+			if !e.allowed[name] {
+				return fmt.Errorf("dynamo: SafeExpr: attribute name %q is not allowed", name)
+			}
+			idx++
+		case exprs.ItemValuePlaceholder:
+			idx++
+		case exprs.ItemMagicLiteral:
+			return fmt.Errorf("dynamo: SafeExpr: literals are not allowed")
+		}
+	}
+	return nil
+}
+
+// checkSafeText returns an error if text, the unquoted part of an expression,
+// contains anything other than operators, parentheses, list indexes, keywords, and functions.
+func checkSafeText(text string) error {
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case strings.IndexByte(" \t\n()[],=<>", c) != -1:
+			i++
+			continue
+		case c >= '0' && c <= '9':
+			for i < len(text) && text[i] >= '0' && text[i] <= '9' {
+				i++
+			}
+			continue
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		default:
+			return fmt.Errorf("dynamo: SafeExpr: unexpected %q, use $ for names and ? for values", c)
+		}
+		start := i
+		for i < len(text) && (text[i] == '_' || text[i] >= 'a' && text[i] <= 'z' || text[i] >= 'A' && text[i] <= 'Z') {
+			i++
+		}
+		word := text[start:i]
+		isFunc := strings.HasPrefix(strings.TrimLeft(text[i:], " \t\n"), "(")
+		// keywords can come before a parenthesis, as in IN (...) or NOT (...), but only functions are called
+		switch {
+		case safeKeywords[strings.ToUpper(word)]:
+		case isFunc && safeFuncs[word]:
+		default:
+			return fmt.Errorf("dynamo: SafeExpr: unexpected %q, use $ for names and ? for values", word)
+		}
+	}
+	return nil
+}
+
+// FilterSafe adds a filter built with SafeExpr, like Filter.
+func (q *Query) FilterSafe(e *SafeExpr) *Query {
+	expr, args, err := e.Build()
+	if err != nil {
+		q.setError(err)
+		return q
+	}
+	return q.Filter(expr, args...)
+}
+
+// FilterSafe adds a filter built with SafeExpr, like Filter.
+func (s *Scan) FilterSafe(e *SafeExpr) *Scan {
+	expr, args, err := e.Build()
+	if err != nil {
+		s.setError(err)
+		return s
+	}
+	return s.Filter(expr, args...)
+}
+
+func (e *SafeExpr) setError(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
@@ -0,0 +1,75 @@
+package dynamo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSafeExpr(t *testing.T) {
+	expr, args, err := NewSafeExpr("Status", "Price", "Tags").
+		And("$ = ?", "Status", "active").
+		And("$ BETWEEN ? AND ?", "Price", 10, 20).
+		Or("contains($, ?) AND size('Tags') > ?", "Tags", "sale", 1).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "(($ = ?) AND ($ BETWEEN ? AND ?)) OR (contains($, ?) AND size('Tags') > ?)"; expr != want {
+		t.Errorf("bad expression. %s ≠ %s", expr, want)
+	}
+	if want := []interface{}{"Status", "active", "Price", 10, 20, "Tags", "sale", 1}; !reflect.DeepEqual(args, want) {
+		t.Errorf("bad args. %v ≠ %v", args, want)
+	}
+
+	scan := NewFromIface(nil).Table(testTable).Scan().FilterSafe(NewSafeExpr("Status").And("$ = ?", "Status", 1))
+	if scan.err != nil {
+		t.Error("unexpected error:", scan.err)
+	}
+
+	valid := []struct {
+		expr string
+		args []interface{}
+	}{
+		{"$ IN (?, ?)", []interface{}{"Status", "a", "b"}},
+		{"NOT ($ = ?)", []interface{}{"Status", "a"}},
+		{"$ = ? AND ($ = ? OR $ = ?)", []interface{}{"Status", "a", "Price", 1, "Price", 2}},
+		{"NOT attribute_exists($) OR ($ IN (?))", []interface{}{"Tags", "Status", "a"}},
+	}
+	for _, tc := range valid {
+		if _, _, err := NewSafeExpr("Status", "Price", "Tags").And(tc.expr, tc.args...).Build(); err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.expr, err)
+		}
+	}
+
+	invalid := []struct {
+		expr string
+		args []interface{}
+		want string
+	}{
+		{"$ = ?", []interface{}{"Secret", 1}, `"Secret" is not allowed`},
+		{"'Secret' = ?", []interface{}{1}, `"Secret" is not allowed`},
+		{"Msg = ?", []interface{}{1}, `unexpected "Msg"`},
+		{"$ = :x", []interface{}{"Status"}, `unexpected ':'`},
+		{"$.foo = ?", []interface{}{"Status", 1}, `unexpected '.'`},
+		{"$ = ? OR attribute_exists(Secret)", []interface{}{"Status", 1}, `unexpected "Secret"`},
+		{"$ = ? OR list_append($, ?)", []interface{}{"Status", 1, "Status", 2}, `unexpected "list_append"`},
+		{"$ IN (Secret)", []interface{}{"Status"}, `unexpected "Secret"`},
+		{"$ = ?", []interface{}{1, 1}, "must be a string"},
+		{"$ = ?", []interface{}{"Status"}, "1 args"},
+	}
+	for _, tc := range invalid {
+		_, _, err := NewSafeExpr("Status").And(tc.expr, tc.args...).Build()
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got: %v", tc.expr, tc.want, err)
+		}
+	}
+
+	if _, _, err := NewSafeExpr("Status").Build(); err == nil {
+		t.Error("expected error for empty expression")
+	}
+	q := NewFromIface(nil).Table(testTable).Get("UserID", 1).FilterSafe(NewSafeExpr().And("$ = ?", "Status", 1))
+	if q.err == nil {
+		t.Error("expected query error for disallowed name")
+	}
+}
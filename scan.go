@@ -1,7 +1,9 @@
 package dynamo
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -16,20 +18,37 @@ type Scan struct {
 
 	projection  string
 	filters     []string
-	consistent  bool
+	consistent  *bool
 	limit       int64
 	searchLimit int64
 
+	segment       int64
+	totalSegments int64
+
 	subber
 
-	err error
-	cc  *ConsumedCapacity
+	err     error
+	cc      *ConsumedCapacity
+	timeout time.Duration
+	onPage  func(PageStats)
+	stats   *PageStats
+
+	includeDeleted bool
+	maxDuration    time.Duration
+	maxRCU         float64
+	decodeWorkers  int
+	autoProject    bool
+	checkpoint     CheckpointStore
 }
 
 // Scan creates a new request to scan this table.
 func (table Table) Scan() *Scan {
+	defs := table.db.requestDefaults()
 	return &Scan{
-		table: table,
+		table:   table,
+		subber:  subber{codec: table.db.codec()},
+		cc:      defs.cc,
+		timeout: defs.timeout,
 	}
 }
 
@@ -40,6 +59,31 @@ func (s *Scan) StartFrom(key PagingKey) *Scan {
 	return s
 }
 
+// Cursor makes this scan start from the position encoded in token, a cursor returned by CursorIter.Cursor.
+// An empty token starts from the beginning.
+func (s *Scan) Cursor(token string) *Scan {
+	if token == "" {
+		return s
+	}
+	key, err := s.table.db.decodeCursor(s.table.Name(), token)
+	s.setError(err)
+	s.startKey = key
+	return s
+}
+
+// Segment makes this scan read only one segment of the table, for use in a parallel scan.
+// The table is divided into totalSegments segments, numbered from 0.
+// Each segment can be scanned concurrently by a separate worker.
+// See: http://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Scan.html#Scan.ParallelScan
+func (s *Scan) Segment(segment, totalSegments int64) *Scan {
+	if segment < 0 || segment >= totalSegments {
+		s.setError(fmt.Errorf("dynamo: scan: invalid segment %d of %d", segment, totalSegments))
+	}
+	s.segment = segment
+	s.totalSegments = totalSegments
+	return s
+}
+
 // Index specifies the name of the index that Scan will operate on.
 func (s *Scan) Index(name string) *Scan {
 	s.index = name
@@ -68,13 +112,22 @@ func (s *Scan) Filter(expr string, args ...interface{}) *Scan {
 }
 
 // Consistent will, if on is true, make this scan use a strongly consistent read.
-// Scans are eventually consistent by default.
+// Scans are eventually consistent by default, unless changed with DB.ConsistentReads or Table.ConsistentReads.
 // Strongly consistent reads are more resource-heavy than eventually consistent reads.
 func (s *Scan) Consistent(on bool) *Scan {
-	s.consistent = on
+	s.consistent = &on
 	return s
 }
 
+// consistentRead returns true if this scan should be strongly consistent.
+func (s *Scan) consistentRead() bool {
+	if s.consistent != nil {
+		return *s.consistent
+	}
+	// global secondary indexes don't support consistent reads, so defaults only apply to the base table
+	return s.index == "" && s.table.consistentReads()
+}
+
 // Limit specifies the maximum amount of results to return.
 func (s *Scan) Limit(limit int64) *Scan {
 	s.limit = limit
@@ -95,18 +148,71 @@ func (s *Scan) ConsumedCapacity(cc *ConsumedCapacity) *Scan {
 	return s
 }
 
+// Timeout limits the total amount of time this scan may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+// For iterators, it applies to each call of Next or NextPage separately, rather than to the whole iteration;
+// to bound an iteration, pass NextWithContext a context with a deadline.
+func (s *Scan) Timeout(timeout time.Duration) *Scan {
+	s.timeout = timeout
+	return s
+}
+
+// MaxDuration stops this scan from requesting more pages once d has passed since the first page was requested.
+// Along with MaxRCU, it slices long maintenance scans into windows:
+// when the scan stops early, the iterator's LastEvaluatedKey and Cursor can be used to resume it later.
+// They are empty if the scan finished within its budget.
+func (s *Scan) MaxDuration(d time.Duration) *Scan {
+	s.maxDuration = d
+	return s
+}
+
+// MaxRCU stops this scan from requesting more pages once it has consumed at least n read capacity units.
+// Because the cost of a page isn't known until it has been read, the last page may go over n.
+// Consumed capacity is requested automatically if ConsumedCapacity isn't set.
+// See MaxDuration for resuming the scan.
+func (s *Scan) MaxRCU(n float64) *Scan {
+	s.maxRCU = n
+	return s
+}
+
+// IncludeDeleted includes soft-deleted items in the results. See DB.WithSoftDelete.
+func (s *Scan) IncludeDeleted() *Scan {
+	s.includeDeleted = true
+	return s
+}
+
+// OnPage sets fn to be called after each page of results is fetched, with the progress so far.
+// Consumed capacity is requested automatically if ConsumedCapacity isn't set.
+func (s *Scan) OnPage(fn func(stats PageStats)) *Scan {
+	s.onPage = fn
+	return s
+}
+
+// Stats sets stats to be updated with the progress of this scan as pages are fetched,
+// so that once All or iteration has finished, it holds a summary of the whole run. See Query.Stats.
+func (s *Scan) Stats(stats *PageStats) *Scan {
+	s.stats = stats
+	return s
+}
+
+// codec returns the codec of this iterator's DB.
+func (itr *scanIter) codec() codec {
+	return itr.scan.table.db.codec()
+}
+
 // Iter returns a results iterator for this request.
 func (s *Scan) Iter() PagingIter {
 	return &scanIter{
 		scan:      s,
-		unmarshal: unmarshalItem,
-		err:       s.err,
+		unmarshal: s.table.db.codec().unmarshalItem,
+		err:       s.runErr(),
+		progress:  pageTracker{fn: s.onPage, out: s.stats},
 	}
 }
 
 // All executes this request and unmarshals all results to out, which must be a pointer to a slice.
 func (s *Scan) All(out interface{}) error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(s.timeout)
 	defer cancel()
 	_, err := s.AllWithLastEvaluatedKeyContext(ctx, out)
 	return err
@@ -118,10 +224,35 @@ func (s *Scan) AllWithContext(ctx aws.Context, out interface{}) error {
 	return err
 }
 
+// First executes this request and unmarshals the first result to out.
+// It stops as soon as a match is found, so when filtering it reads only as many pages as needed.
+// It returns ErrNotFound if there are no results.
+func (s *Scan) First(out interface{}) error {
+	ctx, cancel := timeoutContext(s.timeout)
+	defer cancel()
+	return s.FirstWithContext(ctx, out)
+}
+
+// FirstWithContext executes this request and unmarshals the first result to out.
+// It stops as soon as a match is found, so when filtering it reads only as many pages as needed.
+// It returns ErrNotFound if there are no results.
+func (s *Scan) FirstWithContext(ctx aws.Context, out interface{}) error {
+	first := *s
+	first.limit = 1
+	iter := first.Iter()
+	if iter.NextWithContext(ctx, out) {
+		return nil
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	return ErrNotFound
+}
+
 // AllWithLastEvaluatedKey executes this request and unmarshals all results to out, which must be a pointer to a slice.
 // It returns a key you can use with StartWith to continue this query.
 func (s *Scan) AllWithLastEvaluatedKey(out interface{}) (PagingKey, error) {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(s.timeout)
 	defer cancel()
 	return s.AllWithLastEvaluatedKeyContext(ctx, out)
 }
@@ -129,10 +260,20 @@ func (s *Scan) AllWithLastEvaluatedKey(out interface{}) (PagingKey, error) {
 // AllWithLastEvaluatedKeyContext executes this request and unmarshals all results to out, which must be a pointer to a slice.
 // It returns a key you can use with StartWith to continue this query.
 func (s *Scan) AllWithLastEvaluatedKeyContext(ctx aws.Context, out interface{}) (PagingKey, error) {
+	ctx, cancel := withTimeout(ctx, s.timeout)
+	defer cancel()
+	s = s.autoProjected(out)
 	itr := &scanIter{
 		scan:      s,
-		unmarshal: unmarshalAppend,
-		err:       s.err,
+		unmarshal: s.table.db.codec().unmarshalAppend,
+		err:       s.runErr(),
+		progress:  pageTracker{fn: s.onPage, out: s.stats},
+	}
+	if s.decodeWorkers > 1 && itr.err == nil {
+		if err := s.table.db.codec().decodePages(ctx, itr, s.decodeWorkers, out); err != nil {
+			return nil, err
+		}
+		return itr.LastEvaluatedKey(), itr.Err()
 	}
 	for itr.NextWithContext(ctx, out) {
 	}
@@ -143,12 +284,16 @@ func (s *Scan) scanInput() *dynamodb.ScanInput {
 	input := &dynamodb.ScanInput{
 		ExclusiveStartKey:         s.startKey,
 		TableName:                 &s.table.name,
-		ConsistentRead:            &s.consistent,
+		ConsistentRead:            aws.Bool(s.consistentRead()),
 		ExpressionAttributeNames:  s.nameExpr,
 		ExpressionAttributeValues: s.valueExpr,
 	}
+	filters := s.filters
+	if !s.includeDeleted {
+		filters = withFilter(filters, s.table.db.softFilter(&s.subber))
+	}
 	if s.limit > 0 {
-		if len(s.filters) == 0 {
+		if len(filters) == 0 {
 			input.Limit = &s.limit
 		}
 	}
@@ -158,19 +303,38 @@ func (s *Scan) scanInput() *dynamodb.ScanInput {
 	if s.index != "" {
 		input.IndexName = &s.index
 	}
+	if s.totalSegments > 0 {
+		input.Segment = &s.segment
+		input.TotalSegments = &s.totalSegments
+	}
 	if s.projection != "" {
 		input.ProjectionExpression = &s.projection
 	}
-	if len(s.filters) > 0 {
-		filter := strings.Join(s.filters, " AND ")
+	if len(filters) > 0 {
+		filter := strings.Join(filters, " AND ")
 		input.FilterExpression = &filter
+		// the soft delete filter might have added a name
+		input.ExpressionAttributeNames = s.nameExpr
 	}
 	if s.cc != nil {
 		input.ReturnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityIndexes)
+	} else if s.onPage != nil || s.maxRCU > 0 {
+		input.ReturnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityTotal)
 	}
 	return input
 }
 
+// overBudget returns true if a scan has used up its MaxDuration or MaxRCU, given its progress so far.
+func (s *Scan) overBudget(progress pageTracker) bool {
+	switch {
+	case s.maxDuration > 0 && time.Since(progress.start) >= s.maxDuration:
+		return true
+	case s.maxRCU > 0 && progress.stats.TotalCapacity >= s.maxRCU:
+		return true
+	}
+	return false
+}
+
 func (s *Scan) setError(err error) {
 	if s.err == nil {
 		s.err = err
@@ -187,17 +351,24 @@ type scanIter struct {
 	n      int64
 
 	unmarshal unmarshalFunc
+	progress  pageTracker
+	reqID     lastRequestID
+
+	// checkpointed is whether the scan's segment has been saved as done.
+	checkpointed bool
 }
 
 // Next tries to unmarshal the next result into out.
 // Returns false when it is complete or if it runs into an error.
 func (itr *scanIter) Next(out interface{}) bool {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(itr.scan.timeout)
 	defer cancel()
 	return itr.NextWithContext(ctx, out)
 }
 
 func (itr *scanIter) NextWithContext(ctx aws.Context, out interface{}) bool {
+	ctx, cancel := withTimeout(ctx, itr.scan.timeout)
+	defer cancel()
 	// stop if we have an error
 	if itr.err != nil {
 		return false
@@ -209,54 +380,127 @@ func (itr *scanIter) NextWithContext(ctx aws.Context, out interface{}) bool {
 	}
 
 	// can we use results we already have?
-	if itr.output != nil && itr.idx < len(itr.output.Items) {
-		item := itr.output.Items[itr.idx]
-		itr.err = itr.unmarshal(item, out)
-		itr.idx++
-		itr.n++
-		return itr.err == nil
+	if !itr.buffered() && !itr.fetch(ctx) {
+		return false
 	}
 
-	// new scan
-	if itr.input == nil {
-		itr.input = itr.scan.scanInput()
+	itr.err = itr.unmarshal(itr.output.Items[itr.idx], out)
+	itr.idx++
+	itr.n++
+	return itr.err == nil
+}
+
+// NextPage unmarshals the rest of the current page of results into out, which must be a pointer to a slice.
+// If the current page has been consumed, the next page is requested.
+// out is replaced, not appended to. Returns false when it is complete or if it runs into an error.
+func (itr *scanIter) NextPage(out interface{}) bool {
+	ctx, cancel := timeoutContext(itr.scan.timeout)
+	defer cancel()
+	return itr.NextPageWithContext(ctx, out)
+}
+
+func (itr *scanIter) NextPageWithContext(ctx aws.Context, out interface{}) bool {
+	ctx, cancel := withTimeout(ctx, itr.scan.timeout)
+	defer cancel()
+	if itr.err != nil {
+		return false
 	}
-	if itr.output != nil && itr.idx >= len(itr.output.Items) {
-		// have we exhausted all results?
-		if itr.output.LastEvaluatedKey == nil || itr.scan.searchLimit > 0 {
+	if itr.scan.limit > 0 && itr.n == itr.scan.limit {
+		return false
+	}
+	if itr.err = resetSlice(out); itr.err != nil {
+		return false
+	}
+	if !itr.buffered() && !itr.fetch(ctx) {
+		return false
+	}
+
+	items := itr.output.Items[itr.idx:]
+	if itr.scan.limit > 0 && int64(len(items)) > itr.scan.limit-itr.n {
+		items = items[:itr.scan.limit-itr.n]
+	}
+	for _, item := range items {
+		if itr.err = itr.scan.table.db.codec().unmarshalAppend(item, out); itr.err != nil {
 			return false
 		}
+		itr.idx++
+		itr.n++
+	}
+	return true
+}
 
-		// no, prepare next request and reset index
-		itr.input.ExclusiveStartKey = itr.output.LastEvaluatedKey
-		itr.idx = 0
+func (itr *scanIter) nextItems(ctx aws.Context) ([]map[string]*dynamodb.AttributeValue, bool) {
+	if itr.err != nil || (itr.scan.limit > 0 && itr.n == itr.scan.limit) {
+		return nil, false
 	}
+	if !itr.buffered() && !itr.fetch(ctx) {
+		return nil, false
+	}
+	items := itr.output.Items[itr.idx:]
+	if itr.scan.limit > 0 && int64(len(items)) > itr.scan.limit-itr.n {
+		items = items[:itr.scan.limit-itr.n]
+	}
+	// hand over the page, so it can be freed as soon as the caller is done with it
+	itr.output.Items, itr.idx = nil, 0
+	itr.n += int64(len(items))
+	return items, true
+}
 
-	itr.err = retry(ctx, func() error {
-		var err error
-		itr.output, err = itr.scan.table.db.client.ScanWithContext(ctx, itr.input)
-		return err
-	})
+// buffered returns true if there are unread results from the last request.
+func (itr *scanIter) buffered() bool {
+	return itr.output != nil && itr.idx < len(itr.output.Items)
+}
 
-	if itr.err != nil {
-		return false
-	}
+// fetch requests pages until one with results is found.
+// Returns false when there are no more results or if it runs into an error.
+func (itr *scanIter) fetch(ctx aws.Context) bool {
+	for {
+		// new scan
+		if itr.input == nil {
+			itr.input = itr.scan.scanInput()
+			if !itr.resume(ctx) {
+				return false
+			}
+		}
+		if itr.output != nil {
+			// the last page has been read
+			if !itr.saveCheckpoint(ctx) {
+				return false
+			}
+			// have we exhausted all results?
+			if itr.output.LastEvaluatedKey == nil || itr.scan.searchLimit > 0 {
+				return false
+			}
+			// or our budget?
+			if itr.scan.overBudget(itr.progress) {
+				return false
+			}
+
+			// no, prepare next request and reset index
+			itr.input.ExclusiveStartKey = itr.output.LastEvaluatedKey
+		}
+		itr.idx = 0
 
-	if itr.scan.cc != nil {
-		addConsumedCapacity(itr.scan.cc, itr.output.ConsumedCapacity)
-	}
+		itr.progress.begin()
+		itr.err = itr.scan.table.db.retry(ctx, itr.scan.table.Name(), func() error {
+			var err error
+			itr.output, err = itr.scan.table.db.client.ScanWithContext(ctx, itr.input, itr.reqID.options(itr.scan.table.db.opts)...)
+			itr.progress.attempt(err)
+			return err
+		})
+		if itr.err != nil {
+			return false
+		}
+		if itr.scan.cc != nil {
+			addConsumedCapacity(itr.scan.cc, itr.output.ConsumedCapacity)
+		}
+		itr.progress.page(len(itr.output.Items), itr.output.ScannedCount, itr.output.ConsumedCapacity)
 
-	if len(itr.output.Items) == 0 {
-		if itr.output.LastEvaluatedKey != nil {
-			return itr.NextWithContext(ctx, out)
+		if len(itr.output.Items) > 0 {
+			return true
 		}
-		return false
+		// keep going until we get some data
 	}
-
-	itr.err = itr.unmarshal(itr.output.Items[itr.idx], out)
-	itr.idx++
-	itr.n++
-	return itr.err == nil
 }
 
 // Err returns the error encountered, if any.
@@ -273,3 +517,18 @@ func (itr *scanIter) LastEvaluatedKey() PagingKey {
 	}
 	return nil
 }
+
+// Stats returns statistics about the pages fetched so far.
+func (itr *scanIter) Stats() PageStats {
+	return itr.progress.stats
+}
+
+// RequestID returns the ID of the latest request sent by this iterator.
+func (itr *scanIter) RequestID() string {
+	return itr.reqID.get()
+}
+
+// Cursor returns LastEvaluatedKey as an opaque token.
+func (itr *scanIter) Cursor() (string, error) {
+	return itr.scan.table.db.encodeCursor(itr.scan.table.Name(), itr.LastEvaluatedKey())
+}
@@ -2,8 +2,14 @@ package dynamo
 
 import (
 	"reflect"
+	"strconv"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 func TestScan(t *testing.T) {
@@ -74,3 +80,110 @@ func TestScanPaging(t *testing.T) {
 		itr = table.Scan().StartFrom(itr.LastEvaluatedKey()).SearchLimit(1).Iter()
 	}
 }
+
+func TestScanSegment(t *testing.T) {
+	table := NewFromIface(nil).Table("Segments")
+	input := table.Scan().Segment(2, 4).scanInput()
+	if input.Segment == nil || *input.Segment != 2 || input.TotalSegments == nil || *input.TotalSegments != 4 {
+		t.Error("bad segment:", input.Segment, input.TotalSegments)
+	}
+	if input := table.Scan().scanInput(); input.Segment != nil || input.TotalSegments != nil {
+		t.Error("unexpected segment:", input.Segment, input.TotalSegments)
+	}
+	if err := table.Scan().Segment(4, 4).err; err == nil {
+		t.Error("expected error for invalid segment")
+	}
+}
+
+func TestScanFirst(t *testing.T) {
+	client := newBackfillClient(5)
+	table := NewFromIface(client).Table("Backfill")
+
+	var item map[string]*dynamodb.AttributeValue
+	if err := table.Scan().First(&item); err != nil {
+		t.Fatal(err)
+	}
+	if *item["ID"].S != "0" || client.scans != 1 {
+		t.Error("bad first result:", item, client.scans)
+	}
+
+	client.ids = nil
+	if err := table.Scan().First(&item); err != ErrNotFound {
+		t.Error("expected ErrNotFound, got", err)
+	}
+}
+
+// fakeScanClient is a fake client that scans pages, each costing 2 capacity units and taking delay.
+type fakeScanClient struct {
+	dynamodbiface.DynamoDBAPI
+	pages [][]map[string]*dynamodb.AttributeValue
+	delay time.Duration
+}
+
+func (c fakeScanClient) ScanWithContext(_ aws.Context, input *dynamodb.ScanInput, _ ...request.Option) (*dynamodb.ScanOutput, error) {
+	time.Sleep(c.delay)
+	var page int
+	if input.ExclusiveStartKey != nil {
+		page, _ = strconv.Atoi(*input.ExclusiveStartKey["Page"].N)
+	}
+	out := &dynamodb.ScanOutput{
+		Items: c.pages[page],
+	}
+	if input.ReturnConsumedCapacity != nil {
+		out.ConsumedCapacity = &dynamodb.ConsumedCapacity{CapacityUnits: aws.Float64(2)}
+	}
+	if page+1 < len(c.pages) {
+		out.LastEvaluatedKey = map[string]*dynamodb.AttributeValue{
+			"Page": {N: aws.String(strconv.Itoa(page + 1))},
+		}
+	}
+	return out, nil
+}
+
+func TestScanBudget(t *testing.T) {
+	pages := fakePages(t, 2, 2, 2, 2)
+
+	t.Run("MaxRCU", func(t *testing.T) {
+		table := NewFromIface(fakeScanClient{pages: pages}).Table(testTable)
+		var results []widget
+		lek, err := table.Scan().MaxRCU(3).AllWithLastEvaluatedKey(&results)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 4 {
+			t.Error("expected 2 pages of results, got", len(results))
+		}
+		if lek == nil || *lek["Page"].N != "2" {
+			t.Fatal("expected key to resume from, got", lek)
+		}
+
+		// resume
+		results = nil
+		lek, err = table.Scan().StartFrom(lek).MaxRCU(10).AllWithLastEvaluatedKey(&results)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 4 || lek != nil {
+			t.Error("expected the rest of the results, got", len(results), lek)
+		}
+	})
+
+	t.Run("MaxDuration", func(t *testing.T) {
+		table := NewFromIface(fakeScanClient{pages: pages, delay: 50 * time.Millisecond}).Table(testTable)
+		iter := table.Scan().MaxDuration(75 * time.Millisecond).Iter().(CursorIter)
+		var w widget
+		var n int
+		for iter.Next(&w) {
+			n++
+		}
+		if iter.Err() != nil {
+			t.Fatal(iter.Err())
+		}
+		if n != 4 {
+			t.Error("expected 2 pages of results, got", n)
+		}
+		if cursor, err := iter.Cursor(); err != nil || cursor == "" {
+			t.Error("expected cursor to resume from, got", cursor, err)
+		}
+	})
+}
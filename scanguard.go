@@ -0,0 +1,53 @@
+package dynamo
+
+import (
+	"errors"
+)
+
+// ErrTableScan is returned when running a scan that would read a whole table, if forbidden by DB.ForbidTableScan.
+var ErrTableScan = errors.New("dynamo: scan: full table scan forbidden; use Segment, Limit, or SearchLimit to bound it")
+
+// ForbidTableScan returns a copy of this DB that, if on is true, fails scans that would read a whole table
+// with ErrTableScan, without sending them. Scans using Segment, Limit, or SearchLimit are still allowed,
+// as bounded or deliberately parallel reads.
+// This catches scans that were shipped by accident, for example when enabled in production configuration.
+//
+// If tables are given, the setting only applies to them, overriding the setting for other tables.
+// For example, ForbidTableScan(true) followed by ForbidTableScan(false, "Migrations")
+// forbids table scans everywhere except on the Migrations table.
+func (db *DB) ForbidTableScan(on bool, tables ...string) *DB {
+	cp := *db
+	if len(tables) == 0 {
+		// replaces any per-table settings
+		cp.forbidScans = map[string]bool{"": on}
+		return &cp
+	}
+	cp.forbidScans = make(map[string]bool, len(db.forbidScans)+len(tables))
+	for table, forbid := range db.forbidScans {
+		cp.forbidScans[table] = forbid
+	}
+	for _, table := range tables {
+		cp.forbidScans[table] = on
+	}
+	return &cp
+}
+
+// scanForbidden returns true if table scans of the given table are forbidden.
+func (db *DB) scanForbidden(table string) bool {
+	if forbid, ok := db.forbidScans[table]; ok {
+		return forbid
+	}
+	return db.forbidScans[""]
+}
+
+// runErr returns the error that running this scan would fail with before sending any requests.
+func (s *Scan) runErr() error {
+	if s.err != nil {
+		return s.err
+	}
+	bounded := s.totalSegments > 0 || s.limit > 0 || s.searchLimit > 0
+	if !bounded && s.table.db.scanForbidden(s.table.Name()) {
+		return ErrTableScan
+	}
+	return nil
+}
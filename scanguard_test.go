@@ -0,0 +1,35 @@
+package dynamo
+
+import (
+	"testing"
+)
+
+func TestForbidTableScan(t *testing.T) {
+	client := newBackfillClient(3)
+	db := NewFromIface(client).ForbidTableScan(true)
+	table := db.Table("Backfill")
+
+	var items []map[string]interface{}
+	if err := table.Scan().All(&items); err != ErrTableScan {
+		t.Error("expected ErrTableScan, got", err)
+	}
+	if client.scans != 0 {
+		t.Error("forbidden scan was sent")
+	}
+	if err := table.Scan().Limit(2).All(&items); err != nil {
+		t.Error("unexpected error for limited scan:", err)
+	}
+	if err := table.Scan().Segment(0, 1).All(&items); err != nil {
+		t.Error("unexpected error for segmented scan:", err)
+	}
+
+	if err := db.ForbidTableScan(false, "Backfill").Table("Backfill").Scan().All(&items); err != nil {
+		t.Error("unexpected error for allowed table:", err)
+	}
+	if err := db.ForbidTableScan(false, "Backfill").Table("Other").Scan().All(&items); err != ErrTableScan {
+		t.Error("expected ErrTableScan for other table, got", err)
+	}
+	if err := db.ForbidTableScan(false).Table("Backfill").Scan().All(&items); err != nil {
+		t.Error("unexpected error after allowing scans:", err)
+	}
+}
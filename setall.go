@@ -0,0 +1,65 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// SetAll sets every attribute of item, a struct encoded as in MarshalItem, leaving other attributes of the stored item as is.
+// The key attributes of this update and the version field of item are left out automatically.
+// If onlyFields are given, only the attributes with those names are changed,
+// and those that are empty in item are removed:
+//	table.Update("ID", user.ID).SetAll(user)                   // SET every attribute of user
+//	table.Update("ID", user.ID).SetAll(user, "Name", "Email") // SET Name and Email, or REMOVE them if empty
+// Naming a key attribute, the version field, or an attribute that item's type doesn't have is an error.
+func (u *Update) SetAll(item interface{}, onlyFields ...string) *Update {
+	avs, err := u.codec.marshalItem(item)
+	if err != nil {
+		u.setError(err)
+		return u
+	}
+	skip := map[string]bool{u.hashKey: true}
+	if u.rangeKey != "" {
+		skip[u.rangeKey] = true
+	}
+	if version := u.codec.versionField(item); version != "" {
+		skip[version] = true
+	}
+	u.model = item
+
+	names := onlyFields
+	if len(names) == 0 {
+		for name := range avs {
+			if !skip[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	} else {
+		fields := u.codec.modelFields(reflect.TypeOf(item))
+		for _, name := range names {
+			if _, ok := fields[name]; !ok {
+				u.setError(fmt.Errorf("dynamo: update: SetAll: no field for attribute %s in %T", name, item))
+				return u
+			}
+			if skip[name] {
+				u.setError(fmt.Errorf("dynamo: update: SetAll: can't set key or version attribute %s", name))
+				return u
+			}
+		}
+	}
+
+	for _, name := range names {
+		path := u.subName(name)
+		av, ok := avs[name]
+		if !ok {
+			u.remove[path] = struct{}{}
+			continue
+		}
+		vsub, err := u.subValue(av, "")
+		u.setError(err)
+		u.set = append(u.set, path+" = "+vsub)
+	}
+	return u
+}
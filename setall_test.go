@@ -0,0 +1,44 @@
+package dynamo
+
+import (
+	"testing"
+)
+
+func TestUpdateSetAll(t *testing.T) {
+	type Address struct {
+		City string
+	}
+	type User struct {
+		ID      string `dynamo:",hash"`
+		Time    int    `dynamo:",range"`
+		Name    string
+		Email   string `dynamo:"mail"`
+		Age     int
+		Home    Address `dynamo:",prefix=Home_"`
+		Version int     `dynamo:",version"`
+	}
+	table := NewFromIface(&lastItemClient{}).Table("Users")
+	user := User{ID: "u1", Time: 1, Name: "Alice", Age: 30, Home: Address{City: "Tokyo"}, Version: 3}
+
+	u := table.Update("ID", "u1").Range("Time", 1).SetAll(user)
+	if u.err != nil {
+		t.Fatal("unexpected error:", u.err)
+	}
+	if expr := unsubstitute(*u.updateExpr(), u.nameExpr); expr != "SET Age = :v0, Home_City = :v1, Name = :v2" {
+		t.Error("bad update expression:", expr)
+	}
+
+	u = table.Update("ID", "u1").Range("Time", 1).SetAll(&user, "Name", "mail")
+	if u.err != nil {
+		t.Fatal("unexpected error:", u.err)
+	}
+	if expr := unsubstitute(*u.updateExpr(), u.nameExpr); expr != "SET Name = :v0 REMOVE mail" {
+		t.Error("bad update expression:", expr)
+	}
+
+	for _, name := range []string{"ID", "Version", "Nope"} {
+		if err := table.Update("ID", "u1").SetAll(user, name).err; err == nil {
+			t.Error("expected error for", name)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package dynamo
+
+import (
+	"errors"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// ShardedTable spreads the items of hot partition keys across several partitions,
+// to get around DynamoDB's per-partition throughput limits.
+// Items are written to the logical partition key with a shard suffix, like "user#42#3",
+// and reads are fanned out across all shards, merging the results.
+// The shard of an item is calculated from its range key, so updates and deletes go straight to the right shard.
+// Partition keys must be strings.
+// Results read through a ShardedTable have the shard suffix removed from their partition key.
+type ShardedTable struct {
+	table    Table
+	hashKey  string
+	rangeKey string
+	shards   int
+}
+
+// Sharded returns a ShardedTable that spreads each value of hashKey across the given number of shards.
+// Use Range to specify the table's range key, which is needed for Update and Delete.
+// Without one, Put picks a random shard.
+func (table Table) Sharded(hashKey string, shards int) ShardedTable {
+	if shards < 1 {
+		shards = 1
+	}
+	return ShardedTable{
+		table:   table,
+		hashKey: hashKey,
+		shards:  shards,
+	}
+}
+
+// Range returns a copy of this ShardedTable that calculates shards from the given range key.
+func (st ShardedTable) Range(rangeKey string) ShardedTable {
+	st.rangeKey = rangeKey
+	return st
+}
+
+// Table returns the underlying table.
+func (st ShardedTable) Table() Table {
+	return st.table
+}
+
+// Shards returns the sharded partition keys of the logical partition key hashValue.
+func (st ShardedTable) Shards(hashValue string) []string {
+	keys := make([]string, st.shards)
+	for i := range keys {
+		keys[i] = shardKey(hashValue, i)
+	}
+	return keys
+}
+
+// shardOf returns the shard for the item with the given keys.
+func (st ShardedTable) shardOf(hashValue string, rangeValue *dynamodb.AttributeValue) int {
+	if st.shards == 1 {
+		return 0
+	}
+	if rangeValue == nil {
+		return rand.Intn(st.shards)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(hashValue))
+	h.Write([]byte(keyString(map[string]*dynamodb.AttributeValue{st.rangeKey: rangeValue})))
+	return int(h.Sum32() % uint32(st.shards))
+}
+
+func shardKey(hashValue string, shard int) string {
+	return hashValue + "#" + strconv.Itoa(shard)
+}
+
+// Put creates a new request to create or replace an item in its shard.
+func (st ShardedTable) Put(item interface{}) *Put {
+	p := st.table.Put(item)
+	if p.err != nil {
+		return p
+	}
+	hv := p.item[st.hashKey]
+	if hv == nil || hv.S == nil {
+		p.setError(errors.New("dynamo: sharded table: partition key " + st.hashKey + " must be a string"))
+		return p
+	}
+	var rv *dynamodb.AttributeValue
+	if st.rangeKey != "" {
+		rv = p.item[st.rangeKey]
+	}
+	p.item[st.hashKey] = &dynamodb.AttributeValue{S: aws.String(shardKey(*hv.S, st.shardOf(*hv.S, rv)))}
+	return p
+}
+
+// Update creates a new request to modify the item with the given keys in its shard.
+func (st ShardedTable) Update(hashValue string, rangeValue interface{}) *Update {
+	rv, err := st.table.db.codec().marshal(rangeValue, "")
+	if err == nil && st.rangeKey == "" {
+		err = errors.New("dynamo: sharded table: Update requires a range key")
+	}
+	u := st.table.Update(st.hashKey, shardKey(hashValue, st.shardOf(hashValue, rv))).Range(st.rangeKey, rv)
+	u.setError(err)
+	return u
+}
+
+// Delete creates a new request to delete the item with the given keys from its shard.
+func (st ShardedTable) Delete(hashValue string, rangeValue interface{}) *Delete {
+	rv, err := st.table.db.codec().marshal(rangeValue, "")
+	if err == nil && st.rangeKey == "" {
+		err = errors.New("dynamo: sharded table: Delete requires a range key")
+	}
+	d := st.table.Delete(st.hashKey, shardKey(hashValue, st.shardOf(hashValue, rv))).Range(st.rangeKey, rv)
+	d.setError(err)
+	return d
+}
+
+// Get creates a new request to query every shard of the logical partition key hashValue.
+// Results are merged in order of the range key.
+// Without a range key, use BucketQuery.MergeBy to choose the attribute to merge by.
+func (st ShardedTable) Get(hashValue string) *BucketQuery {
+	bq := &BucketQuery{
+		mergeKey: st.rangeKey,
+		order:    Ascending,
+		unshard:  st.hashKey,
+	}
+	for _, key := range st.Shards(hashValue) {
+		bq.queries = append(bq.queries, st.table.Get(st.hashKey, key))
+	}
+	return bq
+}
+
+// unshardItem returns a copy of item with the shard suffix removed from its partition key.
+func unshardItem(item map[string]*dynamodb.AttributeValue, hashKey string) map[string]*dynamodb.AttributeValue {
+	hv := item[hashKey]
+	if hv == nil || hv.S == nil {
+		return item
+	}
+	i := strings.LastIndexByte(*hv.S, '#')
+	if i < 0 {
+		return item
+	}
+	cp := make(map[string]*dynamodb.AttributeValue, len(item))
+	for k, v := range item {
+		cp[k] = v
+	}
+	cp[hashKey] = &dynamodb.AttributeValue{S: aws.String((*hv.S)[:i])}
+	return cp
+}
@@ -0,0 +1,80 @@
+package dynamo
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func (c partitionClient) PutItemWithContext(_ aws.Context, input *dynamodb.PutItemInput, _ ...request.Option) (*dynamodb.PutItemOutput, error) {
+	pk := *input.Item["PK"].S
+	items := append(c.parts[pk], input.Item)
+	sort.Slice(items, func(i, j int) bool {
+		a, _ := strconv.Atoi(*items[i]["Time"].N)
+		b, _ := strconv.Atoi(*items[j]["Time"].N)
+		return a < b
+	})
+	c.parts[pk] = items
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestShardedTable(t *testing.T) {
+	type event struct {
+		PK   string
+		Time int64
+	}
+	client := partitionClient{parts: make(map[string][]map[string]*dynamodb.AttributeValue)}
+	st := NewFromIface(client).Table("Events").Sharded("PK", 4).Range("Time")
+
+	for i := int64(1); i <= 20; i++ {
+		if err := st.Put(event{PK: "hot", Time: i}).Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(client.parts) < 2 {
+		t.Error("writes weren't spread across shards:", len(client.parts))
+	}
+	for pk := range client.parts {
+		if !strings.HasPrefix(pk, "hot#") {
+			t.Error("bad shard key:", pk)
+		}
+	}
+
+	var events []event
+	if err := st.Get("hot").All(&events); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 20 {
+		t.Fatal("expected 20 events, got", len(events))
+	}
+	for i, e := range events {
+		if e.PK != "hot" || e.Time != int64(i+1) {
+			t.Error("bad merged event:", i, e)
+		}
+	}
+
+	// updates and deletes go to the shard their item was written to
+	for i := int64(1); i <= 20; i++ {
+		pk := *st.Update("hot", i).hashValue.S
+		var found bool
+		for _, item := range client.parts[pk] {
+			found = found || *item["Time"].N == strconv.FormatInt(i, 10)
+		}
+		if !found {
+			t.Error("update for", i, "targets the wrong shard:", pk)
+		}
+		if del := st.Delete("hot", i); !reflect.DeepEqual(del.hashValue.S, &pk) {
+			t.Error("delete for", i, "targets the wrong shard:", *del.hashValue.S)
+		}
+	}
+
+	if err := NewFromIface(client).Table("Events").Sharded("PK", 4).Update("hot", 1).Set("A", 1).Run(); err == nil {
+		t.Error("expected error updating without a range key")
+	}
+}
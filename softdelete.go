@@ -0,0 +1,129 @@
+package dynamo
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// SoftDelete configures soft deletion. See DB.WithSoftDelete.
+type SoftDelete struct {
+	// Attribute marks soft-deleted items, set to the time they were deleted. Defaults to "DeletedAt".
+	Attribute string
+	// ExpireAttribute, if set, is also set when deleting, to the time (in Unix seconds) that
+	// DynamoDB's Time to Live should remove the item permanently. It should be the table's TTL attribute.
+	ExpireAttribute string
+	// TTL is how long soft-deleted items are kept before they expire. Used with ExpireAttribute.
+	TTL time.Duration
+}
+
+// WithSoftDelete returns a copy of this DB that soft-deletes items.
+// Instead of removing items, Delete marks them by setting an attribute to the time of deletion,
+// and optionally sets a TTL attribute so DynamoDB removes them later.
+// Deleting an item that doesn't exist or was already deleted does nothing.
+// Query, Scan, and BatchGet skip soft-deleted items unless IncludeDeleted is used.
+// Deletes made by BatchWrite and WriteTx, and reads made by GetTx, are not affected.
+func (db *DB) WithSoftDelete(cfg SoftDelete) *DB {
+	if cfg.Attribute == "" {
+		cfg.Attribute = "DeletedAt"
+	}
+	cp := *db
+	cp.soft = &cfg
+	return &cp
+}
+
+// softFilter returns a filter expression that excludes soft-deleted items,
+// or "" if soft deletion is disabled.
+func (db *DB) softFilter(s *subber) string {
+	if db.soft == nil {
+		return ""
+	}
+	return "attribute_not_exists(" + s.subName(db.soft.Attribute) + ")"
+}
+
+// softDeleted returns true if soft deletion is enabled and item has been soft-deleted.
+func (db *DB) softDeleted(item map[string]*dynamodb.AttributeValue) bool {
+	if db.soft == nil {
+		return false
+	}
+	_, deleted := item[db.soft.Attribute]
+	return deleted
+}
+
+// withFilter returns filters with filter added, without modifying filters.
+func withFilter(filters []string, filter string) []string {
+	if filter == "" {
+		return filters
+	}
+	return append(filters[:len(filters):len(filters)], filter)
+}
+
+// softDeleteInput returns a request that marks the item of this delete as deleted.
+func (d *Delete) softDeleteInput() (*dynamodb.UpdateItemInput, error) {
+	cfg := d.table.db.soft
+	now := time.Now().UTC()
+
+	deletedAt, err := d.subValue(now, "")
+	if err != nil {
+		return nil, err
+	}
+	expr := "SET " + d.subName(cfg.Attribute) + " = " + deletedAt
+	if cfg.ExpireAttribute != "" {
+		expires, err := d.subValue(now.Add(cfg.TTL).Unix(), "")
+		if err != nil {
+			return nil, err
+		}
+		expr += ", " + d.subName(cfg.ExpireAttribute) + " = " + expires
+	}
+
+	cond := "attribute_exists(" + d.subName(d.hashKey) + ") AND attribute_not_exists(" + d.subName(cfg.Attribute) + ")"
+	if d.condition != "" {
+		cond = d.condition + " AND " + cond
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:                 &d.table.name,
+		Key:                       d.key(),
+		UpdateExpression:          &expr,
+		ConditionExpression:       &cond,
+		ReturnValues:              &d.returnType,
+		ExpressionAttributeNames:  d.nameExpr,
+		ExpressionAttributeValues: d.valueExpr,
+	}
+	if d.cc != nil {
+		input.ReturnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityIndexes)
+	}
+	if d.icm != nil {
+		input.ReturnItemCollectionMetrics = aws.String(dynamodb.ReturnItemCollectionMetricsSize)
+	}
+	return input, nil
+}
+
+// softDelete marks the item of this delete as deleted.
+// If the item doesn't exist and there is no custom condition, it returns an empty output.
+func (d *Delete) softDelete(ctx aws.Context) (*dynamodb.DeleteItemOutput, error) {
+	input, err := d.softDeleteInput()
+	if err != nil {
+		return nil, err
+	}
+	var output *dynamodb.UpdateItemOutput
+	err = d.table.db.retry(ctx, d.table.Name(), func() error {
+		var err error
+		output, err = d.table.db.client.UpdateItemWithContext(ctx, input, d.table.db.requestOptions(d.reqID)...)
+		return err
+	})
+	if ae, ok := err.(awserr.Error); ok && ae.Code() == dynamodb.ErrCodeConditionalCheckFailedException && d.condition == "" {
+		// nothing to delete
+		return &dynamodb.DeleteItemOutput{}, nil
+	}
+	if output == nil {
+		return nil, err
+	}
+	return &dynamodb.DeleteItemOutput{
+		Attributes:            output.Attributes,
+		ConsumedCapacity:      output.ConsumedCapacity,
+		ItemCollectionMetrics: output.ItemCollectionMetrics,
+	}, err
+}
@@ -0,0 +1,77 @@
+package dynamo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestSoftDeleteInputs(t *testing.T) {
+	db := NewFromIface(newMemClient()).WithSoftDelete(SoftDelete{ExpireAttribute: "ExpiresAt"})
+	table := db.Table(testTable)
+
+	input := table.Get("UserID", 42).Range("Time", Greater, 0).queryInput()
+	if input.FilterExpression == nil || !strings.HasPrefix(*input.FilterExpression, "attribute_not_exists(") {
+		t.Fatal("missing soft delete filter:", input.FilterExpression)
+	}
+	if len(input.ExpressionAttributeNames) != 1 {
+		t.Error("missing attribute name:", input.ExpressionAttributeNames)
+	}
+	if input := table.Get("UserID", 42).Range("Time", Greater, 0).IncludeDeleted().queryInput(); input.FilterExpression != nil {
+		t.Error("unexpected filter with IncludeDeleted:", *input.FilterExpression)
+	}
+	if input := table.Scan().scanInput(); input.FilterExpression == nil {
+		t.Error("missing soft delete filter for scan")
+	}
+
+	update, err := table.Delete("UserID", 42).softDeleteInput()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expr := *update.UpdateExpression
+	for sub, name := range update.ExpressionAttributeNames {
+		expr = strings.Replace(expr, sub, *name, -1)
+	}
+	if !strings.HasPrefix(expr, "SET DeletedAt = :v0, ExpiresAt = :v1") {
+		t.Error("bad update expression:", expr)
+	}
+	if update.ConditionExpression == nil {
+		t.Error("missing condition")
+	}
+}
+
+func TestSoftDeleteReads(t *testing.T) {
+	client := newMemClient()
+	db := NewFromIface(client).WithSoftDelete(SoftDelete{})
+	table := db.Table(testTable)
+
+	for _, w := range []widget{{UserID: 1, Msg: "alive"}, {UserID: 2, Msg: "deleted"}} {
+		if err := table.Put(w).Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deleted := client.items[keyString(map[string]*dynamodb.AttributeValue{"UserID": {N: aws.String("2")}})]
+	deleted["DeletedAt"] = &dynamodb.AttributeValue{S: aws.String("2019-01-01T00:00:00Z")}
+
+	var w widget
+	if err := table.Get("UserID", 2).One(&w); err != ErrNotFound {
+		t.Error("expected ErrNotFound for deleted item, got", err)
+	}
+	if err := table.Get("UserID", 2).IncludeDeleted().One(&w); err != nil || w.Msg != "deleted" {
+		t.Error("expected deleted item with IncludeDeleted, got", w, err)
+	}
+
+	var results []widget
+	if err := table.Batch("UserID").Get(Keys{1}, Keys{2}).All(&results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Msg != "alive" {
+		t.Error("bad batch results:", results)
+	}
+	results = nil
+	if err := table.Batch("UserID").Get(Keys{1}, Keys{2}).IncludeDeleted().All(&results); err != nil || len(results) != 2 {
+		t.Error("expected 2 results with IncludeDeleted, got", results, err)
+	}
+}
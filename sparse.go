@@ -0,0 +1,99 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// AddToIndex sets the index key attribute name to value, adding the item to the sparse index keyed by it.
+// Call it for both keys of an index that has a range key.
+// Unlike Set, an empty value is an error instead of removing the attribute.
+func (u *Update) AddToIndex(name string, value interface{}) *Update {
+	av, err := u.codec.marshal(value, "")
+	if err != nil {
+		u.setError(err)
+		return u
+	}
+	if av == nil {
+		u.setError(fmt.Errorf("dynamo: AddToIndex: empty value for %s would leave the item out of the index", name))
+		return u
+	}
+	return u.Set(name, av)
+}
+
+// RemoveFromIndex removes the given index key attributes, taking the item out of the sparse indexes keyed by them.
+func (u *Update) RemoveFromIndex(names ...string) *Update {
+	if len(names) == 0 {
+		u.setError(fmt.Errorf("dynamo: RemoveFromIndex: no attribute names"))
+		return u
+	}
+	return u.Remove(names...)
+}
+
+// modelField describes how a struct field is encoded.
+type modelField struct {
+	// omitempty is whether the field is tagged omitempty.
+	omitempty bool
+	// omittable is whether the field's zero value is left out of items, with or without omitempty.
+	omittable bool
+}
+
+// modelFields returns the encoded fields of the given struct type by attribute name, including those of embedded structs.
+func (c codec) modelFields(rt reflect.Type) map[string]modelField {
+	fields := make(map[string]modelField)
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if flatten, prefix := flattening(field); flatten {
+			for name, f := range c.modelFields(field.Type) {
+				// don't clobber pre-existing fields
+				if _, exists := fields[prefix+name]; !exists {
+					fields[prefix+name] = f
+				}
+			}
+			continue
+		}
+		name, special, omitempty := c.fieldInfo(field)
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		av, err := c.marshal(reflect.Zero(field.Type).Interface(), special)
+		fields[name] = modelField{
+			omitempty: omitempty,
+			omittable: omitempty || (err == nil && av == nil),
+		}
+	}
+	return fields
+}
+
+// compareSparseKeys checks that the primary keys of a model aren't tagged omitempty,
+// and that index keys whose empty values are left out of items, making the index sparse, are tagged omitempty.
+// hashKey and rangeKey are the index's keys, or empty for the table itself.
+func compareSparseKeys(what string, fields map[string]modelField, tableHash, tableRange, hashKey, rangeKey string) []string {
+	var mismatches []string
+	check := func(kind, name string) {
+		f, ok := fields[name]
+		switch {
+		case name == "" || !ok:
+		case name == tableHash || name == tableRange:
+			if hashKey == "" && rangeKey == "" && f.omitempty {
+				mismatches = append(mismatches, fmt.Sprintf("%s %s key %s is tagged omitempty, but every item must have it", what, kind, name))
+			}
+		case f.omittable && !f.omitempty:
+			mismatches = append(mismatches, fmt.Sprintf("%s %s key %s is left out of items when empty, making the index sparse; tag it omitempty to make this explicit", what, kind, name))
+		}
+	}
+	if hashKey == "" && rangeKey == "" {
+		check("hash", tableHash)
+		check("range", tableRange)
+		return mismatches
+	}
+	check("hash", hashKey)
+	check("range", rangeKey)
+	return mismatches
+}
@@ -0,0 +1,96 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestValidateModelSparse(t *testing.T) {
+	type Order struct {
+		ID     string `dynamo:",hash"`
+		Status string `index:"Status-index,hash"`
+		Rank   int    `dynamo:",omitempty" index:"Status-index,range"`
+		Placed *int   `localIndex:"ID-Placed-index,range"`
+	}
+	desc := &dynamodb.TableDescription{
+		TableName: aws.String("Orders"),
+		KeySchema: keySchema("ID", ""),
+		AttributeDefinitions: []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("ID"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("Status"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("Rank"), AttributeType: aws.String("N")},
+			{AttributeName: aws.String("Placed"), AttributeType: aws.String("N")},
+		},
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{{
+			IndexName:   aws.String("Status-index"),
+			IndexArn:    aws.String("arn"),
+			IndexStatus: aws.String("ACTIVE"),
+			KeySchema:   keySchema("Status", "Rank"),
+		}},
+		LocalSecondaryIndexes: []*dynamodb.LocalSecondaryIndexDescription{{
+			IndexName: aws.String("ID-Placed-index"),
+			IndexArn:  aws.String("arn"),
+			KeySchema: keySchema("ID", "Placed"),
+		}},
+	}
+	table := NewFromIface(newDescribeClient(desc)).Table("Orders")
+
+	err := table.ValidateModel(Order{})
+	schemaErr, ok := err.(*SchemaError)
+	if !ok {
+		t.Fatal("expected SchemaError, got", err)
+	}
+	expected := []string{
+		"global secondary index Status-index hash key Status is left out of items when empty, making the index sparse; tag it omitempty to make this explicit",
+		"local secondary index ID-Placed-index range key Placed is left out of items when empty, making the index sparse; tag it omitempty to make this explicit",
+	}
+	if !reflect.DeepEqual(schemaErr.Mismatches, expected) {
+		t.Error("bad mismatches:", schemaErr.Mismatches, "≠", expected)
+	}
+
+	type SparseOrder struct {
+		ID     string `dynamo:",hash,omitempty"`
+		Status string `dynamo:",omitempty" index:"Status-index,hash"`
+		Rank   int    `dynamo:",omitempty" index:"Status-index,range"`
+		Placed *int   `dynamo:",omitempty" localIndex:"ID-Placed-index,range"`
+	}
+	err = table.ValidateModel(SparseOrder{})
+	schemaErr, ok = err.(*SchemaError)
+	if !ok {
+		t.Fatal("expected SchemaError, got", err)
+	}
+	expected = []string{"table hash key ID is tagged omitempty, but every item must have it"}
+	if !reflect.DeepEqual(schemaErr.Mismatches, expected) {
+		t.Error("bad mismatches:", schemaErr.Mismatches, "≠", expected)
+	}
+}
+
+func TestUpdateSparseIndex(t *testing.T) {
+	table := NewFromIface(newDescribeClient()).Table("Orders")
+
+	u := table.Update("ID", "1").AddToIndex("Status", "open").AddToIndex("Rank", 0)
+	if u.err != nil {
+		t.Fatal("unexpected error:", u.err)
+	}
+	if expr := unsubstitute(*u.updateExpr(), u.nameExpr); expr != "SET Status = :v0, Rank = :v1" {
+		t.Error("bad update expression:", expr)
+	}
+
+	if err := table.Update("ID", "1").AddToIndex("Status", "").err; err == nil {
+		t.Error("expected error for empty value")
+	}
+
+	u = table.Update("ID", "1").RemoveFromIndex("Status", "Rank")
+	if u.err != nil {
+		t.Fatal("unexpected error:", u.err)
+	}
+	if len(u.remove) != 2 {
+		t.Error("expected 2 removals, got:", u.remove)
+	}
+	if err := table.Update("ID", "1").RemoveFromIndex().err; err == nil {
+		t.Error("expected error for no names")
+	}
+}
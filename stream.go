@@ -0,0 +1,95 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// AllStream executes this request and sends each result to out, which must be a channel of the result type,
+// such as chan Widget or chan<- *Widget. out is closed when AllStream returns.
+// Unlike All, it never holds more than one page of raw results in memory,
+// and sending blocks until results are received, so it is suitable for exporting huge tables:
+//	ch := make(chan Widget)
+//	var err error
+//	go func() { err = table.Scan().AllStream(ch) }()
+//	for w := range ch {
+//		// ...
+//	}
+//	// check err
+func (s *Scan) AllStream(out interface{}) error {
+	ctx, cancel := timeoutContext(s.timeout)
+	defer cancel()
+	return s.AllStreamWithContext(ctx, out)
+}
+
+// AllStreamWithContext executes this request and sends each result to out, which is closed when it returns.
+// See AllStream.
+func (s *Scan) AllStreamWithContext(ctx aws.Context, out interface{}) error {
+	ctx, cancel := withTimeout(ctx, s.timeout)
+	defer cancel()
+	itr := &scanIter{
+		scan:     s,
+		err:      s.runErr(),
+		progress: pageTracker{fn: s.onPage, out: s.stats},
+	}
+	if err := s.table.db.codec().stream(ctx, itr, out); err != nil {
+		return err
+	}
+	return itr.Err()
+}
+
+// AllStream executes this request and sends each result to out, which must be a channel of the result type.
+// out is closed when AllStream returns. See Scan.AllStream.
+func (q *Query) AllStream(out interface{}) error {
+	ctx, cancel := timeoutContext(q.timeout)
+	defer cancel()
+	return q.AllStreamWithContext(ctx, out)
+}
+
+// AllStreamWithContext executes this request and sends each result to out, which is closed when it returns.
+// See Scan.AllStream.
+func (q *Query) AllStreamWithContext(ctx aws.Context, out interface{}) error {
+	ctx, cancel := withTimeout(ctx, q.timeout)
+	defer cancel()
+	itr := &queryIter{
+		query:    q,
+		err:      q.err,
+		progress: pageTracker{fn: q.onPage, out: q.stats},
+	}
+	if err := q.table.db.codec().stream(ctx, itr, out); err != nil {
+		return err
+	}
+	return itr.Err()
+}
+
+// stream decodes every result from src and sends them to out, a channel, one at a time, closing it when done.
+func (c codec) stream(ctx aws.Context, src pageSource, out interface{}) error {
+	ch := reflect.ValueOf(out)
+	if ch.Kind() != reflect.Chan || ch.Type().ChanDir()&reflect.SendDir == 0 || ch.IsNil() {
+		return fmt.Errorf("dynamo: stream: result argument must be a channel that can be sent to")
+	}
+	defer ch.Close()
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectSend, Chan: ch},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+	}
+	for {
+		items, ok := src.nextItems(ctx)
+		if !ok {
+			return nil
+		}
+		for _, item := range items {
+			v := reflect.New(ch.Type().Elem())
+			if err := c.unmarshalItem(item, v.Interface()); err != nil {
+				return err
+			}
+			cases[0].Send = v.Elem()
+			if chosen, _, _ := reflect.Select(cases); chosen == 1 {
+				return ctx.Err()
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"golang.org/x/net/context"
+)
+
+func TestScanAllStream(t *testing.T) {
+	table := NewFromIface(fakeScanClient{pages: fakePages(t, 3, 0, 4)}).Table(testTable)
+
+	var expect []widget
+	if err := table.Scan().All(&expect); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan widget)
+	errc := make(chan error, 1)
+	go func() { errc <- table.Scan().AllStream(ch) }()
+	var got []widget
+	for w := range ch {
+		got = append(got, w)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Error("bad results:", got, "≠", expect)
+	}
+
+	// only the current page is kept
+	itr := table.Scan().Iter().(*scanIter)
+	if items, ok := itr.nextItems(aws.BackgroundContext()); !ok || len(items) != 3 || itr.output.Items != nil {
+		t.Error("page not handed over:", len(items), itr.output.Items)
+	}
+
+	if err := table.Scan().AllStream(make(<-chan widget)); err == nil {
+		t.Error("expected error for receive-only channel")
+	}
+	if err := table.Scan().AllStream(&got); err == nil {
+		t.Error("expected error for non-channel")
+	}
+}
+
+func TestQueryAllStream(t *testing.T) {
+	table := NewFromIface(fakeQueryClient{pages: fakePages(t, 2, 3)}).Table(testTable)
+
+	ch := make(chan *widget)
+	var send chan<- *widget = ch
+	errc := make(chan error, 1)
+	go func() { errc <- table.Get("UserID", 42).Limit(4).AllStream(send) }()
+	var msgs []string
+	for w := range ch {
+		msgs = append(msgs, w.Msg)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if expect := []string{"0-0", "0-1", "1-0", "1-1"}; !reflect.DeepEqual(msgs, expect) {
+		t.Error("bad results:", msgs, "≠", expect)
+	}
+
+	// canceling stops a stream that isn't being received
+	ctx, cancel := context.WithCancel(aws.BackgroundContext())
+	cancel()
+	if err := table.Get("UserID", 42).AllStreamWithContext(ctx, make(chan widget)); err != context.Canceled {
+		t.Error("expected context.Canceled, got", err)
+	}
+}
@@ -18,6 +18,7 @@ import (
 type subber struct {
 	nameExpr  map[string]*string
 	valueExpr map[string]*dynamodb.AttributeValue
+	codec     codec
 }
 
 func (s *subber) subName(name string) string {
@@ -36,7 +37,7 @@ func (s *subber) subValue(value interface{}, special string) (string, error) {
 	}
 
 	sub := fmt.Sprintf(":v%d", len(s.valueExpr))
-	av, err := marshal(value, special)
+	av, err := s.codec.marshal(value, special)
 	if err != nil {
 		return "", err
 	}
@@ -44,6 +45,24 @@ func (s *subber) subValue(value interface{}, special string) (string, error) {
 	return sub, nil
 }
 
+// clone returns a copy of s that doesn't share its maps.
+func (s subber) clone() subber {
+	cp := subber{codec: s.codec}
+	if s.nameExpr != nil {
+		cp.nameExpr = make(map[string]*string, len(s.nameExpr))
+		for k, v := range s.nameExpr {
+			cp.nameExpr[k] = v
+		}
+	}
+	if s.valueExpr != nil {
+		cp.valueExpr = make(map[string]*dynamodb.AttributeValue, len(s.valueExpr))
+		for k, v := range s.valueExpr {
+			cp.valueExpr[k] = v
+		}
+	}
+	return cp
+}
+
 // subExpr takes a dynamo-flavored expression and fills in its placeholders
 // with the given args.
 func (s *subber) subExpr(expr string, args ...interface{}) (string, error) {
@@ -1,6 +1,9 @@
 package dynamo
 
 import (
+	"sync"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
@@ -23,6 +26,8 @@ const (
 type Table struct {
 	name string
 	db   *DB
+
+	consistent *bool
 }
 
 // Table returns a Table handle specified by name.
@@ -33,15 +38,38 @@ func (db *DB) Table(name string) Table {
 	}
 }
 
+// ConsistentReads returns a copy of this table handle whose reads are strongly consistent by default if on is true,
+// or eventually consistent if false, overriding DB.ConsistentReads.
+// Individual requests can override this with Consistent.
+// Like DB.ConsistentReads, it doesn't apply to queries and scans of secondary indexes.
+func (table Table) ConsistentReads(on bool) Table {
+	table.consistent = &on
+	return table
+}
+
+// consistentReads returns the default read consistency of this table.
+func (table Table) consistentReads() bool {
+	if table.consistent != nil {
+		return *table.consistent
+	}
+	return table.db.consistent
+}
+
 // Name returns this table's name.
 func (table Table) Name() string {
 	return table.name
 }
 
+// DB returns the DB this table belongs to.
+func (table Table) DB() *DB {
+	return table.db
+}
+
 // DeleteTable is a request to delete a table.
 // See: http://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_DeleteTable.html
 type DeleteTable struct {
-	table Table
+	table   Table
+	timeout time.Duration
 }
 
 // DeleteTable begins a new request to delete this table.
@@ -49,18 +77,28 @@ func (table Table) DeleteTable() *DeleteTable {
 	return &DeleteTable{table: table}
 }
 
+// Timeout limits the total amount of time this request may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (dt *DeleteTable) Timeout(timeout time.Duration) *DeleteTable {
+	dt.timeout = timeout
+	return dt
+}
+
 // Run executes this request and deletes the table.
 func (dt *DeleteTable) Run() error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(dt.timeout)
 	defer cancel()
 	return dt.RunWithContext(ctx)
 }
 
 // RunWithContext executes this request and deletes the table.
 func (dt *DeleteTable) RunWithContext(ctx aws.Context) error {
+	ctx, cancel := withTimeout(ctx, dt.timeout)
+	defer cancel()
 	input := dt.input()
+	defer dt.table.db.descs.forget(dt.table.Name())
 	return retry(ctx, func() error {
-		_, err := dt.table.db.client.DeleteTableWithContext(ctx, input)
+		_, err := dt.table.db.client.DeleteTableWithContext(ctx, input, dt.table.db.opts...)
 		return err
 	})
 }
@@ -110,10 +148,15 @@ type ConsumedCapacity struct {
 	TableName string
 }
 
+// capacityMu guards consumed capacity shared by concurrent requests, such as with DefaultConsumedCapacity.
+var capacityMu sync.Mutex
+
 func addConsumedCapacity(cc *ConsumedCapacity, raw *dynamodb.ConsumedCapacity) {
 	if cc == nil || raw == nil {
 		return
 	}
+	capacityMu.Lock()
+	defer capacityMu.Unlock()
 	if raw.CapacityUnits != nil {
 		cc.Total += *raw.CapacityUnits
 	}
@@ -176,3 +219,37 @@ func addConsumedCapacity(cc *ConsumedCapacity, raw *dynamodb.ConsumedCapacity) {
 		cc.TableName = *raw.TableName
 	}
 }
+
+// ItemCollectionMetrics represents the estimated size of an item collection affected by an operation.
+// Item collections only exist for tables with local secondary indexes, and are limited to 10GB each.
+type ItemCollectionMetrics struct {
+	// Key is the partition key of the item collection.
+	Key map[string]*dynamodb.AttributeValue
+	// SizeLower is the lower bound of the estimated item collection size, in gigabytes.
+	SizeLower float64
+	// SizeUpper is the upper bound of the estimated item collection size, in gigabytes.
+	SizeUpper float64
+	// TableName is the name of the table this item collection belongs to.
+	TableName string
+}
+
+func newItemCollectionMetrics(table string, raw *dynamodb.ItemCollectionMetrics) ItemCollectionMetrics {
+	icm := ItemCollectionMetrics{
+		Key:       raw.ItemCollectionKey,
+		TableName: table,
+	}
+	if len(raw.SizeEstimateRangeGB) > 0 && raw.SizeEstimateRangeGB[0] != nil {
+		icm.SizeLower = *raw.SizeEstimateRangeGB[0]
+	}
+	if len(raw.SizeEstimateRangeGB) > 1 && raw.SizeEstimateRangeGB[1] != nil {
+		icm.SizeUpper = *raw.SizeEstimateRangeGB[1]
+	}
+	return icm
+}
+
+func setItemCollectionMetrics(icm *ItemCollectionMetrics, table string, raw *dynamodb.ItemCollectionMetrics) {
+	if icm == nil || raw == nil {
+		return
+	}
+	*icm = newItemCollectionMetrics(table, raw)
+}
@@ -57,3 +57,54 @@ func TestAddConsumedCapacity(t *testing.T) {
 		t.Error("bad ConsumedCapacity:", cc, "≠", expected)
 	}
 }
+
+func TestNewItemCollectionMetrics(t *testing.T) {
+	raw := &dynamodb.ItemCollectionMetrics{
+		ItemCollectionKey: map[string]*dynamodb.AttributeValue{
+			"UserID": &dynamodb.AttributeValue{N: aws.String("42")},
+		},
+		SizeEstimateRangeGB: []*float64{aws.Float64(1), aws.Float64(2)},
+	}
+	expected := ItemCollectionMetrics{
+		Key:       raw.ItemCollectionKey,
+		SizeLower: 1,
+		SizeUpper: 2,
+		TableName: "TestTable",
+	}
+
+	var icm ItemCollectionMetrics
+	setItemCollectionMetrics(&icm, "TestTable", raw)
+
+	if !reflect.DeepEqual(icm, expected) {
+		t.Error("bad ItemCollectionMetrics:", icm, "≠", expected)
+	}
+}
+
+func TestConsistentReads(t *testing.T) {
+	db := NewFromIface(nil).ConsistentReads(true)
+	table := db.Table(testTable)
+
+	if !aws.BoolValue(table.Get("UserID", 1).queryInput().ConsistentRead) {
+		t.Error("query should be consistent by default")
+	}
+	if aws.BoolValue(table.Get("UserID", 1).Consistent(false).getItemInput().ConsistentRead) {
+		t.Error("request should override default")
+	}
+	if aws.BoolValue(table.Scan().Index("Msg-Time-index").scanInput().ConsistentRead) {
+		t.Error("default shouldn't apply to indexes")
+	}
+	if !aws.BoolValue(table.Scan().Index("Msg-Time-index").Consistent(true).scanInput().ConsistentRead) {
+		t.Error("index scan should be consistent when requested")
+	}
+	if aws.BoolValue(table.ConsistentReads(false).Scan().scanInput().ConsistentRead) {
+		t.Error("table default should override DB default")
+	}
+
+	bg := table.Batch("UserID").Get(Keys{1})
+	if in, _ := bg.input(0); !aws.BoolValue(in.RequestItems[testTable].ConsistentRead) {
+		t.Error("batch get should be consistent by default")
+	}
+	if aws.BoolValue(NewFromIface(nil).Table(testTable).Get("UserID", 1).queryInput().ConsistentRead) {
+		t.Error("reads should be eventually consistent without a default")
+	}
+}
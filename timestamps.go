@@ -0,0 +1,225 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Clock configures the time used for createdTime and updatedTime fields. See DB.WithClock.
+type Clock struct {
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+	// Skew is added to the time returned by Now, to correct for a clock known to be
+	// ahead of (negative Skew) or behind (positive Skew) the server's clock.
+	Skew time.Duration
+	// Precision, if set, truncates timestamps to a multiple of it, such as time.Millisecond.
+	// Coarser precision makes timestamps written by hosts with slightly different clocks compare equal.
+	Precision time.Duration
+}
+
+// WithClock returns a copy of this DB that uses the given clock for timestamp fields.
+//
+// Struct fields tagged with createdTime or updatedTime, like
+//	Created time.Time `dynamo:",createdTime"`
+//	Updated int64     `dynamo:"UpdatedAt,updatedTime"`
+// are filled automatically when writing.
+// Put and BatchWrite set updatedTime fields to the current time,
+// and createdTime fields to the current time if they are zero.
+// Update sets them when using Patch or Timestamps.
+// Fields of type time.Time or *time.Time are encoded as usual, and integer fields as Unix seconds.
+// Combine them with unixtime, as in `dynamo:",createdTime,unixtime"`, to encode time.Time fields as Unix seconds too.
+// When the item is passed as a pointer, its timestamp fields are updated as well.
+func (db *DB) WithClock(clock Clock) *DB {
+	cp := *db
+	cp.clock = &clock
+	return &cp
+}
+
+// now returns the current time according to this DB's clock.
+func (db *DB) now() time.Time {
+	if db.clock == nil {
+		return time.Now().UTC()
+	}
+	now := time.Now
+	if db.clock.Now != nil {
+		now = db.clock.Now
+	}
+	t := now().Add(db.clock.Skew).UTC()
+	if db.clock.Precision > 0 {
+		t = t.Truncate(db.clock.Precision)
+	}
+	return t
+}
+
+// autoField is a struct field filled automatically when writing,
+// tagged with createdTime, updatedTime, ulid, ksuid, or version.
+type autoField struct {
+	name    string
+	index   []int
+	typ     reflect.Type
+	special string
+	// encoding is the field's encoding option, such as unixtime
+	encoding string
+}
+
+func (f autoField) isTime() bool {
+	return f.special == "createdTime" || f.special == "updatedTime"
+}
+
+// isAutoOption returns true if opt is a tag option for a field filled automatically when writing.
+func isAutoOption(opt string) bool {
+	switch opt {
+	case "createdTime", "updatedTime", "ulid", "ksuid", "version":
+		return true
+	}
+	return false
+}
+
+// autoOption returns the auto option in field's tag, if any.
+// Timestamps can also be encoded with unixtime, but other encoding options and multiple auto options are an error.
+func autoOption(field reflect.StructField, encoding string) (string, error) {
+	var auto string
+	for _, opt := range strings.Split(field.Tag.Get("dynamo"), ",")[1:] {
+		if !isAutoOption(opt) {
+			continue
+		}
+		if auto != "" {
+			return "", fmt.Errorf("dynamo: field %s can't be both %s and %s", field.Name, auto, opt)
+		}
+		auto = opt
+	}
+	switch {
+	case auto == "", encoding == "":
+	case encoding == "unixtime" && (auto == "createdTime" || auto == "updatedTime"):
+	default:
+		return "", fmt.Errorf("dynamo: field %s can't be both %s and %s", field.Name, auto, encoding)
+	}
+	return auto, nil
+}
+
+// autoFields returns the automatically filled fields of the given struct type, including those of embedded structs.
+func (c codec) autoFields(rt reflect.Type) ([]autoField, error) {
+	for rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	var fields []autoField
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if flatten, prefix := flattening(field); flatten {
+			inner, err := c.autoFields(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range inner {
+				f.name = prefix + f.name
+				f.index = append([]int{i}, f.index...)
+				fields = append(fields, f)
+			}
+			continue
+		}
+		name, encoding, _ := c.fieldInfo(field)
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		special, err := autoOption(field, encoding)
+		if err != nil {
+			return nil, err
+		}
+		if special != "" {
+			fields = append(fields, autoField{
+				name:     name,
+				index:    []int{i},
+				typ:      field.Type,
+				special:  special,
+				encoding: encoding,
+			})
+		}
+	}
+	return fields, nil
+}
+
+// stampItem fills the timestamp and ID fields of v in its encoded form item.
+// If v is a pointer, its fields are set too.
+func (c codec) stampItem(v interface{}, item map[string]*dynamodb.AttributeValue, now time.Time) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	fields, err := c.autoFields(rv.Type())
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if f.special == "version" {
+			// only incremented by versioned writes
+			continue
+		}
+		fv := rv.FieldByIndex(f.index)
+		if f.special != "updatedTime" && !isZero(fv) {
+			continue
+		}
+		if !f.isTime() {
+			id, err := newID(f.special, f.typ, now)
+			if err != nil {
+				return err
+			}
+			if item[f.name], err = c.marshal(id.Interface(), ""); err != nil {
+				return err
+			}
+			if fv.CanSet() {
+				fv.Set(id)
+			}
+			continue
+		}
+		av, err := c.marshalStamp(f.typ, f.encoding, now)
+		if err != nil {
+			return err
+		}
+		item[f.name] = av
+		if fv.CanSet() {
+			setStamp(fv, now)
+		}
+	}
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// marshalStamp encodes now for a timestamp field of type rt with the given encoding option.
+func (c codec) marshalStamp(rt reflect.Type, encoding string, now time.Time) (*dynamodb.AttributeValue, error) {
+	switch {
+	case rt == timeType, rt.Kind() == reflect.Ptr && rt.Elem() == timeType:
+		return c.marshal(now, encoding)
+	}
+	switch rt.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		ts := strconv.FormatInt(now.Unix(), 10)
+		return &dynamodb.AttributeValue{N: &ts}, nil
+	}
+	return nil, fmt.Errorf("dynamo: timestamp field must be time.Time or an integer, not %v", rt)
+}
+
+// setStamp sets the timestamp field fv to now.
+func setStamp(fv reflect.Value, now time.Time) {
+	switch fv.Kind() {
+	case reflect.Struct:
+		fv.Set(reflect.ValueOf(now))
+	case reflect.Ptr:
+		fv.Set(reflect.ValueOf(&now))
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		fv.SetInt(now.Unix())
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		fv.SetUint(uint64(now.Unix()))
+	}
+}
@@ -0,0 +1,136 @@
+package dynamo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+type stampedWidget struct {
+	UserID  int
+	Msg     string
+	Created time.Time `dynamo:",createdTime"`
+	Updated int64     `dynamo:"UpdatedAt,updatedTime"`
+}
+
+func TestTimestamps(t *testing.T) {
+	now := time.Date(2019, 5, 1, 12, 30, 15, 123456789, time.UTC)
+	db := NewFromIface(newMemClient()).WithClock(Clock{
+		Now:       func() time.Time { return now },
+		Skew:      time.Second,
+		Precision: time.Millisecond,
+	})
+	table := db.Table(testTable)
+	expect := now.Add(time.Second).Truncate(time.Millisecond)
+
+	w := stampedWidget{UserID: 42, Msg: "hello"}
+	put := table.Put(&w)
+	if put.err != nil {
+		t.Fatal(put.err)
+	}
+	if !w.Created.Equal(expect) || w.Updated != expect.Unix() {
+		t.Error("fields not stamped:", w)
+	}
+	if got := put.item["UpdatedAt"]; aws.StringValue(got.N) != "1556713816" {
+		t.Error("bad updatedTime:", got)
+	}
+	created, _ := marshal(expect, "")
+	if got := put.item["Created"]; !reflect.DeepEqual(got, created) {
+		t.Error("bad createdTime:", got, "≠", created)
+	}
+
+	// createdTime is kept when already set
+	earlier := expect.Add(-time.Hour)
+	put = table.Put(stampedWidget{UserID: 42, Created: earlier})
+	if got, _ := marshal(earlier, ""); !reflect.DeepEqual(put.item["Created"], got) {
+		t.Error("createdTime overwritten:", put.item["Created"])
+	}
+
+	u := table.Update("UserID", 42).Patch(stampedWidget{Msg: "a"}, stampedWidget{Msg: "b", Updated: 1})
+	if u.err != nil {
+		t.Fatal(u.err)
+	}
+	input := u.updateInput()
+	expr := *input.UpdateExpression
+	for sub, name := range input.ExpressionAttributeNames {
+		expr = strings.Replace(expr, sub, *name, -1)
+	}
+	for _, want := range []string{"Msg = ", "Created = if_not_exists(Created, ", "UpdatedAt = "} {
+		if strings.Count(expr, want) != 1 {
+			t.Errorf("expected %q once in %q", want, expr)
+		}
+	}
+
+	if put := table.Put(struct {
+		UserID int
+		When   string `dynamo:",updatedTime"`
+	}{UserID: 1}); put.err == nil {
+		t.Error("expected error for string timestamp field")
+	}
+}
+
+func TestTimestampsBatchWrite(t *testing.T) {
+	db := NewFromIface(newMemClient())
+	bw := db.Table(testTable).Batch("UserID").Write().Put(stampedWidget{UserID: 1})
+	if bw.err != nil {
+		t.Fatal(bw.err)
+	}
+	item := bw.ops[0].PutRequest.Item
+	if _, ok := item["UpdatedAt"]; !ok {
+		t.Error("updatedTime not set:", item)
+	}
+	var decoded stampedWidget
+	if err := unmarshalItem(item, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Created.IsZero() || decoded.Created.Unix() != decoded.Updated {
+		t.Error("bad timestamps:", decoded)
+	}
+}
+
+func TestTimestampsUnixtime(t *testing.T) {
+	now := time.Date(2019, 5, 1, 12, 30, 15, 0, time.UTC)
+	table := NewFromIface(newMemClient()).WithClock(Clock{Now: func() time.Time { return now }}).Table(testTable)
+
+	type unixWidget struct {
+		UserID  int
+		Created time.Time `dynamo:"Created,createdTime,unixtime"`
+		Updated time.Time `dynamo:",unixtime,updatedTime"`
+	}
+	put := table.Put(unixWidget{UserID: 42})
+	if put.err != nil {
+		t.Fatal(put.err)
+	}
+	for _, name := range []string{"Created", "Updated"} {
+		if got := put.item[name]; got == nil || aws.StringValue(got.N) != "1556713815" {
+			t.Errorf("bad %s: %v", name, got)
+		}
+	}
+
+	u := table.Update("UserID", 42).Timestamps(unixWidget{})
+	if u.err != nil {
+		t.Fatal(u.err)
+	}
+	for _, av := range u.updateInput().ExpressionAttributeValues {
+		if aws.StringValue(av.N) != "1556713815" {
+			t.Error("bad update timestamp:", av)
+		}
+	}
+
+	// options that can't be combined
+	if put := table.Put(struct {
+		UserID int
+		When   time.Time `dynamo:",createdTime,updatedTime"`
+	}{UserID: 1}); put.err == nil {
+		t.Error("expected error for createdTime and updatedTime")
+	}
+	if put := table.Put(struct {
+		UserID int
+		ID     string `dynamo:",ulid,unixtime"`
+	}{UserID: 1}); put.err == nil {
+		t.Error("expected error for ulid and unixtime")
+	}
+}
@@ -1,6 +1,8 @@
 package dynamo
 
 import (
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
@@ -13,6 +15,7 @@ type UpdateTTL struct {
 	table   Table
 	attrib  string
 	enabled bool
+	timeout time.Duration
 }
 
 // UpdateTTL begins a new request to enable or disable this table's time to live.
@@ -28,19 +31,28 @@ func (table Table) UpdateTTL(attribute string, enabled bool) *UpdateTTL {
 	}
 }
 
+// Timeout limits the total amount of time this request may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (ttl *UpdateTTL) Timeout(timeout time.Duration) *UpdateTTL {
+	ttl.timeout = timeout
+	return ttl
+}
+
 // Run executes this request.
 func (ttl *UpdateTTL) Run() error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(ttl.timeout)
 	defer cancel()
 	return ttl.RunWithContext(ctx)
 }
 
 // RunWithContext executes this request.
 func (ttl *UpdateTTL) RunWithContext(ctx aws.Context) error {
+	ctx, cancel := withTimeout(ctx, ttl.timeout)
+	defer cancel()
 	input := ttl.input()
 
 	err := retry(ctx, func() error {
-		_, err := ttl.table.db.client.UpdateTimeToLiveWithContext(ctx, input)
+		_, err := ttl.table.db.client.UpdateTimeToLiveWithContext(ctx, input, ttl.table.db.opts...)
 		return err
 	})
 	return err
@@ -58,29 +70,39 @@ func (ttl *UpdateTTL) input() *dynamodb.UpdateTimeToLiveInput {
 
 // DescribeTTL is a request to obtain details about a table's time to live configuration.
 type DescribeTTL struct {
-	table Table
+	table   Table
+	timeout time.Duration
 }
 
 // DescribeTTL begins a new request to obtain details about this table's time to live configuration.
 func (table Table) DescribeTTL() *DescribeTTL {
-	return &DescribeTTL{table}
+	return &DescribeTTL{table: table}
+}
+
+// Timeout limits the total amount of time this request may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (d *DescribeTTL) Timeout(timeout time.Duration) *DescribeTTL {
+	d.timeout = timeout
+	return d
 }
 
 // Run executes this request and returns details about time to live, or an error.
 func (d *DescribeTTL) Run() (TTLDescription, error) {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(d.timeout)
 	defer cancel()
 	return d.RunWithContext(ctx)
 }
 
 // RunWithContext executes this request and returns details about time to live, or an error.
 func (d *DescribeTTL) RunWithContext(ctx aws.Context) (TTLDescription, error) {
+	ctx, cancel := withTimeout(ctx, d.timeout)
+	defer cancel()
 	input := d.input()
 
 	var result *dynamodb.DescribeTimeToLiveOutput
 	err := retry(ctx, func() error {
 		var err error
-		result, err = d.table.db.client.DescribeTimeToLiveWithContext(ctx, input)
+		result, err = d.table.db.client.DescribeTimeToLiveWithContext(ctx, input, d.table.db.opts...)
 		return err
 	})
 	if err != nil {
@@ -0,0 +1,34 @@
+package dynamo
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+)
+
+// ttlPrincipal is the principal of the user identity of stream records for Time To Live deletes.
+const ttlPrincipal = "dynamodb.amazonaws.com"
+
+// IsTTLExpiry returns true if rec, a record read from a DynamoDB stream, is the removal of an item
+// that expired according to the table's Time To Live setting, rather than a delete made by an application.
+// DynamoDB marks these records with a user identity of type "Service" and principal "dynamodb.amazonaws.com".
+func IsTTLExpiry(rec *dynamodbstreams.Record) bool {
+	if rec == nil || rec.UserIdentity == nil {
+		return false
+	}
+	return aws.StringValue(rec.EventName) == dynamodbstreams.OperationTypeRemove &&
+		aws.StringValue(rec.UserIdentity.Type) == "Service" &&
+		aws.StringValue(rec.UserIdentity.PrincipalId) == ttlPrincipal
+}
+
+// SplitTTLExpiries separates the Time To Live expirations among records from every other record, keeping their order.
+// This is useful for archival pipelines, which usually keep expired items but drop deleted ones. See IsTTLExpiry.
+func SplitTTLExpiries(records []*dynamodbstreams.Record) (expired, other []*dynamodbstreams.Record) {
+	for _, rec := range records {
+		if IsTTLExpiry(rec) {
+			expired = append(expired, rec)
+			continue
+		}
+		other = append(other, rec)
+	}
+	return expired, other
+}
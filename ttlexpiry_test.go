@@ -0,0 +1,52 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+)
+
+func TestTTLExpiry(t *testing.T) {
+	expired := &dynamodbstreams.Record{
+		EventName: aws.String(dynamodbstreams.OperationTypeRemove),
+		UserIdentity: &dynamodbstreams.Identity{
+			Type:        aws.String("Service"),
+			PrincipalId: aws.String("dynamodb.amazonaws.com"),
+		},
+	}
+	deleted := &dynamodbstreams.Record{EventName: aws.String(dynamodbstreams.OperationTypeRemove)}
+	inserted := &dynamodbstreams.Record{EventName: aws.String(dynamodbstreams.OperationTypeInsert)}
+	impostor := &dynamodbstreams.Record{
+		EventName: aws.String(dynamodbstreams.OperationTypeRemove),
+		UserIdentity: &dynamodbstreams.Identity{
+			Type:        aws.String("Service"),
+			PrincipalId: aws.String("lambda.amazonaws.com"),
+		},
+	}
+
+	table := []struct {
+		rec    *dynamodbstreams.Record
+		expiry bool
+	}{
+		{expired, true},
+		{deleted, false},
+		{inserted, false},
+		{impostor, false},
+		{nil, false},
+	}
+	for i, tc := range table {
+		if got := IsTTLExpiry(tc.rec); got != tc.expiry {
+			t.Errorf("record %d: IsTTLExpiry = %v, want %v", i, got, tc.expiry)
+		}
+	}
+
+	ttl, other := SplitTTLExpiries([]*dynamodbstreams.Record{inserted, expired, deleted, expired})
+	if expect := []*dynamodbstreams.Record{expired, expired}; !reflect.DeepEqual(ttl, expect) {
+		t.Error("bad expired records:", ttl)
+	}
+	if expect := []*dynamodbstreams.Record{inserted, deleted}; !reflect.DeepEqual(other, expect) {
+		t.Error("bad other records:", other)
+	}
+}
@@ -1,6 +1,8 @@
 package dynamo
 
 import (
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/gofrs/uuid"
@@ -19,12 +21,17 @@ type GetTx struct {
 	items        []getTxOp
 	unmarshalers map[getTxOp]interface{}
 	cc           *ConsumedCapacity
+	timeout      time.Duration
+	conflicts    txConflicts
 }
 
 // GetTx begins a new get transaction.
 func (db *DB) GetTx() *GetTx {
+	defs := db.requestDefaults()
 	return &GetTx{
-		db: db,
+		db:      db,
+		cc:      defs.cc,
+		timeout: defs.timeout,
 	}
 }
 
@@ -51,29 +58,57 @@ func (tx *GetTx) ConsumedCapacity(cc *ConsumedCapacity) *GetTx {
 	return tx
 }
 
+// Timeout limits the total amount of time this transaction may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (tx *GetTx) Timeout(timeout time.Duration) *GetTx {
+	tx.timeout = timeout
+	return tx
+}
+
+// RetryConflicts sets whether this transaction is automatically retried when it conflicts with another transaction.
+// It is enabled by default.
+func (tx *GetTx) RetryConflicts(enabled bool) *GetTx {
+	tx.conflicts.disabled = !enabled
+	return tx
+}
+
+// ConflictBackoff sets how long to wait before retrying a conflict with another transaction.
+// By default, DefaultConflictBackoff is used.
+func (tx *GetTx) ConflictBackoff(b Backoff) *GetTx {
+	tx.conflicts.backoff = &b
+	return tx
+}
+
 // Run executes this transaction and unmarshals everything specified by GetOne.
 func (tx *GetTx) Run() error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(tx.timeout)
 	defer cancel()
 	return tx.RunWithContext(ctx)
 }
 
 // RunWithContext executes this transaction and unmarshals everything specified by GetOne.
 func (tx *GetTx) RunWithContext(ctx aws.Context) error {
+	ctx, cancel := withTimeout(ctx, tx.timeout)
+	defer cancel()
 	input, err := tx.input()
 	if err != nil {
 		return err
 	}
 	var resp *dynamodb.TransactGetItemsOutput
-	err = retry(ctx, func() error {
-		var err error
-		resp, err = tx.db.client.TransactGetItemsWithContext(ctx, input)
-		if tx.cc != nil && resp != nil {
-			for _, cc := range resp.ConsumedCapacity {
-				addConsumedCapacity(tx.cc, cc)
+	ctx = tx.db.budgeted(ctx)
+	err = tx.conflicts.retry(ctx, func() error {
+		canceled := txCancellation{codec: tx.db.codec()}
+		err := retry(ctx, func() error {
+			var err error
+			resp, err = tx.db.client.TransactGetItemsWithContext(ctx, input, canceled.options(tx.db.opts)...)
+			if tx.cc != nil && resp != nil {
+				for _, cc := range resp.ConsumedCapacity {
+					addConsumedCapacity(tx.cc, cc)
+				}
 			}
-		}
-		return err
+			return err
+		})
+		return canceled.wrap(err)
 	})
 	if err != nil {
 		return err
@@ -90,7 +125,7 @@ func (tx *GetTx) unmarshal(resp *dynamodb.TransactGetItemsOutput) error {
 			continue
 		}
 		if target := tx.unmarshalers[tx.items[i]]; target != nil {
-			if err := UnmarshalItem(item.Item, target); err != nil {
+			if err := tx.db.codec().unmarshalItem(item.Item, target); err != nil {
 				return err
 			}
 		}
@@ -100,27 +135,34 @@ func (tx *GetTx) unmarshal(resp *dynamodb.TransactGetItemsOutput) error {
 
 // All executes this transaction and unmarshals every value to out, which must be a pointer to a slice.
 func (tx *GetTx) All(out interface{}) error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(tx.timeout)
 	defer cancel()
 	return tx.AllWithContext(ctx, out)
 }
 
 // AllWithContext executes this transaction and unmarshals every value to out, which must be a pointer to a slice.
 func (tx *GetTx) AllWithContext(ctx aws.Context, out interface{}) error {
+	ctx, cancel := withTimeout(ctx, tx.timeout)
+	defer cancel()
 	input, err := tx.input()
 	if err != nil {
 		return err
 	}
 	var resp *dynamodb.TransactGetItemsOutput
-	err = retry(ctx, func() error {
-		var err error
-		resp, err = tx.db.client.TransactGetItems(input)
-		if tx.cc != nil && resp != nil {
-			for _, cc := range resp.ConsumedCapacity {
-				addConsumedCapacity(tx.cc, cc)
+	ctx = tx.db.budgeted(ctx)
+	err = tx.conflicts.retry(ctx, func() error {
+		canceled := txCancellation{codec: tx.db.codec()}
+		err := retry(ctx, func() error {
+			var err error
+			resp, err = tx.db.client.TransactGetItemsWithContext(ctx, input, canceled.options(tx.db.opts)...)
+			if tx.cc != nil && resp != nil {
+				for _, cc := range resp.ConsumedCapacity {
+					addConsumedCapacity(tx.cc, cc)
+				}
 			}
-		}
-		return err
+			return err
+		})
+		return canceled.wrap(err)
 	})
 	if err != nil {
 		return err
@@ -135,7 +177,7 @@ func (tx *GetTx) AllWithContext(ctx aws.Context, out interface{}) error {
 		if item.Item == nil {
 			continue
 		}
-		if err := unmarshalAppend(item.Item, out); err != nil {
+		if err := tx.db.codec().unmarshalAppend(item.Item, out); err != nil {
 			return err
 		}
 	}
@@ -167,17 +209,26 @@ type writeTxOp interface {
 // WriteTx is analogous to TransactWriteItems in DynamoDB's API.
 // See: https://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_TransactWriteItems.html
 type WriteTx struct {
-	db    *DB
-	items []writeTxOp
-	token string
-	cc    *ConsumedCapacity
-	err   error
+	db       *DB
+	items    []writeTxOp
+	token    string
+	versions bool
+	// returnFailed is set by ReturnFailedItems
+	returnFailed bool
+	cc           *ConsumedCapacity
+	err          error
+	timeout      time.Duration
+
+	conflicts txConflicts
 }
 
 // WriteTx begins a new write transaction.
 func (db *DB) WriteTx() *WriteTx {
+	defs := db.requestDefaults()
 	return &WriteTx{
-		db: db,
+		db:      db,
+		cc:      defs.cc,
+		timeout: defs.timeout,
 	}
 }
 
@@ -206,7 +257,9 @@ func (tx *WriteTx) Check(check *ConditionCheck) *WriteTx {
 }
 
 // Idempotent marks this transaction as idempotent when enabled is true.
-// This automatically generates a unique idempotency token for you.
+// This automatically generates a unique idempotency token for you, which is kept for the lifetime of this builder,
+// so running it again after a network error or timeout won't apply it twice.
+// Even without Idempotent, each run uses its own token, so automatic retries of a run are never applied twice.
 // An idempotent transaction ran multiple times will have the same effect as being run once.
 // An idempotent request is only good for 10 minutes, after that it will be considered a new request.
 func (tx *WriteTx) Idempotent(enabled bool) *WriteTx {
@@ -234,21 +287,52 @@ func (tx *WriteTx) IdempotentWithToken(token string) *WriteTx {
 	return tx
 }
 
+// ReturnFailedItems sets whether DynamoDB returns the current item for operations that fail their condition
+// when this transaction is canceled. They are available from the Item and Unmarshal of the TxCancelReasons of the TxCanceledError.
+func (tx *WriteTx) ReturnFailedItems(enabled bool) *WriteTx {
+	tx.returnFailed = enabled
+	return tx
+}
+
 // ConsumedCapacity will measure the throughput capacity consumed by this transaction and add it to cc.
 func (tx *WriteTx) ConsumedCapacity(cc *ConsumedCapacity) *WriteTx {
 	tx.cc = cc
 	return tx
 }
 
+// Timeout limits the total amount of time this transaction may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (tx *WriteTx) Timeout(timeout time.Duration) *WriteTx {
+	tx.timeout = timeout
+	return tx
+}
+
+// RetryConflicts sets whether this transaction is automatically retried when it conflicts with another transaction,
+// such as when an item it writes is part of another transaction in progress.
+// It is enabled by default.
+func (tx *WriteTx) RetryConflicts(enabled bool) *WriteTx {
+	tx.conflicts.disabled = !enabled
+	return tx
+}
+
+// ConflictBackoff sets how long to wait before retrying a conflict with another transaction.
+// By default, DefaultConflictBackoff is used.
+func (tx *WriteTx) ConflictBackoff(b Backoff) *WriteTx {
+	tx.conflicts.backoff = &b
+	return tx
+}
+
 // Run executes this transaction.
 func (tx *WriteTx) Run() error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(tx.timeout)
 	defer cancel()
 	return tx.RunWithContext(ctx)
 }
 
 // RunWithContext executes this transaction.
 func (tx *WriteTx) RunWithContext(ctx aws.Context) error {
+	ctx, cancel := withTimeout(ctx, tx.timeout)
+	defer cancel()
 	if tx.err != nil {
 		return tx.err
 	}
@@ -256,25 +340,68 @@ func (tx *WriteTx) RunWithContext(ctx aws.Context) error {
 	if err != nil {
 		return err
 	}
-	err = retry(ctx, func() error {
-		out, err := tx.db.client.TransactWriteItemsWithContext(ctx, input)
-		if tx.cc != nil && out != nil {
-			for _, cc := range out.ConsumedCapacity {
-				addConsumedCapacity(tx.cc, cc)
-			}
+	if input.ClientRequestToken == nil {
+		// make retries of this run idempotent
+		token, err := uuid.NewV4()
+		if err != nil {
+			return err
 		}
-		return err
+		input.ClientRequestToken = aws.String(token.String())
+	}
+	if tx.db.hasWriteHooks() {
+		events := txWriteEvents(input.TransactItems)
+		if err := tx.db.beforeWrite(events...); err != nil {
+			return err
+		}
+		defer func() {
+			tx.db.afterWrite(err, events...)
+		}()
+	}
+	ctx = tx.db.budgeted(ctx)
+	err = tx.conflicts.retry(ctx, func() error {
+		canceled := txCancellation{codec: tx.db.codec()}
+		err := retry(ctx, func() error {
+			out, err := tx.db.client.TransactWriteItemsWithContext(ctx, input, canceled.options(tx.db.opts)...)
+			if tx.cc != nil && out != nil {
+				for _, cc := range out.ConsumedCapacity {
+					addConsumedCapacity(tx.cc, cc)
+				}
+			}
+			return err
+		})
+		return canceled.wrap(err)
 	})
+	if tx.db.cache != nil {
+		tx.db.cache.invalidateTx(input.TransactItems)
+	}
+	if err == nil {
+		tx.bumpVersions()
+	}
 	return err
 }
 
 func (tx *WriteTx) input() (*dynamodb.TransactWriteItemsInput, error) {
 	input := &dynamodb.TransactWriteItemsInput{}
 	for _, item := range tx.items {
+		if tx.versions {
+			switch x := item.(type) {
+			case *Put:
+				if err := x.checkVersion(); err != nil {
+					return nil, err
+				}
+			case *Update:
+				if x.model != nil {
+					x.CheckVersion(x.model)
+				}
+			}
+		}
 		wti, err := item.writeTxItem()
 		if err != nil {
 			return nil, err
 		}
+		if tx.returnFailed {
+			returnOnFailure(wti, aws.String(dynamodb.ReturnValuesOnConditionCheckFailureAllOld))
+		}
 		input.TransactItems = append(input.TransactItems, wti)
 	}
 	if tx.token != "" {
@@ -286,6 +413,20 @@ func (tx *WriteTx) input() (*dynamodb.TransactWriteItemsInput, error) {
 	return input, nil
 }
 
+// returnOnFailure sets what wti returns when its condition fails.
+func returnOnFailure(wti *dynamodb.TransactWriteItem, rv *string) {
+	switch {
+	case wti.Put != nil:
+		wti.Put.ReturnValuesOnConditionCheckFailure = rv
+	case wti.Update != nil:
+		wti.Update.ReturnValuesOnConditionCheckFailure = rv
+	case wti.Delete != nil:
+		wti.Delete.ReturnValuesOnConditionCheckFailure = rv
+	case wti.ConditionCheck != nil:
+		wti.ConditionCheck.ReturnValuesOnConditionCheckFailure = rv
+	}
+}
+
 func (tx *WriteTx) setError(err error) {
 	if tx.err == nil {
 		tx.err = err
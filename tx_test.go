@@ -6,7 +6,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 func TestTx(t *testing.T) {
@@ -164,3 +168,50 @@ func TestTx(t *testing.T) {
 	t.Logf("1: %+v 2: %+v 3: %+v", record1, record2, record3)
 	t.Logf("All: %+v (len: %d)", records, len(records))
 }
+
+// txTokenClient is a fake client that records transaction tokens, failing the first attempt of each run.
+type txTokenClient struct {
+	dynamodbiface.DynamoDBAPI
+	tokens []string
+}
+
+func (c *txTokenClient) TransactWriteItemsWithContext(_ aws.Context, input *dynamodb.TransactWriteItemsInput, _ ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.tokens = append(c.tokens, aws.StringValue(input.ClientRequestToken))
+	if len(c.tokens)%2 == 1 {
+		return nil, awserr.NewRequestFailure(awserr.New("InternalServerError", "oops", nil), 500, "")
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func TestWriteTxToken(t *testing.T) {
+	client := &txTokenClient{}
+	db := NewFromIface(client)
+	table := db.Table(testTable)
+
+	tx := db.WriteTx().Put(table.Put(widget{UserID: 1}))
+	for i := 0; i < 2; i++ {
+		if err := tx.Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(client.tokens) != 4 || client.tokens[0] == "" || client.tokens[0] != client.tokens[1] || client.tokens[2] != client.tokens[3] {
+		t.Error("retries of a run should share a token:", client.tokens)
+	}
+	if client.tokens[0] == client.tokens[2] {
+		t.Error("separate runs without Idempotent should have different tokens:", client.tokens)
+	}
+
+	client.tokens = nil
+	tx = db.WriteTx().Put(table.Put(widget{UserID: 1})).Idempotent(true)
+	for i := 0; i < 2; i++ {
+		if err := tx.Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, token := range client.tokens {
+		if token != tx.token {
+			t.Error("idempotent runs should share a token:", client.tokens)
+			break
+		}
+	}
+}
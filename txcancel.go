@@ -0,0 +1,142 @@
+package dynamo
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// TxCanceledError is returned when DynamoDB cancels a transaction.
+// It embeds the error from DynamoDB, so it can still be inspected as an awserr.RequestFailure.
+type TxCanceledError struct {
+	awserr.RequestFailure
+	// Reasons describes the outcome of each operation, in the order they were added to the transaction.
+	// It is empty if DynamoDB didn't report any reasons.
+	Reasons []TxCancelReason
+}
+
+// TxCancelReason describes why an operation of a canceled transaction failed, if it did.
+type TxCancelReason struct {
+	// Code is the reason the operation failed, such as "ConditionalCheckFailed",
+	// or "None" if it didn't cause the cancellation.
+	Code string
+	// Message describes the failure, if DynamoDB provided one.
+	Message string
+	// Item is the item that failed a condition, if DynamoDB returned it.
+	// It is only returned for transactions with ReturnFailedItems enabled.
+	Item map[string]*dynamodb.AttributeValue
+
+	codec codec
+}
+
+// Failed returns true if this operation caused the transaction to be canceled.
+func (r TxCancelReason) Failed() bool {
+	return r.Code != "" && r.Code != "None"
+}
+
+// Unmarshal unmarshals the item that failed a condition into out.
+// Returns ErrNotFound if DynamoDB didn't return it.
+func (r TxCancelReason) Unmarshal(out interface{}) error {
+	if r.Item == nil {
+		return ErrNotFound
+	}
+	return r.codec.unmarshalItem(r.Item, out)
+}
+
+// Failed returns the indexes of the operations that caused the transaction to be canceled.
+func (e *TxCanceledError) Failed() []int {
+	var failed []int
+	for i, r := range e.Reasons {
+		if r.Failed() {
+			failed = append(failed, i)
+		}
+	}
+	return failed
+}
+
+// Unwrap returns the error from DynamoDB.
+func (e *TxCanceledError) Unwrap() error {
+	return e.RequestFailure
+}
+
+// txCancellation captures the cancellation reasons from the body of a TransactionCanceledException,
+// which the SDK's error unmarshaling discards.
+type txCancellation struct {
+	reasons []TxCancelReason
+	codec   codec
+}
+
+// options returns opts plus an option that records cancellation reasons.
+func (c *txCancellation) options(opts []request.Option) []request.Option {
+	capture := func(r *request.Request) {
+		c.reasons = nil
+		r.Handlers.UnmarshalError.PushFront(func(r *request.Request) {
+			if r.HTTPResponse == nil || r.HTTPResponse.Body == nil {
+				return
+			}
+			body, err := ioutil.ReadAll(r.HTTPResponse.Body)
+			r.HTTPResponse.Body.Close()
+			// let the SDK read it again
+			r.HTTPResponse.Body = ioutil.NopCloser(bytes.NewReader(body))
+			if err != nil {
+				return
+			}
+			c.reasons = decodeCancelReasons(body)
+		})
+	}
+	return append(opts[:len(opts):len(opts)], capture)
+}
+
+// wrap returns err as a *TxCanceledError if the transaction was canceled.
+func (c *txCancellation) wrap(err error) error {
+	rf, ok := err.(awserr.RequestFailure)
+	if !ok || rf.Code() != dynamodb.ErrCodeTransactionCanceledException {
+		return err
+	}
+	reasons := c.reasons
+	if reasons == nil {
+		reasons = parseCancelReasons(rf.Message())
+	}
+	for i := range reasons {
+		reasons[i].codec = c.codec
+	}
+	return &TxCanceledError{RequestFailure: rf, Reasons: reasons}
+}
+
+func decodeCancelReasons(body []byte) []TxCancelReason {
+	var resp struct {
+		CancellationReasons []struct {
+			Code    string
+			Message string
+			Item    map[string]*dynamodb.AttributeValue
+		}
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil
+	}
+	reasons := make([]TxCancelReason, 0, len(resp.CancellationReasons))
+	for _, r := range resp.CancellationReasons {
+		reasons = append(reasons, TxCancelReason{Code: r.Code, Message: r.Message, Item: r.Item})
+	}
+	return reasons
+}
+
+// parseCancelReasons parses the reason codes from the message of a TransactionCanceledException, such as
+//	Transaction cancelled, please refer cancellation reasons for specific reasons [None, ConditionalCheckFailed]
+func parseCancelReasons(msg string) []TxCancelReason {
+	start, end := strings.LastIndex(msg, "["), strings.LastIndex(msg, "]")
+	if start == -1 || end < start {
+		return nil
+	}
+	codes := strings.Split(msg[start+1:end], ",")
+	reasons := make([]TxCancelReason, 0, len(codes))
+	for _, code := range codes {
+		reasons = append(reasons, TxCancelReason{Code: strings.TrimSpace(code)})
+	}
+	return reasons
+}
@@ -0,0 +1,105 @@
+package dynamo
+
+import (
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+const txCanceledMsg = "Transaction cancelled, please refer cancellation reasons for specific reasons [None, ConditionalCheckFailed]"
+
+// txCanceledClient is a fake client that cancels every transaction.
+// If body is set, it is passed through the request options like the SDK would.
+type txCanceledClient struct {
+	dynamodbiface.DynamoDBAPI
+	body  string
+	input *dynamodb.TransactWriteItemsInput
+}
+
+func (c *txCanceledClient) TransactWriteItemsWithContext(_ aws.Context, input *dynamodb.TransactWriteItemsInput, opts ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.input = input
+	if c.body != "" {
+		r := &request.Request{HTTPResponse: &http.Response{Body: ioutil.NopCloser(strings.NewReader(c.body))}}
+		r.ApplyOptions(opts...)
+		r.Handlers.UnmarshalError.Run(r)
+	}
+	return nil, awserr.NewRequestFailure(awserr.New(dynamodb.ErrCodeTransactionCanceledException, txCanceledMsg, nil), 400, "")
+}
+
+func TestTxCanceled(t *testing.T) {
+	t.Run("message", func(t *testing.T) {
+		client := &txCanceledClient{}
+		db := NewFromIface(client)
+		table := db.Table(testTable)
+		err := db.WriteTx().
+			Put(table.Put(widget{UserID: 1})).
+			Check(table.Check("UserID", 2).IfNotExists()).
+			Run()
+		if rv := client.input.TransactItems[0].Put.ReturnValuesOnConditionCheckFailure; rv != nil {
+			t.Error("unexpected ReturnValuesOnConditionCheckFailure:", *rv)
+		}
+		canceled, ok := err.(*TxCanceledError)
+		if !ok {
+			t.Fatalf("expected *TxCanceledError, got %T: %v", err, err)
+		}
+		if canceled.Code() != dynamodb.ErrCodeTransactionCanceledException {
+			t.Error("bad code:", canceled.Code())
+		}
+		want := []TxCancelReason{{Code: "None"}, {Code: "ConditionalCheckFailed"}}
+		if !reflect.DeepEqual(canceled.Reasons, want) {
+			t.Errorf("bad reasons. %#v ≠ %#v", canceled.Reasons, want)
+		}
+		if failed := canceled.Failed(); !reflect.DeepEqual(failed, []int{1}) {
+			t.Error("bad failed indexes:", failed)
+		}
+	})
+
+	t.Run("body", func(t *testing.T) {
+		body := `{"__type":"com.amazonaws.dynamodb.v20120810#TransactionCanceledException","Message":"` + txCanceledMsg + `",
+			"CancellationReasons":[{"Code":"None"},{"Code":"ConditionalCheckFailed","Message":"The conditional request failed","Item":{"UserID":{"N":"2"},"Msg":{"S":"hello"}}}]}`
+		client := &txCanceledClient{body: body}
+		db := NewFromIface(client)
+		table := db.Table(testTable)
+		err := db.WriteTx().
+			Put(table.Put(widget{UserID: 1})).
+			Check(table.Check("UserID", 2).IfNotExists()).
+			ReturnFailedItems(true).
+			Run()
+		items := client.input.TransactItems
+		if rv := aws.StringValue(items[0].Put.ReturnValuesOnConditionCheckFailure); rv != dynamodb.ReturnValuesOnConditionCheckFailureAllOld {
+			t.Error("bad ReturnValuesOnConditionCheckFailure for put:", rv)
+		}
+		if rv := aws.StringValue(items[1].ConditionCheck.ReturnValuesOnConditionCheckFailure); rv != dynamodb.ReturnValuesOnConditionCheckFailureAllOld {
+			t.Error("bad ReturnValuesOnConditionCheckFailure for check:", rv)
+		}
+		canceled, ok := err.(*TxCanceledError)
+		if !ok {
+			t.Fatalf("expected *TxCanceledError, got %T: %v", err, err)
+		}
+		if len(canceled.Reasons) != 2 {
+			t.Fatal("bad reasons:", canceled.Reasons)
+		}
+		if err := canceled.Reasons[0].Unmarshal(&widget{}); err != ErrNotFound {
+			t.Error("expected ErrNotFound, got", err)
+		}
+		reason := canceled.Reasons[1]
+		if !reason.Failed() || reason.Message != "The conditional request failed" {
+			t.Errorf("bad reason: %#v", reason)
+		}
+		var w widget
+		if err := reason.Unmarshal(&w); err != nil {
+			t.Fatal(err)
+		}
+		if w.UserID != 2 || w.Msg != "hello" {
+			t.Errorf("bad item: %#v", w)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package dynamo
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/cenkalti/backoff"
+)
+
+// DefaultConflictBackoff is how transactions wait before retrying a conflict, unless configured otherwise.
+var DefaultConflictBackoff = Backoff{
+	Strategy:    JitteredBackoff,
+	Interval:    100 * time.Millisecond,
+	MaxInterval: 5 * time.Second,
+}
+
+// maxConflictRetries is how many times a transaction is retried because of conflicts.
+const maxConflictRetries = 10
+
+// txConflicts configures how a transaction retries conflicts with other transactions.
+type txConflicts struct {
+	disabled bool
+	backoff  *Backoff
+}
+
+func (c txConflicts) newBackOff() backoff.BackOff {
+	b := DefaultConflictBackoff
+	if c.backoff != nil {
+		b = *c.backoff
+	}
+	return backoff.WithMaxRetries(b.newBackOff(), maxConflictRetries)
+}
+
+// retry calls f, retrying with backoff while it fails because of a transaction conflict.
+// Retries count towards the retry budget of ctx.
+func (c txConflicts) retry(ctx aws.Context, f func() error) error {
+	return c.retryIf(ctx, isTxConflict, f)
+}
+
+// retryIf is like retry, but retries whenever conflict returns true.
+func (c txConflicts) retryIf(ctx aws.Context, conflict func(error) bool, f func() error) error {
+	err := f()
+	if c.disabled {
+		return err
+	}
+	b := c.newBackOff()
+	budget := budgetFrom(ctx)
+	for err != nil && conflict(err) {
+		next := b.NextBackOff()
+		if next == backoff.Stop || !budget.spend(next) {
+			return err
+		}
+		if err := aws.SleepWithContext(ctx, next); err != nil {
+			return err
+		}
+		err = f()
+	}
+	return err
+}
+
+// isTxConflict returns true if err means that the transaction conflicted with another request
+// and could succeed if retried: either a TransactionConflictException or TransactionInProgressException,
+// or a cancellation where every failed operation was due to a conflict.
+func isTxConflict(err error) bool {
+	if canceled, ok := err.(*TxCanceledError); ok {
+		failed := canceled.Failed()
+		for _, i := range failed {
+			if canceled.Reasons[i].Code != "TransactionConflict" {
+				return false
+			}
+		}
+		return len(failed) > 0
+	}
+	if ae, ok := err.(awserr.RequestFailure); ok {
+		switch ae.Code() {
+		case dynamodb.ErrCodeTransactionConflictException,
+			dynamodb.ErrCodeTransactionInProgressException:
+			return true
+		}
+	}
+	return false
+}
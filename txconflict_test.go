@@ -0,0 +1,124 @@
+package dynamo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// txConflictClient is a fake client that fails transactions with errs before succeeding.
+type txConflictClient struct {
+	dynamodbiface.DynamoDBAPI
+	errs  []error
+	calls int
+}
+
+func (c *txConflictClient) next() error {
+	c.calls++
+	if c.calls <= len(c.errs) {
+		return c.errs[c.calls-1]
+	}
+	return nil
+}
+
+func (c *txConflictClient) TransactWriteItemsWithContext(_ aws.Context, _ *dynamodb.TransactWriteItemsInput, _ ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+	if err := c.next(); err != nil {
+		return nil, err
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (c *txConflictClient) TransactGetItemsWithContext(_ aws.Context, _ *dynamodb.TransactGetItemsInput, _ ...request.Option) (*dynamodb.TransactGetItemsOutput, error) {
+	if err := c.next(); err != nil {
+		return nil, err
+	}
+	return &dynamodb.TransactGetItemsOutput{
+		Responses: []*dynamodb.ItemResponse{{Item: map[string]*dynamodb.AttributeValue{"UserID": {N: aws.String("1")}}}},
+	}, nil
+}
+
+func txErr(code, msg string) error {
+	return awserr.NewRequestFailure(awserr.New(code, msg, nil), 400, "")
+}
+
+func TestTxConflictRetry(t *testing.T) {
+	fast := Backoff{Strategy: ConstantBackoff, Interval: time.Millisecond}
+	conflict := txErr(dynamodb.ErrCodeTransactionCanceledException,
+		"Transaction cancelled, please refer cancellation reasons for specific reasons [None, TransactionConflict]")
+	failed := txErr(dynamodb.ErrCodeTransactionCanceledException,
+		"Transaction cancelled, please refer cancellation reasons for specific reasons [TransactionConflict, ConditionalCheckFailed]")
+
+	t.Run("write", func(t *testing.T) {
+		client := &txConflictClient{errs: []error{
+			txErr(dynamodb.ErrCodeTransactionConflictException, "conflict"),
+			txErr(dynamodb.ErrCodeTransactionInProgressException, "in progress"),
+			conflict,
+		}}
+		db := NewFromIface(client)
+		err := db.WriteTx().Put(db.Table(testTable).Put(widget{UserID: 1})).ConflictBackoff(fast).Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if client.calls != 4 {
+			t.Error("expected 4 calls, got", client.calls)
+		}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		client := &txConflictClient{errs: []error{conflict}}
+		db := NewFromIface(client)
+		var w widget
+		err := db.GetTx().GetOne(db.Table(testTable).Get("UserID", 1), &w).ConflictBackoff(fast).Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if client.calls != 2 || w.UserID != 1 {
+			t.Error("unexpected result:", client.calls, w)
+		}
+	})
+
+	t.Run("other failure", func(t *testing.T) {
+		client := &txConflictClient{errs: []error{failed}}
+		db := NewFromIface(client)
+		err := db.WriteTx().Put(db.Table(testTable).Put(widget{UserID: 1})).ConflictBackoff(fast).Run()
+		if _, ok := err.(*TxCanceledError); !ok {
+			t.Errorf("expected *TxCanceledError, got %T: %v", err, err)
+		}
+		if client.calls != 1 {
+			t.Error("expected 1 call, got", client.calls)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		client := &txConflictClient{errs: []error{conflict}}
+		db := NewFromIface(client)
+		err := db.WriteTx().Put(db.Table(testTable).Put(widget{UserID: 1})).RetryConflicts(false).Run()
+		if !isTxConflict(err) {
+			t.Error("expected conflict error, got", err)
+		}
+		if client.calls != 1 {
+			t.Error("expected 1 call, got", client.calls)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		errs := make([]error, maxConflictRetries+5)
+		for i := range errs {
+			errs[i] = conflict
+		}
+		client := &txConflictClient{errs: errs}
+		db := NewFromIface(client)
+		err := db.WriteTx().Put(db.Table(testTable).Put(widget{UserID: 1})).ConflictBackoff(fast).Run()
+		if !isTxConflict(err) {
+			t.Error("expected conflict error, got", err)
+		}
+		if client.calls != maxConflictRetries+1 {
+			t.Error("unexpected number of calls:", client.calls)
+		}
+	})
+}
@@ -0,0 +1,286 @@
+package dynamo
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Tx is a read-then-write transaction, used with DB.Tx.
+// Items read with Get must be unchanged for its writes to be committed.
+type Tx struct {
+	db     *DB
+	ctx    aws.Context
+	reads  []*txRead
+	writes []writeTxOp
+}
+
+// txRead is an item read by a Tx, along with the condition that it hasn't changed since.
+type txRead struct {
+	table     Table
+	key       map[string]*dynamodb.AttributeValue
+	hashKey   string
+	rangeKey  string
+	condition string
+	args      []interface{}
+	versioned bool // condition is on the version field
+}
+
+// Tx runs fn, then commits the writes it made as a single write transaction.
+// Items read with Tx.Get are checked to be unchanged when committing;
+// if any of them changed, or the transaction conflicted with another, fn is run again from the start,
+// up to 10 times, waiting for DefaultConflictBackoff in between.
+// This makes fn serializable with respect to the items it reads and writes.
+// If fn returns an error, nothing is written and that error is returned.
+// Because fn may run more than once, it shouldn't have side effects other than its reads and writes.
+// A write to an item that was read, and that has a condition of its own, also checks the read,
+// because a transaction can't include the same item twice. As it can't be told which one failed,
+// it isn't retried when its condition fails.
+func (db *DB) Tx(fn func(tx *Tx) error) error {
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return db.TxWithContext(ctx, fn)
+}
+
+// TxWithContext runs fn, then commits the writes it made as a single write transaction.
+// See Tx.
+func (db *DB) TxWithContext(ctx aws.Context, fn func(tx *Tx) error) error {
+	var guarded []bool
+	return txConflicts{}.retryIf(ctx, func(err error) bool {
+		return isTxConflict(err) || isTxReadConflict(err, guarded)
+	}, func() error {
+		tx := &Tx{db: db, ctx: ctx}
+		if err := fn(tx); err != nil {
+			guarded = nil
+			return err
+		}
+		wtx, g, err := tx.commit()
+		guarded = g
+		if err != nil {
+			return err
+		}
+		if len(wtx.items) == 0 {
+			return nil
+		}
+		return wtx.RunWithContext(ctx)
+	})
+}
+
+// Get reads the item specified by q into out, using a strongly consistent read.
+// q must specify the item's primary key.
+// Returns ErrNotFound if the item doesn't exist, in which case it must still not exist when committing.
+// If out has a version field (see WriteTx.CheckVersions), only the version is checked when committing.
+// Otherwise, every attribute read must be unchanged.
+func (tx *Tx) Get(q *Query, out interface{}) error {
+	if !q.canGetItem() {
+		return errors.New("dynamo: Tx: Get must specify the item's primary key")
+	}
+	var item map[string]*dynamodb.AttributeValue
+	err := q.Consistent(true).OneWithContext(tx.ctx, &item)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	read := &txRead{
+		table:   q.table,
+		key:     q.keys(),
+		hashKey: q.hashKey,
+	}
+	if len(q.rangeValues) > 0 {
+		read.rangeKey = q.rangeKey
+	}
+	switch version := tx.db.codec().versionField(out); {
+	case item == nil:
+		read.condition = "attribute_not_exists($)"
+		read.args = []interface{}{q.hashKey}
+	case version != "" && item[version] != nil:
+		read.condition = "$ = ?"
+		read.args = []interface{}{version, item[version]}
+		read.versioned = true
+	case version != "":
+		read.condition = "attribute_not_exists($)"
+		read.args = []interface{}{version}
+		read.versioned = true
+	default:
+		names := make([]string, 0, len(item))
+		for name := range item {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if read.condition != "" {
+				read.condition += " AND "
+			}
+			read.condition += "$ = ?"
+			read.args = append(read.args, name, item[name])
+		}
+	}
+	tx.reads = append(tx.reads, read)
+
+	if err != nil {
+		return err
+	}
+	return tx.db.codec().unmarshalItem(item, out)
+}
+
+// Put adds a put operation to this transaction.
+func (tx *Tx) Put(p *Put) *Tx {
+	tx.writes = append(tx.writes, p)
+	return tx
+}
+
+// Update adds an update operation to this transaction.
+func (tx *Tx) Update(u *Update) *Tx {
+	tx.writes = append(tx.writes, u)
+	return tx
+}
+
+// Delete adds a delete operation to this transaction.
+func (tx *Tx) Delete(d *Delete) *Tx {
+	tx.writes = append(tx.writes, d)
+	return tx
+}
+
+// Check adds a conditional check to this transaction.
+func (tx *Tx) Check(check *ConditionCheck) *Tx {
+	tx.writes = append(tx.writes, check)
+	return tx
+}
+
+// commit builds the write transaction for this Tx.
+// The conditions of items that were read are added to writes of the same item,
+// because a transaction can't include the same item twice, or checked separately otherwise.
+// Puts of versioned items already check the version they were read with, so they don't need another condition.
+// guarded reports which operations can only fail their condition if an item read changed.
+func (tx *Tx) commit() (wtx *WriteTx, guarded []bool, err error) {
+	wtx = tx.db.WriteTx().CheckVersions().RetryConflicts(false)
+	merged := make([]bool, len(tx.reads))
+	for _, op := range tx.writes {
+		// the read condition is added either way, but a failure might be the op's own condition's fault
+		own := hasCondition(op)
+		g := false
+		for i, read := range tx.reads {
+			if merged[i] || !read.matches(op) {
+				continue
+			}
+			if put, ok := op.(*Put); ok && read.versioned {
+				if err := put.checkVersion(); err != nil {
+					return nil, nil, err
+				}
+				if !put.versioned {
+					read.addTo(op)
+				}
+			} else {
+				read.addTo(op)
+			}
+			merged[i], g = true, !own
+		}
+		wtx.items = append(wtx.items, op)
+		guarded = append(guarded, g)
+	}
+	for i, read := range tx.reads {
+		if merged[i] {
+			continue
+		}
+		check := read.table.Check(read.hashKey, read.key[read.hashKey])
+		if read.rangeKey != "" {
+			check.Range(read.rangeKey, read.key[read.rangeKey])
+		}
+		read.addTo(check)
+		wtx.items = append(wtx.items, check)
+		guarded = append(guarded, true)
+	}
+	return wtx, guarded, nil
+}
+
+// matches returns true if op writes this item.
+func (r *txRead) matches(op writeTxOp) bool {
+	var table string
+	var item map[string]*dynamodb.AttributeValue
+	switch op := op.(type) {
+	case *Put:
+		table, item = op.table.name, op.item
+	case *Update:
+		table, item = op.table.name, op.key()
+	case *Delete:
+		table, item = op.table.name, op.key()
+	case *ConditionCheck:
+		table, item = op.table.name, op.keys()
+	default:
+		return false
+	}
+	if table != r.table.name {
+		return false
+	}
+	for name, v := range r.key {
+		if !reflect.DeepEqual(item[name], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasCondition returns true if op has a condition of its own.
+func hasCondition(op writeTxOp) bool {
+	switch op := op.(type) {
+	case *Put:
+		return op.condition != ""
+	case *Update:
+		return op.condition != ""
+	case *Delete:
+		return op.condition != ""
+	case *ConditionCheck:
+		return op.condition != ""
+	}
+	return false
+}
+
+func (r *txRead) addTo(op writeTxOp) {
+	switch op := op.(type) {
+	case *Put:
+		op.If(r.condition, r.args...)
+	case *Update:
+		op.If(r.condition, r.args...)
+	case *Delete:
+		op.If(r.condition, r.args...)
+	case *ConditionCheck:
+		op.If(r.condition, r.args...)
+	}
+}
+
+// isTxReadConflict returns true if err is a cancellation where every failed operation
+// either conflicted with another transaction or failed a condition guarding a read.
+func isTxReadConflict(err error, guarded []bool) bool {
+	canceled, ok := err.(*TxCanceledError)
+	if !ok || len(canceled.Reasons) != len(guarded) {
+		return false
+	}
+	failed := canceled.Failed()
+	for _, i := range failed {
+		switch canceled.Reasons[i].Code {
+		case "TransactionConflict":
+		case "ConditionalCheckFailed":
+			if !guarded[i] {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return len(failed) > 0
+}
+
+// versionField returns the name of the version field of out's type, if it has one.
+func (c codec) versionField(out interface{}) string {
+	// bad tags are reported when the item is written
+	fields, _ := c.autoFields(reflect.TypeOf(out))
+	for _, f := range fields {
+		if f.special == "version" {
+			return f.name
+		}
+	}
+	return ""
+}
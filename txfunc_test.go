@@ -0,0 +1,168 @@
+package dynamo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// txFuncClient is an in-memory client whose transactions fail with errs before succeeding.
+type txFuncClient struct {
+	*memClient
+	errs   []error
+	inputs []*dynamodb.TransactWriteItemsInput
+}
+
+func (c *txFuncClient) TransactWriteItemsWithContext(_ aws.Context, input *dynamodb.TransactWriteItemsInput, _ ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.inputs = append(c.inputs, input)
+	if len(c.inputs) <= len(c.errs) {
+		return nil, c.errs[len(c.inputs)-1]
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func TestDBTx(t *testing.T) {
+	type counter struct {
+		UserID  int `dynamo:",hash"`
+		Count   int
+		Version int `dynamo:",version"`
+	}
+	newClient := func(errs ...error) *txFuncClient {
+		client := &txFuncClient{memClient: newMemClient(), errs: errs}
+		item := map[string]*dynamodb.AttributeValue{
+			"UserID":  {N: aws.String("1")},
+			"Count":   {N: aws.String("5")},
+			"Version": {N: aws.String("2")},
+		}
+		client.items[keyString(map[string]*dynamodb.AttributeValue{"UserID": item["UserID"]})] = item
+		return client
+	}
+	increment := func(runs *int) func(tx *Tx) error {
+		return func(tx *Tx) error {
+			*runs++
+			table := tx.db.Table(testTable)
+			var c counter
+			if err := tx.Get(table.Get("UserID", 1), &c); err != nil {
+				return err
+			}
+			c.Count++
+			tx.Put(table.Put(&c))
+			var w widget
+			if err := tx.Get(table.Get("UserID", 2), &w); err != ErrNotFound {
+				return err
+			}
+			tx.Check(table.Check("UserID", 3).IfExists())
+			return nil
+		}
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		client := newClient()
+		runs := 0
+		if err := NewFromIface(client).Tx(increment(&runs)); err != nil {
+			t.Fatal(err)
+		}
+		if runs != 1 || len(client.inputs) != 1 {
+			t.Fatal("unexpected runs:", runs, len(client.inputs))
+		}
+		items := client.inputs[0].TransactItems
+		if len(items) != 3 {
+			t.Fatal("expected 3 operations, got", len(items))
+		}
+		put := items[0].Put
+		if cond := unsubstitute(aws.StringValue(put.ConditionExpression), put.ExpressionAttributeNames); cond != "(Version = :v0)" {
+			t.Error("bad put condition:", cond)
+		}
+		if v := aws.StringValue(put.Item["Version"].N); v != "3" {
+			t.Error("expected version 3, got", v)
+		}
+		if v := aws.StringValue(put.Item["Count"].N); v != "6" {
+			t.Error("expected count 6, got", v)
+		}
+		user := items[1].ConditionCheck
+		if cond := unsubstitute(aws.StringValue(user.ConditionExpression), user.ExpressionAttributeNames); cond != "(attribute_exists(UserID))" {
+			t.Error("bad user check:", cond)
+		}
+		missing := items[2].ConditionCheck
+		if cond := unsubstitute(aws.StringValue(missing.ConditionExpression), missing.ExpressionAttributeNames); cond != "(attribute_not_exists(UserID))" {
+			t.Error("bad read check:", cond)
+		}
+		if v := aws.StringValue(missing.Key["UserID"].N); v != "2" {
+			t.Error("bad read check key:", v)
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		client := newClient(
+			txErr(dynamodb.ErrCodeTransactionCanceledException, "Transaction cancelled, please refer cancellation reasons for specific reasons [ConditionalCheckFailed, None, None]"),
+			txErr(dynamodb.ErrCodeTransactionCanceledException, "Transaction cancelled, please refer cancellation reasons for specific reasons [None, None, TransactionConflict]"),
+		)
+		runs := 0
+		if err := NewFromIface(client).Tx(increment(&runs)); err != nil {
+			t.Fatal(err)
+		}
+		if runs != 3 {
+			t.Error("expected 3 runs, got", runs)
+		}
+	})
+
+	t.Run("unguarded failure", func(t *testing.T) {
+		client := newClient(
+			txErr(dynamodb.ErrCodeTransactionCanceledException, "Transaction cancelled, please refer cancellation reasons for specific reasons [None, ConditionalCheckFailed, None]"),
+		)
+		runs := 0
+		err := NewFromIface(client).Tx(increment(&runs))
+		if _, ok := err.(*TxCanceledError); !ok {
+			t.Errorf("expected *TxCanceledError, got %T: %v", err, err)
+		}
+		if runs != 1 {
+			t.Error("expected 1 run, got", runs)
+		}
+	})
+
+	t.Run("conditioned write", func(t *testing.T) {
+		client := newClient(
+			txErr(dynamodb.ErrCodeTransactionCanceledException, "Transaction cancelled, please refer cancellation reasons for specific reasons [ConditionalCheckFailed]"),
+		)
+		runs := 0
+		err := NewFromIface(client).Tx(func(tx *Tx) error {
+			runs++
+			table := tx.db.Table(testTable)
+			var c counter
+			if err := tx.Get(table.Get("UserID", 1), &c); err != nil {
+				return err
+			}
+			c.Count++
+			tx.Put(table.Put(&c).If("Count < ?", 10))
+			return nil
+		})
+		if _, ok := err.(*TxCanceledError); !ok {
+			t.Errorf("expected *TxCanceledError, got %T: %v", err, err)
+		}
+		if runs != 1 {
+			t.Error("expected 1 run, got", runs)
+		}
+		put := client.inputs[0].TransactItems[0].Put
+		if cond := unsubstitute(aws.StringValue(put.ConditionExpression), put.ExpressionAttributeNames); cond != "(Count < :v0) AND (Version = :v1)" {
+			t.Error("bad put condition:", cond)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		client := newClient()
+		oops := errors.New("oops")
+		err := NewFromIface(client).Tx(func(tx *Tx) error {
+			tx.Put(tx.db.Table(testTable).Put(widget{UserID: 1}))
+			return oops
+		})
+		if err != oops {
+			t.Error("expected closure's error, got", err)
+		}
+		if len(client.inputs) != 0 {
+			t.Error("expected no transaction, got", len(client.inputs))
+		}
+	})
+}
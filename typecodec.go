@@ -0,0 +1,90 @@
+package dynamo
+
+import (
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// EncoderFunc encodes a value of a registered type. See DB.RegisterEncoder.
+// Returning a nil AttributeValue omits the value, like an empty string.
+type EncoderFunc func(v interface{}) (*dynamodb.AttributeValue, error)
+
+// DecoderFunc decodes av into out, a pointer to a value of a registered type. See DB.RegisterDecoder.
+type DecoderFunc func(av *dynamodb.AttributeValue, out interface{}) error
+
+// RegisterEncoder returns a copy of this DB that encodes values of type t with fn,
+// so that third-party types can be used in models without wrapping them in a Marshaler.
+// It takes precedence over the type's own methods, such as MarshalText, and also applies to pointers to t.
+//	db = db.RegisterEncoder(reflect.TypeOf(decimal.Decimal{}), func(v interface{}) (*dynamodb.AttributeValue, error) {
+//		return &dynamodb.AttributeValue{N: aws.String(v.(decimal.Decimal).String())}, nil
+//	})
+// Like WithFieldNameMapper, it applies to this DB's requests and to DB.MarshalItem, but not to the package-level MarshalItem.
+func (db *DB) RegisterEncoder(t reflect.Type, fn EncoderFunc) *DB {
+	cp := *db
+	cp.encoders = make(map[reflect.Type]EncoderFunc, len(db.encoders)+1)
+	for k, v := range db.encoders {
+		cp.encoders[k] = v
+	}
+	cp.encoders[t] = fn
+	return &cp
+}
+
+// RegisterDecoder returns a copy of this DB that decodes values of type t with fn.
+// fn is called with a pointer to the value to decode into, of type *t.
+// It takes precedence over the type's own methods, such as UnmarshalText.
+//	db = db.RegisterDecoder(reflect.TypeOf(decimal.Decimal{}), func(av *dynamodb.AttributeValue, out interface{}) error {
+//		d, err := decimal.NewFromString(aws.StringValue(av.N))
+//		*out.(*decimal.Decimal) = d
+//		return err
+//	})
+// Like WithFieldNameMapper, it applies to this DB's requests and to DB.UnmarshalItem, but not to the package-level UnmarshalItem.
+func (db *DB) RegisterDecoder(t reflect.Type, fn DecoderFunc) *DB {
+	cp := *db
+	cp.decoders = make(map[reflect.Type]DecoderFunc, len(db.decoders)+1)
+	for k, v := range db.decoders {
+		cp.decoders[k] = v
+	}
+	cp.decoders[t] = fn
+	return &cp
+}
+
+// encodeRegistered encodes v with its registered encoder, returning false if it has none.
+func (c codec) encodeRegistered(v interface{}) (*dynamodb.AttributeValue, bool, error) {
+	if len(c.encoders) == 0 || v == nil {
+		return nil, false, nil
+	}
+	rt := reflect.TypeOf(v)
+	if fn, ok := c.encoders[rt]; ok {
+		av, err := fn(v)
+		return av, true, err
+	}
+	if rt.Kind() == reflect.Ptr {
+		if fn, ok := c.encoders[rt.Elem()]; ok {
+			rv := reflect.ValueOf(v)
+			if rv.IsNil() {
+				return nil, true, nil
+			}
+			av, err := fn(rv.Elem().Interface())
+			return av, true, err
+		}
+	}
+	return nil, false, nil
+}
+
+// decodeRegistered decodes av into rv with its type's registered decoder, returning false if it has none.
+func (c codec) decodeRegistered(av *dynamodb.AttributeValue, rv reflect.Value) (bool, error) {
+	fn, ok := c.decoders[rv.Type()]
+	if !ok {
+		return false, nil
+	}
+	if rv.CanAddr() {
+		return true, fn(av, rv.Addr().Interface())
+	}
+	ptr := reflect.New(rv.Type())
+	if err := fn(av, ptr.Interface()); err != nil {
+		return true, err
+	}
+	rv.Set(ptr.Elem())
+	return true, nil
+}
@@ -0,0 +1,115 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// cents is a stand-in for a third-party decimal type, with a MarshalText method that registered codecs override.
+type cents struct {
+	units int64
+}
+
+func (c cents) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("$%d.%02d", c.units/100, c.units%100)), nil
+}
+
+// registerCents returns a copy of db that encodes cents as a number.
+func registerCents(db *DB) *DB {
+	return db.
+		RegisterEncoder(reflect.TypeOf(cents{}), func(v interface{}) (*dynamodb.AttributeValue, error) {
+			return &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(v.(cents).units, 10))}, nil
+		}).
+		RegisterDecoder(reflect.TypeOf(cents{}), func(av *dynamodb.AttributeValue, out interface{}) error {
+			units, err := strconv.ParseInt(aws.StringValue(av.N), 10, 64)
+			out.(*cents).units = units
+			return err
+		})
+}
+
+func TestRegisterCodec(t *testing.T) {
+	db := registerCents(NewFromIface(nil))
+
+	type order struct {
+		Price    cents
+		Discount *cents
+		Missing  *cents
+		Lines    []cents
+	}
+	in := order{Price: cents{1250}, Discount: &cents{100}, Lines: []cents{{1}, {2}}}
+	item, err := db.MarshalItem(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]*dynamodb.AttributeValue{
+		"Price":    {N: aws.String("1250")},
+		"Discount": {N: aws.String("100")},
+		"Lines":    {L: []*dynamodb.AttributeValue{{N: aws.String("1")}, {N: aws.String("2")}}},
+	}
+	if !reflect.DeepEqual(item, expect) {
+		t.Errorf("bad item: %v ≠ %v", item, expect)
+	}
+
+	var out order
+	if err := db.UnmarshalItem(item, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("bad result: %+v ≠ %+v", out, in)
+	}
+
+	// other DBs and the package-level functions are unaffected
+	item, err = MarshalItem(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price := aws.StringValue(item["Price"].S); price != "$12.50" {
+		t.Error("package-level MarshalItem used registered encoder:", item["Price"])
+	}
+}
+
+func TestRegisterCodecKeys(t *testing.T) {
+	client := newMemClient()
+	table := registerCents(NewFromIface(client)).Table(testTable)
+
+	type account struct {
+		UserID cents
+		Msg    string
+	}
+	in := account{UserID: cents{42}, Msg: "hello"}
+	if err := table.Put(in).Run(); err != nil {
+		t.Fatal(err)
+	}
+	key := keyString(map[string]*dynamodb.AttributeValue{"UserID": {N: aws.String("42")}})
+	if _, ok := client.items[key]; !ok {
+		t.Fatal("key wasn't encoded with the registered encoder:", client.items)
+	}
+
+	var out account
+	if err := table.Get("UserID", cents{42}).One(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Errorf("bad result: %+v ≠ %+v", out, in)
+	}
+
+	var outs []account
+	if err := table.Batch("UserID").Get(Keys{cents{42}}).All(&outs); err != nil {
+		t.Fatal(err)
+	}
+	if len(outs) != 1 || outs[0] != in {
+		t.Errorf("bad batch results: %+v", outs)
+	}
+
+	if err := table.Delete("UserID", cents{42}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.items) != 0 {
+		t.Error("item wasn't deleted:", client.items)
+	}
+}
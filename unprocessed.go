@@ -0,0 +1,31 @@
+package dynamo
+
+// UnprocessedEvent describes one round of a BatchGet or BatchWrite. See DB.OnUnprocessed.
+// Sustained unprocessed keys or items are an early sign of insufficient throughput capacity.
+type UnprocessedEvent struct {
+	// Op is the API call made, "BatchGetItem" or "BatchWriteItem".
+	Op string
+	// Table is the name of the table.
+	Table string
+	// Requested is the number of keys or items sent in this round.
+	Requested int
+	// Unprocessed is the number of keys or items DynamoDB left unprocessed, which will be retried.
+	Unprocessed int
+}
+
+// OnUnprocessed returns a copy of this DB that calls fn after each round of a BatchGet or BatchWrite,
+// with the number of keys or items that DynamoDB left unprocessed, even if there were none.
+func (db *DB) OnUnprocessed(fn func(UnprocessedEvent)) *DB {
+	cp := *db
+	cp.unprocessed = append(db.unprocessed[:len(db.unprocessed):len(db.unprocessed)], fn)
+	return &cp
+}
+
+func (db *DB) reportUnprocessed(ev UnprocessedEvent, fn func(UnprocessedEvent)) {
+	for _, hook := range db.unprocessed {
+		hook(ev)
+	}
+	if fn != nil {
+		fn(ev)
+	}
+}
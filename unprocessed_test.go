@@ -0,0 +1,80 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// underProvisionedClient is a fake client that leaves the last key or item of the first batch request unprocessed.
+type underProvisionedClient struct {
+	dynamodbiface.DynamoDBAPI
+	calls int
+}
+
+func (c *underProvisionedClient) BatchGetItemWithContext(_ aws.Context, input *dynamodb.BatchGetItemInput, _ ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	c.calls++
+	ka := input.RequestItems[testTable]
+	out := &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]*dynamodb.AttributeValue{}}
+	keys := ka.Keys
+	if c.calls == 1 {
+		keys = keys[:len(keys)-1]
+		out.UnprocessedKeys = map[string]*dynamodb.KeysAndAttributes{
+			testTable: {Keys: ka.Keys[len(ka.Keys)-1:]},
+		}
+	}
+	out.Responses[testTable] = keys
+	return out, nil
+}
+
+func (c *underProvisionedClient) BatchWriteItemWithContext(_ aws.Context, input *dynamodb.BatchWriteItemInput, _ ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	c.calls++
+	out := &dynamodb.BatchWriteItemOutput{}
+	if wrs := input.RequestItems[testTable]; c.calls == 1 {
+		out.UnprocessedItems = map[string][]*dynamodb.WriteRequest{testTable: wrs[len(wrs)-1:]}
+	}
+	return out, nil
+}
+
+func TestOnUnprocessed(t *testing.T) {
+	var events, mine []UnprocessedEvent
+	db := NewFromIface(&underProvisionedClient{}).OnUnprocessed(func(ev UnprocessedEvent) {
+		events = append(events, ev)
+	})
+	batch := db.Table(testTable).Batch("UserID")
+
+	var results []widget
+	err := batch.Get(Keys{1}, Keys{2}, Keys{3}).
+		OnUnprocessed(func(ev UnprocessedEvent) { mine = append(mine, ev) }).
+		All(&results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []UnprocessedEvent{
+		{Op: "BatchGetItem", Table: testTable, Requested: 3, Unprocessed: 1},
+		{Op: "BatchGetItem", Table: testTable, Requested: 1, Unprocessed: 0},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("bad events. want: %v got: %v", want, events)
+	}
+	if !reflect.DeepEqual(mine, want) {
+		t.Errorf("bad request events. want: %v got: %v", want, mine)
+	}
+
+	events = nil
+	db.client = &underProvisionedClient{}
+	if _, err := batch.Write().Put(widget{UserID: 1}, widget{UserID: 2}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	want = []UnprocessedEvent{
+		{Op: "BatchWriteItem", Table: testTable, Requested: 2, Unprocessed: 1},
+		{Op: "BatchWriteItem", Table: testTable, Requested: 1, Unprocessed: 0},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("bad events. want: %v got: %v", want, events)
+	}
+}
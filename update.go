@@ -1,10 +1,14 @@
 package dynamo
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 )
 
@@ -27,25 +31,37 @@ type Update struct {
 	remove map[string]struct{}
 
 	condition string
+	mustExist string // condition added by MustExist
+
+	model     interface{} // item passed to SetAll
+	versioned bool        // version condition added by CheckVersion
+	bump      func()
 
 	subber
 
-	err error
-	cc  *ConsumedCapacity
+	err     error
+	cc      *ConsumedCapacity
+	icm     *ItemCollectionMetrics
+	reqID   *string
+	timeout time.Duration
 }
 
 // Update creates a new request to modify an existing item.
 func (table Table) Update(hashKey string, value interface{}) *Update {
+	defs := table.db.requestDefaults()
 	u := &Update{
 		table:   table,
 		hashKey: hashKey,
+		subber:  subber{codec: table.db.codec()},
+		cc:      defs.cc,
+		timeout: defs.timeout,
 
 		set:    make([]string, 0),
 		add:    make(map[string]string),
 		del:    make(map[string]string),
 		remove: make(map[string]struct{}),
 	}
-	u.hashValue, u.err = marshal(value, "")
+	u.hashValue, u.err = table.db.codec().marshal(value, "")
 	return u
 }
 
@@ -53,7 +69,7 @@ func (table Table) Update(hashKey string, value interface{}) *Update {
 func (u *Update) Range(name string, value interface{}) *Update {
 	var err error
 	u.rangeKey = name
-	u.rangeValue, err = marshal(value, "")
+	u.rangeValue, err = u.table.db.codec().marshal(value, "")
 	u.setError(err)
 	return u
 }
@@ -74,13 +90,19 @@ func (u *Update) Set(path string, value interface{}) *Update {
 	return u
 }
 
+// SetNull sets the given path to NULL, instead of removing it like Set does for nil values.
+// Paths that are reserved words are automatically escaped.
+func (u *Update) SetNull(path string) *Update {
+	return u.Set(path, nullAV())
+}
+
 // SetSet changes a set at the given path to the given value.
 // SetSet marshals value to a string set, number set, or binary set.
 // If value is of zero length or nil, path will be removed instead.
 // Paths that are reserved words are automatically escaped.
 // Use single quotes to escape complex values like 'User'.'Count'.
 func (u *Update) SetSet(path string, value interface{}) *Update {
-	v, err := marshal(value, "set")
+	v, err := u.table.db.codec().marshal(value, "set")
 	if v == nil && err == nil {
 		// empty set
 		return u.Remove(path)
@@ -199,6 +221,31 @@ func (u *Update) Remove(paths ...string) *Update {
 	return u
 }
 
+// RemoveIndex removes the elements at the given indices from the list specified by path.
+// Indices refer to positions in the list as it was before this update.
+//	RemoveIndex("Items", 3)    // REMOVE Items[3]
+//	RemoveIndex("Items", 0, 2) // REMOVE Items[0], Items[2]
+func (u *Update) RemoveIndex(path string, indices ...int) *Update {
+	if path == "" {
+		u.setError(errors.New("dynamo: update: RemoveIndex: empty path"))
+		return u
+	}
+	if len(indices) == 0 {
+		u.setError(fmt.Errorf("dynamo: update: RemoveIndex: no indices given for path %s", path))
+		return u
+	}
+	path, err := u.escape(path)
+	u.setError(err)
+	for _, idx := range indices {
+		if idx < 0 {
+			u.setError(fmt.Errorf("dynamo: update: RemoveIndex: invalid index %d", idx))
+			return u
+		}
+		u.remove[fmt.Sprintf("%s[%d]", path, idx)] = struct{}{}
+	}
+	return u
+}
+
 // RemoveExpr performs a custom remove expression, substituting the args into expr as in filter expressions.
 // 	RemoveExpr("MyList[$]", 5)
 func (u *Update) RemoveExpr(expr string, args ...interface{}) *Update {
@@ -208,6 +255,89 @@ func (u *Update) RemoveExpr(expr string, args ...interface{}) *Update {
 	return u
 }
 
+// Patch sets and removes only the attributes that differ between oldItem and newItem, as computed by Diff.
+// This keeps updates of large items with small changes cheap.
+// Nested maps are patched attribute by attribute, while lists and sets are replaced as a whole.
+// Changing the value of a key attribute is an error.
+// Fields that become empty are removed, unless they are tagged null, in which case they are set to NULL.
+// Patch also sets the timestamp fields of newItem's type, as in Timestamps.
+func (u *Update) Patch(oldItem, newItem interface{}) *Update {
+	c := u.table.db.codec()
+	changes, err := c.diff(oldItem, newItem)
+	if err != nil {
+		u.setError(err)
+		return u
+	}
+	fields, err := c.autoFields(reflect.TypeOf(newItem))
+	if err != nil {
+		u.setError(err)
+		return u
+	}
+	stamped := make(map[string]bool)
+	for _, f := range fields {
+		if f.isTime() {
+			stamped[f.name] = true
+		}
+	}
+	for _, change := range changes {
+		if stamped[change.path[0]] {
+			continue
+		}
+		if top := change.path[0]; top == u.hashKey || (u.rangeKey != "" && top == u.rangeKey) {
+			u.setError(fmt.Errorf("dynamo: update: Patch: can't change key attribute %s", top))
+			return u
+		}
+		names := make([]string, len(change.path))
+		for i, name := range change.path {
+			names[i] = u.subName(name)
+		}
+		path := strings.Join(names, ".")
+		if change.Type == ChangeRemoved {
+			u.remove[path] = struct{}{}
+			continue
+		}
+		vsub, err := u.subValue(change.New, "")
+		u.setError(err)
+		u.set = append(u.set, path+" = "+vsub)
+	}
+	if len(stamped) > 0 {
+		u.Timestamps(newItem)
+	}
+	return u
+}
+
+// Timestamps sets the fields of model's type tagged with updatedTime to the current time,
+// and the fields tagged with createdTime to the current time if the item doesn't have them yet.
+// model is a struct or a pointer to one; only its type is used.
+func (u *Update) Timestamps(model interface{}) *Update {
+	c := u.table.db.codec()
+	fields, err := c.autoFields(reflect.TypeOf(model))
+	if err != nil {
+		u.setError(err)
+		return u
+	}
+	now := u.table.db.now()
+	for _, f := range fields {
+		if !f.isTime() {
+			continue
+		}
+		av, err := c.marshalStamp(f.typ, f.encoding, now)
+		if err != nil {
+			u.setError(err)
+			return u
+		}
+		name := u.subName(f.name)
+		vsub, err := u.subValue(av, "")
+		u.setError(err)
+		if f.special == "createdTime" {
+			u.set = append(u.set, name+" = if_not_exists("+name+", "+vsub+")")
+		} else {
+			u.set = append(u.set, name+" = "+vsub)
+		}
+	}
+	return u
+}
+
 // If specifies a conditional expression for this update to succeed.
 // Use single quotes to specificy reserved names inline (like 'Count').
 // Use the placeholder ? within the expression to substitute values, and use $ for names.
@@ -224,15 +354,50 @@ func (u *Update) If(expr string, args ...interface{}) *Update {
 	return u
 }
 
+// MustExist specifies that this update must only change an existing item.
+// By default, DynamoDB creates a new item when updating one that doesn't exist.
+// If the item doesn't exist, the update fails with ErrNotFound.
+// When combined with other conditions, a ConditionalCheckFailedException is returned instead,
+// as it can't be told which condition failed.
+func (u *Update) MustExist() *Update {
+	u.mustExist = "attribute_exists(" + u.subName(u.hashKey) + ")"
+	if u.condition != "" {
+		u.condition += " AND "
+	}
+	u.condition += u.mustExist
+	return u
+}
+
 // ConsumedCapacity will measure the throughput capacity consumed by this operation and add it to cc.
 func (u *Update) ConsumedCapacity(cc *ConsumedCapacity) *Update {
 	u.cc = cc
 	return u
 }
 
+// RequestID will record the ID of the last request sent by this update in id, even if it failed.
+// It is useful for correlating with AWS support cases and server-side logs.
+func (u *Update) RequestID(id *string) *Update {
+	u.reqID = id
+	return u
+}
+
+// ItemCollectionMetrics will record the estimated size of the item collection affected by this update in icm.
+// This is only reported for tables with local secondary indexes.
+func (u *Update) ItemCollectionMetrics(icm *ItemCollectionMetrics) *Update {
+	u.icm = icm
+	return u
+}
+
+// Timeout limits the total amount of time this update may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (u *Update) Timeout(timeout time.Duration) *Update {
+	u.timeout = timeout
+	return u
+}
+
 // Run executes this update.
 func (u *Update) Run() error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(u.timeout)
 	defer cancel()
 	return u.RunWithContext(ctx)
 }
@@ -245,7 +410,7 @@ func (u *Update) RunWithContext(ctx aws.Context) error {
 
 // Value executes this update, encoding out with the new value.
 func (u *Update) Value(out interface{}) error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(u.timeout)
 	defer cancel()
 	return u.ValueWithContext(ctx, out)
 }
@@ -256,12 +421,12 @@ func (u *Update) ValueWithContext(ctx aws.Context, out interface{}) error {
 	if err != nil {
 		return err
 	}
-	return unmarshalItem(output.Attributes, out)
+	return u.table.db.codec().unmarshalItem(output.Attributes, out)
 }
 
 // OldValue executes this update, encoding out with the previous value.
 func (u *Update) OldValue(out interface{}) error {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(u.timeout)
 	defer cancel()
 	return u.OldValueWithContext(ctx, out)
 }
@@ -271,24 +436,88 @@ func (u *Update) OldValueWithContext(ctx aws.Context, out interface{}) error {
 	if err != nil {
 		return err
 	}
-	return unmarshalItem(output.Attributes, out)
+	return u.table.db.codec().unmarshalItem(output.Attributes, out)
 }
 
-func (u *Update) run(ctx aws.Context) (*dynamodb.UpdateItemOutput, error) {
+// OnlyUpdatedValue executes this update, encoding out with only the new values of the attributes that were changed.
+// Out can be a partial struct or a map.
+func (u *Update) OnlyUpdatedValue(out interface{}) error {
+	ctx, cancel := timeoutContext(u.timeout)
+	defer cancel()
+	return u.OnlyUpdatedValueWithContext(ctx, out)
+}
+
+// OnlyUpdatedValueWithContext executes this update, encoding out with only the new values of the attributes that were changed.
+// Out can be a partial struct or a map.
+func (u *Update) OnlyUpdatedValueWithContext(ctx aws.Context, out interface{}) error {
+	u.returnType = "UPDATED_NEW"
+	output, err := u.run(ctx)
+	if err != nil {
+		return err
+	}
+	return u.table.db.codec().unmarshalItem(output.Attributes, out)
+}
+
+// OnlyUpdatedOldValue executes this update, encoding out with only the old values of the attributes that were changed.
+// Out can be a partial struct or a map.
+func (u *Update) OnlyUpdatedOldValue(out interface{}) error {
+	ctx, cancel := timeoutContext(u.timeout)
+	defer cancel()
+	return u.OnlyUpdatedOldValueWithContext(ctx, out)
+}
+
+// OnlyUpdatedOldValueWithContext executes this update, encoding out with only the old values of the attributes that were changed.
+// Out can be a partial struct or a map.
+func (u *Update) OnlyUpdatedOldValueWithContext(ctx aws.Context, out interface{}) error {
+	u.returnType = "UPDATED_OLD"
+	output, err := u.run(ctx)
+	if err != nil {
+		return err
+	}
+	return u.table.db.codec().unmarshalItem(output.Attributes, out)
+}
+
+func (u *Update) run(ctx aws.Context) (output *dynamodb.UpdateItemOutput, err error) {
+	ctx, cancel := withTimeout(ctx, u.timeout)
+	defer cancel()
 	if u.err != nil {
 		return nil, u.err
 	}
 
 	input := u.updateInput()
-	var output *dynamodb.UpdateItemOutput
-	err := retry(ctx, func() error {
+	if db := u.table.db; db.hasWriteHooks() {
+		ev := WriteEvent{Op: WriteUpdate, Table: u.table.Name(), Key: input.Key}
+		if err := db.beforeWrite(ev); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if output != nil {
+				ev.Item = output.Attributes
+			}
+			db.afterWrite(err, ev)
+		}()
+	}
+	err = u.table.db.retry(ctx, u.table.Name(), func() error {
 		var err error
-		output, err = u.table.db.client.UpdateItemWithContext(ctx, input)
+		output, err = u.table.db.client.UpdateItemWithContext(ctx, input, u.table.db.requestOptions(u.reqID)...)
 		return err
 	})
-	if u.cc != nil {
+	if ae, ok := err.(awserr.Error); ok && ae.Code() == dynamodb.ErrCodeConditionalCheckFailedException &&
+		u.mustExist != "" && u.condition == u.mustExist {
+		err = ErrNotFound
+	}
+	if c := u.table.db.cache; c != nil {
+		c.invalidate(u.table.Name(), input.Key)
+	}
+	if err == nil {
+		u.bumpVersion()
+	}
+	if u.cc != nil && output != nil {
 		addConsumedCapacity(u.cc, output.ConsumedCapacity)
 	}
+	if u.icm != nil && output != nil {
+		setItemCollectionMetrics(u.icm, u.table.name, output.ItemCollectionMetrics)
+	}
 	return output, err
 }
 
@@ -307,6 +536,9 @@ func (u *Update) updateInput() *dynamodb.UpdateItemInput {
 	if u.cc != nil {
 		input.ReturnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityIndexes)
 	}
+	if u.icm != nil {
+		input.ReturnItemCollectionMetrics = aws.String(dynamodb.ReturnItemCollectionMetricsSize)
+	}
 	return input
 }
 
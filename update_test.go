@@ -2,8 +2,16 @@ package dynamo
 
 import (
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 func TestUpdate(t *testing.T) {
@@ -119,3 +127,154 @@ func TestUpdateNil(t *testing.T) {
 		t.Errorf("bad result. %+v ≠ %+v", result, expected)
 	}
 }
+
+func TestUpdateOnlyUpdated(t *testing.T) {
+	if testDB == nil {
+		t.Skip(offlineSkipMsg)
+	}
+	table := testDB.Table(testTable)
+
+	item := widget{
+		UserID: 4243,
+		Time:   time.Now().UTC(),
+		Msg:    "before",
+		Count:  1,
+	}
+	if err := table.Put(item).Run(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	var updated map[string]interface{}
+	err := table.Update("UserID", item.UserID).Range("Time", item.Time).
+		Set("Msg", "after").
+		OnlyUpdatedValue(&updated)
+	if err != nil {
+		t.Error("unexpected error:", err)
+	}
+	if expected := map[string]interface{}{"Msg": "after"}; !reflect.DeepEqual(updated, expected) {
+		t.Errorf("bad updated value. %+v ≠ %+v", updated, expected)
+	}
+
+	var old struct {
+		Count int
+	}
+	err = table.Update("UserID", item.UserID).Range("Time", item.Time).
+		Add("Count", 1).
+		OnlyUpdatedOldValue(&old)
+	if err != nil {
+		t.Error("unexpected error:", err)
+	}
+	if old.Count != item.Count {
+		t.Error("bad old value:", old.Count, "≠", item.Count)
+	}
+}
+
+func TestUpdateRemoveIndex(t *testing.T) {
+	table := testDB.Table(testTable)
+
+	u := table.Update("UserID", 42).RemoveIndex("Children", 3)
+	if u.err != nil {
+		t.Fatal("unexpected error:", u.err)
+	}
+	if expr := *u.updateExpr(); expr != "REMOVE Children[3]" {
+		t.Error("bad update expression:", expr)
+	}
+
+	u = table.Update("UserID", 42).RemoveIndex("Children", 0, 2)
+	if len(u.remove) != 2 {
+		t.Error("expected 2 removals, got:", u.remove)
+	}
+	for _, path := range []string{"Children[0]", "Children[2]"} {
+		if _, ok := u.remove[path]; !ok {
+			t.Error("missing removal:", path)
+		}
+	}
+
+	if err := table.Update("UserID", 42).RemoveIndex("Children", -1).err; err == nil {
+		t.Error("expected error for negative index")
+	}
+	if err := table.Update("UserID", 42).RemoveIndex("", 1).err; err == nil {
+		t.Error("expected error for empty path")
+	}
+}
+
+func TestUpdatePatch(t *testing.T) {
+	table := testDB.Table(testTable)
+
+	old := widget{
+		UserID: 42,
+		Msg:    "old",
+		Count:  1,
+		Meta:   map[string]string{"color": "red", "size": "L"},
+	}
+	new := old
+	new.Msg = "new"
+	new.Count = 2
+	new.Meta = map[string]string{"color": "blue"}
+
+	u := table.Update("UserID", 42).Range("Time", old.Time).Patch(old, new)
+	if u.err != nil {
+		t.Fatal("unexpected error:", u.err)
+	}
+	input := u.updateInput()
+	got := append([]string(nil), u.set...)
+	for path := range u.remove {
+		got = append(got, "REMOVE "+path)
+	}
+	if len(got) != 4 {
+		t.Fatal("expected 4 changes, got:", *input.UpdateExpression)
+	}
+	for i := range got {
+		for sub, name := range input.ExpressionAttributeNames {
+			got[i] = strings.Replace(got[i], sub, *name, -1)
+		}
+		for sub, av := range input.ExpressionAttributeValues {
+			got[i] = strings.Replace(got[i], sub, av.String(), -1)
+		}
+		got[i] = strings.Join(strings.Fields(got[i]), " ")
+	}
+	sort.Strings(got)
+	expected := []string{
+		`Count = { N: "2" }`,
+		`Meta.color = { S: "blue" }`,
+		`Msg = { S: "new" }`,
+		"REMOVE Meta.size",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Error("bad patch:", got, "≠", expected)
+	}
+
+	changed := old
+	changed.UserID = 43
+	if err := table.Update("UserID", 42).Range("Time", old.Time).Patch(old, changed).err; err == nil {
+		t.Error("expected error when changing key")
+	}
+}
+
+// emptyTableClient is a fake client for a table with no items, which fails every conditional update.
+type emptyTableClient struct {
+	dynamodbiface.DynamoDBAPI
+}
+
+func (emptyTableClient) UpdateItemWithContext(_ aws.Context, input *dynamodb.UpdateItemInput, _ ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	if input.ConditionExpression != nil {
+		return nil, awserr.NewRequestFailure(awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "failed", nil), 400, "")
+	}
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func TestUpdateMustExist(t *testing.T) {
+	table := NewFromIface(emptyTableClient{}).Table(testTable)
+
+	err := table.Update("UserID", 42).Set("Msg", "hello").MustExist().Run()
+	if err != ErrNotFound {
+		t.Error("expected ErrNotFound, got", err)
+	}
+	err = table.Update("UserID", 42).Set("Msg", "hello").If("'Count' > ?", 0).MustExist().Run()
+	if !isConditionalCheckErr(err) {
+		t.Error("expected ConditionalCheckFailedException, got", err)
+	}
+	if err := table.Update("UserID", 42).Set("Msg", "hello").Run(); err != nil {
+		t.Error("unexpected error:", err)
+	}
+}
@@ -2,6 +2,7 @@ package dynamo
 
 import (
 	"errors"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -23,7 +24,9 @@ type UpdateTable struct {
 	deleteIdx []string
 	ads       []*dynamodb.AttributeDefinition
 
-	err error
+	checkLimits bool
+	err         error
+	timeout     time.Duration
 }
 
 // UpdateTable makes changes to this table's settings.
@@ -104,26 +107,61 @@ func (ut *UpdateTable) DisableStream() *UpdateTable {
 	return ut
 }
 
+// CheckLimits specifies that the provisioned throughput requested for this table and its global secondary indexes
+// should be checked against the account's limits, as reported by DescribeLimits, before updating the table.
+func (ut *UpdateTable) CheckLimits() *UpdateTable {
+	ut.checkLimits = true
+	return ut
+}
+
+// Timeout limits the total amount of time this request may take, including all retries and backoff.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (ut *UpdateTable) Timeout(timeout time.Duration) *UpdateTable {
+	ut.timeout = timeout
+	return ut
+}
+
 // Run executes this request and describes the table.
 func (ut *UpdateTable) Run() (Description, error) {
-	ctx, cancel := defaultContext()
+	ctx, cancel := timeoutContext(ut.timeout)
 	defer cancel()
 	return ut.RunWithContext(ctx)
 }
 
 func (ut *UpdateTable) RunWithContext(ctx aws.Context) (Description, error) {
+	ctx, cancel := withTimeout(ctx, ut.timeout)
+	defer cancel()
 	if ut.err != nil {
 		return Description{}, ut.err
 	}
 
 	input := ut.input()
+	if ut.checkLimits {
+		limits, err := ut.table.db.DescribeLimits().RunWithContext(ctx)
+		if err != nil {
+			return Description{}, err
+		}
+		indexes := make(map[string]*dynamodb.ProvisionedThroughput)
+		for _, up := range input.GlobalSecondaryIndexUpdates {
+			switch {
+			case up.Update != nil:
+				indexes[*up.Update.IndexName] = up.Update.ProvisionedThroughput
+			case up.Create != nil:
+				indexes[*up.Create.IndexName] = up.Create.ProvisionedThroughput
+			}
+		}
+		if err := checkThroughput(limits, "update table", input.ProvisionedThroughput, indexes); err != nil {
+			return Description{}, err
+		}
+	}
 
 	var result *dynamodb.UpdateTableOutput
 	err := retry(ctx, func() error {
 		var err error
-		result, err = ut.table.db.client.UpdateTableWithContext(ctx, input)
+		result, err = ut.table.db.client.UpdateTableWithContext(ctx, input, ut.table.db.opts...)
 		return err
 	})
+	ut.table.db.descs.forget(ut.table.Name())
 	if err != nil {
 		return Description{}, err
 	}
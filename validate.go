@@ -0,0 +1,138 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// SchemaError is returned by ValidateModel when a model doesn't match its table's schema.
+type SchemaError struct {
+	// Table is the name of the table.
+	Table string
+	// Mismatches describes each difference found.
+	Mismatches []string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("dynamo: model doesn't match table %s: %s", e.Table, strings.Join(e.Mismatches, "; "))
+}
+
+// ValidateModel describes this table and checks that the hash key, range key, and index struct tags of model
+// match the table's actual schema, as used by CreateTable.
+// It checks key names and types, and that every index tagged in model exists.
+// Index keys that are left out of items when empty, making the index sparse, must be tagged omitempty,
+// and the table's keys must not be.
+// Indexes of the table that model doesn't mention are ignored.
+// Mismatches are returned as a *SchemaError.
+// Use it at startup to catch mistakes that would otherwise cause ValidationExceptions later.
+func (table Table) ValidateModel(model interface{}) error {
+	ctx, cancel := defaultContext()
+	defer cancel()
+	return table.ValidateModelWithContext(ctx, model)
+}
+
+// ValidateModelWithContext describes this table and checks that model matches its schema.
+// See ValidateModel.
+func (table Table) ValidateModelWithContext(ctx aws.Context, model interface{}) error {
+	ct := table.db.CreateTable(table.Name(), model)
+	if ct.err != nil {
+		return ct.err
+	}
+	desc, err := table.description(ctx)
+	if err != nil {
+		return err
+	}
+
+	var mismatches []string
+	fields := table.db.codec().modelFields(reflect.TypeOf(model))
+	hashKey, rangeKey := schemaKeys(ct.schema)
+	if hashKey == "" {
+		mismatches = append(mismatches, "model has no hash key tag")
+	} else {
+		mismatches = append(mismatches, compareKeys("table", ct.attribs, hashKey, rangeKey,
+			desc.HashKey, desc.HashKeyType, desc.RangeKey, desc.RangeKeyType)...)
+		mismatches = append(mismatches, compareSparseKeys("table", fields, hashKey, rangeKey, "", "")...)
+	}
+
+	gsiNames := make([]string, 0, len(ct.globalIndices))
+	for name := range ct.globalIndices {
+		gsiNames = append(gsiNames, name)
+	}
+	sort.Strings(gsiNames)
+	for _, name := range gsiNames {
+		idx, ok := findGSI(desc, name)
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("global secondary index %s doesn't exist", name))
+			continue
+		}
+		hk, rk := schemaKeys(ct.globalIndices[name].KeySchema)
+		mismatches = append(mismatches, compareKeys("global secondary index "+name, ct.attribs, hk, rk,
+			idx.HashKey, idx.HashKeyType, idx.RangeKey, idx.RangeKeyType)...)
+		mismatches = append(mismatches, compareSparseKeys("global secondary index "+name, fields, hashKey, rangeKey, hk, rk)...)
+	}
+
+	lsiNames := make([]string, 0, len(ct.localIndices))
+	for name := range ct.localIndices {
+		lsiNames = append(lsiNames, name)
+	}
+	sort.Strings(lsiNames)
+	for _, name := range lsiNames {
+		idx, ok := findLSI(desc, name)
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("local secondary index %s doesn't exist", name))
+			continue
+		}
+		hk, rk := schemaKeys(ct.localIndices[name].KeySchema)
+		if hk == "" {
+			// only the range key was tagged, the hash key is the table's
+			hk = hashKey
+		}
+		mismatches = append(mismatches, compareKeys("local secondary index "+name, ct.attribs, hk, rk,
+			idx.HashKey, idx.HashKeyType, idx.RangeKey, idx.RangeKeyType)...)
+		mismatches = append(mismatches, compareSparseKeys("local secondary index "+name, fields, hashKey, rangeKey, hk, rk)...)
+	}
+
+	if len(mismatches) > 0 {
+		return &SchemaError{Table: table.Name(), Mismatches: mismatches}
+	}
+	return nil
+}
+
+// compareKeys describes the differences between the keys of a model and those of a table or index.
+func compareKeys(what string, attribs []*dynamodb.AttributeDefinition, hashKey, rangeKey string,
+	haveHash string, haveHashType KeyType, haveRange string, haveRangeType KeyType) []string {
+	var mismatches []string
+	compare := func(kind, want, have string, haveType KeyType) {
+		switch {
+		case want == have && want == "":
+		case want == "":
+			mismatches = append(mismatches, fmt.Sprintf("%s has %s key %s, but the model doesn't tag one", what, kind, have))
+		case have == "":
+			mismatches = append(mismatches, fmt.Sprintf("%s has no %s key, but the model tags %s", what, kind, want))
+		case want != have:
+			mismatches = append(mismatches, fmt.Sprintf("%s %s key is %s, but the model tags %s", what, kind, have, want))
+		default:
+			if wantType := lookupADType(attribs, want); wantType != haveType {
+				mismatches = append(mismatches, fmt.Sprintf("%s %s key %s has type %s, but the model's field has type %s",
+					what, kind, have, haveType, wantType))
+			}
+		}
+	}
+	compare("hash", hashKey, haveHash, haveHashType)
+	compare("range", rangeKey, haveRange, haveRangeType)
+	return mismatches
+}
+
+func findLSI(desc Description, name string) (Index, bool) {
+	for _, idx := range desc.LSI {
+		if idx.Name == name {
+			return idx, true
+		}
+	}
+	return Index{}, false
+}
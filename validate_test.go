@@ -0,0 +1,105 @@
+package dynamo
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// describeClient is a fake client that describes a table going through the given states, one per request,
+// repeating the last one once it is reached. It counts the requests in describes.
+type describeClient struct {
+	dynamodbiface.DynamoDBAPI
+	mu        sync.Mutex
+	states    []*dynamodb.TableDescription
+	describes int
+}
+
+func newDescribeClient(states ...*dynamodb.TableDescription) *describeClient {
+	return &describeClient{states: states}
+}
+
+func (c *describeClient) DescribeTableWithContext(aws.Context, *dynamodb.DescribeTableInput, ...request.Option) (*dynamodb.DescribeTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.describes++
+	if len(c.states) == 0 {
+		return &dynamodb.DescribeTableOutput{}, nil
+	}
+	i := c.describes - 1
+	if i >= len(c.states) {
+		i = len(c.states) - 1
+	}
+	return &dynamodb.DescribeTableOutput{Table: c.states[i]}, nil
+}
+
+func keySchema(hashKey, rangeKey string) []*dynamodb.KeySchemaElement {
+	schema := []*dynamodb.KeySchemaElement{{AttributeName: aws.String(hashKey), KeyType: aws.String(dynamodb.KeyTypeHash)}}
+	if rangeKey != "" {
+		schema = append(schema, &dynamodb.KeySchemaElement{AttributeName: aws.String(rangeKey), KeyType: aws.String(dynamodb.KeyTypeRange)})
+	}
+	return schema
+}
+
+func TestValidateModel(t *testing.T) {
+	type UserAction struct {
+		UserID string    `dynamo:"ID,hash" index:"Seq-ID-index,range"`
+		Time   time.Time `dynamo:",range"`
+		Seq    int64     `localIndex:"ID-Seq-index,range" index:"Seq-ID-index,hash"`
+		UUID   string
+	}
+	attribs := func(seqType string) []*dynamodb.AttributeDefinition {
+		return []*dynamodb.AttributeDefinition{
+			{AttributeName: aws.String("ID"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("Time"), AttributeType: aws.String("S")},
+			{AttributeName: aws.String("Seq"), AttributeType: aws.String(seqType)},
+		}
+	}
+	desc := &dynamodb.TableDescription{
+		TableName:            aws.String("UserActions"),
+		KeySchema:            keySchema("ID", "Time"),
+		AttributeDefinitions: attribs("N"),
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{{
+			IndexName:   aws.String("Seq-ID-index"),
+			IndexArn:    aws.String("arn"),
+			IndexStatus: aws.String("ACTIVE"),
+			KeySchema:   keySchema("Seq", "ID"),
+		}},
+		LocalSecondaryIndexes: []*dynamodb.LocalSecondaryIndexDescription{{
+			IndexName: aws.String("ID-Seq-index"),
+			IndexArn:  aws.String("arn"),
+			KeySchema: keySchema("ID", "Seq"),
+		}},
+	}
+	table := NewFromIface(newDescribeClient(desc)).Table("UserActions")
+	if err := table.ValidateModel(UserAction{}); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	desc.AttributeDefinitions = attribs("S")
+	desc.KeySchema = keySchema("ID", "")
+	desc.LocalSecondaryIndexes = nil
+	err := table.ValidateModel(&UserAction{})
+	schemaErr, ok := err.(*SchemaError)
+	if !ok {
+		t.Fatal("expected SchemaError, got", err)
+	}
+	expected := []string{
+		"table has no range key, but the model tags Time",
+		"global secondary index Seq-ID-index hash key Seq has type S, but the model's field has type N",
+		"local secondary index ID-Seq-index doesn't exist",
+	}
+	if !reflect.DeepEqual(schemaErr.Mismatches, expected) {
+		t.Error("bad mismatches:", schemaErr.Mismatches, "≠", expected)
+	}
+
+	if err := table.ValidateModel(struct{ Name string }{}); err == nil {
+		t.Error("expected error for model without keys")
+	}
+}
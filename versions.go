@@ -0,0 +1,162 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// CheckVersions enables optimistic concurrency for the items of this transaction.
+//
+// Puts of items with an integer field tagged with version, like
+//	Version int64 `dynamo:",version"`
+// only succeed if the stored item still has the version being put, and write the next version.
+// Items with a zero version must not have been written with a version yet.
+// If any item is out of date, the whole transaction is canceled.
+// When the item is passed as a pointer, its version field is incremented once the transaction succeeds.
+// Updates made with SetAll are checked the same way, using the version of the item passed to SetAll.
+// Other updates and deletes don't have an item to take the version from; use their CheckVersion methods instead.
+// Puts of items without a version field are unaffected.
+func (tx *WriteTx) CheckVersions() *WriteTx {
+	tx.versions = true
+	return tx
+}
+
+// versionCheck is the condition on the version field of an item, and the version to write next.
+type versionCheck struct {
+	name string
+	cond string
+	args []interface{}
+	next *dynamodb.AttributeValue
+	// bump increments the item's version field, if it was passed as a pointer
+	bump func()
+}
+
+// versionOf returns the version check for item, or nil if its type has no version field.
+func (c codec) versionOf(item interface{}) (*versionCheck, error) {
+	rv := reflect.ValueOf(item)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	fields, err := c.autoFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		if f.special != "version" {
+			continue
+		}
+		fv := rv.FieldByIndex(f.index)
+		vc := &versionCheck{name: f.name}
+		var zero bool
+		var next string
+		switch fv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			zero = fv.Int() == 0
+			next = strconv.FormatInt(fv.Int()+1, 10)
+			if fv.CanSet() {
+				vc.bump = func() { fv.SetInt(fv.Int() + 1) }
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			zero = fv.Uint() == 0
+			next = strconv.FormatUint(fv.Uint()+1, 10)
+			if fv.CanSet() {
+				vc.bump = func() { fv.SetUint(fv.Uint() + 1) }
+			}
+		default:
+			return nil, fmt.Errorf("dynamo: version field %s must be an integer, not %v", f.name, f.typ)
+		}
+		if zero {
+			vc.cond, vc.args = "attribute_not_exists($)", []interface{}{f.name}
+		} else {
+			vc.cond, vc.args = "$ = ?", []interface{}{f.name, fv.Interface()}
+		}
+		vc.next = &dynamodb.AttributeValue{N: &next}
+		return vc, nil
+	}
+	return nil, nil
+}
+
+// checkVersion adds a condition on the version field of this put's item and writes the next version, if it has one.
+// The item's version field is incremented by bumpVersion, once the write succeeds.
+func (p *Put) checkVersion() error {
+	if p.versioned {
+		return nil
+	}
+	vc, err := p.table.db.codec().versionOf(p.model)
+	if err != nil || vc == nil {
+		return err
+	}
+	p.If(vc.cond, vc.args...)
+	p.item[vc.name] = vc.next
+	p.versioned = true
+	p.bump = vc.bump
+	return p.err
+}
+
+// bumpVersions increments the version fields of this transaction's items after it succeeds.
+func (tx *WriteTx) bumpVersions() {
+	for _, item := range tx.items {
+		switch x := item.(type) {
+		case *Put:
+			x.bumpVersion()
+		case *Update:
+			x.bumpVersion()
+		}
+	}
+}
+
+// bumpVersion increments the version field of this put's item after a versioned write, once.
+func (p *Put) bumpVersion() {
+	if p.bump != nil {
+		p.bump()
+		p.bump = nil
+	}
+}
+
+// CheckVersion makes this update only succeed if the stored item has the version of item's version field
+// (see WriteTx.CheckVersions), and sets the next version.
+// When item is a pointer, its version field is incremented once the update succeeds.
+// If item's type has no version field, it has no effect.
+func (u *Update) CheckVersion(item interface{}) *Update {
+	if u.versioned {
+		return u
+	}
+	vc, err := u.table.db.codec().versionOf(item)
+	if err != nil || vc == nil {
+		u.setError(err)
+		return u
+	}
+	u.If(vc.cond, vc.args...)
+	u.Set(vc.name, vc.next)
+	u.versioned = true
+	u.bump = vc.bump
+	return u
+}
+
+// bumpVersion increments the version field of this update's item after a versioned write, once.
+func (u *Update) bumpVersion() {
+	if u.bump != nil {
+		u.bump()
+		u.bump = nil
+	}
+}
+
+// CheckVersion makes this delete only succeed if the stored item has the version of item's version field.
+// See WriteTx.CheckVersions. If item's type has no version field, it has no effect.
+func (d *Delete) CheckVersion(item interface{}) *Delete {
+	vc, err := d.table.db.codec().versionOf(item)
+	if err != nil || vc == nil {
+		d.setError(err)
+		return d
+	}
+	return d.If(vc.cond, vc.args...)
+}
@@ -0,0 +1,118 @@
+package dynamo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestWriteTxCheckVersions(t *testing.T) {
+	type doc struct {
+		ID      string `dynamo:",hash"`
+		Version int64  `dynamo:",version"`
+	}
+	db := NewFromIface(newMemClient())
+	table := db.Table(testTable)
+
+	created := &doc{ID: "a"}
+	updated := doc{ID: "b", Version: 3}
+	tx := db.WriteTx().CheckVersions().
+		Put(table.Put(created)).
+		Put(table.Put(updated)).
+		Put(table.Put(widget{UserID: 1}))
+	input, err := tx.input()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := input.TransactItems[0].Put
+	if cond := unsubstitute(aws.StringValue(first.ConditionExpression), first.ExpressionAttributeNames); cond != "(attribute_not_exists(Version))" {
+		t.Error("bad condition for new item:", cond)
+	}
+	if v := aws.StringValue(first.Item["Version"].N); v != "1" {
+		t.Error("expected version 1, got", v)
+	}
+	if created.Version != 0 {
+		t.Error("expected item's version to be left alone until the transaction runs, got", created.Version)
+	}
+
+	second := input.TransactItems[1].Put
+	if cond := unsubstitute(aws.StringValue(second.ConditionExpression), second.ExpressionAttributeNames); cond != "(Version = :v0)" {
+		t.Error("bad condition for existing item:", cond)
+	}
+	if v := aws.StringValue(second.ExpressionAttributeValues[":v0"].N); v != "3" {
+		t.Error("expected check of version 3, got", v)
+	}
+	if v := aws.StringValue(second.Item["Version"].N); v != "4" {
+		t.Error("expected version 4, got", v)
+	}
+
+	if third := input.TransactItems[2].Put; third.ConditionExpression != nil {
+		t.Error("unexpected condition for unversioned item:", *third.ConditionExpression)
+	}
+
+	// building the input again must not increment twice
+	if input, err = tx.input(); err != nil {
+		t.Fatal(err)
+	}
+	if v := aws.StringValue(input.TransactItems[1].Put.Item["Version"].N); v != "4" {
+		t.Error("expected version 4 after rebuilding, got", v)
+	}
+
+	// updates made with SetAll are checked too
+	tx = db.WriteTx().CheckVersions().Update(table.Update("ID", "b").SetAll(updated))
+	if input, err = tx.input(); err != nil {
+		t.Fatal(err)
+	}
+	upd := input.TransactItems[0].Update
+	if cond := unsubstitute(aws.StringValue(upd.ConditionExpression), upd.ExpressionAttributeNames); cond != "(Version = :v0)" {
+		t.Error("bad condition for update:", cond)
+	}
+	if expr := unsubstitute(aws.StringValue(upd.UpdateExpression), upd.ExpressionAttributeNames); !strings.Contains(expr, "Version = ") {
+		t.Error("expected update to set the next version:", expr)
+	}
+
+	// plain puts ignore versions
+	put := table.Put(&doc{ID: "c", Version: 7})
+	if put.input().ConditionExpression != nil {
+		t.Error("unexpected condition without CheckVersions")
+	}
+}
+
+func TestWriteTxCheckVersionsRun(t *testing.T) {
+	type doc struct {
+		ID      string `dynamo:",hash"`
+		Version int64  `dynamo:",version"`
+	}
+	client := &txFuncClient{memClient: newMemClient(), errs: []error{txErr("ValidationException", "invalid")}}
+	db := NewFromIface(client)
+	table := db.Table(testTable)
+
+	put := &doc{ID: "a", Version: 1}
+	updated := &doc{ID: "b", Version: 5}
+	tx := db.WriteTx().CheckVersions().
+		Put(table.Put(put)).
+		Update(table.Update("ID", "b").Set("Name", "x").CheckVersion(updated))
+	if err := tx.Run(); err == nil {
+		t.Fatal("expected the first run to fail")
+	}
+	if put.Version != 1 || updated.Version != 5 {
+		t.Error("versions changed by a failed transaction:", put.Version, updated.Version)
+	}
+	if err := tx.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if put.Version != 2 || updated.Version != 6 {
+		t.Error("expected versions 2 and 6 after the transaction succeeded, got", put.Version, updated.Version)
+	}
+	next := client.inputs[1].TransactItems[1].Update
+	if cond := unsubstitute(aws.StringValue(next.ConditionExpression), next.ExpressionAttributeNames); cond != "(Version = :v1)" {
+		t.Error("bad condition for update:", cond)
+	}
+
+	del := table.Delete("ID", "c").CheckVersion(doc{ID: "c", Version: 2})
+	if cond := unsubstitute(aws.StringValue(del.deleteInput().ConditionExpression), del.deleteInput().ExpressionAttributeNames); cond != "(Version = :v0)" {
+		t.Error("bad condition for delete:", cond)
+	}
+}
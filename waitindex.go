@@ -0,0 +1,113 @@
+package dynamo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/cenkalti/backoff"
+)
+
+// WaitForIndex is a request to wait until a global secondary index is ready for use,
+// such as after adding it with UpdateTable.
+type WaitForIndex struct {
+	table    Table
+	name     string
+	interval time.Duration
+	progress func(Index)
+	timeout  time.Duration
+}
+
+// WaitForIndex begins a new request to wait until the global secondary index with the given name
+// is active and finished backfilling.
+// Backfilling an index for a large table can take a long time, so consider using Timeout or RunWithContext
+// to wait longer than RetryTimeout.
+func (table Table) WaitForIndex(name string) *WaitForIndex {
+	return &WaitForIndex{
+		table:    table,
+		name:     name,
+		interval: 20 * time.Second,
+	}
+}
+
+// MaxInterval sets the longest time to wait between checks of the index's status.
+// Checks start frequently and back off up to this interval. The default is 20 seconds.
+func (w *WaitForIndex) MaxInterval(interval time.Duration) *WaitForIndex {
+	w.interval = interval
+	return w
+}
+
+// OnProgress sets a function to be called with the index's description every time its status is checked.
+// The index's item count can be used to estimate backfilling progress,
+// but DynamoDB only updates it about every six hours.
+func (w *WaitForIndex) OnProgress(fn func(Index)) *WaitForIndex {
+	w.progress = fn
+	return w
+}
+
+// Timeout limits the total amount of time this request may take.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (w *WaitForIndex) Timeout(timeout time.Duration) *WaitForIndex {
+	w.timeout = timeout
+	return w
+}
+
+// Run waits until the index is ready.
+func (w *WaitForIndex) Run() error {
+	ctx, cancel := timeoutContext(w.timeout)
+	defer cancel()
+	return w.RunWithContext(ctx)
+}
+
+// RunWithContext waits until the index is ready.
+// It returns an error if the index doesn't exist or is being deleted.
+func (w *WaitForIndex) RunWithContext(ctx aws.Context) error {
+	ctx, cancel := withTimeout(ctx, w.timeout)
+	defer cancel()
+
+	boff := waitBackoff(w.interval)
+	for {
+		desc, err := w.table.Describe().RunWithContext(ctx)
+		if err != nil {
+			return err
+		}
+		idx, ok := findGSI(desc, w.name)
+		if !ok {
+			return fmt.Errorf("dynamo: wait for index: table %s has no global secondary index %s", w.table.Name(), w.name)
+		}
+		if w.progress != nil {
+			w.progress(idx)
+		}
+		switch {
+		case idx.Status == DeletingStatus:
+			return fmt.Errorf("dynamo: wait for index: index %s is being deleted", w.name)
+		case idx.Status == ActiveStatus && !idx.Backfilling:
+			return nil
+		}
+		if err := sleepBackoff(ctx, boff); err != nil {
+			return err
+		}
+	}
+}
+
+func findGSI(desc Description, name string) (Index, bool) {
+	for _, idx := range desc.GSI {
+		if idx.Name == name {
+			return idx, true
+		}
+	}
+	return Index{}, false
+}
+
+// waitBackoff returns a backoff for polling that starts at one second, or max if it is shorter, and grows up to max.
+func waitBackoff(max time.Duration) *backoff.ExponentialBackOff {
+	boff := backoff.NewExponentialBackOff()
+	boff.InitialInterval = time.Second
+	if max < boff.InitialInterval {
+		boff.InitialInterval = max
+	}
+	boff.MaxInterval = max
+	boff.MaxElapsedTime = 0
+	boff.Reset()
+	return boff
+}
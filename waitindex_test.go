@@ -0,0 +1,54 @@
+package dynamo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// gsiState returns a description of the test table whose index is in the given state.
+func gsiState(status string, backfilling bool, items int64) *dynamodb.TableDescription {
+	return &dynamodb.TableDescription{
+		TableName: aws.String(testTable),
+		GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndexDescription{{
+			IndexName:   aws.String("Msg-index"),
+			IndexArn:    aws.String("arn:aws:dynamodb:local:index/Msg-index"),
+			IndexStatus: aws.String(status),
+			Backfilling: aws.Bool(backfilling),
+			ItemCount:   aws.Int64(items),
+		}},
+	}
+}
+
+func TestWaitForIndex(t *testing.T) {
+	client := newDescribeClient(
+		gsiState("CREATING", true, 0),
+		gsiState("CREATING", true, 100),
+		gsiState("ACTIVE", true, 200),
+		gsiState("ACTIVE", false, 300),
+	)
+	table := NewFromIface(client).Table(testTable)
+
+	var seen []int64
+	err := table.WaitForIndex("Msg-index").MaxInterval(time.Millisecond).OnProgress(func(idx Index) {
+		seen = append(seen, idx.Items)
+	}).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 4 || seen[3] != 300 {
+		t.Error("bad progress reports:", seen)
+	}
+
+	if err := table.WaitForIndex("Other-index").Run(); err == nil {
+		t.Error("expected error for missing index")
+	}
+
+	client = newDescribeClient(gsiState("CREATING", true, 0))
+	err = NewFromIface(client).Table(testTable).WaitForIndex("Msg-index").MaxInterval(time.Millisecond).Timeout(20 * time.Millisecond).Run()
+	if err == nil {
+		t.Error("expected timeout")
+	}
+}
@@ -0,0 +1,81 @@
+package dynamo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// WaitForTable is a request to wait until a table is ready for use,
+// such as after creating it or changing its settings with UpdateTable.
+type WaitForTable struct {
+	table    Table
+	interval time.Duration
+	progress func(Description)
+	timeout  time.Duration
+}
+
+// WaitForTable begins a new request to wait until this table is active.
+// It doesn't wait for global secondary indexes to finish backfilling; use WaitForIndex for that.
+func (table Table) WaitForTable() *WaitForTable {
+	return &WaitForTable{
+		table:    table,
+		interval: 20 * time.Second,
+	}
+}
+
+// MaxInterval sets the longest time to wait between checks of the table's status.
+// Checks start frequently and back off up to this interval. The default is 20 seconds.
+func (w *WaitForTable) MaxInterval(interval time.Duration) *WaitForTable {
+	w.interval = interval
+	return w
+}
+
+// OnProgress sets a function to be called with the table's description every time its status is checked,
+// such as to display the status of the table and its indexes while it is being created or updated.
+func (w *WaitForTable) OnProgress(fn func(Description)) *WaitForTable {
+	w.progress = fn
+	return w
+}
+
+// Timeout limits the total amount of time this request may take.
+// When set, it is used instead of RetryTimeout for methods that do not take a context.
+func (w *WaitForTable) Timeout(timeout time.Duration) *WaitForTable {
+	w.timeout = timeout
+	return w
+}
+
+// Run waits until the table is active.
+func (w *WaitForTable) Run() error {
+	ctx, cancel := timeoutContext(w.timeout)
+	defer cancel()
+	return w.RunWithContext(ctx)
+}
+
+// RunWithContext waits until the table is active.
+// It returns an error if the table is being deleted.
+func (w *WaitForTable) RunWithContext(ctx aws.Context) error {
+	ctx, cancel := withTimeout(ctx, w.timeout)
+	defer cancel()
+
+	boff := waitBackoff(w.interval)
+	for {
+		desc, err := w.table.Describe().RunWithContext(ctx)
+		if err != nil {
+			return err
+		}
+		if w.progress != nil {
+			w.progress(desc)
+		}
+		switch desc.Status {
+		case DeletingStatus:
+			return fmt.Errorf("dynamo: wait for table: table %s is being deleted", w.table.Name())
+		case ActiveStatus:
+			return nil
+		}
+		if err := sleepBackoff(ctx, boff); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package dynamo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// tableStates returns descriptions of the test table with the given statuses.
+func tableStates(statuses ...string) []*dynamodb.TableDescription {
+	states := make([]*dynamodb.TableDescription, 0, len(statuses))
+	for _, status := range statuses {
+		states = append(states, &dynamodb.TableDescription{
+			TableName:   aws.String(testTable),
+			TableStatus: aws.String(status),
+		})
+	}
+	return states
+}
+
+func TestWaitForTable(t *testing.T) {
+	client := newDescribeClient(tableStates("CREATING", "CREATING", "ACTIVE")...)
+	table := NewFromIface(client).Table(testTable)
+
+	var seen []Status
+	err := table.WaitForTable().MaxInterval(time.Millisecond).OnProgress(func(desc Description) {
+		seen = append(seen, desc.Status)
+	}).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []Status{CreatingStatus, CreatingStatus, ActiveStatus}; !reflect.DeepEqual(seen, expected) {
+		t.Error("bad progress reports:", seen, "≠", expected)
+	}
+
+	client = newDescribeClient(tableStates("UPDATING", "DELETING")...)
+	table = NewFromIface(client).Table(testTable)
+	if err := table.WaitForTable().MaxInterval(time.Millisecond).Run(); err == nil {
+		t.Error("expected error for deleted table")
+	}
+}